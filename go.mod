@@ -2,4 +2,6 @@ module github.com/alexfrick92/opcli
 
 go 1.25.5
 
-require github.com/gopcua/opcua v0.8.0 // indirect
+require github.com/gopcua/opcua v0.8.0
+
+require github.com/google/uuid v1.6.0 // indirect
@@ -0,0 +1,105 @@
+// Package audit ведёт журнал изменяющих операций opcli: записи значений и
+// атрибутов и изменений архива. Журнал - файл JSON Lines, в который записи
+// только дописываются.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// Entry - запись журнала об изменении одного узла
+type Entry struct {
+	Time time.Time `json:"time"`
+	// User - пользователь ОС, выполнивший операцию
+	User     string `json:"user"`
+	Endpoint string `json:"endpoint"`
+	// Session - идентификатор соединения opcli с сервером
+	Session string `json:"session"`
+	// Operation - write, rollback, history insert, history replace,
+	// history delete
+	Operation string `json:"operation"`
+	Node      string `json:"node"`
+	// Attribute - записанный атрибут; пусто для изменений архива
+	Attribute  string `json:"attribute,omitempty"`
+	IndexRange string `json:"indexRange,omitempty"`
+	// At - метка времени изменённого значения архива; From и To -
+	// интервал удаления из архива
+	At   *time.Time `json:"at,omitempty"`
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+	// Old и New - прежнее и новое значение в JSON-форме DataValue
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+	// Status - код результата операции или ошибка запроса
+	Status string `json:"status"`
+}
+
+// Log - открытый журнал аудита
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	user string
+}
+
+// Open открывает журнал для дописывания, создавая файл при необходимости
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{f: f, user: currentUser()}, nil
+}
+
+// Record дописывает записи в журнал, заполняя время и пользователя, и
+// сбрасывает их на диск
+func (l *Log) Record(entries ...Entry) error {
+	var buf []byte
+	now := time.Now().UTC()
+	for _, e := range entries {
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+		if e.User == "" {
+			e.User = l.user
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(buf); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Close закрывает журнал
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// currentUser возвращает имя пользователя ОС; без базы пользователей -
+// из переменных окружения
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return ""
+}
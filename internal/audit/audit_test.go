@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLog проверяет дописывание записей в журнал аудита.
+//
+// Основные аспекты тестирования:
+// - Каждая запись - отдельная строка JSON с временем и пользователем ОС.
+// - Повторно открытый журнал дописывается, а не перезаписывается.
+// - Пустые необязательные поля не выводятся.
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() получена непредвиденная ошибка = %v", err)
+	}
+	err = l.Record(
+		Entry{Operation: "write", Node: "ns=2;s=Temp", Attribute: "Value", Old: 20.5, New: 42, Status: "Good"},
+		Entry{Operation: "history delete", Node: "ns=2;s=Temp", At: &at, Status: "Good"},
+	)
+	if err != nil {
+		t.Fatalf("Record() получена непредвиденная ошибка = %v", err)
+	}
+	l.Close()
+
+	if l, err = Open(path); err != nil {
+		t.Fatalf("Open() существующего журнала получена непредвиденная ошибка = %v", err)
+	}
+	if err := l.Record(Entry{Operation: "write", Node: "ns=2;s=Mode", User: "operator", Status: "BadUserAccessDenied"}); err != nil {
+		t.Fatalf("Record() получена непредвиденная ошибка = %v", err)
+	}
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("журнал содержит %d строк, ожидалось 3:\n%s", len(lines), data)
+	}
+	var first map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("строка журнала не JSON: %v", err)
+	}
+	if first["time"] == "" || first["user"] != currentUser() || first["old"] != 20.5 || first["new"] != float64(42) {
+		t.Errorf("первая запись = %v", first)
+	}
+	if strings.Contains(lines[0], `"at"`) || strings.Contains(lines[0], `"from"`) || !strings.Contains(lines[1], `"at":"2026-01-01T00:00:00Z"`) {
+		t.Errorf("необязательные поля: %s\n%s", lines[0], lines[1])
+	}
+	if !strings.Contains(lines[2], `"user":"operator"`) || !strings.Contains(lines[2], `"status":"BadUserAccessDenied"`) {
+		t.Errorf("дописанная запись = %s", lines[2])
+	}
+}
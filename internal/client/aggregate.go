@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// Aggregate - функция агрегирования архива (Part 13)
+type Aggregate struct {
+	Name   string
+	NodeID *ua.NodeID
+}
+
+// AggregateValues - значения одной функции агрегирования по интервалам
+type AggregateValues struct {
+	Aggregate Aggregate
+	Values    []*ua.DataValue
+}
+
+// standardAggregates - функции агрегирования Part 13 из пространства имён 0.
+// По ним находятся функции, которые сервер не перечисляет в возможностях.
+var standardAggregates = []uint32{
+	id.AggregateFunction_Interpolative,
+	id.AggregateFunction_Average,
+	id.AggregateFunction_TimeAverage,
+	id.AggregateFunction_Total,
+	id.AggregateFunction_Minimum,
+	id.AggregateFunction_Maximum,
+	id.AggregateFunction_MinimumActualTime,
+	id.AggregateFunction_MaximumActualTime,
+	id.AggregateFunction_Range,
+	id.AggregateFunction_AnnotationCount,
+	id.AggregateFunction_Count,
+	id.AggregateFunction_DurationInStateZero,
+	id.AggregateFunction_DurationInStateNonZero,
+	id.AggregateFunction_NumberOfTransitions,
+	id.AggregateFunction_Start,
+	id.AggregateFunction_End,
+	id.AggregateFunction_Delta,
+	id.AggregateFunction_StartBound,
+	id.AggregateFunction_EndBound,
+	id.AggregateFunction_DeltaBounds,
+	id.AggregateFunction_DurationGood,
+	id.AggregateFunction_DurationBad,
+	id.AggregateFunction_PercentGood,
+	id.AggregateFunction_PercentBad,
+	id.AggregateFunction_WorstQuality,
+	id.AggregateFunction_StandardDeviationSample,
+	id.AggregateFunction_StandardDeviationPopulation,
+	id.AggregateFunction_VarianceSample,
+	id.AggregateFunction_VariancePopulation,
+}
+
+// aggregateAliases - короткие имена распространённых функций
+var aggregateAliases = map[string]string{
+	"min": "Minimum",
+	"max": "Maximum",
+	"avg": "Average",
+}
+
+// ReadAggregates возвращает функции агрегирования, которые сервер перечисляет
+// в ServerCapabilities и HistoryServerCapabilities
+func ReadAggregates() ([]Aggregate, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	return readAggregates(context.Background())
+}
+
+func readAggregates(ctx context.Context) ([]Aggregate, error) {
+	var aggs []Aggregate
+	var firstErr error
+	seen := make(map[string]bool)
+	for _, folder := range []uint32{id.Server_ServerCapabilities_AggregateFunctions, id.HistoryServerCapabilities_AggregateFunctions} {
+		refs, err := browseRefs(ctx, ua.NewNumericNodeID(0, folder), id.HierarchicalReferences, ua.BrowseDirectionForward)
+		if err != nil {
+			// Папки HistoryServerCapabilities может не быть
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, r := range refs {
+			if r.NodeID == nil || r.BrowseName == nil || seen[r.NodeID.NodeID.String()] {
+				continue
+			}
+			seen[r.NodeID.NodeID.String()] = true
+			aggs = append(aggs, Aggregate{Name: r.BrowseName.Name, NodeID: r.NodeID.NodeID})
+		}
+	}
+	if aggs == nil && firstErr != nil {
+		return nil, fmt.Errorf("failed to read aggregate functions: %w", firstErr)
+	}
+	return aggs, nil
+}
+
+// resolveAggregates находит функции агрегирования по именам без учёта
+// регистра: сначала среди перечисленных сервером, затем среди стандартных.
+// Вместо имени можно указать NodeId функции.
+func resolveAggregates(ctx context.Context, names []string) ([]Aggregate, error) {
+	var known []Aggregate
+	if aggs, err := readAggregates(ctx); err == nil {
+		known = aggs
+	}
+	for _, a := range standardAggregates {
+		known = append(known, Aggregate{Name: strings.TrimPrefix(id.Name(a), "AggregateFunction_"), NodeID: ua.NewNumericNodeID(0, a)})
+	}
+
+	out := make([]Aggregate, 0, len(names))
+next:
+	for _, name := range names {
+		want := name
+		if alias, ok := aggregateAliases[strings.ToLower(name)]; ok {
+			want = alias
+		}
+		for _, a := range known {
+			if strings.EqualFold(a.Name, want) {
+				out = append(out, a)
+				continue next
+			}
+		}
+		// ua.ParseNodeID принимает и просто строку, поэтому NodeId
+		// распознаётся по префиксу вида i=, ns=, nsu=
+		if strings.Contains(name, "=") && !IsBrowsePath(name) {
+			if n, err := ParseNodeID(name); err == nil {
+				out = append(out, Aggregate{Name: name, NodeID: n})
+				continue
+			}
+		}
+		return nil, fmt.Errorf("unknown aggregate: %s", name)
+	}
+	return out, nil
+}
+
+// HistoryReadProcessed вычисляет на сервере функции агрегирования по архиву
+// за интервал [start, end], разбитый на отрезки interval; 0 - один отрезок
+// на весь интервал. Функции задаются именами (Average, Minimum, ...).
+func HistoryReadProcessed(nodeID string, start, end time.Time, interval time.Duration, aggregates []string) ([]AggregateValues, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	if len(aggregates) == 0 {
+		return nil, fmt.Errorf("no aggregates")
+	}
+	ctx := context.Background()
+	aggs, err := resolveAggregates(ctx, aggregates)
+	if err != nil {
+		return nil, err
+	}
+
+	// Каждая функция читается отдельным запросом: в одном запросе список
+	// AggregateType должен совпадать со списком узлов
+	out := make([]AggregateValues, len(aggs))
+	for i, a := range aggs {
+		details := &ua.ReadProcessedDetails{
+			StartTime:              start,
+			EndTime:                end,
+			ProcessingInterval:     float64(interval) / float64(time.Millisecond),
+			AggregateType:          []*ua.NodeID{a.NodeID},
+			AggregateConfiguration: &ua.AggregateConfiguration{UseServerCapabilitiesDefaults: true},
+		}
+		data, err := historyRead(ctx, nodeID, details, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Name, err)
+		}
+		out[i] = AggregateValues{Aggregate: a, Values: data.Values}
+	}
+	return out, nil
+}
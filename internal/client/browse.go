@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// browseRefs возвращает ссылки узла заданного типа (с подтипами) в указанном
+// направлении, следуя точкам продолжения
+func browseRefs(ctx context.Context, nodeID *ua.NodeID, refType uint32, dir ua.BrowseDirection) ([]*ua.ReferenceDescription, error) {
+	req := &ua.BrowseRequest{
+		NodesToBrowse: []*ua.BrowseDescription{{
+			NodeID:          nodeID,
+			BrowseDirection: dir,
+			ReferenceTypeID: ua.NewNumericNodeID(0, refType),
+			IncludeSubtypes: true,
+			ResultMask:      uint32(ua.BrowseResultMaskAll),
+		}},
+	}
+
+	resp, err := session.Browse(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("browse failed: %w", err)
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("no results")
+	}
+
+	res := resp.Results[0]
+	if res.StatusCode != ua.StatusOK {
+		return nil, fmt.Errorf("bad status: %v", res.StatusCode)
+	}
+	return browseContinue(ctx, res)
+}
+
+// readAttributes читает несколько атрибутов одного узла одним запросом
+func readAttributes(ctx context.Context, nodeID *ua.NodeID, attrs ...ua.AttributeID) ([]*ua.DataValue, error) {
+	req := &ua.ReadRequest{TimestampsToReturn: ua.TimestampsToReturnNeither}
+	for _, a := range attrs {
+		req.NodesToRead = append(req.NodesToRead, &ua.ReadValueID{NodeID: nodeID, AttributeID: a})
+	}
+
+	resp, err := session.Read(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	if len(resp.Results) != len(attrs) {
+		return nil, fmt.Errorf("unexpected number of results")
+	}
+	return resp.Results, nil
+}
+
+// readValues читает атрибут Value нескольких узлов одним запросом
+func readValues(ctx context.Context, nodes []*ua.NodeID) ([]*ua.DataValue, error) {
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	req := &ua.ReadRequest{TimestampsToReturn: ua.TimestampsToReturnNeither}
+	for _, n := range nodes {
+		req.NodesToRead = append(req.NodesToRead, &ua.ReadValueID{NodeID: n, AttributeID: ua.AttributeIDValue})
+	}
+
+	resp, err := session.Read(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	if len(resp.Results) != len(nodes) {
+		return nil, fmt.Errorf("unexpected number of results")
+	}
+	return resp.Results, nil
+}
+
+// browseMany обозревает узлы пакетами не более limit описаний, отправляя
+// не более parallel запросов одновременно, и дочитывает точки продолжения.
+// Узлы, которые нельзя обозреть, получают пустой список ссылок.
+func browseMany(ctx context.Context, descs []*ua.BrowseDescription, parallel, limit int) ([][]*ua.ReferenceDescription, error) {
+	res := make([][]*ua.ReferenceDescription, len(descs))
+	err := inBatches(len(descs), limit, parallel, func(lo, hi int) error {
+		resp, err := session.Browse(ctx, &ua.BrowseRequest{NodesToBrowse: descs[lo:hi]})
+		if err != nil {
+			return fmt.Errorf("browse failed: %w", err)
+		}
+		if len(resp.Results) != hi-lo {
+			return fmt.Errorf("unexpected number of results")
+		}
+
+		for j, r := range resp.Results {
+			if r.StatusCode != ua.StatusOK {
+				continue
+			}
+			refs, err := browseContinue(ctx, r)
+			if err != nil {
+				return err
+			}
+			res[lo+j] = refs
+		}
+		return nil
+	})
+	return res, err
+}
+
+// browseContinue дочитывает ссылки узла по точке продолжения
+func browseContinue(ctx context.Context, res *ua.BrowseResult) ([]*ua.ReferenceDescription, error) {
+	refs := res.References
+	for len(res.ContinuationPoint) > 0 {
+		next, err := session.BrowseNext(ctx, &ua.BrowseNextRequest{
+			ContinuationPoints: [][]byte{res.ContinuationPoint},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("browse next failed: %w", err)
+		}
+		if len(next.Results) == 0 {
+			break
+		}
+		res = next.Results[0]
+		if res.StatusCode != ua.StatusOK {
+			return nil, fmt.Errorf("bad status: %v", res.StatusCode)
+		}
+		refs = append(refs, res.References...)
+	}
+	return refs, nil
+}
+
+// readMany читает атрибуты пакетами не более limit элементов, отправляя
+// не более parallel запросов одновременно. Метки времени не запрашиваются.
+func readMany(ctx context.Context, items []*ua.ReadValueID, parallel, limit int) ([]*ua.DataValue, error) {
+	return readBatches(ctx, &ua.ReadRequest{TimestampsToReturn: ua.TimestampsToReturnNeither}, items, parallel, limit)
+}
+
+// readBatches читает элементы пакетами, как readMany; параметры запроса
+// (MaxAge, TimestampsToReturn) берутся из req
+func readBatches(ctx context.Context, req *ua.ReadRequest, items []*ua.ReadValueID, parallel, limit int) ([]*ua.DataValue, error) {
+	res := make([]*ua.DataValue, len(items))
+	err := inBatches(len(items), limit, parallel, func(lo, hi int) error {
+		resp, err := session.Read(ctx, &ua.ReadRequest{
+			MaxAge:             req.MaxAge,
+			NodesToRead:        items[lo:hi],
+			TimestampsToReturn: req.TimestampsToReturn,
+		})
+		if err != nil {
+			return fmt.Errorf("read failed: %w", err)
+		}
+		if len(resp.Results) != hi-lo {
+			return fmt.Errorf("unexpected number of results")
+		}
+		copy(res[lo:hi], resp.Results)
+		return nil
+	})
+	return res, err
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// pathCache хранит NodeId, найденные по путям просмотра. Сбрасывается
+// при смене сессии: пути разрешаются в адресном пространстве сервера.
+type pathCache struct {
+	mu    sync.Mutex
+	nodes map[string]*ua.NodeID
+}
+
+var paths = &pathCache{}
+
+func (c *pathCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nodes = nil
+}
+
+// resolve возвращает NodeId узла по пути, обращаясь к серверу только
+// при первом запросе пути
+func (c *pathCache) resolve(ctx context.Context, path string) (*ua.NodeID, error) {
+	c.mu.Lock()
+	n, ok := c.nodes[path]
+	c.mu.Unlock()
+	if ok {
+		return n, nil
+	}
+
+	names, err := parseBrowsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	n, err = translateBrowsePath(ctx, names)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, path)
+	}
+
+	c.mu.Lock()
+	if c.nodes == nil {
+		c.nodes = make(map[string]*ua.NodeID)
+	}
+	c.nodes[path] = n
+	c.mu.Unlock()
+	return n, nil
+}
+
+// resolveMany находит узлы по путям пакетами не более limit путей, отправляя
+// не более parallel запросов одновременно. Найденные пути запоминаются,
+// ошибки поиска возвращаются по одной на путь.
+func (c *pathCache) resolveMany(ctx context.Context, list []string, parallel, limit int) ([]*ua.NodeID, []error, error) {
+	nodes := make([]*ua.NodeID, len(list))
+	errs := make([]error, len(list))
+	var (
+		missing []int
+		names   [][]*ua.QualifiedName
+	)
+	c.mu.Lock()
+	for i, path := range list {
+		if n, ok := c.nodes[path]; ok {
+			nodes[i] = n
+			continue
+		}
+		q, err := parseBrowsePath(path)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		missing = append(missing, i)
+		names = append(names, q)
+	}
+	c.mu.Unlock()
+
+	err := inBatches(len(missing), limit, parallel, func(lo, hi int) error {
+		found, ferrs, err := translateBrowsePaths(ctx, names[lo:hi])
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.nodes == nil {
+			c.nodes = make(map[string]*ua.NodeID)
+		}
+		for j, i := range missing[lo:hi] {
+			if ferrs[j] != nil {
+				errs[i] = fmt.Errorf("%w: %s", ferrs[j], list[i])
+				continue
+			}
+			nodes[i] = found[j]
+			c.nodes[list[i]] = found[j]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodes, errs, nil
+}
+
+// IsBrowsePath сообщает, задан ли узел путём просмотра, а не NodeId:
+// /Objects/2:DeviceSet или Objects.Server.ServerStatus
+func IsBrowsePath(s string) bool {
+	if strings.HasPrefix(s, "/") {
+		return true
+	}
+	return !hasNodeIDPrefix(s) && strings.Contains(s, ".")
+}
+
+// hasNodeIDPrefix сообщает, начинается ли s с префикса формы NodeId
+func hasNodeIDPrefix(s string) bool {
+	for _, p := range []string{"ns=", "nsu=", "i=", "s=", "g=", "b="} {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBrowsePath разбирает путь из имён просмотра от папки Root.
+// Имена разделяются "/" (путь начинается с "/") или ".", префикс
+// <индекс>: задаёт пространство имён имени, без него используется 0.
+func parseBrowsePath(path string) ([]*ua.QualifiedName, error) {
+	var parts []string
+	switch {
+	case path == "/":
+		return nil, nil
+	case strings.HasPrefix(path, "/"):
+		parts = strings.Split(path[1:], "/")
+	default:
+		parts = strings.Split(path, ".")
+	}
+
+	names := make([]*ua.QualifiedName, len(parts))
+	for i, p := range parts {
+		q := &ua.QualifiedName{Name: p}
+		if j := strings.Index(p, ":"); j > 0 {
+			if ns, err := strconv.ParseUint(p[:j], 10, 16); err == nil {
+				q.NamespaceIndex, q.Name = uint16(ns), p[j+1:]
+			}
+		}
+		if q.Name == "" {
+			return nil, fmt.Errorf("invalid browse path: %s", path)
+		}
+		names[i] = q
+	}
+	return names, nil
+}
+
+// errPathNotFound возвращается, если по пути нет узла
+var errPathNotFound = errors.New("browse path not found")
+
+// translateBrowsePath находит узел службой TranslateBrowsePathsToNodeIds.
+// Поиск по шагам через Browse не используется: клиент gopcua при отказе
+// сервера в службе переподключается, и следующий запрос всё равно не пройдёт.
+func translateBrowsePath(ctx context.Context, names []*ua.QualifiedName) (*ua.NodeID, error) {
+	nodes, errs, err := translateBrowsePaths(ctx, [][]*ua.QualifiedName{names})
+	if err != nil {
+		return nil, err
+	}
+	return nodes[0], errs[0]
+}
+
+// translateBrowsePaths находит узлы по нескольким путям одним запросом.
+// Ошибка поиска пути возвращается в errs, ошибка запроса - в err.
+func translateBrowsePaths(ctx context.Context, paths [][]*ua.QualifiedName) ([]*ua.NodeID, []error, error) {
+	root := ua.NewNumericNodeID(0, id.RootFolder)
+	nodes := make([]*ua.NodeID, len(paths))
+	errs := make([]error, len(paths))
+	req := &ua.TranslateBrowsePathsToNodeIDsRequest{}
+	var index []int
+	for i, names := range paths {
+		if len(names) == 0 {
+			nodes[i] = root
+			continue
+		}
+		rp := &ua.RelativePath{}
+		for _, q := range names {
+			rp.Elements = append(rp.Elements, &ua.RelativePathElement{
+				ReferenceTypeID: ua.NewNumericNodeID(0, id.HierarchicalReferences),
+				IncludeSubtypes: true,
+				TargetName:      q,
+			})
+		}
+		req.BrowsePaths = append(req.BrowsePaths, &ua.BrowsePath{StartingNode: root, RelativePath: rp})
+		index = append(index, i)
+	}
+	if len(index) == 0 {
+		return nodes, errs, nil
+	}
+
+	resp, err := session.TranslateBrowsePathsToNodeIDs(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("translate browse path failed: %w", err)
+	}
+	if len(resp.Results) != len(index) {
+		return nil, nil, fmt.Errorf("no results")
+	}
+
+	for j, res := range resp.Results {
+		i := index[j]
+		switch res.StatusCode {
+		case ua.StatusOK:
+		case ua.StatusBadNoMatch:
+			errs[i] = errPathNotFound
+			continue
+		default:
+			errs[i] = fmt.Errorf("bad status: %v", res.StatusCode)
+			continue
+		}
+		// Цели, найденные не до конца (на другом сервере), пропускаются
+		for _, t := range res.Targets {
+			if t.RemainingPathIndex == ^uint32(0) && t.TargetID != nil && t.TargetID.NodeID != nil {
+				nodes[i] = t.TargetID.NodeID
+				break
+			}
+		}
+		if nodes[i] == nil {
+			errs[i] = errPathNotFound
+		}
+	}
+	return nodes, errs, nil
+}
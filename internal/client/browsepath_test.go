@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestIsBrowsePath проверяет отличие путей просмотра от NodeId.
+func TestIsBrowsePath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"/Objects/2:DeviceSet", true},
+		{"/", true},
+		{"Objects.Server.ServerStatus.State", true},
+		{"ns=1;s=Simulation.Setpoint", false},
+		{"s=Line1.Temperature", false},
+		{"nsu=urn:plant;s=Tag", false},
+		{"i=2258", false},
+		{"AnalogItemType", false},
+	}
+	for _, tt := range tests {
+		if got := IsBrowsePath(tt.in); got != tt.want {
+			t.Errorf("IsBrowsePath(%q) = %v, ожидалось %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestParseBrowsePath проверяет разбор пути на имена просмотра.
+func TestParseBrowsePath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"/", "[]", false},
+		{"/Objects/2:DeviceSet/2:PLC1/3:Temperature", "[0:Objects 2:DeviceSet 2:PLC1 3:Temperature]", false},
+		{"Objects.Server.ServerStatus.State", "[0:Objects 0:Server 0:ServerStatus 0:State]", false},
+		{"/Objects/x:Tag", "[0:Objects 0:x:Tag]", false},
+		{"/Objects//Server", "", true},
+		{"Objects.2:", "", true},
+	}
+	for _, tt := range tests {
+		names, err := parseBrowsePath(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBrowsePath(%q) ошибка = %v, ожидалась ошибка %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		parts := make([]string, len(names))
+		for i, q := range names {
+			parts[i] = fmt.Sprintf("%d:%s", q.NamespaceIndex, q.Name)
+		}
+		if got := fmt.Sprint(parts); got != tt.want {
+			t.Errorf("parseBrowsePath(%q) = %s, ожидалось %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -4,32 +4,26 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/gopcua/opcua"
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/id"
 	"github.com/gopcua/opcua/ua"
 )
 
-var client *opcua.Client
-
 // Connect устанавливает соединение с OPC UA сервером
 func Connect(endpoint string) error {
-	if client != nil {
+	if session != nil {
 		fmt.Println("Already connected. Disconnecting first.")
 		Disconnect()
 	}
 
 	fmt.Printf("Connecting to %s...\n", endpoint)
 
-	ctx := context.Background()
-	var err error
-	client, err = opcua.NewClient(endpoint)
+	s, err := Dial(context.Background(), endpoint)
 	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
-	}
-
-	if err := client.Connect(ctx); err != nil {
-		client = nil
-		return fmt.Errorf("failed to connect: %w", err)
+		return err
 	}
+	Attach(s)
 
 	fmt.Println("Successfully connected!")
 
@@ -46,19 +40,13 @@ func Connect(endpoint string) error {
 
 // Disconnect закрывает соединение с сервером
 func Disconnect() error {
-	if client != nil {
+	if session != nil {
 		fmt.Println("Disconnecting...")
-		client.Close(context.Background())
-		client = nil
+		Attach(nil)
 	}
 	return nil
 }
 
-// GetClient возвращает активный клиент или nil
-func GetClient() *opcua.Client {
-	return client
-}
-
 // ServerInfo содержит информацию о OPC UA сервере
 type ServerInfo struct {
 	ProductName      string
@@ -69,17 +57,17 @@ type ServerInfo struct {
 
 // GetServerInfo получает информацию о сервере
 func GetServerInfo() (*ServerInfo, error) {
-	if client == nil {
+	if session == nil {
 		return nil, fmt.Errorf("not connected to server")
 	}
 
 	ctx := context.Background()
 
 	// Node IDs из стандартного адресного пространства OPC UA
-	productNameID := "i=2261"
-	manufacturerID := "i=2262"
-	versionID := "i=2263"
-	stateID := "i=2259"
+	productNameID := serverNodeID(id.Server_ServerStatus_BuildInfo_ProductName)
+	manufacturerID := serverNodeID(id.Server_ServerStatus_BuildInfo_ManufacturerName)
+	versionID := serverNodeID(id.Server_ServerStatus_BuildInfo_SoftwareVersion)
+	stateID := serverNodeID(id.Server_ServerStatus_State)
 
 	info := &ServerInfo{}
 
@@ -106,6 +94,10 @@ func GetServerInfo() (*ServerInfo, error) {
 	return info, nil
 }
 
+func serverNodeID(n uint32) string {
+	return ua.NewNumericNodeID(0, n).String()
+}
+
 // printServerInfo выводит информацию о сервере в консоль
 func printServerInfo(info *ServerInfo) {
 	fmt.Println("\n=== Server Information ===")
@@ -124,32 +116,21 @@ func printServerInfo(info *ServerInfo) {
 	fmt.Println("==========================")
 }
 
-// readNodeValue читает значение узла по Node ID
+// readNodeValue читает значение узла по Node ID и возвращает его текстовое представление
 func readNodeValue(ctx context.Context, nodeID string) (string, error) {
-	id, err := ua.ParseNodeID(nodeID)
+	dv, err := readDataValue(ctx, nodeID, "")
 	if err != nil {
-		return "", fmt.Errorf("invalid node ID: %w", err)
-	}
-
-	req := &ua.ReadRequest{
-		MaxAge:             2000,
-		NodesToRead:        []*ua.ReadValueID{{NodeID: id}},
-		TimestampsToReturn: ua.TimestampsToReturnBoth,
+		return "", err
 	}
 
-	resp, err := client.Read(ctx, req)
-	if err != nil {
-		return "", fmt.Errorf("read failed: %w", err)
+	if dv.Status != ua.StatusOK {
+		return "", fmt.Errorf("bad status: %v", dv.Status)
 	}
 
-	if len(resp.Results) == 0 {
-		return "", fmt.Errorf("no results")
+	// Перечисления (например ServerState) выводятся вместе с именем: "0 (Running)"
+	var enum *datatype.Definition
+	if id, err := ParseNodeID(nodeID); err == nil {
+		enum = types.nodeEnum(ctx, id)
 	}
-
-	result := resp.Results[0]
-	if result.Status != ua.StatusOK {
-		return "", fmt.Errorf("bad status: %v", result.Status)
-	}
-
-	return fmt.Sprintf("%v", result.Value.Value()), nil
+	return formatter.EnumValue(dv.Value, enum), nil
 }
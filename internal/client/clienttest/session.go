@@ -0,0 +1,453 @@
+// Package clienttest содержит сессию OPC UA в памяти для тестов команд
+// без сервера. Сессия отвечает на запросы по заданным узлам и записывает
+// все полученные запросы, чтобы тест мог проверить, что именно отправила
+// команда.
+package clienttest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+)
+
+// Node - узел сессии
+type Node struct {
+	// Attributes - значения атрибутов; атрибуты, которых нет,
+	// читаются со статусом BadAttributeIdInvalid
+	Attributes map[ua.AttributeID]*ua.DataValue
+	// WriteStatus, если задан, возвращается при записи вместо записи значения
+	WriteStatus ua.StatusCode
+	// References возвращаются при просмотре узла
+	References []*ua.ReferenceDescription
+	// Method вызывается для узлов-методов
+	Method func(args []*ua.Variant) ([]*ua.Variant, ua.StatusCode)
+	// History отвечает на HistoryRead: details - параметры чтения
+	// (*ua.ReadRawModifiedDetails, ...), cp - точка продолжения из запроса.
+	// Без History чтение архива возвращает BadHistoryOperationUnsupported.
+	History func(details interface{}, cp []byte) *ua.HistoryReadResult
+	// HistoryUpdate отвечает на HistoryUpdate: details - изменение архива
+	// (*ua.UpdateDataDetails, ...). Без HistoryUpdate изменение архива
+	// возвращает BadHistoryOperationUnsupported.
+	HistoryUpdate func(details interface{}) *ua.HistoryUpdateResult
+}
+
+// Session реализует client.Session в памяти
+type Session struct {
+	Endpoint   string
+	Namespaces []string
+	// Err, если задана, возвращается всеми запросами
+	Err error
+
+	mu       sync.Mutex
+	nodes    map[string]*Node
+	requests []interface{}
+	subs     []*Subscription
+	closed   bool
+}
+
+// New создаёт пустую сессию с таблицей пространств имён из одного
+// стандартного пространства
+func New() *Session {
+	return &Session{
+		Endpoint:   "opc.tcp://clienttest",
+		Namespaces: []string{"http://opcfoundation.org/UA/"},
+		nodes:      make(map[string]*Node),
+	}
+}
+
+// Attach делает новую сессию активной в пакете client и отключает её
+// после теста
+func Attach(t interface{ Cleanup(func()) }) *Session {
+	s := New()
+	client.Attach(s)
+	t.Cleanup(func() { client.Attach(nil) })
+	return s
+}
+
+// Add добавляет узел
+func (s *Session) Add(nodeID string, n *Node) *Node {
+	if n.Attributes == nil {
+		n.Attributes = make(map[ua.AttributeID]*ua.DataValue)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[ua.MustParseNodeID(nodeID).String()] = n
+	return n
+}
+
+// Variable добавляет записываемую переменную со значением value;
+// тип данных определяется по типу значения
+func (s *Session) Variable(nodeID, name string, value interface{}) *Node {
+	v := ua.MustVariant(value)
+	n := ua.MustParseNodeID(nodeID)
+	return s.Add(nodeID, &Node{Attributes: map[ua.AttributeID]*ua.DataValue{
+		ua.AttributeIDNodeID:      dataValue(n),
+		ua.AttributeIDNodeClass:   dataValue(int32(ua.NodeClassVariable)),
+		ua.AttributeIDBrowseName:  dataValue(&ua.QualifiedName{NamespaceIndex: n.Namespace(), Name: name}),
+		ua.AttributeIDDisplayName: dataValue(&ua.LocalizedText{EncodingMask: ua.LocalizedTextText, Text: name}),
+		ua.AttributeIDValue:       {EncodingMask: ua.DataValueValue, Value: v},
+		ua.AttributeIDDataType:    dataValue(ua.NewNumericNodeID(0, uint32(v.Type()))),
+		ua.AttributeIDValueRank:   dataValue(int32(-1)),
+		ua.AttributeIDAccessLevel: dataValue(uint8(ua.AccessLevelTypeCurrentRead | ua.AccessLevelTypeCurrentWrite)),
+	}})
+}
+
+// Value возвращает текущее значение переменной или nil
+func (s *Session) Value(nodeID string) *ua.Variant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.nodes[ua.MustParseNodeID(nodeID).String()]
+	if !ok || n.Attributes[ua.AttributeIDValue] == nil {
+		return nil
+	}
+	return n.Attributes[ua.AttributeIDValue].Value
+}
+
+// Requests возвращает полученные запросы в порядке поступления
+func (s *Session) Requests() []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]interface{}(nil), s.requests...)
+}
+
+// Writes возвращает все записанные значения в порядке поступления
+func (s *Session) Writes() []*ua.WriteValue {
+	var out []*ua.WriteValue
+	for _, r := range s.Requests() {
+		if w, ok := r.(*ua.WriteRequest); ok {
+			out = append(out, w.NodesToWrite...)
+		}
+	}
+	return out
+}
+
+// Subscriptions возвращает созданные подписки
+func (s *Session) Subscriptions() []*Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Subscription(nil), s.subs...)
+}
+
+// Closed сообщает, закрыта ли сессия
+func (s *Session) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// record записывает запрос и возвращает ошибку Err
+func (s *Session) record(req interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	return s.Err
+}
+
+func (s *Session) node(nodeID *ua.NodeID) *Node {
+	if nodeID == nil {
+		return nil
+	}
+	return s.nodes[nodeID.String()]
+}
+
+func (s *Session) Read(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error) {
+	if err := s.record(req); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]*ua.DataValue, len(req.NodesToRead))
+	for i, r := range req.NodesToRead {
+		n := s.node(r.NodeID)
+		switch {
+		case n == nil:
+			res[i] = statusValue(ua.StatusBadNodeIDUnknown)
+		case n.Attributes[r.AttributeID] == nil:
+			res[i] = statusValue(ua.StatusBadAttributeIDInvalid)
+		default:
+			dv := *n.Attributes[r.AttributeID]
+			res[i] = &dv
+		}
+	}
+	return &ua.ReadResponse{ResponseHeader: header(), Results: res}, nil
+}
+
+func (s *Session) Write(ctx context.Context, req *ua.WriteRequest) (*ua.WriteResponse, error) {
+	if err := s.record(req); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]ua.StatusCode, len(req.NodesToWrite))
+	for i, w := range req.NodesToWrite {
+		n := s.node(w.NodeID)
+		if n == nil {
+			res[i] = ua.StatusBadNodeIDUnknown
+			continue
+		}
+		if n.WriteStatus != ua.StatusOK {
+			res[i] = n.WriteStatus
+			continue
+		}
+		n.Attributes[w.AttributeID] = w.Value
+		res[i] = ua.StatusOK
+	}
+	return &ua.WriteResponse{ResponseHeader: header(), Results: res}, nil
+}
+
+// Browse возвращает ссылки узла с учётом направления просмотра.
+// Тип ссылок и маска классов узлов не учитываются.
+func (s *Session) Browse(ctx context.Context, req *ua.BrowseRequest) (*ua.BrowseResponse, error) {
+	if err := s.record(req); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]*ua.BrowseResult, len(req.NodesToBrowse))
+	for i, d := range req.NodesToBrowse {
+		n := s.node(d.NodeID)
+		if n == nil {
+			res[i] = &ua.BrowseResult{StatusCode: ua.StatusBadNodeIDUnknown}
+			continue
+		}
+		refs := []*ua.ReferenceDescription{}
+		for _, r := range n.References {
+			if d.BrowseDirection == ua.BrowseDirectionForward && !r.IsForward ||
+				d.BrowseDirection == ua.BrowseDirectionInverse && r.IsForward {
+				continue
+			}
+			refs = append(refs, r)
+		}
+		res[i] = &ua.BrowseResult{StatusCode: ua.StatusOK, References: refs}
+	}
+	return &ua.BrowseResponse{ResponseHeader: header(), Results: res}, nil
+}
+
+// BrowseNext не возвращает ссылок: Browse отдаёт все ссылки сразу
+func (s *Session) BrowseNext(ctx context.Context, req *ua.BrowseNextRequest) (*ua.BrowseNextResponse, error) {
+	if err := s.record(req); err != nil {
+		return nil, err
+	}
+	res := make([]*ua.BrowseResult, len(req.ContinuationPoints))
+	for i := range res {
+		res[i] = &ua.BrowseResult{StatusCode: ua.StatusOK}
+	}
+	return &ua.BrowseNextResponse{ResponseHeader: header(), Results: res}, nil
+}
+
+func (s *Session) Call(ctx context.Context, req *ua.CallMethodRequest) (*ua.CallMethodResult, error) {
+	if err := s.record(req); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	n := s.node(req.MethodID)
+	s.mu.Unlock()
+
+	if n == nil || n.Method == nil {
+		return &ua.CallMethodResult{StatusCode: ua.StatusBadMethodInvalid}, nil
+	}
+	out, status := n.Method(req.InputArguments)
+	return &ua.CallMethodResult{StatusCode: status, OutputArguments: out}, nil
+}
+
+// TranslateBrowsePathsToNodeIDs проходит пути по прямым ссылкам узлов,
+// сравнивая имена просмотра; тип ссылок не учитывается
+func (s *Session) TranslateBrowsePathsToNodeIDs(ctx context.Context, req *ua.TranslateBrowsePathsToNodeIDsRequest) (*ua.TranslateBrowsePathsToNodeIDsResponse, error) {
+	if err := s.record(req); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]*ua.BrowsePathResult, len(req.BrowsePaths))
+	for i, p := range req.BrowsePaths {
+		n := p.StartingNode
+		for _, e := range p.RelativePath.Elements {
+			n = s.child(n, e.TargetName)
+			if n == nil {
+				break
+			}
+		}
+		if n == nil {
+			res[i] = &ua.BrowsePathResult{StatusCode: ua.StatusBadNoMatch}
+			continue
+		}
+		res[i] = &ua.BrowsePathResult{StatusCode: ua.StatusOK, Targets: []*ua.BrowsePathTarget{{
+			TargetID:           ua.NewExpandedNodeID(n, "", 0),
+			RemainingPathIndex: ^uint32(0),
+		}}}
+	}
+	return &ua.TranslateBrowsePathsToNodeIDsResponse{ResponseHeader: header(), Results: res}, nil
+}
+
+// child возвращает узел, на который ссылается parent, с именем просмотра name
+func (s *Session) child(parent *ua.NodeID, name *ua.QualifiedName) *ua.NodeID {
+	n := s.node(parent)
+	if n == nil {
+		return nil
+	}
+	for _, r := range n.References {
+		bn := r.BrowseName
+		if r.IsForward && bn != nil && bn.NamespaceIndex == name.NamespaceIndex && bn.Name == name.Name {
+			return r.NodeID.NodeID
+		}
+	}
+	return nil
+}
+
+// HistoryRead передаёт параметры чтения обработчикам History узлов.
+// Запрос на освобождение точек продолжения только записывается.
+func (s *Session) HistoryRead(ctx context.Context, req *ua.HistoryReadRequest) (*ua.HistoryReadResponse, error) {
+	if err := s.record(req); err != nil {
+		return nil, err
+	}
+	var details interface{}
+	if req.HistoryReadDetails != nil {
+		details = req.HistoryReadDetails.Value
+	}
+
+	res := make([]*ua.HistoryReadResult, len(req.NodesToRead))
+	for i, r := range req.NodesToRead {
+		s.mu.Lock()
+		n := s.node(r.NodeID)
+		s.mu.Unlock()
+		switch {
+		case req.ReleaseContinuationPoints:
+			res[i] = &ua.HistoryReadResult{StatusCode: ua.StatusOK}
+		case n == nil:
+			res[i] = &ua.HistoryReadResult{StatusCode: ua.StatusBadNodeIDUnknown}
+		case n.History == nil:
+			res[i] = &ua.HistoryReadResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+		default:
+			res[i] = n.History(details, r.ContinuationPoint)
+		}
+	}
+	return &ua.HistoryReadResponse{ResponseHeader: header(), Results: res}, nil
+}
+
+// HistoryUpdate передаёт изменения архива обработчикам HistoryUpdate
+// узлов, указанных в параметрах изменения
+func (s *Session) HistoryUpdate(ctx context.Context, req *ua.HistoryUpdateRequest) (*ua.HistoryUpdateResponse, error) {
+	if err := s.record(req); err != nil {
+		return nil, err
+	}
+	res := make([]*ua.HistoryUpdateResult, len(req.HistoryUpdateDetails))
+	for i, eo := range req.HistoryUpdateDetails {
+		var details interface{}
+		var nodeID *ua.NodeID
+		if eo != nil {
+			details = eo.Value
+		}
+		switch d := details.(type) {
+		case *ua.UpdateDataDetails:
+			nodeID = d.NodeID
+		case *ua.DeleteRawModifiedDetails:
+			nodeID = d.NodeID
+		case *ua.DeleteAtTimeDetails:
+			nodeID = d.NodeID
+		}
+		s.mu.Lock()
+		n := s.node(nodeID)
+		s.mu.Unlock()
+		switch {
+		case n == nil:
+			res[i] = &ua.HistoryUpdateResult{StatusCode: ua.StatusBadNodeIDUnknown}
+		case n.HistoryUpdate == nil:
+			res[i] = &ua.HistoryUpdateResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+		default:
+			res[i] = n.HistoryUpdate(details)
+		}
+	}
+	return &ua.HistoryUpdateResponse{ResponseHeader: header(), Results: res}, nil
+}
+
+func (s *Session) Subscribe(ctx context.Context, params *opcua.SubscriptionParameters, notify chan<- *opcua.PublishNotificationData) (client.Subscription, error) {
+	if err := s.record(params); err != nil {
+		return nil, err
+	}
+	sub := &Subscription{Params: params, Notify: notify}
+	s.mu.Lock()
+	s.subs = append(s.subs, sub)
+	s.mu.Unlock()
+	return sub, nil
+}
+
+func (s *Session) Info() client.SessionInfo {
+	return client.SessionInfo{Endpoint: s.Endpoint, Namespaces: s.Namespaces}
+}
+
+func (s *Session) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// Subscription - подписка записывающей сессии. Уведомления тест
+// отправляет сам в канал Notify.
+type Subscription struct {
+	Params *opcua.SubscriptionParameters
+	Notify chan<- *opcua.PublishNotificationData
+
+	mu        sync.Mutex
+	items     []*ua.MonitoredItemCreateRequest
+	cancelled bool
+}
+
+// Monitor записывает элементы; их MonitoredItemId - порядковые номера с 1
+func (s *Subscription) Monitor(ctx context.Context, ts ua.TimestampsToReturn, items ...*ua.MonitoredItemCreateRequest) (*ua.CreateMonitoredItemsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]*ua.MonitoredItemCreateResult, len(items))
+	for i, it := range items {
+		s.items = append(s.items, it)
+		res[i] = &ua.MonitoredItemCreateResult{
+			StatusCode:              ua.StatusOK,
+			MonitoredItemID:         uint32(len(s.items)),
+			RevisedSamplingInterval: it.RequestedParameters.SamplingInterval,
+			RevisedQueueSize:        it.RequestedParameters.QueueSize,
+		}
+	}
+	return &ua.CreateMonitoredItemsResponse{ResponseHeader: header(), Results: res}, nil
+}
+
+func (s *Subscription) Cancel(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelled = true
+	return nil
+}
+
+// Items возвращает отслеживаемые элементы
+func (s *Subscription) Items() []*ua.MonitoredItemCreateRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*ua.MonitoredItemCreateRequest(nil), s.items...)
+}
+
+// Cancelled сообщает, отменена ли подписка
+func (s *Subscription) Cancelled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cancelled
+}
+
+func dataValue(v interface{}) *ua.DataValue {
+	return &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(v)}
+}
+
+func statusValue(code ua.StatusCode) *ua.DataValue {
+	return &ua.DataValue{EncodingMask: ua.DataValueStatusCode, Status: code}
+}
+
+func header() *ua.ResponseHeader {
+	return &ua.ResponseHeader{ServiceResult: ua.StatusOK}
+}
+
+var _ client.Session = (*Session)(nil)
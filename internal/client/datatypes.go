@@ -0,0 +1,394 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// typeResolver загружает описания типов данных с сервера и кэширует их
+// на время соединения. Реализует datatype.Resolver.
+type typeResolver struct {
+	mu           sync.Mutex
+	byType       map[string]*datatype.Definition
+	byEncoding   map[string]*datatype.Definition
+	dictionaries map[string]map[string]*datatype.Definition
+	// nodeEnums - перечисления переменных; nil означает, что значение
+	// узла перечислением не является
+	nodeEnums map[string]*datatype.Definition
+}
+
+var types = newTypeResolver()
+
+// registeredEncodings - кодировки, для которых в gopcua зарегистрирован
+// datatype.RawBody. Реестр gopcua глобален, поэтому набор не сбрасывается
+// при переподключении.
+var registeredEncodings sync.Map
+
+func newTypeResolver() *typeResolver {
+	return &typeResolver{
+		byType:       make(map[string]*datatype.Definition),
+		byEncoding:   make(map[string]*datatype.Definition),
+		dictionaries: make(map[string]map[string]*datatype.Definition),
+		nodeEnums:    make(map[string]*datatype.Definition),
+	}
+}
+
+// Types возвращает загрузчик описаний типов данных текущего соединения
+func Types() datatype.Resolver {
+	return types
+}
+
+// DataType возвращает описание типа по NodeId узла DataType. Описание берётся
+// из атрибута DataTypeDefinition, а на серверах старше 1.04 - из словаря
+// типов (DataTypeDictionary).
+func (r *typeResolver) DataType(dataType *ua.NodeID) (*datatype.Definition, error) {
+	if def, ok := datatype.Builtin(dataType); ok {
+		return def, nil
+	}
+	if def := r.cached(r.byType, dataType); def != nil {
+		return def, nil
+	}
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+
+	ctx := context.Background()
+	vals, err := readAttributes(ctx, dataType, ua.AttributeIDBrowseName, ua.AttributeIDDataTypeDefinition)
+	if err != nil {
+		return nil, err
+	}
+	if vals[0].Status != ua.StatusOK {
+		return nil, fmt.Errorf("data type %s: %v", dataType, vals[0].Status)
+	}
+
+	def := &datatype.Definition{NodeID: dataType, Name: dataType.String()}
+	if qn := vals[0].Value.QualifiedName(); qn != nil {
+		def.Name = qn.Name
+	}
+	// Сохраняем описание до разбора полей, чтобы рекурсивные типы не зацикливались
+	r.store(r.byType, dataType, def)
+
+	if vals[1].Status == ua.StatusOK && vals[1].Value != nil {
+		if eo := vals[1].Value.ExtensionObject(); eo != nil {
+			switch d := eo.Value.(type) {
+			case *ua.StructureDefinition:
+				if err := r.fillStructure(def, d); err != nil {
+					// Недостроенное описание не должно остаться в кэше
+					r.forget(r.byType, dataType)
+					if def.EncodingID != nil {
+						r.forget(r.byEncoding, def.EncodingID)
+					}
+					return nil, err
+				}
+				return def, nil
+			case *ua.EnumDefinition:
+				def.Kind = datatype.KindEnum
+				for _, f := range d.Fields {
+					name := f.Name
+					if name == "" && f.DisplayName != nil {
+						name = f.DisplayName.Text
+					}
+					def.EnumValues = append(def.EnumValues, datatype.EnumValue{Value: f.Value, Name: name})
+				}
+				return def, nil
+			}
+		}
+	}
+
+	// DataTypeDefinition нет: простой подтип встроенного типа, перечисление
+	// или структура сервера старого образца
+	super, err := r.supertype(ctx, dataType)
+	if err != nil {
+		r.forget(r.byType, dataType)
+		return nil, err
+	}
+	base, err := r.DataType(super)
+	if err != nil {
+		r.forget(r.byType, dataType)
+		return nil, err
+	}
+
+	switch {
+	case base.Kind == datatype.KindEnum:
+		def.Kind = datatype.KindEnum
+		def.EnumValues = enumValues(ctx, dataType)
+	case base.Kind == datatype.KindStructure || base.Builtin == ua.TypeIDExtensionObject:
+		if err := r.legacyStructure(ctx, dataType, def); err != nil {
+			// Без описания структура остаётся непрозрачным ExtensionObject
+			def.Kind = datatype.KindBuiltin
+			def.Builtin = ua.TypeIDExtensionObject
+		}
+	default:
+		def.Kind = base.Kind
+		def.Builtin = base.Builtin
+	}
+	return def, nil
+}
+
+// Encoding возвращает описание структуры по NodeId её двоичной кодировки
+// (TypeId в ExtensionObject)
+func (r *typeResolver) Encoding(encoding *ua.NodeID) (*datatype.Definition, error) {
+	if def := r.cached(r.byEncoding, encoding); def != nil {
+		return def, nil
+	}
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+
+	refs, err := browseRefs(context.Background(), encoding, id.HasEncoding, ua.BrowseDirectionInverse)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", encoding, err)
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("encoding %s: data type not found", encoding)
+	}
+
+	def, err := r.DataType(refs[0].NodeID.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	if def.Kind != datatype.KindStructure {
+		return nil, fmt.Errorf("encoding %s: %s is not a structure", encoding, def.Name)
+	}
+	if def.EncodingID == nil {
+		def.EncodingID = encoding
+	}
+	r.store(r.byEncoding, encoding, def)
+	return def, nil
+}
+
+func (r *typeResolver) fillStructure(def *datatype.Definition, sd *ua.StructureDefinition) error {
+	def.Kind = datatype.KindStructure
+	def.StructureType = sd.StructureType
+	def.EncodingID = sd.DefaultEncodingID
+	if def.EncodingID != nil {
+		r.store(r.byEncoding, def.EncodingID, def)
+	}
+
+	for _, f := range sd.Fields {
+		ft, err := r.DataType(f.DataType)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", def.Name, f.Name, err)
+		}
+		def.Fields = append(def.Fields, &datatype.Field{
+			Name:       f.Name,
+			Type:       ft,
+			ValueRank:  f.ValueRank,
+			IsOptional: f.IsOptional,
+		})
+	}
+	return nil
+}
+
+// legacyStructure загружает описание структуры из словаря типов OPC Binary:
+// DataType -HasEncoding-> Default Binary -HasDescription-> DataTypeDescription,
+// который является компонентом DataTypeDictionary
+func (r *typeResolver) legacyStructure(ctx context.Context, dataType *ua.NodeID, def *datatype.Definition) error {
+	encodings, err := browseRefs(ctx, dataType, id.HasEncoding, ua.BrowseDirectionForward)
+	if err != nil {
+		return err
+	}
+	var encoding *ua.NodeID
+	for _, ref := range encodings {
+		if ref.BrowseName != nil && ref.BrowseName.Name == "Default Binary" {
+			encoding = ref.NodeID.NodeID
+		}
+	}
+	if encoding == nil {
+		return fmt.Errorf("%s has no binary encoding", def.Name)
+	}
+
+	descs, err := browseRefs(ctx, encoding, id.HasDescription, ua.BrowseDirectionForward)
+	if err != nil || len(descs) == 0 {
+		return fmt.Errorf("%s has no data type description", def.Name)
+	}
+	desc := descs[0].NodeID.NodeID
+
+	vals, err := readAttributes(ctx, desc, ua.AttributeIDValue)
+	if err != nil {
+		return err
+	}
+	name, _ := vals[0].Value.Value().(string)
+
+	parents, err := browseRefs(ctx, desc, id.HasComponent, ua.BrowseDirectionInverse)
+	if err != nil || len(parents) == 0 {
+		return fmt.Errorf("%s: data type dictionary not found", def.Name)
+	}
+	dict, err := r.dictionary(ctx, parents[0].NodeID.NodeID)
+	if err != nil {
+		return err
+	}
+
+	d, ok := dict[name]
+	if !ok || d.Kind != datatype.KindStructure {
+		return fmt.Errorf("%s: %q not found in data type dictionary", def.Name, name)
+	}
+	def.Kind = d.Kind
+	def.StructureType = d.StructureType
+	def.Fields = d.Fields
+	def.EncodingID = encoding
+	r.store(r.byEncoding, encoding, def)
+	return nil
+}
+
+// dictionary читает и разбирает словарь типов; словари кэшируются целиком
+func (r *typeResolver) dictionary(ctx context.Context, node *ua.NodeID) (map[string]*datatype.Definition, error) {
+	r.mu.Lock()
+	dict, ok := r.dictionaries[node.String()]
+	r.mu.Unlock()
+	if ok {
+		return dict, nil
+	}
+
+	vals, err := readAttributes(ctx, node, ua.AttributeIDValue)
+	if err != nil {
+		return nil, err
+	}
+	if vals[0].Status != ua.StatusOK {
+		return nil, fmt.Errorf("data type dictionary %s: %v", node, vals[0].Status)
+	}
+	dict, err = datatype.ParseDictionary(vals[0].Value.ByteString())
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.dictionaries[node.String()] = dict
+	r.mu.Unlock()
+	return dict, nil
+}
+
+// supertype возвращает родительский тип данных
+func (r *typeResolver) supertype(ctx context.Context, dataType *ua.NodeID) (*ua.NodeID, error) {
+	refs, err := browseRefs(ctx, dataType, id.HasSubtype, ua.BrowseDirectionInverse)
+	if err != nil {
+		return nil, fmt.Errorf("data type %s: %w", dataType, err)
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("data type %s has no supertype", dataType)
+	}
+	return refs[0].NodeID.NodeID, nil
+}
+
+func (r *typeResolver) cached(m map[string]*datatype.Definition, n *ua.NodeID) *datatype.Definition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return m[n.String()]
+}
+
+func (r *typeResolver) store(m map[string]*datatype.Definition, n *ua.NodeID, def *datatype.Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m[n.String()] = def
+}
+
+func (r *typeResolver) forget(m map[string]*datatype.Definition, n *ua.NodeID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(m, n.String())
+}
+
+// reset очищает кэш; вызывается при смене соединения
+func (r *typeResolver) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byType = make(map[string]*datatype.Definition)
+	r.byEncoding = make(map[string]*datatype.Definition)
+	r.dictionaries = make(map[string]map[string]*datatype.Definition)
+	r.nodeEnums = make(map[string]*datatype.Definition)
+}
+
+// registerUnknown регистрирует в gopcua кодировки структур, тела которых
+// были отброшены при декодировании. Возвращает true, если значение нужно
+// прочитать повторно.
+func (r *typeResolver) registerUnknown(v *ua.Variant) bool {
+	reread := false
+	for _, eo := range extensionObjects(v) {
+		if eo.Value != nil || eo.EncodingMask != ua.ExtensionObjectBinary || eo.TypeID == nil {
+			continue
+		}
+		enc := eo.TypeID.NodeID
+		if _, err := r.Encoding(enc); err != nil {
+			continue
+		}
+		if _, loaded := registeredEncodings.LoadOrStore(enc.String(), true); !loaded {
+			ua.RegisterExtensionObject(enc, new(datatype.RawBody))
+			reread = true
+		}
+	}
+	return reread
+}
+
+// decodeStructures заменяет двоичные тела структур разобранными значениями
+func (r *typeResolver) decodeStructures(v *ua.Variant) {
+	for _, eo := range extensionObjects(v) {
+		raw, ok := eo.Value.(*datatype.RawBody)
+		if !ok || eo.TypeID == nil {
+			continue
+		}
+		def, err := r.Encoding(eo.TypeID.NodeID)
+		if err != nil {
+			continue
+		}
+		if s, err := datatype.Decode(def, *raw); err == nil {
+			eo.Value = s
+		}
+	}
+}
+
+// extensionObjects возвращает ExtensionObject из скалярного значения или массива
+func extensionObjects(v *ua.Variant) []*ua.ExtensionObject {
+	if v == nil {
+		return nil
+	}
+	switch x := v.Value().(type) {
+	case *ua.ExtensionObject:
+		return []*ua.ExtensionObject{x}
+	case []*ua.ExtensionObject:
+		return x
+	}
+	return nil
+}
+
+// enumValues читает элементы перечисления из свойств EnumValues или EnumStrings
+func enumValues(ctx context.Context, dataType *ua.NodeID) []datatype.EnumValue {
+	props, err := browseRefs(ctx, dataType, id.HasProperty, ua.BrowseDirectionForward)
+	if err != nil {
+		return nil
+	}
+
+	for _, p := range props {
+		if p.BrowseName == nil {
+			continue
+		}
+		vals, err := readAttributes(ctx, p.NodeID.NodeID, ua.AttributeIDValue)
+		if err != nil || vals[0].Status != ua.StatusOK || vals[0].Value == nil {
+			continue
+		}
+
+		var res []datatype.EnumValue
+		switch p.BrowseName.Name {
+		case "EnumStrings":
+			texts, _ := vals[0].Value.Value().([]*ua.LocalizedText)
+			for i, t := range texts {
+				res = append(res, datatype.EnumValue{Value: int64(i), Name: t.Text})
+			}
+		case "EnumValues":
+			eos, _ := vals[0].Value.Value().([]*ua.ExtensionObject)
+			for _, eo := range eos {
+				if ev, ok := eo.Value.(*ua.EnumValueType); ok && ev.DisplayName != nil {
+					res = append(res, datatype.EnumValue{Value: ev.Value, Name: ev.DisplayName.Text})
+				}
+			}
+		}
+		if res != nil {
+			return res
+		}
+	}
+	return nil
+}
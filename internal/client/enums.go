@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/ua"
+)
+
+// EnumType возвращает описание перечисления, которым описано значение узла:
+// тип данных переменной, производный от Enumeration, или свойства
+// EnumStrings/EnumValues самой переменной (MultiStateDiscreteType и т.п.).
+// Для остальных узлов возвращается nil.
+func EnumType(nodeID string) *datatype.Definition {
+	if session == nil {
+		return nil
+	}
+	id, err := ParseNodeID(nodeID)
+	if err != nil {
+		return nil
+	}
+	return types.nodeEnum(context.Background(), id)
+}
+
+// nodeEnum определяет перечисление значения переменной. Результат, в том
+// числе отрицательный, кэшируется на время соединения.
+func (r *typeResolver) nodeEnum(ctx context.Context, node *ua.NodeID) *datatype.Definition {
+	key := node.String()
+	r.mu.Lock()
+	def, ok := r.nodeEnums[key]
+	r.mu.Unlock()
+	if ok {
+		return def
+	}
+
+	def = r.lookupNodeEnum(ctx, node)
+
+	r.mu.Lock()
+	r.nodeEnums[key] = def
+	r.mu.Unlock()
+	return def
+}
+
+func (r *typeResolver) lookupNodeEnum(ctx context.Context, node *ua.NodeID) *datatype.Definition {
+	vals, err := readAttributes(ctx, node, ua.AttributeIDDataType)
+	if err != nil || vals[0].Status != ua.StatusOK || vals[0].Value == nil {
+		return nil
+	}
+	dataType := vals[0].Value.NodeID()
+	if dataType == nil {
+		return nil
+	}
+
+	def, err := r.DataType(dataType)
+	if err != nil {
+		return nil
+	}
+	if def.Kind == datatype.KindEnum && len(def.EnumValues) > 0 {
+		return def
+	}
+
+	// Перечисление может быть задано свойствами самой переменной, при этом
+	// её тип данных - обычное целое (UInt32 у MultiStateDiscreteType)
+	if def.Kind == datatype.KindEnum || isInteger(def.Builtin) {
+		if values := enumValues(ctx, node); values != nil {
+			return &datatype.Definition{NodeID: dataType, Name: def.Name, Kind: datatype.KindEnum, EnumValues: values}
+		}
+	}
+	if def.Kind == datatype.KindEnum {
+		return def
+	}
+	return nil
+}
+
+// isInteger сообщает, является ли встроенный тип целочисленным
+func isInteger(t ua.TypeID) bool {
+	switch t {
+	case ua.TypeIDSByte, ua.TypeIDByte, ua.TypeIDInt16, ua.TypeIDUint16,
+		ua.TypeIDInt32, ua.TypeIDUint32, ua.TypeIDInt64, ua.TypeIDUint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// DefaultEventFields - поля события, выбираемые, если поля не заданы
+var DefaultEventFields = []string{"Time", "SourceName", "Severity", "Message"}
+
+// compareOps - операторы сравнения условия отбора событий
+var compareOps = map[string]ua.FilterOperator{
+	"==":   ua.FilterOperatorEquals,
+	"=":    ua.FilterOperatorEquals,
+	"!=":   ua.FilterOperatorEquals,
+	"<":    ua.FilterOperatorLessThan,
+	"<=":   ua.FilterOperatorLessThanOrEqual,
+	">":    ua.FilterOperatorGreaterThan,
+	">=":   ua.FilterOperatorGreaterThanOrEqual,
+	"like": ua.FilterOperatorLike,
+}
+
+// ParseEventFilter строит EventFilter (Part 4, 7.22.3) из полей события и
+// условия отбора. Поле - путь имён просмотра от BaseEventType, разделённых
+// "/" или ".": Message, ActiveState/Id, 2:Pressure. Условие - сравнения
+// <поле> <оператор> <значение> и ofType <NodeId>, объединённые and и or;
+// and связывает сильнее. Операторы: == != < <= > >= like. Строки
+// заключаются в кавычки, числа, true/false и NodeId пишутся как есть.
+func ParseEventFilter(fields []string, where string) (*ua.EventFilter, error) {
+	if len(fields) == 0 {
+		fields = DefaultEventFields
+	}
+	filter := &ua.EventFilter{WhereClause: &ua.ContentFilter{Elements: []*ua.ContentFilterElement{}}}
+	for _, f := range fields {
+		op, err := eventField(f)
+		if err != nil {
+			return nil, err
+		}
+		filter.SelectClauses = append(filter.SelectClauses, op)
+	}
+
+	if strings.TrimSpace(where) == "" {
+		return filter, nil
+	}
+	tokens, err := filterTokens(where)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("invalid filter: unexpected %q", t.text)
+	}
+	expr.emit(filter.WhereClause)
+	return filter, nil
+}
+
+// eventField возвращает операнд поля события
+func eventField(field string) (*ua.SimpleAttributeOperand, error) {
+	path := field
+	if strings.Contains(path, "/") {
+		path = "/" + strings.TrimPrefix(path, "/")
+	}
+	names, err := parseBrowsePath(path)
+	if err != nil || len(names) == 0 {
+		return nil, fmt.Errorf("invalid event field: %s", field)
+	}
+	return &ua.SimpleAttributeOperand{
+		TypeDefinitionID: ua.NewNumericNodeID(0, id.BaseEventType),
+		BrowsePath:       names,
+		AttributeID:      ua.AttributeIDValue,
+	}, nil
+}
+
+// filterToken - слово условия отбора; quoted - строка в кавычках
+type filterToken struct {
+	text   string
+	quoted bool
+}
+
+// filterTokens разбивает условие на слова по пробелам с учётом кавычек
+func filterTokens(s string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '\'' || c == '"':
+			j := strings.IndexByte(s[i+1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("invalid filter: unterminated string")
+			}
+			tokens = append(tokens, filterToken{text: s[i+1 : i+1+j], quoted: true})
+			i += j + 2
+		default:
+			j := strings.IndexAny(s[i:], " \t")
+			if j < 0 {
+				j = len(s) - i
+			}
+			tokens = append(tokens, filterToken{text: s[i : i+j]})
+			i += j
+		}
+	}
+	return tokens, nil
+}
+
+// filterExpr - узел условия отбора: сравнение с операндами либо
+// логическая операция над вложенными условиями
+type filterExpr struct {
+	op       ua.FilterOperator
+	operands []interface{}
+	args     []*filterExpr
+}
+
+// emit добавляет условие в ContentFilter. Элементы нумеруются в порядке
+// обхода, поэтому корень условия получает индекс 0.
+func (e *filterExpr) emit(cf *ua.ContentFilter) uint32 {
+	idx := len(cf.Elements)
+	el := &ua.ContentFilterElement{FilterOperator: e.op}
+	cf.Elements = append(cf.Elements, el)
+	for _, o := range e.operands {
+		el.FilterOperands = append(el.FilterOperands, ua.NewExtensionObject(o))
+	}
+	for _, a := range e.args {
+		el.FilterOperands = append(el.FilterOperands, ua.NewExtensionObject(&ua.ElementOperand{Index: a.emit(cf)}))
+	}
+	return uint32(idx)
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() (filterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *filterParser) next(what string) (filterToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("invalid filter: expected %s", what)
+	}
+	p.pos++
+	return t, nil
+}
+
+// keyword сообщает, что следующее слово - ключевое слово kw, и пропускает его
+func (p *filterParser) keyword(kw string) bool {
+	t, ok := p.peek()
+	if ok && !t.quoted && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) or() (*filterExpr, error) {
+	left, err := p.and()
+	for err == nil && p.keyword("or") {
+		var right *filterExpr
+		if right, err = p.and(); err == nil {
+			left = &filterExpr{op: ua.FilterOperatorOr, args: []*filterExpr{left, right}}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) and() (*filterExpr, error) {
+	left, err := p.term()
+	for err == nil && p.keyword("and") {
+		var right *filterExpr
+		if right, err = p.term(); err == nil {
+			left = &filterExpr{op: ua.FilterOperatorAnd, args: []*filterExpr{left, right}}
+		}
+	}
+	return left, err
+}
+
+// term разбирает сравнение или ofType <NodeId>
+func (p *filterParser) term() (*filterExpr, error) {
+	if p.keyword("ofType") {
+		t, err := p.next("event type")
+		if err != nil {
+			return nil, err
+		}
+		typeID, err := ParseNodeID(t.text)
+		if err != nil || t.quoted {
+			return nil, fmt.Errorf("invalid filter: invalid event type %s", t.text)
+		}
+		return &filterExpr{op: ua.FilterOperatorOfType, operands: []interface{}{&ua.LiteralOperand{Value: ua.MustVariant(typeID)}}}, nil
+	}
+
+	field, err := p.next("field")
+	if err != nil {
+		return nil, err
+	}
+	operand, err := eventField(field.text)
+	if err != nil || field.quoted {
+		return nil, fmt.Errorf("invalid filter: invalid field %s", field.text)
+	}
+	opTok, err := p.next("operator after " + field.text)
+	if err != nil {
+		return nil, err
+	}
+	op, ok := compareOps[strings.ToLower(opTok.text)]
+	if !ok || opTok.quoted {
+		return nil, fmt.Errorf("invalid filter: unknown operator %s", opTok.text)
+	}
+	value, err := p.next("value after " + opTok.text)
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &filterExpr{op: op, operands: []interface{}{operand, &ua.LiteralOperand{Value: filterLiteral(value)}}}
+	if opTok.text == "!=" {
+		// В ContentFilter нет оператора "не равно"
+		return &filterExpr{op: ua.FilterOperatorNot, args: []*filterExpr{cmp}}, nil
+	}
+	return cmp, nil
+}
+
+// filterLiteral возвращает значение литерала: строку в кавычках, логическое
+// значение, число или NodeId; прочие слова считаются строками
+func filterLiteral(t filterToken) *ua.Variant {
+	if t.quoted {
+		return ua.MustVariant(t.text)
+	}
+	switch strings.ToLower(t.text) {
+	case "true":
+		return ua.MustVariant(true)
+	case "false":
+		return ua.MustVariant(false)
+	}
+	if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+		if int64(int32(n)) == n {
+			return ua.MustVariant(int32(n))
+		}
+		return ua.MustVariant(n)
+	}
+	if f, err := strconv.ParseFloat(t.text, 64); err == nil {
+		return ua.MustVariant(f)
+	}
+	if strings.Contains(t.text, "=") {
+		if n, err := ParseNodeID(t.text); err == nil {
+			return ua.MustVariant(n)
+		}
+	}
+	return ua.MustVariant(t.text)
+}
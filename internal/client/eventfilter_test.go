@@ -0,0 +1,90 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestParseEventFilter проверяет построение EventFilter из полей и условия.
+//
+// Основные аспекты тестирования:
+// - Поля события задаются путями от BaseEventType через "/" или ".".
+// - and связывает сильнее or, корень условия - элемент 0.
+// - != заменяется на Not(Equals), литералы получают тип по записи.
+// - Ошибки разбора условия.
+func TestParseEventFilter(t *testing.T) {
+	tests := []struct {
+		where   string
+		want    string
+		wantErr string
+	}{
+		{where: "", want: ""},
+		{where: "Severity >= 500", want: "0:GreaterThanOrEqual(Severity, int32 500)"},
+		{
+			where: "Severity > 100 or Message like 'Pump%' and SourceName != \"Tank 1\"",
+			want: "0:Or(#1, #2) 1:GreaterThan(Severity, int32 100) 2:And(#3, #4) 3:Like(Message, string Pump%) " +
+				"4:Not(#5) 5:Equals(SourceName, string Tank 1)",
+		},
+		{where: "ofType i=2915 AND ActiveState/Id == true", want: "0:And(#1, #2) 1:OfType(*ua.NodeID i=2915) 2:Equals(ActiveState/Id, bool true)"},
+		{where: "Time < 1.5", want: "0:LessThan(Time, float64 1.5)"},
+		{where: "Severity >=", wantErr: "invalid filter: expected value after >="},
+		{where: "Severity ~ 1", wantErr: "invalid filter: unknown operator ~"},
+		{where: "Severity > 1 Message", wantErr: `invalid filter: unexpected "Message"`},
+		{where: "Message == 'Pump", wantErr: "invalid filter: unterminated string"},
+	}
+	for _, tt := range tests {
+		f, err := ParseEventFilter(nil, tt.where)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseEventFilter(%q) ошибка = %v, ожидалась %q", tt.where, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseEventFilter(%q) получена непредвиденная ошибка = %v", tt.where, err)
+			continue
+		}
+		if got := contentFilterString(f.WhereClause); got != tt.want {
+			t.Errorf("ParseEventFilter(%q) = %s, ожидалось %s", tt.where, got, tt.want)
+		}
+		if len(f.SelectClauses) != len(DefaultEventFields) || f.SelectClauses[3].BrowsePath[0].Name != "Message" {
+			t.Errorf("ParseEventFilter(%q) поля = %v, ожидались поля по умолчанию", tt.where, f.SelectClauses)
+		}
+	}
+
+	f, err := ParseEventFilter([]string{"2:Pressure", "ActiveState.Id"}, "")
+	if err != nil || len(f.SelectClauses) != 2 || f.SelectClauses[0].BrowsePath[0].NamespaceIndex != 2 || len(f.SelectClauses[1].BrowsePath) != 2 {
+		t.Errorf("ParseEventFilter() поля = %v, %v", f, err)
+	}
+	if _, err := ParseEventFilter([]string{"Active//Id"}, ""); err == nil || err.Error() != "invalid event field: Active//Id" {
+		t.Errorf("ParseEventFilter() ошибка = %v, ожидалась ошибка поля", err)
+	}
+}
+
+// contentFilterString записывает условие в виде "индекс:Оператор(операнды)"
+func contentFilterString(cf *ua.ContentFilter) string {
+	var parts []string
+	for i, el := range cf.Elements {
+		var ops []string
+		for _, o := range el.FilterOperands {
+			switch v := o.Value.(type) {
+			case *ua.ElementOperand:
+				ops = append(ops, fmt.Sprintf("#%d", v.Index))
+			case *ua.SimpleAttributeOperand:
+				var names []string
+				for _, q := range v.BrowsePath {
+					names = append(names, q.Name)
+				}
+				ops = append(ops, strings.Join(names, "/"))
+			case *ua.LiteralOperand:
+				ops = append(ops, fmt.Sprintf("%T %v", v.Value.Value(), v.Value.Value()))
+			}
+		}
+		op := strings.TrimPrefix(el.FilterOperator.String(), "FilterOperator")
+		parts = append(parts, fmt.Sprintf("%d:%s(%s)", i, op, strings.Join(ops, ", ")))
+	}
+	return strings.Join(parts, " ")
+}
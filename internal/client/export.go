@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/nodeset"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// ExportOptions задаёт параметры экспорта адресного пространства
+type ExportOptions struct {
+	// Values включает текущие значения переменных
+	Values bool
+	// Parallel - число одновременных запросов к серверу
+	Parallel int
+}
+
+// ExportNodeSet обходит адресное пространство от узлов roots и собирает
+// атрибуты и ссылки найденных узлов. Узлы стандартного пространства имён 0
+// проходятся, но не экспортируются: они уже описаны в Opc.Ua.NodeSet2.xml.
+// Узел, найденный от нескольких начальных узлов, экспортируется один раз.
+func ExportNodeSet(roots []string, opts ExportOptions) (*nodeset.NodeSet, error) {
+	if opts.Parallel <= 0 {
+		opts.Parallel = defaultParallel
+	}
+
+	var walkedNodes []*BrowseNode
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		_, err := Walk(root, WalkOptions{
+			Parallel: opts.Parallel,
+			Visit: func(n *BrowseNode) bool {
+				if key := n.NodeID.String(); n.NodeID.Namespace() != 0 && !seen[key] {
+					seen[key] = true
+					walkedNodes = append(walkedNodes, n)
+				}
+				return true
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := context.Background()
+	limits := walked.operationLimits(ctx)
+
+	set := &nodeset.NodeSet{LastModified: time.Now()}
+	if ns := Namespaces(); len(ns) > 1 {
+		set.NamespaceURIs = ns[1:]
+	}
+
+	// Атрибуты всех узлов читаются одним потоком пакетов
+	var (
+		items   []*ua.ReadValueID
+		offsets = make([]int, len(walkedNodes))
+		attrs   = make([][]ua.AttributeID, len(walkedNodes))
+	)
+	for i, n := range walkedNodes {
+		attrs[i] = exportAttributes(n.NodeClass, opts.Values)
+		offsets[i] = len(items)
+		for _, a := range attrs[i] {
+			items = append(items, &ua.ReadValueID{NodeID: n.NodeID, AttributeID: a})
+		}
+	}
+	vals, err := readMany(ctx, items, opts.Parallel, limits.read)
+	if err != nil {
+		return nil, err
+	}
+
+	descs := make([]*ua.BrowseDescription, len(walkedNodes))
+	for i, n := range walkedNodes {
+		descs[i] = &ua.BrowseDescription{
+			NodeID:          n.NodeID,
+			BrowseDirection: ua.BrowseDirectionBoth,
+			ReferenceTypeID: ua.NewNumericNodeID(0, id.References),
+			IncludeSubtypes: true,
+			ResultMask:      uint32(ua.BrowseResultMaskReferenceTypeID | ua.BrowseResultMaskIsForward),
+		}
+	}
+	refs, err := browseMany(ctx, descs, opts.Parallel, limits.browse)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, n := range walkedNodes {
+		node := &nodeset.Node{
+			NodeID:     n.NodeID,
+			NodeClass:  n.NodeClass,
+			BrowseName: n.BrowseName,
+			Attributes: make(map[ua.AttributeID]*ua.Variant),
+		}
+		// ParentNodeId задаётся только для экземпляров
+		switch n.NodeClass {
+		case ua.NodeClassObject, ua.NodeClassVariable, ua.NodeClassMethod:
+			if n.Parent != nil {
+				node.ParentNodeID = n.Parent.NodeID
+			}
+		}
+
+		for j, a := range attrs[i] {
+			dv := vals[offsets[i]+j]
+			if dv == nil || dv.Status != ua.StatusOK || dv.Value == nil {
+				continue
+			}
+			switch a {
+			case ua.AttributeIDDisplayName:
+				node.DisplayName = dv.Value.LocalizedText()
+			case ua.AttributeIDDescription:
+				node.Description = dv.Value.LocalizedText()
+			case ua.AttributeIDValue:
+				node.Value = dv.Value
+			default:
+				node.Attributes[a] = dv.Value
+			}
+		}
+
+		for _, r := range refs[i] {
+			if r.ReferenceTypeID == nil || r.NodeID == nil {
+				continue
+			}
+			node.References = append(node.References, nodeset.Reference{
+				ReferenceType: r.ReferenceTypeID,
+				Target:        r.NodeID,
+				IsForward:     r.IsForward,
+			})
+		}
+		set.Nodes = append(set.Nodes, node)
+	}
+	return set, nil
+}
+
+// exportAttributes возвращает атрибуты, которые записываются в UANodeSet
+// для узла класса class. NodeId, NodeClass и BrowseName известны из обхода.
+func exportAttributes(class ua.NodeClass, values bool) []ua.AttributeID {
+	attrs := []ua.AttributeID{
+		ua.AttributeIDDisplayName, ua.AttributeIDDescription,
+		ua.AttributeIDWriteMask, ua.AttributeIDUserWriteMask,
+	}
+	for _, a := range classAttributes[class] {
+		if a == ua.AttributeIDValue && !values {
+			continue
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
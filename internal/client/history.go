@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// HistoryData - значения, прочитанные из архива сервера
+type HistoryData struct {
+	Values []*ua.DataValue
+	// Modifications - сведения об изменениях, по одному на значение;
+	// заполняются только при чтении изменённых значений
+	Modifications []*ua.ModificationInfo
+	// Events - поля событий в порядке EventFilter.SelectClauses; заполняются
+	// только при чтении событий
+	Events [][]*ua.Variant
+	// More сообщает, что в архиве остались значения сверх заданного предела
+	More bool
+}
+
+// HistoryReadRaw читает значения из архива за интервал [start, end].
+// Если end раньше start, значения возвращаются в обратном порядке.
+// С modified читаются прежние версии изменённых и удалённых значений.
+// max ограничивает число значений; 0 - без ограничения.
+func HistoryReadRaw(nodeID string, start, end time.Time, max int, modified bool) (*HistoryData, error) {
+	details := &ua.ReadRawModifiedDetails{
+		IsReadModified:   modified,
+		StartTime:        start,
+		EndTime:          end,
+		NumValuesPerNode: uint32(max),
+	}
+	return historyRead(context.Background(), nodeID, details, max)
+}
+
+// HistoryReadAtTime читает значения архива на заданные моменты времени.
+// Если значения на момент нет, сервер вычисляет его по соседним значениям.
+func HistoryReadAtTime(nodeID string, times []time.Time) (*HistoryData, error) {
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no timestamps")
+	}
+	details := &ua.ReadAtTimeDetails{ReqTimes: times, UseSimpleBounds: true}
+	return historyRead(context.Background(), nodeID, details, 0)
+}
+
+// HistoryReadEvents читает события из архива узла notifier (объекта с
+// EventNotifier) за интервал [start, end]. filter задаёт поля событий и
+// условие отбора; max ограничивает число событий, 0 - без ограничения.
+func HistoryReadEvents(notifier string, start, end time.Time, max int, filter *ua.EventFilter) (*HistoryData, error) {
+	details := &ua.ReadEventDetails{
+		NumValuesPerNode: uint32(max),
+		StartTime:        start,
+		EndTime:          end,
+		Filter:           filter,
+	}
+	return historyRead(context.Background(), notifier, details, max)
+}
+
+// historyRead выполняет HistoryRead, следуя точкам продолжения, пока сервер
+// не вернёт все значения или не наберётся max значений. Незавершённое
+// чтение освобождается, чтобы сервер не держал точку продолжения.
+func historyRead(ctx context.Context, nodeID string, details interface{}, max int) (*HistoryData, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	n, err := ParseNodeID(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ID: %w", err)
+	}
+
+	data := &HistoryData{}
+	var cp []byte
+	for {
+		res, err := historyReadPage(ctx, n, details, cp, false)
+		if err != nil {
+			return nil, err
+		}
+		if isBad(res.StatusCode) {
+			return nil, fmt.Errorf("history read failed: %w", res.StatusCode)
+		}
+		if err := data.add(res.HistoryData); err != nil {
+			return nil, err
+		}
+
+		cp = res.ContinuationPoint
+		if len(cp) == 0 {
+			break
+		}
+		if max > 0 && data.count() >= max {
+			data.More = true
+			historyReadPage(ctx, n, details, cp, true)
+			break
+		}
+	}
+
+	if max > 0 && data.count() > max {
+		data.More = true
+		if len(data.Values) > max {
+			data.Values = data.Values[:max]
+		}
+		if len(data.Modifications) > max {
+			data.Modifications = data.Modifications[:max]
+		}
+		if len(data.Events) > max {
+			data.Events = data.Events[:max]
+		}
+	}
+	return data, nil
+}
+
+func historyReadPage(ctx context.Context, n *ua.NodeID, details interface{}, cp []byte, release bool) (*ua.HistoryReadResult, error) {
+	req := &ua.HistoryReadRequest{
+		HistoryReadDetails:        ua.NewExtensionObject(details),
+		TimestampsToReturn:        ua.TimestampsToReturnBoth,
+		ReleaseContinuationPoints: release,
+		// Пустой DataEncoding обязателен: без него запрос кодируется
+		// короче, и сервер не может его разобрать
+		NodesToRead: []*ua.HistoryReadValueID{{NodeID: n, DataEncoding: &ua.QualifiedName{}, ContinuationPoint: cp}},
+	}
+	resp, err := session.HistoryRead(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("history read failed: %w", err)
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("no results")
+	}
+	return resp.Results[0], nil
+}
+
+// count возвращает число прочитанных значений или событий
+func (d *HistoryData) count() int {
+	return len(d.Values) + len(d.Events)
+}
+
+// add добавляет значения из HistoryData, HistoryModifiedData или HistoryEvent
+func (d *HistoryData) add(x *ua.ExtensionObject) error {
+	if x == nil || x.Value == nil {
+		return nil
+	}
+	switch v := x.Value.(type) {
+	case *ua.HistoryData:
+		d.Values = append(d.Values, v.DataValues...)
+	case *ua.HistoryModifiedData:
+		d.Values = append(d.Values, v.DataValues...)
+		d.Modifications = append(d.Modifications, v.ModificationInfos...)
+	case *ua.HistoryEvent:
+		for _, e := range v.Events {
+			if e != nil {
+				d.Events = append(d.Events, e.EventFields)
+			}
+		}
+	default:
+		return fmt.Errorf("unexpected history data %T", x.Value)
+	}
+	return nil
+}
+
+// isBad сообщает, что код статуса относится к классу Bad
+func isBad(code ua.StatusCode) bool {
+	return code&0x80000000 != 0
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// HistoryUpdateData записывает значения в архив узла (Part 11, 6.8.2).
+// Без replace значения вставляются, и сервер отклоняет значения с уже
+// занятой меткой времени; с replace заменяются существующие значения.
+// Возвращает результаты по одному на значение в порядке values.
+func HistoryUpdateData(nodeID string, values []*ua.DataValue, replace bool) ([]ua.StatusCode, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values")
+	}
+	details := &ua.UpdateDataDetails{PerformInsertReplace: ua.PerformUpdateTypeInsert, UpdateValues: values}
+	if replace {
+		details.PerformInsertReplace = ua.PerformUpdateTypeReplace
+	}
+	res, err := historyUpdate(context.Background(), nodeID, func(n *ua.NodeID) interface{} {
+		details.NodeID = n
+		return details
+	})
+	if err != nil {
+		return nil, err
+	}
+	return operationResults(res, len(values))
+}
+
+// HistoryDeleteRaw удаляет из архива узла значения за интервал [start, end)
+func HistoryDeleteRaw(nodeID string, start, end time.Time) error {
+	_, err := historyUpdate(context.Background(), nodeID, func(n *ua.NodeID) interface{} {
+		return &ua.DeleteRawModifiedDetails{NodeID: n, StartTime: start, EndTime: end}
+	})
+	return err
+}
+
+// HistoryDeleteAtTime удаляет из архива узла значения с заданными метками
+// времени. Возвращает результаты по одному на метку времени.
+func HistoryDeleteAtTime(nodeID string, times []time.Time) ([]ua.StatusCode, error) {
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no timestamps")
+	}
+	res, err := historyUpdate(context.Background(), nodeID, func(n *ua.NodeID) interface{} {
+		return &ua.DeleteAtTimeDetails{NodeID: n, ReqTimes: times}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return operationResults(res, len(times))
+}
+
+// historyUpdate выполняет HistoryUpdate с одним изменением архива; details
+// строит параметры изменения по NodeId узла
+func historyUpdate(ctx context.Context, nodeID string, details func(n *ua.NodeID) interface{}) (*ua.HistoryUpdateResult, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	n, err := ParseNodeID(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ID: %w", err)
+	}
+
+	req := &ua.HistoryUpdateRequest{HistoryUpdateDetails: []*ua.ExtensionObject{ua.NewExtensionObject(details(n))}}
+	resp, err := session.HistoryUpdate(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("history update failed: %w", err)
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("no results")
+	}
+	res := resp.Results[0]
+	if isBad(res.StatusCode) {
+		return nil, fmt.Errorf("history update failed: %w", res.StatusCode)
+	}
+	return res, nil
+}
+
+// operationResults возвращает результаты операций; сервер может не
+// вернуть их, если все операции выполнены
+func operationResults(res *ua.HistoryUpdateResult, n int) ([]ua.StatusCode, error) {
+	if len(res.OperationResults) == 0 {
+		return make([]ua.StatusCode, n), nil
+	}
+	if len(res.OperationResults) != n {
+		return nil, fmt.Errorf("server returned %d results for %d operations", len(res.OperationResults), n)
+	}
+	return res.OperationResults, nil
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseIndexRange разбирает диапазон индексов NumericRange (Part 4, 7.27):
+// индекс или диапазон lo:hi (lo < hi) для каждого измерения массива, через
+// запятую: "3", "2:5", "0:1,2:3". Возвращает границы [lo, hi] по
+// измерениям; для одного индекса lo == hi.
+func ParseIndexRange(s string) ([][2]uint32, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty index range")
+	}
+	var dims [][2]uint32
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(part, ":")
+		a, err := strconv.ParseUint(lo, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index range %q", s)
+		}
+		b := a
+		if isRange {
+			if b, err = strconv.ParseUint(hi, 10, 32); err != nil || b <= a {
+				return nil, fmt.Errorf("invalid index range %q", s)
+			}
+		}
+		dims = append(dims, [2]uint32{uint32(a), uint32(b)})
+	}
+	return dims, nil
+}
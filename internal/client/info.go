@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// NodeInfo содержит атрибуты узла, применимые к его классу, и его свойства
+type NodeInfo struct {
+	NodeClass ua.NodeClass
+	// Attributes - атрибуты в порядке Part 3; неподдерживаемые сервером
+	// необязательные атрибуты (BadAttributeIdInvalid) опускаются
+	Attributes []AttributeValue
+	Properties []Property
+	// Enum - перечисление, которым описано значение переменной
+	Enum *datatype.Definition
+}
+
+// AttributeValue - прочитанный атрибут узла
+type AttributeValue struct {
+	ID    ua.AttributeID
+	Value *ua.DataValue
+}
+
+// Property - свойство узла (EngineeringUnits, EURange и т.п.)
+type Property struct {
+	Name   string
+	NodeID *ua.NodeID
+	Value  *ua.DataValue
+}
+
+// commonAttributes есть у узлов всех классов
+var commonAttributes = []ua.AttributeID{
+	ua.AttributeIDNodeID, ua.AttributeIDNodeClass, ua.AttributeIDBrowseName,
+	ua.AttributeIDDisplayName, ua.AttributeIDDescription,
+	ua.AttributeIDWriteMask, ua.AttributeIDUserWriteMask,
+}
+
+// classAttributes - атрибуты, специфичные для класса узла (Part 3, 5)
+var classAttributes = map[ua.NodeClass][]ua.AttributeID{
+	ua.NodeClassObject: {ua.AttributeIDEventNotifier},
+	ua.NodeClassVariable: {
+		ua.AttributeIDValue, ua.AttributeIDDataType, ua.AttributeIDValueRank,
+		ua.AttributeIDArrayDimensions, ua.AttributeIDAccessLevel, ua.AttributeIDUserAccessLevel,
+		ua.AttributeIDMinimumSamplingInterval, ua.AttributeIDHistorizing,
+	},
+	ua.NodeClassMethod:     {ua.AttributeIDExecutable, ua.AttributeIDUserExecutable},
+	ua.NodeClassObjectType: {ua.AttributeIDIsAbstract},
+	ua.NodeClassVariableType: {
+		ua.AttributeIDValue, ua.AttributeIDDataType, ua.AttributeIDValueRank,
+		ua.AttributeIDArrayDimensions, ua.AttributeIDIsAbstract,
+	},
+	ua.NodeClassReferenceType: {ua.AttributeIDIsAbstract, ua.AttributeIDSymmetric, ua.AttributeIDInverseName},
+	ua.NodeClassDataType:      {ua.AttributeIDIsAbstract},
+	ua.NodeClassView:          {ua.AttributeIDContainsNoLoops, ua.AttributeIDEventNotifier},
+}
+
+// GetNodeInfo читает атрибуты узла, применимые к его NodeClass, и значения
+// его свойств
+func GetNodeInfo(nodeID string) (*NodeInfo, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	n, err := ParseNodeID(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ID: %w", err)
+	}
+
+	ctx := context.Background()
+	vals, err := readAttributes(ctx, n, ua.AttributeIDNodeClass)
+	if err != nil {
+		return nil, err
+	}
+	if vals[0].Status != ua.StatusOK {
+		return nil, fmt.Errorf("bad status: %v", vals[0].Status)
+	}
+	class := ua.NodeClass(vals[0].Value.Int())
+
+	attrs := append(append([]ua.AttributeID(nil), commonAttributes...), classAttributes[class]...)
+	if vals, err = readAttributes(ctx, n, attrs...); err != nil {
+		return nil, err
+	}
+
+	info := &NodeInfo{NodeClass: class}
+	for i, a := range attrs {
+		if vals[i].Status == ua.StatusBadAttributeIDInvalid {
+			continue
+		}
+		if a == ua.AttributeIDValue {
+			// Как в Read: тела структур неизвестных типов gopcua отбрасывает,
+			// поэтому после регистрации их кодировок значение читается повторно
+			if types.registerUnknown(vals[i].Value) {
+				again, err := readAttributes(ctx, n, ua.AttributeIDValue)
+				if err != nil {
+					return nil, err
+				}
+				vals[i] = again[0]
+			}
+			types.decodeStructures(vals[i].Value)
+			info.Enum = types.nodeEnum(ctx, n)
+		}
+		info.Attributes = append(info.Attributes, AttributeValue{ID: a, Value: vals[i]})
+	}
+
+	props, err := browseRefs(ctx, n, id.HasProperty, ua.BrowseDirectionForward)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*ua.NodeID, len(props))
+	for i, p := range props {
+		nodes[i] = p.NodeID.NodeID
+	}
+	values, err := readValues(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+	reread := false
+	for _, dv := range values {
+		reread = types.registerUnknown(dv.Value) || reread
+	}
+	if reread {
+		if values, err = readValues(ctx, nodes); err != nil {
+			return nil, err
+		}
+	}
+	for i, p := range props {
+		types.decodeStructures(values[i].Value)
+		prop := Property{NodeID: nodes[i], Value: values[i]}
+		if p.BrowseName != nil {
+			prop.Name = p.BrowseName.Name
+		}
+		info.Properties = append(info.Properties, prop)
+	}
+	return info, nil
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// namespaceTable - таблица пространств имён сервера (NamespaceArray),
+// прочитанная при первом обращении. Сбрасывается при смене сессии.
+type namespaceTable struct {
+	mu   sync.Mutex
+	uris []string
+}
+
+var nsTable = &namespaceTable{}
+
+// NodeId в значениях, вводимых текстом, разбираются так же, как узлы команд
+func init() {
+	formatter.ResolveNodeID = ParseNodeID
+}
+
+func (t *namespaceTable) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.uris = nil
+}
+
+// get возвращает таблицу, читая её с сервера при первом обращении
+func (t *namespaceTable) get(ctx context.Context) []string {
+	t.mu.Lock()
+	uris := t.uris
+	t.mu.Unlock()
+	if uris != nil {
+		return uris
+	}
+	return t.refresh(ctx)
+}
+
+// refresh перечитывает NamespaceArray. Если сервер его не вернул,
+// используется таблица, полученная сессией при подключении.
+func (t *namespaceTable) refresh(ctx context.Context) []string {
+	uris, err := readNamespaceArray(ctx)
+	if err != nil {
+		uris = session.Info().Namespaces
+	}
+	t.mu.Lock()
+	t.uris = uris
+	t.mu.Unlock()
+	return uris
+}
+
+func readNamespaceArray(ctx context.Context) ([]string, error) {
+	vals, err := readValues(ctx, []*ua.NodeID{ua.NewNumericNodeID(0, id.Server_NamespaceArray)})
+	if err != nil {
+		return nil, err
+	}
+	if vals[0].Status != ua.StatusOK {
+		return nil, fmt.Errorf("bad status: %v", vals[0].Status)
+	}
+	var uris []string
+	if v := vals[0].Value; v != nil {
+		uris, _ = v.Value().([]string)
+	}
+	if len(uris) == 0 {
+		return nil, fmt.Errorf("NamespaceArray is empty")
+	}
+	return uris, nil
+}
+
+// index возвращает индекс пространства имён uri. Если URI в таблице нет,
+// таблица перечитывается: сервер мог зарегистрировать пространство позже.
+func (t *namespaceTable) index(ctx context.Context, uri string) (uint16, error) {
+	if idx, ok := namespaceIndex(t.get(ctx), uri); ok {
+		return idx, nil
+	}
+	if idx, ok := namespaceIndex(t.refresh(ctx), uri); ok {
+		return idx, nil
+	}
+	return 0, fmt.Errorf("unknown namespace URI: %s", uri)
+}
+
+func namespaceIndex(uris []string, uri string) (uint16, bool) {
+	for i, u := range uris {
+		if u == uri {
+			return uint16(i), true
+		}
+	}
+	return 0, false
+}
+
+// Namespaces возвращает таблицу пространств имён сервера
+func Namespaces() []string {
+	if session == nil {
+		return nil
+	}
+	return nsTable.get(context.Background())
+}
+
+// ReadNamespaces читает таблицу пространств имён с сервера
+func ReadNamespaces() ([]string, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	uris, err := readNamespaceArray(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read NamespaceArray: %w", err)
+	}
+	nsTable.mu.Lock()
+	nsTable.uris = uris
+	nsTable.mu.Unlock()
+	return uris, nil
+}
+
+// ParseNodeID разбирает NodeId, введённый пользователем. Кроме обычной формы
+// ns=<индекс>;... принимается nsu=<URI>;...: индекс пространства имён
+// определяется по таблице сервера, поэтому такой идентификатор не зависит
+// от порядка регистрации пространств имён после перезапуска сервера.
+// Узел можно задать и путём просмотра (см. IsBrowsePath); найденные по
+// путям NodeId запоминаются до смены сессии.
+func ParseNodeID(s string) (*ua.NodeID, error) {
+	if IsBrowsePath(s) {
+		if session == nil {
+			return nil, fmt.Errorf("not connected to server")
+		}
+		return paths.resolve(context.Background(), s)
+	}
+	if !strings.HasPrefix(s, "nsu=") {
+		return ua.ParseNodeID(s)
+	}
+	uri, ident, ok := splitNamespaceURI(strings.TrimPrefix(s, "nsu="))
+	if !ok {
+		return nil, fmt.Errorf("no identifier after namespace URI: %s", s)
+	}
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	idx, err := nsTable.index(context.Background(), uri)
+	if err != nil {
+		return nil, err
+	}
+	return ua.ParseNodeID(fmt.Sprintf("ns=%d;%s", idx, ident))
+}
+
+// IsNodeID сообщает, задан ли узел в одной из форм, которые разбирает
+// ParseNodeID (ns=, nsu=, i=, ... или путь просмотра), а не именем
+func IsNodeID(s string) bool {
+	return hasNodeIDPrefix(s) || IsBrowsePath(s)
+}
+
+// splitNamespaceURI отделяет URI от идентификатора: URI может содержать
+// любые символы, поэтому граница ищется по первому ";i=", ";s=", ";g=" или ";b="
+func splitNamespaceURI(s string) (uri, ident string, ok bool) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != ';' || i+2 >= len(s) || s[i+2] != '=' {
+			continue
+		}
+		switch s[i+1] {
+		case 'i', 's', 'g', 'b':
+			if i == 0 {
+				return "", "", false
+			}
+			return s[:i], s[i+1:], true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,27 @@
+package client
+
+import "testing"
+
+// TestSplitNamespaceURI проверяет отделение URI пространства имён от
+// идентификатора в форме nsu=<URI>;<идентификатор>.
+func TestSplitNamespaceURI(t *testing.T) {
+	tests := []struct {
+		in    string
+		uri   string
+		ident string
+		ok    bool
+	}{
+		{"http://vendor.com/UA/;s=Tag", "http://vendor.com/UA/", "s=Tag", true},
+		{"urn:plant;i=1001", "urn:plant", "i=1001", true},
+		{"urn:a;b;s=x;y", "urn:a;b", "s=x;y", true},
+		{"urn:plant;g=5BDC3E6D-2A6F-4A3D-8E2C-1F0B1C2D3E4F", "urn:plant", "g=5BDC3E6D-2A6F-4A3D-8E2C-1F0B1C2D3E4F", true},
+		{"urn:plant", "", "", false},
+		{";s=Tag", "", "", false},
+	}
+	for _, tt := range tests {
+		uri, ident, ok := splitNamespaceURI(tt.in)
+		if uri != tt.uri || ident != tt.ident || ok != tt.ok {
+			t.Errorf("splitNamespaceURI(%q) = %q, %q, %v, ожидалось %q, %q, %v", tt.in, uri, ident, ok, tt.uri, tt.ident, tt.ok)
+		}
+	}
+}
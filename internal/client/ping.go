@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+	"github.com/gopcua/opcua/uacp"
+)
+
+// Ping выполняет лёгкий запрос через сессию s: чтение
+// ServerStatus.CurrentTime (i=2258)
+func Ping(ctx context.Context, s Session) error {
+	resp, err := s.Read(ctx, &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{{
+			NodeID:      ua.NewNumericNodeID(0, id.Server_ServerStatus_CurrentTime),
+			AttributeID: ua.AttributeIDValue,
+		}},
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	})
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	if len(resp.Results) == 0 {
+		return fmt.Errorf("no results")
+	}
+	if st := resp.Results[0].Status; st != ua.StatusOK {
+		return fmt.Errorf("bad status: %v", st)
+	}
+	return nil
+}
+
+// Hello устанавливает TCP-соединение с сервером и выполняет обмен
+// сообщениями Hello/Acknowledge без создания защищённого канала и сессии
+func Hello(ctx context.Context, endpoint string) error {
+	conn, err := uacp.Dial(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// Read читает атрибут Value узла и возвращает DataValue целиком,
+// сохраняя тип значения, статус и метки времени. Пользовательские структуры
+// разбираются в datatype.Structure по описанию типа с сервера.
+func Read(nodeID string) (*ua.DataValue, error) {
+	return ReadRange(nodeID, "")
+}
+
+// ReadRange читает часть значения-массива, заданную диапазоном индексов
+// NumericRange (см. ParseIndexRange); пустой диапазон - значение целиком
+func ReadRange(nodeID, indexRange string) (*ua.DataValue, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+
+	ctx := context.Background()
+	dv, err := readDataValue(ctx, nodeID, indexRange)
+	if err != nil {
+		return nil, err
+	}
+
+	// Тела структур неизвестных типов gopcua отбрасывает, поэтому после
+	// регистрации их кодировок значение читается повторно
+	if types.registerUnknown(dv.Value) {
+		if dv, err = readDataValue(ctx, nodeID, indexRange); err != nil {
+			return nil, err
+		}
+	}
+	types.decodeStructures(dv.Value)
+	return dv, nil
+}
+
+// ReadAttribute читает атрибут узла; Value читается через ReadRange
+func ReadAttribute(nodeID string, attr ua.AttributeID) (*ua.DataValue, error) {
+	if attr == ua.AttributeIDValue {
+		return ReadRange(nodeID, "")
+	}
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+
+	id, err := ParseNodeID(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ID: %w", err)
+	}
+	res, err := readAttributes(context.Background(), id, attr)
+	if err != nil {
+		return nil, err
+	}
+	return res[0], nil
+}
+
+// readDataValue читает значение узла по Node ID
+func readDataValue(ctx context.Context, nodeID, indexRange string) (*ua.DataValue, error) {
+	id, err := ParseNodeID(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ID: %w", err)
+	}
+
+	req := &ua.ReadRequest{
+		MaxAge:             2000,
+		NodesToRead:        []*ua.ReadValueID{{NodeID: id, AttributeID: ua.AttributeIDValue, IndexRange: indexRange}},
+		TimestampsToReturn: ua.TimestampsToReturnBoth,
+	}
+
+	resp, err := session.Read(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("no results")
+	}
+
+	return resp.Results[0], nil
+}
+
+// ReadResult - результат чтения одного узла списка
+type ReadResult struct {
+	NodeID string
+	Value  *ua.DataValue
+	// Err - ошибка разбора NodeId или поиска пути; Value при этом nil
+	Err error
+}
+
+// ReadMany читает значения списка узлов, заданных NodeId или путями
+// просмотра. Узлы читаются пакетами не более MaxNodesPerRead сервера, до
+// parallel пакетов одновременно (0 - defaultParallel). Ошибки отдельных
+// узлов возвращаются в результатах, ошибка запроса прерывает чтение.
+func ReadMany(nodeIDs []string, parallel int) ([]ReadResult, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	if parallel <= 0 {
+		parallel = defaultParallel
+	}
+	ctx := context.Background()
+	limits := walked.operationLimits(ctx)
+
+	ids, errs, err := resolveNodeIDs(ctx, nodeIDs, parallel, limits.translate)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ReadResult, len(nodeIDs))
+	for i, s := range nodeIDs {
+		results[i] = ReadResult{NodeID: s, Err: errs[i]}
+	}
+
+	var (
+		index []int
+		items []*ua.ReadValueID
+	)
+	for i, n := range ids {
+		if n != nil {
+			index = append(index, i)
+			items = append(items, &ua.ReadValueID{NodeID: n, AttributeID: ua.AttributeIDValue})
+		}
+	}
+	req := &ua.ReadRequest{MaxAge: 2000, TimestampsToReturn: ua.TimestampsToReturnBoth}
+	vals, err := readBatches(ctx, req, items, parallel, limits.read)
+	if err != nil {
+		return nil, err
+	}
+
+	// Значения структур неизвестных типов перечитываются после регистрации
+	// кодировок, как в Read
+	var (
+		reread      []int
+		rereadItems []*ua.ReadValueID
+	)
+	for j, dv := range vals {
+		if types.registerUnknown(dv.Value) {
+			reread = append(reread, j)
+			rereadItems = append(rereadItems, items[j])
+		}
+	}
+	if len(reread) > 0 {
+		again, err := readBatches(ctx, req, rereadItems, parallel, limits.read)
+		if err != nil {
+			return nil, err
+		}
+		for k, j := range reread {
+			vals[j] = again[k]
+		}
+	}
+
+	for j, i := range index {
+		types.decodeStructures(vals[j].Value)
+		results[i].Value = vals[j]
+	}
+	return results, nil
+}
+
+// resolveNodeIDs разбирает список NodeId и путей просмотра. Пути ищутся
+// пакетами не более limit путей; ошибки разбора и поиска возвращаются по
+// одной на узел, ошибка запроса - в err.
+func resolveNodeIDs(ctx context.Context, nodeIDs []string, parallel, limit int) ([]*ua.NodeID, []error, error) {
+	ids := make([]*ua.NodeID, len(nodeIDs))
+	errs := make([]error, len(nodeIDs))
+	var (
+		pathIndex []int
+		pathList  []string
+	)
+	for i, s := range nodeIDs {
+		if IsBrowsePath(s) {
+			pathIndex = append(pathIndex, i)
+			pathList = append(pathList, s)
+			continue
+		}
+		n, err := ParseNodeID(s)
+		if err != nil {
+			errs[i] = fmt.Errorf("invalid node ID: %w", err)
+			continue
+		}
+		ids[i] = n
+	}
+	found, ferrs, err := paths.resolveMany(ctx, pathList, parallel, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	for j, i := range pathIndex {
+		ids[i], errs[i] = found[j], ferrs[j]
+	}
+	return ids, errs, nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+)
+
+// Session - соединение с OPC UA сервером. Все запросы пакета client
+// выполняются через активную сессию, поэтому транспорт можно подменить:
+// в тестах - записывающей сессией из пакета clienttest.
+type Session interface {
+	Read(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error)
+	Write(ctx context.Context, req *ua.WriteRequest) (*ua.WriteResponse, error)
+	Browse(ctx context.Context, req *ua.BrowseRequest) (*ua.BrowseResponse, error)
+	BrowseNext(ctx context.Context, req *ua.BrowseNextRequest) (*ua.BrowseNextResponse, error)
+	Call(ctx context.Context, req *ua.CallMethodRequest) (*ua.CallMethodResult, error)
+	TranslateBrowsePathsToNodeIDs(ctx context.Context, req *ua.TranslateBrowsePathsToNodeIDsRequest) (*ua.TranslateBrowsePathsToNodeIDsResponse, error)
+	HistoryRead(ctx context.Context, req *ua.HistoryReadRequest) (*ua.HistoryReadResponse, error)
+	HistoryUpdate(ctx context.Context, req *ua.HistoryUpdateRequest) (*ua.HistoryUpdateResponse, error)
+	// Subscribe создаёт подписку; уведомления передаются в notify
+	Subscribe(ctx context.Context, params *opcua.SubscriptionParameters, notify chan<- *opcua.PublishNotificationData) (Subscription, error)
+	// Info возвращает сведения о соединении
+	Info() SessionInfo
+	Close(ctx context.Context) error
+}
+
+// Subscription - подписка на изменения значений
+type Subscription interface {
+	Monitor(ctx context.Context, ts ua.TimestampsToReturn, items ...*ua.MonitoredItemCreateRequest) (*ua.CreateMonitoredItemsResponse, error)
+	Cancel(ctx context.Context) error
+}
+
+// SessionInfo содержит сведения о соединении
+type SessionInfo struct {
+	Endpoint string
+	// Namespaces - таблица пространств имён сервера
+	Namespaces []string
+}
+
+// session - активная сессия или nil
+var session Session
+
+// sessionID - идентификатор активной сессии, см. SessionID
+var sessionID string
+
+// Attach делает s активной сессией, закрывая предыдущую. Кэши типов,
+// обхода, пространств имён и путей сбрасываются: они относятся к прежнему
+// серверу.
+func Attach(s Session) {
+	if session != nil {
+		session.Close(context.Background())
+	}
+	session = s
+	sessionID = ""
+	if s != nil {
+		b := make([]byte, 8)
+		rand.Read(b)
+		sessionID = hex.EncodeToString(b)
+	}
+	types.reset()
+	walked.reset()
+	nsTable.reset()
+	paths.reset()
+}
+
+// SessionID возвращает случайный идентификатор, который opcli присваивает
+// каждому соединению, или пустую строку без соединения. Им связываются
+// записи журнала аудита одного соединения: SessionId сервера gopcua не
+// сообщает.
+func SessionID() string {
+	return sessionID
+}
+
+// GetSession возвращает активную сессию или nil
+func GetSession() Session {
+	return session
+}
+
+// gopcuaSession реализует Session поверх клиента gopcua
+type gopcuaSession struct {
+	*opcua.Client
+	endpoint string
+}
+
+// Dial подключается к серверу через gopcua. Сессия не становится активной:
+// для этого её передают в Attach.
+func Dial(ctx context.Context, endpoint string) (Session, error) {
+	c, err := opcua.NewClient(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	if err := c.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return &gopcuaSession{Client: c, endpoint: endpoint}, nil
+}
+
+func (s *gopcuaSession) Subscribe(ctx context.Context, params *opcua.SubscriptionParameters, notify chan<- *opcua.PublishNotificationData) (Subscription, error) {
+	sub, err := s.Client.Subscribe(ctx, params, notify)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// TranslateBrowsePathsToNodeIDs отправляет запрос напрямую: в клиенте gopcua
+// есть только вариант для одного пути от заданного узла
+func (s *gopcuaSession) TranslateBrowsePathsToNodeIDs(ctx context.Context, req *ua.TranslateBrowsePathsToNodeIDsRequest) (*ua.TranslateBrowsePathsToNodeIDsResponse, error) {
+	return send[*ua.TranslateBrowsePathsToNodeIDsResponse](ctx, s.Client, req)
+}
+
+// HistoryRead отправляет запрос напрямую: методы клиента gopcua задают
+// TimestampsToReturn сами и не принимают готовый запрос
+func (s *gopcuaSession) HistoryRead(ctx context.Context, req *ua.HistoryReadRequest) (*ua.HistoryReadResponse, error) {
+	return send[*ua.HistoryReadResponse](ctx, s.Client, req)
+}
+
+// HistoryUpdate отправляет запрос напрямую: в клиенте gopcua нет метода
+// для изменения архива
+func (s *gopcuaSession) HistoryUpdate(ctx context.Context, req *ua.HistoryUpdateRequest) (*ua.HistoryUpdateResponse, error) {
+	return send[*ua.HistoryUpdateResponse](ctx, s.Client, req)
+}
+
+// send отправляет запрос и проверяет тип ответа
+func send[T ua.Response](ctx context.Context, c *opcua.Client, req ua.Request) (T, error) {
+	var resp T
+	err := c.Send(ctx, req, func(v ua.Response) error {
+		r, ok := v.(T)
+		if !ok {
+			return fmt.Errorf("unexpected response %T", v)
+		}
+		resp = r
+		return nil
+	})
+	return resp, err
+}
+
+func (s *gopcuaSession) Info() SessionInfo {
+	return SessionInfo{Endpoint: s.endpoint, Namespaces: s.Client.Namespaces()}
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// StatusValue - значение одной переменной объекта Server
+type StatusValue struct {
+	Name  string
+	Value *ua.DataValue
+}
+
+// ServerStatus содержит состояние, сборку и возможности сервера
+type ServerStatus struct {
+	// Status - компоненты ServerStatus и BuildInfo, а также ServiceLevel
+	Status []StatusValue
+	// Capabilities - переменные ServerCapabilities
+	Capabilities []StatusValue
+	// OperationLimits - переменные ServerCapabilities.OperationLimits
+	OperationLimits []StatusValue
+	Namespaces      []string
+	// RequestTime - местное время середины запроса; разница с CurrentTime
+	// сервера оценивает расхождение часов
+	RequestTime time.Time
+}
+
+// statusNode - переменная объекта Server, читаемая командой server status
+type statusNode struct {
+	name string
+	id   uint32
+}
+
+var (
+	serverStatusNodes = []statusNode{
+		{"State", id.Server_ServerStatus_State},
+		{"StartTime", id.Server_ServerStatus_StartTime},
+		{"CurrentTime", id.Server_ServerStatus_CurrentTime},
+		{"ProductName", id.Server_ServerStatus_BuildInfo_ProductName},
+		{"ProductUri", id.Server_ServerStatus_BuildInfo_ProductURI},
+		{"ManufacturerName", id.Server_ServerStatus_BuildInfo_ManufacturerName},
+		{"SoftwareVersion", id.Server_ServerStatus_BuildInfo_SoftwareVersion},
+		{"BuildNumber", id.Server_ServerStatus_BuildInfo_BuildNumber},
+		{"BuildDate", id.Server_ServerStatus_BuildInfo_BuildDate},
+		{"SecondsTillShutdown", id.Server_ServerStatus_SecondsTillShutdown},
+		{"ShutdownReason", id.Server_ServerStatus_ShutdownReason},
+		{"ServiceLevel", id.Server_ServiceLevel},
+	}
+	capabilityNodes = []statusNode{
+		{"ServerProfileArray", id.Server_ServerCapabilities_ServerProfileArray},
+		{"LocaleIdArray", id.Server_ServerCapabilities_LocaleIDArray},
+		{"MinSupportedSampleRate", id.Server_ServerCapabilities_MinSupportedSampleRate},
+		{"MaxBrowseContinuationPoints", id.Server_ServerCapabilities_MaxBrowseContinuationPoints},
+		{"MaxQueryContinuationPoints", id.Server_ServerCapabilities_MaxQueryContinuationPoints},
+		{"MaxHistoryContinuationPoints", id.Server_ServerCapabilities_MaxHistoryContinuationPoints},
+		{"MaxArrayLength", id.Server_ServerCapabilities_MaxArrayLength},
+		{"MaxStringLength", id.Server_ServerCapabilities_MaxStringLength},
+		{"MaxByteStringLength", id.Server_ServerCapabilities_MaxByteStringLength},
+		{"MaxSessions", id.Server_ServerCapabilities_MaxSessions},
+		{"MaxSubscriptions", id.Server_ServerCapabilities_MaxSubscriptions},
+		{"MaxMonitoredItems", id.Server_ServerCapabilities_MaxMonitoredItems},
+		{"MaxSubscriptionsPerSession", id.Server_ServerCapabilities_MaxSubscriptionsPerSession},
+		{"MaxMonitoredItemsPerSubscription", id.Server_ServerCapabilities_MaxMonitoredItemsPerSubscription},
+	}
+	operationLimitNodes = []statusNode{
+		{"MaxNodesPerRead", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerRead},
+		{"MaxNodesPerWrite", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerWrite},
+		{"MaxNodesPerBrowse", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerBrowse},
+		{"MaxNodesPerMethodCall", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerMethodCall},
+		{"MaxNodesPerRegisterNodes", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerRegisterNodes},
+		{"MaxNodesPerTranslateBrowsePathsToNodeIds", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerTranslateBrowsePathsToNodeIDs},
+		{"MaxNodesPerNodeManagement", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerNodeManagement},
+		{"MaxMonitoredItemsPerCall", id.Server_ServerCapabilities_OperationLimits_MaxMonitoredItemsPerCall},
+		{"MaxNodesPerHistoryReadData", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerHistoryReadData},
+		{"MaxNodesPerHistoryReadEvents", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerHistoryReadEvents},
+		{"MaxNodesPerHistoryUpdateData", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerHistoryUpdateData},
+		{"MaxNodesPerHistoryUpdateEvents", id.Server_ServerCapabilities_OperationLimits_MaxNodesPerHistoryUpdateEvents},
+	}
+)
+
+// GetServerStatus читает состояние и возможности сервера одним запросом Read
+func GetServerStatus() (*ServerStatus, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+
+	req := &ua.ReadRequest{TimestampsToReturn: ua.TimestampsToReturnNeither}
+	for _, g := range [][]statusNode{serverStatusNodes, capabilityNodes, operationLimitNodes} {
+		for _, n := range g {
+			req.NodesToRead = append(req.NodesToRead, &ua.ReadValueID{
+				NodeID:      ua.NewNumericNodeID(0, n.id),
+				AttributeID: ua.AttributeIDValue,
+			})
+		}
+	}
+	req.NodesToRead = append(req.NodesToRead, &ua.ReadValueID{
+		NodeID:      ua.NewNumericNodeID(0, id.Server_NamespaceArray),
+		AttributeID: ua.AttributeIDValue,
+	})
+
+	start := time.Now()
+	resp, err := session.Read(context.Background(), req)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	if len(resp.Results) != len(req.NodesToRead) {
+		return nil, fmt.Errorf("unexpected number of results")
+	}
+	st := &ServerStatus{RequestTime: start.Add(time.Since(start) / 2)}
+
+	res := resp.Results
+	take := func(g []statusNode) []StatusValue {
+		values := make([]StatusValue, len(g))
+		for i, n := range g {
+			values[i] = StatusValue{Name: n.name, Value: res[i]}
+		}
+		res = res[len(g):]
+		return values
+	}
+	st.Status = take(serverStatusNodes)
+	st.Capabilities = take(capabilityNodes)
+	st.OperationLimits = take(operationLimitNodes)
+	if dv := res[0]; dv.Status == ua.StatusOK && dv.Value != nil {
+		st.Namespaces, _ = dv.Value.Value().([]string)
+	}
+	return st, nil
+}
+
+// ClockSkew возвращает расхождение часов сервера с местными: положительное
+// значение означает, что часы сервера спешат
+func (st *ServerStatus) ClockSkew() (time.Duration, bool) {
+	for _, v := range st.Status {
+		if v.Name != "CurrentTime" || v.Value.Status != ua.StatusOK || v.Value.Value == nil {
+			continue
+		}
+		if t, ok := v.Value.Value.Value().(time.Time); ok && !t.IsZero() {
+			return t.Sub(st.RequestTime), true
+		}
+	}
+	return 0, false
+}
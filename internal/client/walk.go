@@ -0,0 +1,443 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// BrowseNode - узел, найденный при обходе адресного пространства
+type BrowseNode struct {
+	NodeID      *ua.NodeID
+	BrowseName  *ua.QualifiedName
+	DisplayName string
+	NodeClass   ua.NodeClass
+	// TypeDefinition - тип объекта или переменной
+	TypeDefinition *ua.NodeID
+	// DataType - тип данных переменной
+	DataType *ua.NodeID
+	// ReferenceType - тип ссылки, по которой узел найден у родителя
+	ReferenceType *ua.NodeID
+	Parent        *BrowseNode
+	Children      []*BrowseNode
+}
+
+// WalkOptions задаёт параметры обхода адресного пространства
+type WalkOptions struct {
+	// Depth - глубина обхода; 0 - без ограничения
+	Depth int
+	// NodeClassMask - классы узлов, по которым идёт обход; 0 - все классы
+	NodeClassMask uint32
+	// ReferenceType - тип ссылок (с подтипами); nil - HierarchicalReferences
+	ReferenceType *ua.NodeID
+	// Parallel - число одновременных запросов к серверу; 0 - defaultParallel
+	Parallel int
+	// Visit, если задан, вызывается для каждого найденного узла в порядке
+	// обхода; false прекращает обход
+	Visit func(n *BrowseNode) bool
+}
+
+const (
+	defaultParallel = 4
+	// Размеры пакетов, если сервер не сообщает свои ограничения
+	defaultBrowseLimit = 100
+	defaultReadLimit   = 500
+	defaultWriteLimit  = 500
+)
+
+// walkCache хранит результаты обзора и прочитанные типы данных на время
+// соединения, чтобы повторные обходы (tree, find) не нагружали сервер
+type walkCache struct {
+	mu        sync.Mutex
+	refs      map[string][]*ua.ReferenceDescription
+	dataTypes map[string]*ua.NodeID
+	// typeNames - имена типа и всех его супертипов
+	typeNames map[string][]string
+	limits    *operationLimits
+}
+
+// operationLimits - ограничения сервера на число узлов в одном запросе
+// (ServerCapabilities/OperationLimits)
+type operationLimits struct {
+	browse    int
+	read      int
+	translate int
+	write     int
+}
+
+var walked = newWalkCache()
+
+func newWalkCache() *walkCache {
+	return &walkCache{
+		refs:      make(map[string][]*ua.ReferenceDescription),
+		dataTypes: make(map[string]*ua.NodeID),
+		typeNames: make(map[string][]string),
+	}
+}
+
+// reset очищает кэш; вызывается при смене соединения
+func (c *walkCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refs = make(map[string][]*ua.ReferenceDescription)
+	c.dataTypes = make(map[string]*ua.NodeID)
+	c.typeNames = make(map[string][]string)
+	c.limits = nil
+}
+
+// Walk обходит адресное пространство от узла nodeID в ширину. Узлы одного
+// уровня обозреваются пакетами с учётом ограничений сервера, пакеты
+// отправляются параллельно. Каждый узел попадает в дерево один раз - под
+// первым найденным родителем, поэтому циклы в адресном пространстве
+// обход не зацикливают.
+func Walk(nodeID string, opts WalkOptions) (*BrowseNode, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	n, err := ParseNodeID(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ID: %w", err)
+	}
+	if opts.ReferenceType == nil {
+		opts.ReferenceType = ua.NewNumericNodeID(0, id.HierarchicalReferences)
+	}
+	if opts.Parallel <= 0 {
+		opts.Parallel = defaultParallel
+	}
+
+	ctx := context.Background()
+	root, err := walkRoot(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	limits := walked.operationLimits(ctx)
+
+	if opts.Visit != nil && !opts.Visit(root) {
+		return root, nil
+	}
+
+	visited := map[string]bool{n.String(): true}
+	level := []*BrowseNode{root}
+	for depth := 0; len(level) > 0 && (opts.Depth == 0 || depth < opts.Depth); depth++ {
+		refs, err := walked.browse(ctx, level, opts, limits.browse)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []*BrowseNode
+		for i, parent := range level {
+			for _, r := range refs[i] {
+				// Узлы других серверов не обозреваются
+				if r.NodeID == nil || r.NodeID.NodeID == nil || r.NodeID.ServerIndex != 0 {
+					continue
+				}
+				key := r.NodeID.NodeID.String()
+				if visited[key] {
+					continue
+				}
+				visited[key] = true
+
+				child := &BrowseNode{
+					NodeID:        r.NodeID.NodeID,
+					BrowseName:    r.BrowseName,
+					NodeClass:     r.NodeClass,
+					ReferenceType: r.ReferenceTypeID,
+					Parent:        parent,
+				}
+				if r.DisplayName != nil {
+					child.DisplayName = r.DisplayName.Text
+				}
+				if r.TypeDefinition != nil {
+					child.TypeDefinition = r.TypeDefinition.NodeID
+				}
+				parent.Children = append(parent.Children, child)
+				next = append(next, child)
+			}
+		}
+
+		if err := walked.readDataTypes(ctx, next, opts.Parallel, limits.read); err != nil {
+			return nil, err
+		}
+		if opts.Visit != nil {
+			for _, child := range next {
+				if !opts.Visit(child) {
+					return root, nil
+				}
+			}
+		}
+		level = next
+	}
+	return root, nil
+}
+
+// Path возвращает путь узла от начала обхода из имён просмотра:
+// /Objects/2:Boiler/2:Temperature
+func (n *BrowseNode) Path() string {
+	var parts []string
+	for p := n; p != nil; p = p.Parent {
+		parts = append(parts, browseNameText(p.BrowseName))
+	}
+	var b strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteString("/")
+		b.WriteString(parts[i])
+	}
+	return b.String()
+}
+
+func browseNameText(qn *ua.QualifiedName) string {
+	if qn == nil {
+		return ""
+	}
+	if qn.NamespaceIndex == 0 {
+		return qn.Name
+	}
+	return fmt.Sprintf("%d:%s", qn.NamespaceIndex, qn.Name)
+}
+
+// TypeNames возвращает имена и NodeId типа (ObjectType, VariableType или
+// DataType) и всех его супертипов, начиная с самого типа. Используется для
+// отбора узлов по типу с учётом наследования.
+func TypeNames(typeID *ua.NodeID) ([]string, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	return walked.supertypeNames(context.Background(), typeID)
+}
+
+func (c *walkCache) supertypeNames(ctx context.Context, typeID *ua.NodeID) ([]string, error) {
+	key := typeID.String()
+	c.mu.Lock()
+	names, ok := c.typeNames[key]
+	c.mu.Unlock()
+	if ok {
+		return names, nil
+	}
+
+	vals, err := readAttributes(ctx, typeID, ua.AttributeIDBrowseName)
+	if err != nil {
+		return nil, err
+	}
+	if vals[0].Status != ua.StatusOK {
+		return nil, fmt.Errorf("type %s: %v", typeID, vals[0].Status)
+	}
+	if qn := vals[0].Value.QualifiedName(); qn != nil {
+		names = append(names, qn.Name)
+	}
+	names = append(names, key)
+
+	supers, err := browseRefs(ctx, typeID, id.HasSubtype, ua.BrowseDirectionInverse)
+	if err != nil {
+		return nil, err
+	}
+	if len(supers) > 0 {
+		parent, err := c.supertypeNames(ctx, supers[0].NodeID.NodeID)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, parent...)
+	}
+
+	c.mu.Lock()
+	c.typeNames[key] = names
+	c.mu.Unlock()
+	return names, nil
+}
+
+// walkRoot читает атрибуты начального узла обхода
+func walkRoot(ctx context.Context, n *ua.NodeID) (*BrowseNode, error) {
+	vals, err := readAttributes(ctx, n, ua.AttributeIDBrowseName, ua.AttributeIDDisplayName,
+		ua.AttributeIDNodeClass, ua.AttributeIDDataType)
+	if err != nil {
+		return nil, err
+	}
+	if vals[0].Status != ua.StatusOK || vals[0].Value == nil {
+		return nil, fmt.Errorf("bad status: %v", vals[0].Status)
+	}
+
+	// Методы Variant разыменовывают получателя, поэтому атрибуты без
+	// значения пропускаются
+	root := &BrowseNode{NodeID: n, BrowseName: vals[0].Value.QualifiedName()}
+	if vals[1].Status == ua.StatusOK && vals[1].Value != nil {
+		if lt := vals[1].Value.LocalizedText(); lt != nil {
+			root.DisplayName = lt.Text
+		}
+	}
+	if vals[2].Status == ua.StatusOK && vals[2].Value != nil {
+		root.NodeClass = ua.NodeClass(vals[2].Value.Int())
+	}
+	if vals[3].Status == ua.StatusOK && vals[3].Value != nil {
+		root.DataType = vals[3].Value.NodeID()
+	}
+	return root, nil
+}
+
+// operationLimits возвращает ограничения сервера MaxNodesPerBrowse,
+// MaxNodesPerRead, MaxNodesPerTranslateBrowsePathsToNodeIds и
+// MaxNodesPerWrite; 0 или
+// отсутствие значения заменяются размерами по умолчанию
+func (c *walkCache) operationLimits(ctx context.Context) operationLimits {
+	c.mu.Lock()
+	limits := c.limits
+	c.mu.Unlock()
+	if limits != nil {
+		return *limits
+	}
+
+	limits = &operationLimits{browse: defaultBrowseLimit, read: defaultReadLimit, translate: defaultBrowseLimit, write: defaultWriteLimit}
+	vals, err := readValues(ctx, []*ua.NodeID{
+		ua.NewNumericNodeID(0, id.Server_ServerCapabilities_OperationLimits_MaxNodesPerBrowse),
+		ua.NewNumericNodeID(0, id.Server_ServerCapabilities_OperationLimits_MaxNodesPerRead),
+		ua.NewNumericNodeID(0, id.Server_ServerCapabilities_OperationLimits_MaxNodesPerTranslateBrowsePathsToNodeIDs),
+		ua.NewNumericNodeID(0, id.Server_ServerCapabilities_OperationLimits_MaxNodesPerWrite),
+	})
+	if err == nil {
+		if n := limitValue(vals[0]); n > 0 && n < limits.browse {
+			limits.browse = n
+		}
+		if n := limitValue(vals[1]); n > 0 && n < limits.read {
+			limits.read = n
+		}
+		if n := limitValue(vals[2]); n > 0 && n < limits.translate {
+			limits.translate = n
+		}
+		if n := limitValue(vals[3]); n > 0 && n < limits.write {
+			limits.write = n
+		}
+	}
+
+	c.mu.Lock()
+	c.limits = limits
+	c.mu.Unlock()
+	return *limits
+}
+
+func limitValue(dv *ua.DataValue) int {
+	if dv.Status != ua.StatusOK || dv.Value == nil {
+		return 0
+	}
+	return int(dv.Value.Uint())
+}
+
+// browse возвращает ссылки узлов уровня; результаты берутся из кэша,
+// остальные узлы обозреваются пакетами не более limit узлов
+func (c *walkCache) browse(ctx context.Context, nodes []*BrowseNode, opts WalkOptions, limit int) ([][]*ua.ReferenceDescription, error) {
+	res := make([][]*ua.ReferenceDescription, len(nodes))
+	keys := make([]string, len(nodes))
+	var (
+		missing []int
+		descs   []*ua.BrowseDescription
+	)
+
+	c.mu.Lock()
+	for i, n := range nodes {
+		keys[i] = fmt.Sprintf("%s|%s|%d", n.NodeID, opts.ReferenceType, opts.NodeClassMask)
+		if refs, ok := c.refs[keys[i]]; ok {
+			res[i] = refs
+			continue
+		}
+		missing = append(missing, i)
+		descs = append(descs, &ua.BrowseDescription{
+			NodeID:          n.NodeID,
+			BrowseDirection: ua.BrowseDirectionForward,
+			ReferenceTypeID: opts.ReferenceType,
+			IncludeSubtypes: true,
+			NodeClassMask:   opts.NodeClassMask,
+			ResultMask:      uint32(ua.BrowseResultMaskAll),
+		})
+	}
+	c.mu.Unlock()
+
+	refs, err := browseMany(ctx, descs, opts.Parallel, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for j, i := range missing {
+		res[i] = refs[j]
+		c.refs[keys[i]] = refs[j]
+	}
+	return res, nil
+}
+
+// readDataTypes читает атрибут DataType переменных пакетами не более limit узлов
+func (c *walkCache) readDataTypes(ctx context.Context, nodes []*BrowseNode, parallel, limit int) error {
+	var (
+		missing []*BrowseNode
+		items   []*ua.ReadValueID
+	)
+	c.mu.Lock()
+	for _, n := range nodes {
+		if n.NodeClass != ua.NodeClassVariable && n.NodeClass != ua.NodeClassVariableType {
+			continue
+		}
+		if dt, ok := c.dataTypes[n.NodeID.String()]; ok {
+			n.DataType = dt
+			continue
+		}
+		missing = append(missing, n)
+		items = append(items, &ua.ReadValueID{NodeID: n.NodeID, AttributeID: ua.AttributeIDDataType})
+	}
+	c.mu.Unlock()
+
+	vals, err := readMany(ctx, items, parallel, limit)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, n := range missing {
+		if vals[i].Status == ua.StatusOK && vals[i].Value != nil {
+			n.DataType = vals[i].Value.NodeID()
+		}
+		c.dataTypes[n.NodeID.String()] = n.DataType
+	}
+	return nil
+}
+
+// inBatches вызывает fn для отрезков [lo, hi) длиной не более size,
+// выполняя не более parallel вызовов одновременно. Возвращает первую ошибку.
+func inBatches(total, size, parallel int, fn func(lo, hi int) error) error {
+	if size <= 0 {
+		size = total
+	}
+	if parallel <= 0 {
+		parallel = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, parallel)
+	for lo := 0; lo < total; lo += size {
+		hi := lo + size
+		if hi > total {
+			hi = total
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(lo, hi); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(lo, hi)
+	}
+	wg.Wait()
+	return firstErr
+}
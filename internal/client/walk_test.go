@@ -0,0 +1,60 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestInBatches проверяет разбиение работы на пакеты с ограничением параллельности.
+//
+// Основные аспекты тестирования:
+// - Пакеты покрывают весь диапазон без пропусков и не превышают размер.
+// - Одновременно выполняется не больше заданного числа вызовов.
+// - Возвращается ошибка одного из пакетов.
+func TestInBatches(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][2]int
+		running int32
+		peak    int32
+	)
+	err := inBatches(10, 3, 2, func(lo, hi int) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		mu.Lock()
+		batches = append(batches, [2]int{lo, hi})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("inBatches() получена непредвиденная ошибка = %v", err)
+	}
+
+	sort.Slice(batches, func(i, j int) bool { return batches[i][0] < batches[j][0] })
+	want := [][2]int{{0, 3}, {3, 6}, {6, 9}, {9, 10}}
+	if fmt.Sprint(batches) != fmt.Sprint(want) {
+		t.Errorf("пакеты = %v, ожидалось %v", batches, want)
+	}
+	if peak > 2 {
+		t.Errorf("одновременно выполнялось %d вызовов, допустимо 2", peak)
+	}
+
+	err = inBatches(5, 2, 4, func(lo, hi int) error {
+		if lo == 2 {
+			return fmt.Errorf("batch failed")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "batch failed" {
+		t.Errorf("inBatches() = %v, ожидалась ошибка пакета", err)
+	}
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// Write записывает значение в атрибут Value узла
+func Write(nodeID string, value *ua.Variant) error {
+	return WriteRange(nodeID, value, "")
+}
+
+// WriteRange записывает элементы массива, заданные диапазоном индексов
+// NumericRange; value - массив с числом элементов диапазона. Пустой
+// диапазон - запись значения целиком.
+func WriteRange(nodeID string, value *ua.Variant, indexRange string) error {
+	dv := &ua.DataValue{EncodingMask: ua.DataValueValue, Value: value}
+	return WriteAttribute(nodeID, ua.AttributeIDValue, dv, indexRange)
+}
+
+// WriteAttribute записывает атрибут узла. Для атрибута Value dv может
+// содержать код статуса и метки времени - сервер принимает их, только если
+// поддерживает запись статуса и времени (StatusWrite, TimestampWrite).
+func WriteAttribute(nodeID string, attr ua.AttributeID, dv *ua.DataValue, indexRange string) error {
+	if session == nil {
+		return fmt.Errorf("not connected to server")
+	}
+
+	id, err := ParseNodeID(nodeID)
+	if err != nil {
+		return fmt.Errorf("invalid node ID: %w", err)
+	}
+
+	req := &ua.WriteRequest{
+		NodesToWrite: []*ua.WriteValue{{
+			NodeID:      id,
+			AttributeID: attr,
+			IndexRange:  indexRange,
+			Value:       dv,
+		}},
+	}
+
+	resp, err := session.Write(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+
+	if len(resp.Results) == 0 {
+		return fmt.Errorf("no results")
+	}
+	if resp.Results[0] != ua.StatusOK {
+		return fmt.Errorf("bad status: %w", resp.Results[0])
+	}
+	return nil
+}
+
+// WriteMany записывает значения в атрибуты Value узлов, заданных NodeId
+// или путями просмотра, пакетами не более MaxNodesPerWrite сервера, до
+// parallel пакетов одновременно (0 - defaultParallel). Возвращает коды
+// результата по одному на узел. Если пакет не отправлен, его узлы получают
+// код ошибки запроса: остальные пакеты могут быть уже записаны. Если узел
+// не найден, не записывается ни один узел.
+func WriteMany(nodeIDs []string, values []*ua.Variant, parallel int) ([]ua.StatusCode, error) {
+	if session == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+	if len(nodeIDs) != len(values) {
+		return nil, fmt.Errorf("%d values for %d nodes", len(values), len(nodeIDs))
+	}
+	if parallel <= 0 {
+		parallel = defaultParallel
+	}
+	ctx := context.Background()
+	limits := walked.operationLimits(ctx)
+
+	ids, errs, err := resolveNodeIDs(ctx, nodeIDs, parallel, limits.translate)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*ua.WriteValue, len(ids))
+	for i, n := range ids {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("%s: %w", nodeIDs[i], errs[i])
+		}
+		items[i] = &ua.WriteValue{
+			NodeID:      n,
+			AttributeID: ua.AttributeIDValue,
+			Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: values[i]},
+		}
+	}
+
+	res := make([]ua.StatusCode, len(items))
+	inBatches(len(items), limits.write, parallel, func(lo, hi int) error {
+		resp, err := session.Write(ctx, &ua.WriteRequest{NodesToWrite: items[lo:hi]})
+		if err == nil && len(resp.Results) == hi-lo {
+			copy(res[lo:hi], resp.Results)
+			return nil
+		}
+		code := ua.StatusBadCommunicationError
+		if err != nil {
+			errors.As(err, &code)
+		}
+		for i := lo; i < hi; i++ {
+			res[i] = code
+		}
+		return nil
+	})
+	return res, nil
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/audit"
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// Audit - журнал аудита изменяющих команд; nil - журнал не ведётся.
+// Задаётся при запуске (--audit-log).
+var Audit *audit.Log
+
+// record дописывает записи в журнал аудита, дополняя их сведениями о
+// соединении. Операция к этому моменту уже выполнена, поэтому ошибка
+// журнала выводится предупреждением.
+func record(entries ...audit.Entry) {
+	if Audit == nil || len(entries) == 0 {
+		return
+	}
+	var endpoint string
+	if s := client.GetSession(); s != nil {
+		endpoint = s.Info().Endpoint
+	}
+	for i := range entries {
+		entries[i].Endpoint, entries[i].Session = endpoint, client.SessionID()
+	}
+	if err := Audit.Record(entries...); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// auditValue возвращает обратимую JSON-форму DataValue для журнала. Метка
+// времени сервера у прочитанного значения - время чтения, она опускается.
+func auditValue(dv *ua.DataValue) interface{} {
+	if dv == nil {
+		return nil
+	}
+	v := *dv
+	v.ServerTimestamp, v.ServerPicoseconds = time.Time{}, 0
+	enc := &formatter.JSONEncoder{Reversible: true}
+	return enc.DataValue(&v)
+}
+
+// auditVariant - то же, что auditValue, для значения без статуса и времени
+func auditVariant(v *ua.Variant) interface{} {
+	if v == nil {
+		return nil
+	}
+	return auditValue(&ua.DataValue{EncodingMask: ua.DataValueValue, Value: v})
+}
+
+// auditStatus возвращает имя кода результата; ошибку запроса - её кодом
+// или текстом
+func auditStatus(code ua.StatusCode, err error) string {
+	if err != nil && !errors.As(err, &code) {
+		return err.Error()
+	}
+	return formatter.StatusName(code)
+}
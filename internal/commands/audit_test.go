@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/audit"
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestAudit проверяет журнал аудита изменяющих команд.
+//
+// Основные аспекты тестирования:
+// - Запись значения и атрибута сохраняется с прежним и новым значением.
+// - Запись из файла сохраняется по узлу, откат - отдельными записями.
+// - Изменения архива сохраняются с метками времени значений.
+// - Записи содержат эндпоинт и идентификатор соединения.
+func TestAudit(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Endpoint = "opc.tcp://plc1:4840"
+	s.Variable("ns=2;s=Mode", "Mode", int32(1))
+	s.Variable("ns=2;s=Locked", "Locked", int32(0)).WriteStatus = ua.StatusBadUserAccessDenied
+	s.Add("ns=2;s=Temp", &clienttest.Node{
+		Attributes: map[ua.AttributeID]*ua.DataValue{
+			ua.AttributeIDValue:       {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(20.5)},
+			ua.AttributeIDDescription: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(ua.NewLocalizedText("old"))},
+		},
+		HistoryUpdate: func(details interface{}) *ua.HistoryUpdateResult {
+			return &ua.HistoryUpdateResult{OperationResults: []ua.StatusCode{ua.StatusBadNoEntryExists}}
+		},
+	})
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Audit = log
+	t.Cleanup(func() {
+		Audit = nil
+		log.Close()
+	})
+
+	if err := Write("ns=2;s=Temp", "42", WriteOptions{}); err != nil {
+		t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+	}
+	if err := Write("ns=2;s=Temp", "new", WriteOptions{Attribute: "Description"}); err != nil {
+		t.Fatalf("Write() Description получена непредвиденная ошибка = %v", err)
+	}
+	recipe := filepath.Join(t.TempDir(), "recipe.csv")
+	if err := os.WriteFile(recipe, []byte("ns=2;s=Mode,2\nns=2;s=Locked,1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteList(recipe, WriteListOptions{Rollback: true, Yes: true}); err == nil {
+		t.Fatalf("WriteList() с отказом в записи должна вернуть ошибку")
+	}
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := HistoryDeleteAt("ns=2;s=Temp", []time.Time{at}, HistoryUpdateOptions{Yes: true}); err == nil {
+		t.Fatalf("HistoryDeleteAt() отсутствующего значения должна вернуть ошибку")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []struct {
+		operation, node, attribute, status, old, new string
+	}{
+		{"write", "ns=2;s=Temp", "Value", "Good", `"Body":20.5`, `"Body":42`},
+		{"write", "ns=2;s=Temp", "Description", "Good", `"Text":"old"`, `"Text":"new"`},
+		{"write", "ns=2;s=Mode", "Value", "Good", `"Body":1`, `"Body":2`},
+		{"write", "ns=2;s=Locked", "Value", "BadUserAccessDenied", `"Body":0`, `"Body":1`},
+		{"rollback", "ns=2;s=Mode", "Value", "Good", `"Body":2`, `"Body":1`},
+		{"history delete", "ns=2;s=Temp", "", "BadNoEntryExists", "", ""},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("журнал содержит %d записей, ожидалось %d:\n%s", len(lines), len(want), data)
+	}
+	for i, w := range want {
+		var e struct {
+			audit.Entry
+			Old json.RawMessage `json:"old"`
+			New json.RawMessage `json:"new"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &e); err != nil {
+			t.Fatalf("запись %d не JSON: %v", i, err)
+		}
+		if e.Operation != w.operation || e.Node != w.node || e.Attribute != w.attribute || e.Status != w.status ||
+			!strings.Contains(string(e.Old), w.old) || !strings.Contains(string(e.New), w.new) {
+			t.Errorf("запись %d = %s, ожидалось %+v", i, lines[i], w)
+		}
+		if e.Endpoint != "opc.tcp://plc1:4840" || e.Session != client.SessionID() || e.Session == "" {
+			t.Errorf("запись %d: эндпоинт %q, соединение %q", i, e.Endpoint, e.Session)
+		}
+	}
+	if !strings.Contains(lines[5], `"at":"2026-01-01T00:00:00Z"`) {
+		t.Errorf("запись удаления из архива без метки времени: %s", lines[5])
+	}
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestBrowsePathNodeID проверяет пути просмотра вместо NodeId в командах.
+//
+// Основные аспекты тестирования:
+// - Пути с "/" и "." разрешаются в один и тот же узел.
+// - Повторное обращение по пути берётся из кэша без запроса к серверу.
+// - Несуществующий путь возвращает ошибку.
+func TestBrowsePathNodeID(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Add("i=84", &clienttest.Node{References: []*ua.ReferenceDescription{ref("i=85", 0, "Objects")}})
+	s.Add("i=85", &clienttest.Node{References: []*ua.ReferenceDescription{ref("ns=2;s=Boiler", 2, "Boiler")}})
+	s.Add("ns=2;s=Boiler", &clienttest.Node{References: []*ua.ReferenceDescription{ref("ns=2;s=Boiler.Temperature", 2, "Temperature")}})
+	s.Variable("ns=2;s=Boiler.Temperature", "Temperature", 20.0)
+
+	for _, path := range []string{"/Objects/2:Boiler/2:Temperature", "Objects.2:Boiler.2:Temperature", "/Objects/2:Boiler/2:Temperature"} {
+		if err := Write(path, "21.5", WriteOptions{}); err != nil {
+			t.Fatalf("Write(%q) получена непредвиденная ошибка = %v", path, err)
+		}
+	}
+	if v := s.Value("ns=2;s=Boiler.Temperature"); v == nil || v.Value() != 21.5 {
+		t.Errorf("значение = %v, ожидалось 21.5", v)
+	}
+
+	translated := 0
+	for _, r := range s.Requests() {
+		if _, ok := r.(*ua.TranslateBrowsePathsToNodeIDsRequest); ok {
+			translated++
+		}
+	}
+	if translated != 2 {
+		t.Errorf("запросов TranslateBrowsePathsToNodeIds = %d, ожидалось 2", translated)
+	}
+
+	if err := Read("/Objects/2:Pump", ReadOptions{}); err == nil || !strings.Contains(err.Error(), "browse path not found: /Objects/2:Pump") {
+		t.Errorf("Read() = %v, ожидалась ошибка ненайденного пути", err)
+	}
+}
+
+func ref(nodeID string, ns uint16, name string) *ua.ReferenceDescription {
+	return &ua.ReferenceDescription{
+		ReferenceTypeID: ua.NewNumericNodeID(0, id.Organizes),
+		IsForward:       true,
+		NodeID:          ua.NewExpandedNodeID(ua.MustParseNodeID(nodeID), "", 0),
+		BrowseName:      &ua.QualifiedName{NamespaceIndex: ns, Name: name},
+	}
+}
@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Confirm запрашивает у пользователя подтверждение действия. Оболочка
+// заменяет его на ConfirmFrom со своим буфером ввода: иначе ответ может
+// остаться в буфере, прочитанном оболочкой.
+var Confirm = ConfirmFrom(bufio.NewReader(os.Stdin))
+
+// ConfirmFrom возвращает функцию подтверждения, читающую ответ из r.
+// Подтверждением считается y или yes; конец ввода - отказ.
+func ConfirmFrom(r *bufio.Reader) func(prompt string) bool {
+	return func(prompt string) bool {
+		fmt.Printf("%s [y/N] ", prompt)
+		answer, err := r.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Println()
+			return false
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		}
+		return false
+	}
+}
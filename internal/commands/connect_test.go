@@ -1,10 +1,87 @@
 package commands
 
-import "testing"
+import (
+	"net"
+	"testing"
 
-func TestExample(t *testing.T) {
-	sum := 2 + 2
-	if sum != 4 {
-		t.Errorf("Expected 4, got %d", sum)
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/simulator"
+)
+
+// startServer запускает сервер имитации на свободном порту localhost
+// и отключает клиента после теста
+func startServer(t *testing.T) *simulator.Server {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("интеграционный тест пропущен в режиме -short")
+	}
+	s, err := simulator.Start(simulator.Config{Host: "localhost"})
+	if err != nil {
+		t.Fatalf("simulator.Start() получена непредвиденная ошибка = %v", err)
+	}
+	t.Cleanup(func() {
+		client.Disconnect()
+		s.Close()
+	})
+	return s
+}
+
+// closedEndpoint возвращает адрес порта, на котором никто не слушает
+func closedEndpoint(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("net.Listen() получена непредвиденная ошибка = %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return "opc.tcp://" + addr
+}
+
+// TestConnect проверяет подключение к серверу имитации.
+//
+// Основные аспекты тестирования:
+// - Ошибка для пустого endpoint.
+// - Подключение и чтение информации о сервере.
+// - Повторное подключение закрывает предыдущее соединение.
+// - Ошибка подключения к недоступному серверу не оставляет активного клиента.
+func TestConnect(t *testing.T) {
+	s := startServer(t)
+
+	if err := Connect(""); err == nil || err.Error() != "endpoint cannot be empty" {
+		t.Errorf("Connect(\"\") = %v, ожидалась ошибка пустого endpoint", err)
+	}
+
+	if err := Connect(s.Endpoint()); err != nil {
+		t.Fatalf("Connect() получена непредвиденная ошибка = %v", err)
+	}
+	first := client.GetSession()
+	if first == nil {
+		t.Fatalf("GetSession() = nil после подключения")
+	}
+
+	info, err := client.GetServerInfo()
+	if err != nil {
+		t.Fatalf("GetServerInfo() получена непредвиденная ошибка = %v", err)
+	}
+	if info.ProductName != "opcli simulator" {
+		t.Errorf("ProductName = %q, ожидалось %q", info.ProductName, "opcli simulator")
+	}
+	if info.ServerState == "" {
+		t.Errorf("ServerState не прочитан")
+	}
+
+	if err := Connect(s.Endpoint()); err != nil {
+		t.Fatalf("повторный Connect() получена непредвиденная ошибка = %v", err)
+	}
+	if c := client.GetSession(); c == nil || c == first {
+		t.Errorf("повторный Connect() не создал новую сессию")
+	}
+
+	if err := Connect(closedEndpoint(t)); err == nil {
+		t.Errorf("Connect() к недоступному серверу должен вернуть ошибку")
+	}
+	if client.GetSession() != nil {
+		t.Errorf("GetSession() != nil после неудачного подключения")
 	}
 }
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/nodeset"
+)
+
+// SessionSnapshot - операнд diff, обозначающий адресное пространство
+// текущего подключения
+const SessionSnapshot = "session"
+
+// DiffOptions задаёт параметры команды diff
+type DiffOptions struct {
+	// Format - формат вывода: text или json
+	Format string
+	// Values включает сравнение значений переменных
+	Values bool
+	// Parallel - число одновременных запросов к серверу
+	Parallel int
+}
+
+// Diff сравнивает два снимка адресного пространства. Снимок - файл UANodeSet,
+// сохранённый export nodeset, или session - текущее подключение, которое
+// обходится от узла root. Узлы сопоставляются по URI пространств имён,
+// поэтому снимки разных серверов сравниваются корректно.
+func Diff(a, b, root string, opts DiffOptions) error {
+	if opts.Format == "" {
+		opts.Format = "text"
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+
+	left, err := loadSnapshot(a, root, opts)
+	if err != nil {
+		return err
+	}
+	right, err := loadSnapshot(b, root, opts)
+	if err != nil {
+		return err
+	}
+
+	d, err := nodeset.Compare(left, right, nodeset.DiffOptions{Values: opts.Values})
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == "json" {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printDiff(d)
+	return nil
+}
+
+func loadSnapshot(arg, root string, opts DiffOptions) (*nodeset.NodeSet, error) {
+	if arg == SessionSnapshot {
+		return client.ExportNodeSet(exportRoots(root), client.ExportOptions{Values: opts.Values, Parallel: opts.Parallel})
+	}
+	f, err := os.Open(arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", arg, err)
+	}
+	defer f.Close()
+	set, err := nodeset.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	return set, nil
+}
+
+// printDiff выводит различия: + добавленный узел, - удалённый,
+// ~ изменённый с перечнем изменений
+func printDiff(d *nodeset.Diff) {
+	if d.Empty() {
+		fmt.Println("No differences")
+		return
+	}
+	for _, n := range d.Added {
+		fmt.Printf("+ %s %s [%s]\n", n.NodeID, n.BrowseName, n.NodeClass)
+	}
+	for _, n := range d.Removed {
+		fmt.Printf("- %s %s [%s]\n", n.NodeID, n.BrowseName, n.NodeClass)
+	}
+	for _, n := range d.Changed {
+		fmt.Printf("~ %s %s [%s]\n", n.NodeID, n.BrowseName, n.NodeClass)
+		for _, c := range n.Changes {
+			switch {
+			case c.Attribute == "Reference" && c.Old == "":
+				fmt.Printf("    + reference %s\n", c.New)
+			case c.Attribute == "Reference":
+				fmt.Printf("    - reference %s\n", c.Old)
+			default:
+				fmt.Printf("    %s: %s -> %s\n", c.Attribute, orNone(c.Old), orNone(c.New))
+			}
+		}
+	}
+	fmt.Printf("%d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
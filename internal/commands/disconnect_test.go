@@ -1,10 +1,33 @@
 package commands
 
-import "testing"
+import (
+	"testing"
 
-func TestExample1(t *testing.T) {
-	sum := 2 + 2
-	if sum != 4 {
-		t.Errorf("Expected 4, got %d", sum)
+	"github.com/alexfrick92/opcli/internal/client"
+)
+
+// TestDisconnect проверяет отключение от сервера.
+//
+// Основные аспекты тестирования:
+// - Отключение без активного соединения не является ошибкой.
+// - После отключения клиент сброшен и запросы к серверу возвращают ошибку.
+func TestDisconnect(t *testing.T) {
+	s := startServer(t)
+
+	if err := Disconnect(); err != nil {
+		t.Errorf("Disconnect() без соединения получена непредвиденная ошибка = %v", err)
+	}
+
+	if err := Connect(s.Endpoint()); err != nil {
+		t.Fatalf("Connect() получена непредвиденная ошибка = %v", err)
+	}
+	if err := Disconnect(); err != nil {
+		t.Fatalf("Disconnect() получена непредвиденная ошибка = %v", err)
+	}
+	if client.GetSession() != nil {
+		t.Errorf("GetSession() != nil после отключения")
+	}
+	if _, err := client.GetServerInfo(); err == nil || err.Error() != "not connected to server" {
+		t.Errorf("GetServerInfo() после отключения = %v, ожидалась ошибка %q", err, "not connected to server")
 	}
 }
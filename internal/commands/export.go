@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/nodeset"
+)
+
+// ExportOptions задаёт параметры команды export nodeset
+type ExportOptions struct {
+	// Output - путь к файлу UANodeSet
+	Output string
+	// Values включает текущие значения переменных
+	Values bool
+	// Parallel - число одновременных запросов к серверу
+	Parallel int
+}
+
+// typesRoot - папка Types: без неё в снимок не попадают типы объектов,
+// переменных и данных, заданные сервером
+const typesRoot = "i=86"
+
+// exportRoots возвращает узлы, от которых обходится адресное пространство
+// для снимка: заданный root или, по умолчанию, Objects и Types
+func exportRoots(root string) []string {
+	if root == "" {
+		return []string{DefaultRoot, typesRoot}
+	}
+	return []string{root}
+}
+
+// ExportNodeSet сохраняет адресное пространство от узла root в файл
+// UANodeSet (NodeSet2 XML)
+func ExportNodeSet(root string, opts ExportOptions) error {
+	if opts.Output == "" {
+		return fmt.Errorf("output file is required")
+	}
+
+	set, err := client.ExportNodeSet(exportRoots(root), client.ExportOptions{Values: opts.Values, Parallel: opts.Parallel})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(opts.Output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", opts.Output, err)
+	}
+	if err := nodeset.Write(f, set); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", opts.Output, err)
+	}
+
+	fmt.Printf("Exported %d nodes to %s\n", len(set.Nodes), opts.Output)
+	return nil
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestExportNodeSet проверяет выбор начальных узлов экспорта.
+//
+// Основные аспекты тестирования:
+// - Без root экспортируются и объекты, и типы сервера из папки Types.
+// - С root экспортируется только поддерево root.
+func TestExportNodeSet(t *testing.T) {
+	s := clienttest.Attach(t)
+	folder := func(nodeID, name string, children ...*ua.ReferenceDescription) {
+		n := s.Variable(nodeID, name, int32(0))
+		n.Attributes[ua.AttributeIDNodeClass] = &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(int32(ua.NodeClassObject))}
+		n.References = children
+	}
+	child := func(nodeID, name string, class ua.NodeClass) *ua.ReferenceDescription {
+		n := ua.MustParseNodeID(nodeID)
+		return &ua.ReferenceDescription{
+			ReferenceTypeID: ua.NewNumericNodeID(0, 35),
+			IsForward:       true,
+			NodeID:          ua.NewExpandedNodeID(n, "", 0),
+			BrowseName:      &ua.QualifiedName{NamespaceIndex: n.Namespace(), Name: name},
+			NodeClass:       class,
+		}
+	}
+	folder("i=85", "Objects", child("ns=2;s=Boiler", "Boiler", ua.NodeClassObject))
+	folder("i=86", "Types", child("ns=2;i=1001", "BoilerType", ua.NodeClassObjectType))
+	folder("ns=2;s=Boiler", "Boiler")
+	folder("ns=2;i=1001", "BoilerType")
+	s.Variable("i=2255", "NamespaceArray", []string{"http://opcfoundation.org/UA/", "urn:other", "urn:plant"})
+
+	dir := t.TempDir()
+	tests := []struct {
+		name, root string
+		want       []string
+		unwanted   []string
+	}{
+		{name: "По умолчанию", want: []string{`NodeId="ns=2;s=Boiler"`, `NodeId="ns=2;i=1001"`}},
+		{name: "Заданный root", root: "i=85", want: []string{`NodeId="ns=2;s=Boiler"`}, unwanted: []string{`NodeId="ns=2;i=1001"`}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(dir, fmt.Sprintf("snapshot%d.xml", i))
+			if err := ExportNodeSet(tt.root, ExportOptions{Output: out}); err != nil {
+				t.Fatalf("ExportNodeSet() получена непредвиденная ошибка = %v", err)
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(string(data), w) {
+					t.Errorf("в снимке нет %s:\n%s", w, data)
+				}
+			}
+			for _, u := range tt.unwanted {
+				if strings.Contains(string(data), u) {
+					t.Errorf("в снимке не должно быть %s", u)
+				}
+			}
+		})
+	}
+}
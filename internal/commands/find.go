@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/gopcua/opcua/ua"
+)
+
+// FindOptions задаёт условия поиска узлов. Заданные условия должны
+// выполняться одновременно.
+type FindOptions struct {
+	// Name - шаблон имени (BrowseName или DisplayName): glob, без учёта регистра
+	Name string
+	// Regex - Name является регулярным выражением
+	Regex bool
+	// Type - тип объекта или переменной (с подтипами) по имени или NodeId
+	Type string
+	// DataType - тип данных переменной (с подтипами) по имени или NodeId
+	DataType string
+	// Max - наибольшее число результатов; 0 - без ограничения
+	Max int
+	// Depth - глубина обхода; 0 - без ограничения
+	Depth int
+	// Parallel - число одновременных запросов к серверу
+	Parallel int
+}
+
+// Find обходит адресное пространство от узла root и выводит пути и NodeId
+// узлов, удовлетворяющих условиям
+func Find(root string, opts FindOptions) error {
+	if root == "" {
+		root = DefaultRoot
+	}
+	if opts.Name == "" && opts.Type == "" && opts.DataType == "" {
+		return fmt.Errorf("at least one of --name, --type or --datatype is required")
+	}
+	matchName, err := nameMatcher(opts.Name, opts.Regex)
+	if err != nil {
+		return err
+	}
+	// Типы сравниваются с NodeId вида ns=<индекс>;..., поэтому NodeId в
+	// других формах и пути просмотра приводятся к ней заранее; имена типов
+	// сравниваются как есть
+	for _, t := range []*string{&opts.Type, &opts.DataType} {
+		if client.IsNodeID(*t) {
+			n, err := client.ParseNodeID(*t)
+			if err != nil {
+				return err
+			}
+			*t = n.String()
+		}
+	}
+
+	wo, err := walkOptions(opts.Depth, "", "", opts.Parallel)
+	if err != nil {
+		return err
+	}
+
+	found := 0
+	var visitErr error
+	wo.Visit = func(n *client.BrowseNode) bool {
+		ok, err := matches(n, opts, matchName)
+		if err != nil {
+			visitErr = err
+			return false
+		}
+		if ok {
+			found++
+			fmt.Printf("%s  %s\n", n.Path(), n.NodeID)
+		}
+		return opts.Max == 0 || found < opts.Max
+	}
+
+	if _, err := client.Walk(root, wo); err != nil {
+		return err
+	}
+	if visitErr != nil {
+		return visitErr
+	}
+
+	if opts.Max > 0 && found == opts.Max {
+		fmt.Printf("Found %d nodes (limit reached)\n", found)
+	} else {
+		fmt.Printf("Found %d nodes\n", found)
+	}
+	return nil
+}
+
+// nameMatcher возвращает функцию сравнения имени с шаблоном
+func nameMatcher(pattern string, regex bool) (func(string) bool, error) {
+	switch {
+	case pattern == "":
+		return func(string) bool { return true }, nil
+	case regex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return re.MatchString, nil
+	}
+
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid name pattern: %s", pattern)
+	}
+	return func(name string) bool {
+		ok, _ := path.Match(pattern, strings.ToLower(name))
+		return ok
+	}, nil
+}
+
+// matches проверяет узел по всем заданным условиям
+func matches(n *client.BrowseNode, opts FindOptions, matchName func(string) bool) (bool, error) {
+	if opts.Name != "" {
+		byBrowseName := n.BrowseName != nil && matchName(n.BrowseName.Name)
+		if !byBrowseName && !matchName(n.DisplayName) {
+			return false, nil
+		}
+	}
+	if opts.Type != "" {
+		ok, err := isOfType(n.TypeDefinition, opts.Type)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if opts.DataType != "" {
+		ok, err := isOfType(n.DataType, opts.DataType)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// isOfType сообщает, совпадает ли тип typeID с want (по имени или NodeId)
+// или наследует от него
+func isOfType(typeID *ua.NodeID, want string) (bool, error) {
+	if typeID == nil {
+		return false, nil
+	}
+	names, err := client.TypeNames(typeID)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, want) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
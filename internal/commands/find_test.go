@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestFindByName проверяет отбор узлов по имени.
+//
+// Основные аспекты тестирования:
+// - Шаблон glob без учёта регистра по BrowseName и DisplayName.
+// - Регулярное выражение.
+// - Ошибка для некорректного шаблона.
+func TestFindByName(t *testing.T) {
+	node := &client.BrowseNode{
+		BrowseName:  &ua.QualifiedName{NamespaceIndex: 2, Name: "TT101"},
+		DisplayName: "Temperature boiler 1",
+	}
+
+	tests := []struct {
+		pattern string
+		regex   bool
+		want    bool
+	}{
+		{"temp*", false, true},
+		{"TT1?1", false, true},
+		{"Pressure*", false, false},
+		{`^TT\d+$`, true, true},
+		{`^PT`, true, false},
+	}
+	for _, tt := range tests {
+		match, err := nameMatcher(tt.pattern, tt.regex)
+		if err != nil {
+			t.Fatalf("nameMatcher(%q) получена непредвиденная ошибка = %v", tt.pattern, err)
+		}
+		got, err := matches(node, FindOptions{Name: tt.pattern}, match)
+		if err != nil || got != tt.want {
+			t.Errorf("matches(%q) = %v, %v, ожидалось %v", tt.pattern, got, err, tt.want)
+		}
+	}
+
+	if _, err := nameMatcher("[", false); err == nil {
+		t.Errorf("nameMatcher() должна вернуть ошибку для некорректного шаблона")
+	}
+	if _, err := nameMatcher("(", true); err == nil {
+		t.Errorf("nameMatcher() должна вернуть ошибку для некорректного выражения")
+	}
+}
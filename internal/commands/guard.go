@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// ReadOnly запрещает команды, изменяющие данные сервера: запись значений
+// и атрибутов и изменение архива. Задаётся при запуске (--read-only) и в
+// оболочке не меняется.
+var ReadOnly bool
+
+// ConfirmWrites требует подтверждения каждой записи: одиночная запись
+// показывает текущее и новое значение, а --yes не отключает подтверждение.
+// Задаётся при запуске (--confirm-writes).
+var ConfirmWrites bool
+
+// checkWritable возвращает ошибку для изменяющей команды в режиме только чтения
+func checkWritable(command string) error {
+	if ReadOnly {
+		return fmt.Errorf("%s is not allowed in read-only mode", command)
+	}
+	return nil
+}
+
+// confirmed запрашивает подтверждение, если оно не отключено --yes (yes);
+// в режиме ConfirmWrites подтверждение запрашивается всегда
+func confirmed(yes bool, prompt string) bool {
+	if yes && !ConfirmWrites {
+		return true
+	}
+	return Confirm(prompt)
+}
+
+// currentValue читает текущее значение атрибута или диапазона элементов
+// для подтверждения записи и журнала аудита
+func currentValue(nodeID string, attr ua.AttributeID, indexRange string) (*ua.DataValue, error) {
+	if indexRange != "" {
+		return client.ReadRange(nodeID, indexRange)
+	}
+	return client.ReadAttribute(nodeID, attr)
+}
+
+// confirmWrite выводит текущее и новое значение атрибута и запрашивает
+// подтверждение записи
+func confirmWrite(nodeID string, attr ua.AttributeID, indexRange string, current, dv *ua.DataValue) bool {
+	if indexRange != "" {
+		nodeID += "[" + indexRange + "]"
+	}
+	info := &client.NodeInfo{}
+	from := attributeText(client.AttributeValue{ID: attr, Value: current}, info)
+	to := attributeText(client.AttributeValue{ID: attr, Value: dv}, info)
+	fmt.Printf("%s %s: %s -> %s\n", nodeID, formatter.AttributeName(attr), from, to)
+	return Confirm("Write?")
+}
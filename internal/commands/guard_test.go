@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+)
+
+// TestWriteGuards проверяет режим только чтения и подтверждение записи.
+//
+// Основные аспекты тестирования:
+// - В режиме только чтения запись и изменение архива отклоняются без запросов к серверу.
+// - Предпросмотр write --from --dry-run в режиме только чтения доступен.
+// - В режиме подтверждения отказ отменяет запись, а --yes не отключает запрос.
+func TestWriteGuards(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("ns=2;s=Temp", "Temp", 20.5)
+	t.Cleanup(func() { ReadOnly, ConfirmWrites = false, false })
+	confirm := Confirm
+	t.Cleanup(func() { Confirm = confirm })
+	var prompts []string
+	answer := false
+	Confirm = func(prompt string) bool {
+		prompts = append(prompts, prompt)
+		return answer
+	}
+	recipe := filepath.Join(t.TempDir(), "recipe.csv")
+	if err := os.WriteFile(recipe, []byte("ns=2;s=Temp,42\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ReadOnly = true
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"Write", func() error { return Write("ns=2;s=Temp", "42", WriteOptions{}) }},
+		{"Write --attr", func() error { return Write("ns=2;s=Temp", "x", WriteOptions{Attribute: "Description"}) }},
+		{"WriteList", func() error { return WriteList(recipe, WriteListOptions{Yes: true}) }},
+		{"HistoryInsert", func() error {
+			return HistoryInsert("ns=2;s=Temp", []HistoryValue{{Time: time.Now(), Value: "1"}}, HistoryUpdateOptions{Yes: true})
+		}},
+		{"HistoryDelete", func() error {
+			return HistoryDelete("ns=2;s=Temp", time.Now().Add(-time.Hour), time.Now(), HistoryUpdateOptions{Yes: true})
+		}},
+		{"HistoryDeleteAt", func() error {
+			return HistoryDeleteAt("ns=2;s=Temp", []time.Time{time.Now()}, HistoryUpdateOptions{Yes: true})
+		}},
+	}
+	for _, c := range checks {
+		if err := c.run(); err == nil || !strings.Contains(err.Error(), "not allowed in read-only mode") {
+			t.Errorf("%s в режиме только чтения = %v, ожидался отказ", c.name, err)
+		}
+	}
+	if n := len(s.Requests()); n != 0 {
+		t.Errorf("в режиме только чтения отправлено %d запросов, ожидалось 0", n)
+	}
+	if err := WriteList(recipe, WriteListOptions{DryRun: true}); err != nil {
+		t.Errorf("WriteList() --dry-run в режиме только чтения получена непредвиденная ошибка = %v", err)
+	}
+
+	ReadOnly, ConfirmWrites = false, true
+	if err := Write("ns=2;s=Temp", "42", WriteOptions{}); err != nil {
+		t.Fatalf("Write() с отказом в подтверждении получена непредвиденная ошибка = %v", err)
+	}
+	if err := WriteList(recipe, WriteListOptions{Yes: true}); err != nil {
+		t.Fatalf("WriteList() с отказом в подтверждении получена непредвиденная ошибка = %v", err)
+	}
+	if len(prompts) != 2 || prompts[0] != "Write?" || prompts[1] != "Write 1 values?" {
+		t.Errorf("запросы подтверждения = %q, ожидались Write? и Write 1 values?", prompts)
+	}
+	if n := len(s.Writes()); n != 0 {
+		t.Errorf("без подтверждения записано %d значений, ожидалось 0", n)
+	}
+
+	answer = true
+	if err := Write("ns=2;s=Temp", "42", WriteOptions{}); err != nil {
+		t.Fatalf("Write() с подтверждением получена непредвиденная ошибка = %v", err)
+	}
+	if v := s.Value("ns=2;s=Temp"); v == nil || v.Value() != float64(42) {
+		t.Errorf("Temp после подтверждённой записи = %v, ожидалось 42", v)
+	}
+}
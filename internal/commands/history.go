@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// HistoryOptions задаёт параметры чтения архива
+type HistoryOptions struct {
+	// Start и End - интервал чтения; End раньше Start - обратный порядок
+	Start time.Time
+	End   time.Time
+	// Max - наибольшее число значений; 0 - без ограничения
+	Max int
+	// Format - формат вывода: table или csv
+	Format string
+}
+
+// HistoryRaw выводит значения из архива за интервал
+func HistoryRaw(nodeID string, opts HistoryOptions) error {
+	if err := checkHistoryFormat(opts.Format); err != nil {
+		return err
+	}
+	data, err := client.HistoryReadRaw(nodeID, opts.Start, opts.End, opts.Max, false)
+	if err != nil {
+		return err
+	}
+	return printHistory(data, client.EnumType(nodeID), opts)
+}
+
+// HistoryModified выводит прежние версии значений, изменённых или удалённых
+// в архиве за интервал, со временем, видом изменения и пользователем
+func HistoryModified(nodeID string, opts HistoryOptions) error {
+	if err := checkHistoryFormat(opts.Format); err != nil {
+		return err
+	}
+	data, err := client.HistoryReadRaw(nodeID, opts.Start, opts.End, opts.Max, true)
+	if err != nil {
+		return err
+	}
+	return printHistory(data, client.EnumType(nodeID), opts)
+}
+
+// HistoryAt выводит значения архива на заданные моменты времени
+func HistoryAt(nodeID string, times []time.Time, opts HistoryOptions) error {
+	if err := checkHistoryFormat(opts.Format); err != nil {
+		return err
+	}
+	data, err := client.HistoryReadAtTime(nodeID, times)
+	if err != nil {
+		return err
+	}
+	return printHistory(data, client.EnumType(nodeID), opts)
+}
+
+func checkHistoryFormat(format string) error {
+	switch format {
+	case "", "table", "csv":
+		return nil
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+// printHistory выводит значения таблицей или CSV. Столбцы изменения
+// добавляются, только если сервер вернул сведения об изменениях.
+func printHistory(data *client.HistoryData, enum *datatype.Definition, opts HistoryOptions) error {
+	header := []string{"SourceTimestamp", "ServerTimestamp", "Value", "Status"}
+	modified := len(data.Modifications) > 0
+	if modified {
+		header = append(header, "ModificationTime", "UpdateType", "User")
+	}
+
+	rows := make([][]string, len(data.Values))
+	for i, dv := range data.Values {
+		row := []string{
+			timestampText(dv.SourceTimestamp),
+			timestampText(dv.ServerTimestamp),
+			formatter.EnumValue(dv.Value, enum),
+			formatter.StatusName(dv.Status),
+		}
+		if modified {
+			row = append(row, "", "", "")
+			if i < len(data.Modifications) && data.Modifications[i] != nil {
+				m := data.Modifications[i]
+				row[4] = timestampText(m.ModificationTime)
+				row[5] = strings.TrimPrefix(m.UpdateType.String(), "HistoryUpdateType")
+				row[6] = m.UserName
+			}
+		}
+		rows[i] = row
+	}
+
+	if opts.Format == "csv" {
+		return formatter.CSV(os.Stdout, header, rows)
+	}
+	if len(rows) == 0 {
+		fmt.Println("No values")
+		return nil
+	}
+	if err := formatter.Table(os.Stdout, header, rows); err != nil {
+		return err
+	}
+	if data.More {
+		fmt.Printf("... first %d values shown, use --max to read more\n", len(rows))
+	}
+	return nil
+}
+
+// timestampText форматирует метку времени; нулевое время - пустая строка
+func timestampText(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// HistoryAggregate выводит значения функций агрегирования архива по
+// интервалам: по столбцу на функцию, по строке на начало интервала
+func HistoryAggregate(nodeID string, aggregates []string, interval time.Duration, opts HistoryOptions) error {
+	if err := checkHistoryFormat(opts.Format); err != nil {
+		return err
+	}
+	results, err := client.HistoryReadProcessed(nodeID, opts.Start, opts.End, interval, aggregates)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"Timestamp"}
+	var rows [][]string
+	index := make(map[int64]int)
+	for i, res := range results {
+		header = append(header, res.Aggregate.Name)
+		for _, dv := range res.Values {
+			key := dv.SourceTimestamp.UnixNano()
+			r, ok := index[key]
+			if !ok {
+				r = len(rows)
+				index[key] = r
+				rows = append(rows, make([]string, len(results)+1))
+				rows[r][0] = timestampText(dv.SourceTimestamp)
+			}
+			rows[r][i+1] = aggregateText(dv)
+		}
+	}
+
+	if opts.Format == "csv" {
+		return formatter.CSV(os.Stdout, header, rows)
+	}
+	if len(rows) == 0 {
+		fmt.Println("No values")
+		return nil
+	}
+	return formatter.Table(os.Stdout, header, rows)
+}
+
+// aggregateText возвращает значение агрегата; статус, отличный от Good,
+// выводится рядом со значением или вместо него
+func aggregateText(dv *ua.DataValue) string {
+	status := formatter.StatusName(dv.Status)
+	if dv.Value == nil || dv.Value.Value() == nil {
+		return status
+	}
+	v := formatter.Value(dv.Value)
+	if dv.Status&0xC0000000 != 0 {
+		v += " (" + status + ")"
+	}
+	return v
+}
+
+// HistoryAggregates выводит функции агрегирования, поддерживаемые сервером
+func HistoryAggregates() error {
+	aggs, err := client.ReadAggregates()
+	if err != nil {
+		return err
+	}
+	if len(aggs) == 0 {
+		fmt.Println("Server does not list aggregate functions")
+		return nil
+	}
+	rows := make([][]string, len(aggs))
+	for i, a := range aggs {
+		rows[i] = []string{a.Name, a.NodeID.String()}
+	}
+	return formatter.Table(os.Stdout, []string{"Name", "NodeId"}, rows)
+}
+
+// HistoryEvents выводит события из архива узла notifier: по столбцу на поле
+// из fields (по умолчанию client.DefaultEventFields), по строке на событие.
+// where задаёт условие отбора (см. client.ParseEventFilter).
+func HistoryEvents(notifier string, fields []string, where string, opts HistoryOptions) error {
+	if err := checkHistoryFormat(opts.Format); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		fields = client.DefaultEventFields
+	}
+	filter, err := client.ParseEventFilter(fields, where)
+	if err != nil {
+		return err
+	}
+	data, err := client.HistoryReadEvents(notifier, opts.Start, opts.End, opts.Max, filter)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(data.Events))
+	for i, ev := range data.Events {
+		rows[i] = make([]string, len(fields))
+		for j := range fields {
+			if j < len(ev) && ev[j] != nil && ev[j].Value() != nil {
+				rows[i][j] = formatter.Value(ev[j])
+			}
+		}
+	}
+
+	if opts.Format == "csv" {
+		return formatter.CSV(os.Stdout, fields, rows)
+	}
+	if len(rows) == 0 {
+		fmt.Println("No events")
+		return nil
+	}
+	if err := formatter.Table(os.Stdout, fields, rows); err != nil {
+		return err
+	}
+	if data.More {
+		fmt.Printf("... first %d events shown, use --max to read more\n", len(rows))
+	}
+	return nil
+}
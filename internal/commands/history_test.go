@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestHistoryRead проверяет чтение архива с точками продолжения.
+//
+// Основные аспекты тестирования:
+// - Значения со всех страниц собираются в один результат.
+// - При достижении --max чтение прекращается, точка продолжения освобождается.
+// - Сведения об изменениях возвращаются вместе со значениями.
+// - Узел без архива возвращает ошибку.
+func TestHistoryRead(t *testing.T) {
+	s := clienttest.Attach(t)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	value := func(i int) *ua.DataValue {
+		return &ua.DataValue{
+			EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+			Value:           ua.MustVariant(float64(i)),
+			SourceTimestamp: start.Add(time.Duration(i) * time.Second),
+		}
+	}
+	// Архив из 5 значений отдаётся страницами по 2; точка продолжения - номер страницы
+	s.Variable("ns=2;s=Tag", "Tag", 0.0).History = func(details interface{}, cp []byte) *ua.HistoryReadResult {
+		if d, ok := details.(*ua.ReadRawModifiedDetails); ok && d.IsReadModified {
+			return &ua.HistoryReadResult{HistoryData: ua.NewExtensionObject(&ua.HistoryModifiedData{
+				DataValues:        []*ua.DataValue{value(1)},
+				ModificationInfos: []*ua.ModificationInfo{{ModificationTime: start, UpdateType: ua.HistoryUpdateTypeReplace, UserName: "operator"}},
+			})}
+		}
+		page := 0
+		if len(cp) > 0 {
+			page = int(cp[0])
+		}
+		var values []*ua.DataValue
+		for i := page * 2; i < page*2+2 && i < 5; i++ {
+			values = append(values, value(i))
+		}
+		var next []byte
+		if page < 2 {
+			next = []byte{byte(page + 1)}
+		}
+		return &ua.HistoryReadResult{ContinuationPoint: next, HistoryData: ua.NewExtensionObject(&ua.HistoryData{DataValues: values})}
+	}
+	s.Variable("ns=2;s=Plain", "Plain", 0.0)
+
+	data, err := client.HistoryReadRaw("ns=2;s=Tag", start, start.Add(time.Minute), 0, false)
+	if err != nil {
+		t.Fatalf("HistoryReadRaw() получена непредвиденная ошибка = %v", err)
+	}
+	if len(data.Values) != 5 || data.More || data.Values[4].Value.Value() != 4.0 {
+		t.Errorf("HistoryReadRaw() = %d значений (More %v), ожидалось 5", len(data.Values), data.More)
+	}
+
+	data, err = client.HistoryReadRaw("ns=2;s=Tag", start, start.Add(time.Minute), 3, false)
+	if err != nil {
+		t.Fatalf("HistoryReadRaw(max 3) получена непредвиденная ошибка = %v", err)
+	}
+	if len(data.Values) != 3 || !data.More {
+		t.Errorf("HistoryReadRaw(max 3) = %d значений (More %v), ожидалось 3 и More", len(data.Values), data.More)
+	}
+	reqs := s.Requests()
+	if last, ok := reqs[len(reqs)-1].(*ua.HistoryReadRequest); !ok || !last.ReleaseContinuationPoints {
+		t.Errorf("последний запрос %T, ожидалось освобождение точки продолжения", reqs[len(reqs)-1])
+	}
+
+	data, err = client.HistoryReadRaw("ns=2;s=Tag", start, start.Add(time.Minute), 0, true)
+	if err != nil {
+		t.Fatalf("HistoryReadRaw(modified) получена непредвиденная ошибка = %v", err)
+	}
+	if len(data.Modifications) != 1 || data.Modifications[0].UserName != "operator" {
+		t.Errorf("HistoryReadRaw(modified) изменения = %v, ожидался пользователь operator", data.Modifications)
+	}
+
+	if err := HistoryRaw("ns=2;s=Plain", HistoryOptions{Start: start}); err == nil || !strings.Contains(err.Error(), "history read failed") {
+		t.Errorf("HistoryRaw() = %v, ожидалась ошибка чтения архива", err)
+	}
+	if err := HistoryRaw("ns=2;s=Tag", HistoryOptions{Start: start, Format: "xml"}); err == nil || err.Error() != "unknown format: xml" {
+		t.Errorf("HistoryRaw() = %v, ожидалась ошибка формата", err)
+	}
+}
+
+// TestHistoryReadProcessed проверяет чтение агрегатов архива.
+//
+// Основные аспекты тестирования:
+// - Функции находятся по именам без учёта регистра и по сокращениям.
+// - Функции, не перечисленные сервером, берутся из стандартных Part 13.
+// - Каждая функция читается отдельным запросом с её NodeId.
+// - Неизвестное имя функции возвращает ошибку.
+func TestHistoryReadProcessed(t *testing.T) {
+	s := clienttest.Attach(t)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.Add("i=2997", &clienttest.Node{References: []*ua.ReferenceDescription{ref("i=2342", 0, "Average"), ref("i=2346", 0, "Minimum")}})
+	s.Variable("ns=2;s=Tag", "Tag", 0.0).History = func(details interface{}, cp []byte) *ua.HistoryReadResult {
+		d := details.(*ua.ReadProcessedDetails)
+		if d.ProcessingInterval != 300000 || len(d.AggregateType) != 1 {
+			return &ua.HistoryReadResult{StatusCode: ua.StatusBadInvalidArgument}
+		}
+		var values []*ua.DataValue
+		for i := 0; i < 2; i++ {
+			values = append(values, &ua.DataValue{
+				EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+				Value:           ua.MustVariant(float64(d.AggregateType[0].IntID() + uint32(i))),
+				SourceTimestamp: start.Add(time.Duration(i) * 5 * time.Minute),
+			})
+		}
+		return &ua.HistoryReadResult{HistoryData: ua.NewExtensionObject(&ua.HistoryData{DataValues: values})}
+	}
+
+	res, err := client.HistoryReadProcessed("ns=2;s=Tag", start, start.Add(10*time.Minute), 5*time.Minute, []string{"avg", "MINIMUM", "Count"})
+	if err != nil {
+		t.Fatalf("HistoryReadProcessed() получена непредвиденная ошибка = %v", err)
+	}
+	want := []struct {
+		name string
+		id   uint32
+	}{{"Average", 2342}, {"Minimum", 2346}, {"Count", 2352}}
+	if len(res) != len(want) {
+		t.Fatalf("HistoryReadProcessed() = %d функций, ожидалось %d", len(res), len(want))
+	}
+	for i, w := range want {
+		if res[i].Aggregate.Name != w.name || res[i].Aggregate.NodeID.IntID() != w.id || len(res[i].Values) != 2 ||
+			res[i].Values[1].Value.Value() != float64(w.id+1) {
+			t.Errorf("функция %d = %s (%v), %d значений, ожидалась %s (i=%d)", i, res[i].Aggregate.Name, res[i].Aggregate.NodeID, len(res[i].Values), w.name, w.id)
+		}
+	}
+
+	if err := HistoryAggregate("ns=2;s=Tag", []string{"Average", "Bogus"}, 5*time.Minute, HistoryOptions{Start: start}); err == nil || err.Error() != "unknown aggregate: Bogus" {
+		t.Errorf("HistoryAggregate() = %v, ожидалась ошибка неизвестной функции", err)
+	}
+
+	aggs, err := client.ReadAggregates()
+	if err != nil || len(aggs) != 2 || aggs[0].Name != "Average" {
+		t.Errorf("ReadAggregates() = %v, %v, ожидались Average и Minimum", aggs, err)
+	}
+}
+
+// TestHistoryEvents проверяет чтение архива событий.
+//
+// Основные аспекты тестирования:
+// - Фильтр запроса содержит выбранные поля в заданном порядке.
+// - Число событий ограничивается --max.
+// - Ошибка в условии отбора возвращается до обращения к серверу.
+func TestHistoryEvents(t *testing.T) {
+	s := clienttest.Attach(t)
+	var selected []string
+	s.Add("i=2253", &clienttest.Node{History: func(details interface{}, cp []byte) *ua.HistoryReadResult {
+		d := details.(*ua.ReadEventDetails)
+		selected = nil
+		for _, sel := range d.Filter.SelectClauses {
+			selected = append(selected, sel.BrowsePath[0].Name)
+		}
+		var events []*ua.HistoryEventFieldList
+		for i := 0; i < 3; i++ {
+			events = append(events, &ua.HistoryEventFieldList{EventFields: []*ua.Variant{ua.MustVariant(uint16(100 * i)), ua.MustVariant("Overheat")}})
+		}
+		return &ua.HistoryReadResult{HistoryData: ua.NewExtensionObject(&ua.HistoryEvent{Events: events})}
+	}})
+
+	filter, err := client.ParseEventFilter([]string{"Severity", "Message"}, "Severity > 0")
+	if err != nil {
+		t.Fatalf("ParseEventFilter() получена непредвиденная ошибка = %v", err)
+	}
+	data, err := client.HistoryReadEvents("i=2253", time.Now().Add(-time.Hour), time.Now(), 2, filter)
+	if err != nil {
+		t.Fatalf("HistoryReadEvents() получена непредвиденная ошибка = %v", err)
+	}
+	if len(data.Events) != 2 || !data.More || data.Events[1][0].Value() != uint16(100) {
+		t.Errorf("HistoryReadEvents() = %d событий (More %v), ожидалось 2 и More", len(data.Events), data.More)
+	}
+	if strings.Join(selected, ",") != "Severity,Message" {
+		t.Errorf("выбраны поля %v, ожидались Severity,Message", selected)
+	}
+
+	before := len(s.Requests())
+	if err := HistoryEvents("i=2253", nil, "Severity >", HistoryOptions{}); err == nil || err.Error() != "invalid filter: expected value after >" {
+		t.Errorf("HistoryEvents() = %v, ожидалась ошибка условия", err)
+	}
+	if len(s.Requests()) != before {
+		t.Error("запрос отправлен на сервер несмотря на ошибку условия")
+	}
+}
+
+// TestHistoryUpdate проверяет запись значений в архив и удаление из него.
+//
+// Основные аспекты тестирования:
+// - Значения из аргументов и CSV-файла приводятся к типу узла.
+// - Заголовок CSV определяет столбцы, подходит вывод history raw --format csv.
+// - Без подтверждения запрос не отправляется.
+// - Отклонённые сервером значения возвращают ошибку.
+func TestHistoryUpdate(t *testing.T) {
+	s := clienttest.Attach(t)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var updates []interface{}
+	s.Variable("ns=2;s=Tag", "Tag", 0.0).HistoryUpdate = func(details interface{}) *ua.HistoryUpdateResult {
+		updates = append(updates, details)
+		switch d := details.(type) {
+		case *ua.UpdateDataDetails:
+			results := make([]ua.StatusCode, len(d.UpdateValues))
+			for i, v := range d.UpdateValues {
+				results[i] = ua.StatusGoodEntryInserted
+				if v.SourceTimestamp.Equal(start) {
+					results[i] = ua.StatusBadEntryExists
+				}
+			}
+			return &ua.HistoryUpdateResult{OperationResults: results}
+		case *ua.DeleteAtTimeDetails:
+			return &ua.HistoryUpdateResult{OperationResults: make([]ua.StatusCode, len(d.ReqTimes))}
+		}
+		return &ua.HistoryUpdateResult{}
+	}
+	confirm := Confirm
+	t.Cleanup(func() { Confirm = confirm })
+	var prompts []string
+	answer := true
+	Confirm = func(prompt string) bool {
+		prompts = append(prompts, prompt)
+		return answer
+	}
+
+	file := filepath.Join(t.TempDir(), "values.csv")
+	csv := "SourceTimestamp,ServerTimestamp,Value,Status\n" +
+		"2024-05-01T10:01:00Z,2024-05-01T10:01:00Z,1.5,Good\n" +
+		"2024-05-01T10:02:00Z,,,BadSensorFailure\n"
+	if err := os.WriteFile(file, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	values := []HistoryValue{{Time: start.Add(3 * time.Minute), Value: "2.5"}}
+	if err := HistoryReplace("ns=2;s=Tag", values, HistoryUpdateOptions{File: file}); err != nil {
+		t.Fatalf("HistoryReplace() получена непредвиденная ошибка = %v", err)
+	}
+	d, ok := updates[0].(*ua.UpdateDataDetails)
+	if !ok || d.PerformInsertReplace != ua.PerformUpdateTypeReplace || len(d.UpdateValues) != 3 {
+		t.Fatalf("изменение архива = %#v, ожидалась замена 3 значений", updates[0])
+	}
+	if v := d.UpdateValues[0]; v.Value.Value() != 2.5 || !v.SourceTimestamp.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("значение из аргументов = %v %v", v.SourceTimestamp, v.Value)
+	}
+	if v := d.UpdateValues[1]; v.Value.Value() != 1.5 || v.Status != ua.StatusOK {
+		t.Errorf("значение из CSV = %v [%v], ожидалось 1.5", v.Value, v.Status)
+	}
+	if v := d.UpdateValues[2]; v.Value != nil || v.Status != ua.StatusBadSensorFailure {
+		t.Errorf("плохое значение из CSV = %v [%v], ожидалось без значения", v.Value, v.Status)
+	}
+	if len(prompts) != 1 || prompts[0] != "Replace 3 values in the archive of ns=2;s=Tag?" {
+		t.Errorf("запрос подтверждения = %q", prompts)
+	}
+
+	err := HistoryInsert("ns=2;s=Tag", []HistoryValue{{Time: start, Value: "1"}, {Time: start.Add(time.Minute), Value: "2"}}, HistoryUpdateOptions{Yes: true})
+	if err == nil || err.Error() != "failed to insert 1 of 2 values" {
+		t.Errorf("HistoryInsert() = %v, ожидалась ошибка для занятой метки времени", err)
+	}
+	if len(prompts) != 1 {
+		t.Errorf("с --yes запрошено подтверждение")
+	}
+
+	answer = false
+	if err := HistoryDeleteAt("ns=2;s=Tag", []time.Time{start}, HistoryUpdateOptions{}); err != nil {
+		t.Fatalf("HistoryDeleteAt() получена непредвиденная ошибка = %v", err)
+	}
+	if len(updates) != 2 {
+		t.Errorf("удаление выполнено без подтверждения")
+	}
+	answer = true
+	if err := HistoryDelete("ns=2;s=Tag", start, start.Add(time.Hour), HistoryUpdateOptions{}); err != nil {
+		t.Fatalf("HistoryDelete() получена непредвиденная ошибка = %v", err)
+	}
+	if d, ok := updates[2].(*ua.DeleteRawModifiedDetails); !ok || !d.StartTime.Equal(start) || !d.EndTime.Equal(start.Add(time.Hour)) {
+		t.Errorf("удаление за интервал = %#v", updates[2])
+	}
+
+	if err := HistoryInsert("ns=2;s=Tag", []HistoryValue{{Time: start, Value: "abc"}}, HistoryUpdateOptions{Yes: true}); err == nil || !strings.Contains(err.Error(), "invalid Double value") {
+		t.Errorf("HistoryInsert(abc) = %v, ожидалась ошибка разбора", err)
+	}
+	if err := os.WriteFile(file, []byte("Time,Quality\n2024-05-01T10:01:00Z,Good\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := HistoryInsert("ns=2;s=Tag", nil, HistoryUpdateOptions{File: file, Yes: true}); err == nil || !strings.Contains(err.Error(), "header must contain Timestamp and Value columns") {
+		t.Errorf("HistoryInsert() = %v, ожидалась ошибка заголовка", err)
+	}
+	if len(updates) != 3 {
+		t.Errorf("отправлено %d изменений архива, ожидалось 3", len(updates))
+	}
+}
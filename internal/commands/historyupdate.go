@@ -0,0 +1,268 @@
+package commands
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/audit"
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// HistoryValue - значение для записи в архив
+type HistoryValue struct {
+	Time time.Time
+	// Value - значение текстом; приводится к типу текущего значения узла
+	Value string
+	// Status - код статуса (Good, UncertainSubstituteValue, ...); пусто - Good
+	Status string
+}
+
+// HistoryUpdateOptions задаёт параметры изменения архива
+type HistoryUpdateOptions struct {
+	// File - CSV-файл со значениями, дополняющими значения из аргументов
+	File string
+	// Yes отключает запрос подтверждения
+	Yes bool
+}
+
+// HistoryInsert добавляет значения в архив. Значения с метками времени,
+// которые в архиве уже есть, сервер отклоняет.
+func HistoryInsert(nodeID string, values []HistoryValue, opts HistoryUpdateOptions) error {
+	return historyUpdateData(nodeID, values, false, opts)
+}
+
+// HistoryReplace заменяет значения архива с теми же метками времени
+func HistoryReplace(nodeID string, values []HistoryValue, opts HistoryUpdateOptions) error {
+	return historyUpdateData(nodeID, values, true, opts)
+}
+
+func historyUpdateData(nodeID string, values []HistoryValue, replace bool, opts HistoryUpdateOptions) error {
+	if err := checkWritable("history update"); err != nil {
+		return err
+	}
+	if opts.File != "" {
+		fromFile, err := readHistoryCSV(opts.File)
+		if err != nil {
+			return err
+		}
+		values = append(values, fromFile...)
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("no values")
+	}
+	dvs, err := historyDataValues(nodeID, values)
+	if err != nil {
+		return err
+	}
+
+	verb, done, prompt := "insert", "inserted", "Insert %d values into the archive of %s?"
+	if replace {
+		verb, done, prompt = "replace", "replaced", "Replace %d values in the archive of %s?"
+	}
+	if !confirmed(opts.Yes, fmt.Sprintf(prompt, len(values), nodeID)) {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	results, err := client.HistoryUpdateData(nodeID, dvs, replace)
+	entries := make([]audit.Entry, len(values))
+	for i, v := range values {
+		var code ua.StatusCode
+		if err == nil {
+			code = results[i]
+		}
+		entries[i] = audit.Entry{Operation: "history " + verb, Node: nodeID, At: &v.Time, New: auditValue(dvs[i]), Status: auditStatus(code, err)}
+	}
+	record(entries...)
+	if err != nil {
+		return err
+	}
+	times := make([]time.Time, len(values))
+	for i, v := range values {
+		times[i] = v.Time
+	}
+	return printUpdateResults(verb, done, times, results)
+}
+
+// HistoryDelete удаляет из архива значения за интервал [start, end)
+func HistoryDelete(nodeID string, start, end time.Time, opts HistoryUpdateOptions) error {
+	if err := checkWritable("history delete"); err != nil {
+		return err
+	}
+	prompt := fmt.Sprintf("Delete archived values of %s from %s to %s?", nodeID, timestampText(start), timestampText(end))
+	if !confirmed(opts.Yes, prompt) {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	err := client.HistoryDeleteRaw(nodeID, start, end)
+	record(audit.Entry{Operation: "history delete", Node: nodeID, From: &start, To: &end, Status: auditStatus(ua.StatusOK, err)})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Delete successful")
+	return nil
+}
+
+// HistoryDeleteAt удаляет из архива значения с заданными метками времени
+func HistoryDeleteAt(nodeID string, times []time.Time, opts HistoryUpdateOptions) error {
+	if err := checkWritable("history delete"); err != nil {
+		return err
+	}
+	if !confirmed(opts.Yes, fmt.Sprintf("Delete %d archived values of %s?", len(times), nodeID)) {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	results, err := client.HistoryDeleteAtTime(nodeID, times)
+	entries := make([]audit.Entry, len(times))
+	for i := range times {
+		var code ua.StatusCode
+		if err == nil {
+			code = results[i]
+		}
+		entries[i] = audit.Entry{Operation: "history delete", Node: nodeID, At: &times[i], Status: auditStatus(code, err)}
+	}
+	record(entries...)
+	if err != nil {
+		return err
+	}
+	return printUpdateResults("delete", "deleted", times, results)
+}
+
+// printUpdateResults выводит итог изменения архива. Отклонённые значения
+// выводятся таблицей, и команда возвращает ошибку.
+func printUpdateResults(verb, done string, times []time.Time, results []ua.StatusCode) error {
+	var rows [][]string
+	for i, code := range results {
+		if isBadStatus(code) {
+			rows = append(rows, []string{timestampText(times[i]), formatter.StatusName(code)})
+		}
+	}
+	if len(rows) > 0 {
+		if err := formatter.Table(os.Stdout, []string{"Timestamp", "Status"}, rows); err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to %s %d of %d values", verb, len(rows), len(results))
+	}
+	fmt.Printf("%d values %s\n", len(results), done)
+	return nil
+}
+
+func isBadStatus(code ua.StatusCode) bool {
+	return code&0x80000000 != 0
+}
+
+// historyDataValues приводит значения к типу текущего значения узла
+func historyDataValues(nodeID string, values []HistoryValue) ([]*ua.DataValue, error) {
+	current, err := client.Read(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Value == nil || current.Value.Type() == ua.TypeIDNull {
+		return nil, fmt.Errorf("cannot determine data type of %s", nodeID)
+	}
+	t := current.Value.Type()
+	enum := client.EnumType(nodeID)
+
+	out := make([]*ua.DataValue, len(values))
+	for i, v := range values {
+		dv := &ua.DataValue{EncodingMask: ua.DataValueSourceTimestamp, SourceTimestamp: v.Time}
+		if v.Status != "" {
+			code, err := formatter.Parse(ua.TypeIDStatusCode, v.Status)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", timestampText(v.Time), err)
+			}
+			dv.Status = code.(ua.StatusCode)
+			dv.EncodingMask |= ua.DataValueStatusCode
+		}
+		// Плохое значение может быть без значения: датчик не отвечал
+		if v.Value == "" && isBadStatus(dv.Status) {
+			out[i] = dv
+			continue
+		}
+		if dv.Value, err = textValue(t, enum, v.Value); err != nil {
+			return nil, fmt.Errorf("%s: %w", timestampText(v.Time), err)
+		}
+		dv.EncodingMask |= ua.DataValueValue
+		out[i] = dv
+	}
+	return out, nil
+}
+
+// timestampColumns - имена столбца метки времени в заголовке CSV;
+// SourceTimestamp выводит history raw --format csv
+var timestampColumns = []string{"SourceTimestamp", "Timestamp", "Time"}
+
+// readHistoryCSV читает значения из CSV-файла со столбцами метки времени,
+// значения и необязательного статуса. Если первая строка - заголовок,
+// столбцы находятся по именам, поэтому подходит и вывод history raw
+// --format csv.
+func readHistoryCSV(path string) ([]HistoryValue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	timeCol, valueCol, statusCol := 0, 1, 2
+	var values []HistoryValue
+	for first := true; ; first = false {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		line, _ := r.FieldPos(0)
+
+		if _, err := formatter.ParseTime(rec[0]); first && err != nil {
+			timeCol, valueCol, statusCol = -1, -1, -1
+			for i, name := range rec {
+				switch {
+				case containsFold(timestampColumns, name) && timeCol < 0:
+					timeCol = i
+				case strings.EqualFold(name, "Value"):
+					valueCol = i
+				case strings.EqualFold(name, "Status"):
+					statusCol = i
+				}
+			}
+			if timeCol < 0 || valueCol < 0 {
+				return nil, fmt.Errorf("%s: header must contain Timestamp and Value columns", path)
+			}
+			continue
+		}
+
+		if timeCol >= len(rec) || valueCol >= len(rec) {
+			return nil, fmt.Errorf("%s:%d: expected timestamp and value", path, line)
+		}
+		ts, err := formatter.ParseTime(rec[timeCol])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		v := HistoryValue{Time: ts, Value: rec[valueCol]}
+		if statusCol >= 0 && statusCol < len(rec) {
+			v.Status = rec[statusCol]
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, x := range list {
+		if strings.EqualFold(x, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// splitIndexRange отделяет диапазон индексов, заданный после узла в
+// квадратных скобках: ns=2;s=Arr[3], ns=2;s=Matrix[0:1,2]. Скобки, в
+// которых не диапазон, остаются частью NodeId. Строковый идентификатор
+// может и сам оканчиваться на [3], поэтому сначала проверяется узел с
+// NodeId целиком: диапазон отделяется, только если такого узла нет.
+func splitIndexRange(nodeID string) (string, string) {
+	i := strings.LastIndex(nodeID, "[")
+	if i <= 0 || !strings.HasSuffix(nodeID, "]") {
+		return nodeID, ""
+	}
+	rng := nodeID[i+1 : len(nodeID)-1]
+	if _, err := client.ParseIndexRange(rng); err != nil {
+		return nodeID, ""
+	}
+	if dv, err := client.ReadAttribute(nodeID, ua.AttributeIDNodeClass); err == nil && dv.Status != ua.StatusBadNodeIDUnknown {
+		return nodeID, ""
+	}
+	return nodeID[:i], rng
+}
+
+// nodeIndexRange возвращает узел и диапазон индексов, заданный в скобках
+// после узла или флагом --range
+func nodeIndexRange(nodeID, flag string) (string, string, error) {
+	node, rng := splitIndexRange(nodeID)
+	if flag == "" {
+		return node, rng, nil
+	}
+	if rng != "" {
+		return "", "", fmt.Errorf("index range given twice: [%s] and --range %s", rng, flag)
+	}
+	if _, err := client.ParseIndexRange(flag); err != nil {
+		return "", "", err
+	}
+	return node, flag, nil
+}
+
+func isArrayValue(v *ua.Variant) bool {
+	return v != nil && v.Has(ua.VariantArrayValues)
+}
+
+// arrayValue разбирает массив [v1, v2, ...] с элементами типа t. При
+// записи диапазона одно значение без скобок записывается массивом из
+// одного элемента в каждом измерении диапазона.
+func arrayValue(nodeID string, t ua.TypeID, enum *datatype.Definition, value, indexRange string) (*ua.Variant, error) {
+	text := strings.TrimSpace(value)
+	if !strings.HasPrefix(text, "[") {
+		if indexRange == "" {
+			return nil, fmt.Errorf("value of %s is an array, use [v1, v2, ...] or an index range", nodeID)
+		}
+		dims, err := client.ParseIndexRange(indexRange)
+		if err != nil {
+			return nil, err
+		}
+		text = strings.Repeat("[", len(dims)) + strconv.Quote(value) + strings.Repeat("]", len(dims))
+	}
+
+	a, err := formatter.ParseArray(t, text, func(s string) (interface{}, error) {
+		v, err := textValue(t, enum, s)
+		if err != nil {
+			return nil, err
+		}
+		return v.Value(), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid array value: %w", err)
+	}
+	return ua.NewVariant(a)
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// Info выводит атрибуты узла, применимые к его классу, и его свойства
+func Info(nodeID string) error {
+	if nodeID == "" {
+		return fmt.Errorf("node ID cannot be empty")
+	}
+
+	info, err := client.GetNodeInfo(nodeID)
+	if err != nil {
+		return err
+	}
+
+	const width = 25
+	for _, a := range info.Attributes {
+		fmt.Printf("%-*s %s\n", width, formatter.AttributeName(a.ID)+":", attributeText(a, info))
+	}
+	if len(info.Properties) > 0 {
+		fmt.Println("Properties:")
+		for _, p := range info.Properties {
+			fmt.Printf("  %-*s %s\n", width-2, p.Name+":", formatter.DataValue(p.Value))
+		}
+	}
+	return nil
+}
+
+// attributeText форматирует значение атрибута: флаги раскладываются на имена,
+// тип данных выводится по имени
+func attributeText(a client.AttributeValue, info *client.NodeInfo) string {
+	dv := a.Value
+	if dv.Status != ua.StatusOK || dv.Value == nil {
+		return formatter.DataValue(dv)
+	}
+
+	v := dv.Value
+	switch a.ID {
+	case ua.AttributeIDNodeClass:
+		return formatter.NodeClassName(ua.NodeClass(v.Int()))
+	case ua.AttributeIDDataType:
+		if n := v.NodeID(); n != nil {
+			return dataTypeName(n)
+		}
+	case ua.AttributeIDValueRank:
+		return valueRankText(int32(v.Int()))
+	case ua.AttributeIDAccessLevel, ua.AttributeIDUserAccessLevel:
+		return formatter.AccessLevel(uint8(v.Uint()))
+	case ua.AttributeIDEventNotifier:
+		return formatter.EventNotifier(uint8(v.Uint()))
+	case ua.AttributeIDWriteMask, ua.AttributeIDUserWriteMask:
+		return formatter.WriteMask(uint32(v.Uint()))
+	case ua.AttributeIDMinimumSamplingInterval:
+		return fmt.Sprintf("%v ms", v.Value())
+	case ua.AttributeIDValue:
+		// Значение форматируется так же, как в read; многострочные значения
+		// (структуры, матрицы) выводятся с новой строки
+		s := formatter.PrettyDataValue(dv, info.Enum)
+		if strings.Contains(s, "\n") {
+			return "\n  " + strings.ReplaceAll(s, "\n", "\n  ")
+		}
+		return s
+	}
+	return formatter.Value(v)
+}
+
+// dataTypeName возвращает имя типа данных вместе с его NodeId: "Double (i=11)"
+func dataTypeName(n *ua.NodeID) string {
+	name := typeName(n)
+	if name == n.String() {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, n)
+}
+
+// typeName возвращает имя типа данных или его NodeId, если имя неизвестно
+func typeName(n *ua.NodeID) string {
+	def, err := client.Types().DataType(n)
+	if err != nil || def.Name == "" {
+		return n.String()
+	}
+	return def.Name
+}
+
+// valueRankText расшифровывает ValueRank (Part 3, 5.6.2)
+func valueRankText(r int32) string {
+	switch r {
+	case -3:
+		return "-3 (ScalarOrOneDimension)"
+	case -2:
+		return "-2 (Any)"
+	case -1:
+		return "-1 (Scalar)"
+	case 0:
+		return "0 (OneOrMoreDimensions)"
+	case 1:
+		return "1 (OneDimension)"
+	}
+	return fmt.Sprintf("%d (%d dimensions)", r, r)
+}
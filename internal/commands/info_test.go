@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestInfoValue проверяет, что info выводит значение так же, как read.
+//
+// Основные аспекты тестирования:
+// - Матрица выводится по строке на строку, как в read.
+// - Значение структуры неизвестного типа перечитывается после регистрации кодировки.
+func TestInfoValue(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("ns=2;s=Matrix", "Matrix", [][]int32{{1, 2}, {3, 4}})
+
+	encoding := ua.NewNumericNodeID(2, 3902)
+	s.Variable("ns=2;s=Motor", "Motor", ua.NewExtensionObject(nil)).Attributes[ua.AttributeIDValue] = &ua.DataValue{
+		EncodingMask: ua.DataValueValue,
+		Value:        ua.MustVariant(&ua.ExtensionObject{EncodingMask: ua.ExtensionObjectBinary, TypeID: ua.NewExpandedNodeID(encoding, "", 0)}),
+	}
+	s.Add(encoding.String(), &clienttest.Node{References: []*ua.ReferenceDescription{{
+		ReferenceTypeID: ua.NewNumericNodeID(0, 38),
+		NodeID:          ua.NewExpandedNodeID(ua.NewNumericNodeID(2, 3901), "", 0),
+	}}})
+	s.Add("ns=2;i=3901", &clienttest.Node{Attributes: map[ua.AttributeID]*ua.DataValue{
+		ua.AttributeIDBrowseName: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(&ua.QualifiedName{NamespaceIndex: 2, Name: "Motor"})},
+		ua.AttributeIDDataTypeDefinition: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(ua.NewExtensionObject(&ua.StructureDefinition{
+			DefaultEncodingID: encoding,
+			Fields:            []*ua.StructureField{{Name: "Speed", DataType: ua.NewNumericNodeID(0, 11), ValueRank: -1}},
+		}))},
+	}})
+
+	info, err := client.GetNodeInfo("ns=2;s=Matrix")
+	if err != nil {
+		t.Fatalf("GetNodeInfo() получена непредвиденная ошибка = %v", err)
+	}
+	dv, err := client.ReadRange("ns=2;s=Matrix", "")
+	if err != nil {
+		t.Fatalf("ReadRange() получена непредвиденная ошибка = %v", err)
+	}
+	for _, a := range info.Attributes {
+		if a.ID != ua.AttributeIDValue {
+			continue
+		}
+		want := "\n  " + strings.ReplaceAll(formatter.PrettyDataValue(dv, nil), "\n", "\n  ")
+		if got := attributeText(a, info); got != want {
+			t.Errorf("info Value = %q, ожидалось как в read %q", got, want)
+		}
+	}
+
+	before := valueReads(s)
+	if _, err := client.GetNodeInfo("ns=2;s=Motor"); err != nil {
+		t.Fatalf("GetNodeInfo() получена непредвиденная ошибка = %v", err)
+	}
+	if n := valueReads(s) - before; n != 2 {
+		t.Errorf("значение структуры прочитано %d раз, ожидалось 2", n)
+	}
+}
+
+// valueReads возвращает число запросов Read с атрибутом Value
+func valueReads(s *clienttest.Session) int {
+	n := 0
+	for _, r := range s.Requests() {
+		req, ok := r.(*ua.ReadRequest)
+		if !ok {
+			continue
+		}
+		for _, item := range req.NodesToRead {
+			if item.AttributeID == ua.AttributeIDValue && item.NodeID.String() == "ns=2;s=Motor" {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
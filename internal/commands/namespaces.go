@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/alexfrick92/opcli/internal/client"
+)
+
+// Namespaces выводит таблицу пространств имён сервера (NamespaceArray)
+// с индексами. Таблица читается заново, поэтому после перезапуска сервера
+// показывает текущие индексы.
+func Namespaces() error {
+	uris, err := client.ReadNamespaces()
+	if err != nil {
+		return err
+	}
+	printNamespaces(uris)
+	return nil
+}
+
+// printNamespaces выводит индексы и URI пространств имён
+func printNamespaces(uris []string) {
+	for i, uri := range uris {
+		fmt.Printf("  %-3d %s\n", i, uri)
+	}
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestNamespaceURINodeID проверяет NodeId вида nsu=<URI>;... в командах.
+//
+// Основные аспекты тестирования:
+// - URI заменяется текущим индексом пространства имён.
+// - Неизвестный URI возвращает ошибку.
+// - После изменения NamespaceArray (перезапуск сервера) используется новый индекс.
+func TestNamespaceURINodeID(t *testing.T) {
+	s := clienttest.Attach(t)
+	ns := s.Variable("i=2255", "NamespaceArray", []string{"http://opcfoundation.org/UA/", "urn:other", "http://vendor.com/UA/"})
+	s.Variable("ns=2;s=Tag", "Tag", 1.5)
+	s.Variable("ns=1;s=Tag", "Tag", int32(7))
+
+	if err := Write("nsu=http://vendor.com/UA/;s=Tag", "2.5", WriteOptions{}); err != nil {
+		t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+	}
+	if w := s.Writes(); len(w) != 1 || w[0].NodeID.String() != "ns=2;s=Tag" {
+		t.Errorf("записан узел %v, ожидался ns=2;s=Tag", w)
+	}
+
+	if err := Read("nsu=urn:unknown;s=Tag", ReadOptions{}); err == nil || !strings.Contains(err.Error(), "unknown namespace URI: urn:unknown") {
+		t.Errorf("Read() = %v, ожидалась ошибка неизвестного URI", err)
+	}
+
+	// Сервер перезапущен и зарегистрировал пространства в другом порядке
+	ns.Attributes[ua.AttributeIDValue] = &ua.DataValue{
+		EncodingMask: ua.DataValueValue,
+		Value:        ua.MustVariant([]string{"http://opcfoundation.org/UA/", "http://vendor.com/UA/", "urn:other"}),
+	}
+	uris, err := client.ReadNamespaces()
+	if err != nil || len(uris) != 3 {
+		t.Fatalf("ReadNamespaces() = %v, %v", uris, err)
+	}
+	n, err := client.ParseNodeID("nsu=http://vendor.com/UA/;s=Tag")
+	if err != nil || n.String() != "ns=1;s=Tag" {
+		t.Errorf("ParseNodeID() после перезапуска = %v, %v, ожидалось ns=1;s=Tag", n, err)
+	}
+}
+
+// TestNamespaceURIValues проверяет NodeId вида nsu=<URI>;... в значениях.
+//
+// Основные аспекты тестирования:
+// - Значение типа NodeId и атрибут DataType разбираются через таблицу сервера.
+// - Тип ссылки tree --ref задаётся в форме nsu= без отдельной проверки.
+// - Литерал NodeId в условии --where приводится к индексу пространства имён.
+func TestNamespaceURIValues(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("i=2255", "NamespaceArray", []string{"http://opcfoundation.org/UA/", "urn:other", "http://vendor.com/UA/"})
+	s.Variable("ns=2;s=Ref", "Ref", ua.NewNumericNodeID(0, 1))
+
+	if err := Write("ns=2;s=Ref", "nsu=http://vendor.com/UA/;i=3001", WriteOptions{}); err != nil {
+		t.Fatalf("Write() NodeId получена непредвиденная ошибка = %v", err)
+	}
+	if err := Write("ns=2;s=Ref", "nsu=http://vendor.com/UA/;i=3002", WriteOptions{Attribute: "DataType"}); err != nil {
+		t.Fatalf("Write() DataType получена непредвиденная ошибка = %v", err)
+	}
+	want := []string{"ns=2;i=3001", "ns=2;i=3002"}
+	writes := s.Writes()
+	if len(writes) != len(want) {
+		t.Fatalf("записано %d значений, ожидалось %d", len(writes), len(want))
+	}
+	for i, w := range writes {
+		if n, ok := w.Value.Value.Value().(*ua.NodeID); !ok || n.String() != want[i] {
+			t.Errorf("запись %d = %v, ожидалось %s", i, w.Value.Value.Value(), want[i])
+		}
+	}
+
+	wo, err := walkOptions(1, "", "nsu=urn:other;i=4001", 0)
+	if err != nil || wo.ReferenceType.String() != "ns=1;i=4001" {
+		t.Errorf("walkOptions() тип ссылки = %v, %v, ожидалось ns=1;i=4001", wo.ReferenceType, err)
+	}
+
+	f, err := client.ParseEventFilter(nil, "SourceNode == nsu=http://vendor.com/UA/;s=Tank")
+	if err != nil {
+		t.Fatalf("ParseEventFilter() получена непредвиденная ошибка = %v", err)
+	}
+	var n *ua.NodeID
+	if lit, ok := f.WhereClause.Elements[0].FilterOperands[1].Value.(*ua.LiteralOperand); ok {
+		n, _ = lit.Value.Value().(*ua.NodeID)
+	}
+	if n == nil || n.String() != "ns=2;s=Tank" {
+		t.Errorf("литерал условия = %v, ожидалось ns=2;s=Tank", n)
+	}
+}
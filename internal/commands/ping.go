@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client"
+)
+
+const (
+	// DefaultPingCount - число запросов ping по умолчанию
+	DefaultPingCount = 4
+	// DefaultPingTimeout - время ожидания ответа на один запрос
+	DefaultPingTimeout = 5 * time.Second
+)
+
+// PingOptions задаёт параметры команды ping
+type PingOptions struct {
+	// Count - число запросов
+	Count int
+	// Interval - пауза между запросами
+	Interval time.Duration
+	// Timeout - время ожидания ответа на один запрос
+	Timeout time.Duration
+	// Hello проверяет только обмен Hello/Acknowledge без создания сессии
+	Hello bool
+}
+
+// pingStats - статистика ответов
+type pingStats struct {
+	sent     int
+	received int
+	min      time.Duration
+	avg      time.Duration
+	max      time.Duration
+	stddev   time.Duration
+}
+
+// Ping измеряет время ответа сервера. Каждый запрос - чтение
+// ServerStatus.CurrentTime в сессии или, с Hello, новое TCP-соединение
+// с обменом Hello/Acknowledge. Без endpoint используется текущее соединение.
+func Ping(endpoint string, opts PingOptions) error {
+	if opts.Count <= 0 {
+		opts.Count = DefaultPingCount
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultPingTimeout
+	}
+
+	s := client.GetSession()
+	if endpoint == "" {
+		if s == nil {
+			return fmt.Errorf("not connected to server")
+		}
+		endpoint = s.Info().Endpoint
+	}
+
+	var probe func(ctx context.Context) error
+	method := "Hello/Acknowledge"
+	switch {
+	case opts.Hello:
+		probe = func(ctx context.Context) error { return client.Hello(ctx, endpoint) }
+	default:
+		method = "Read i=2258"
+		if s == nil || s.Info().Endpoint != endpoint {
+			// Для другого сервера открывается отдельная сессия,
+			// текущее соединение не затрагивается
+			ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+			start := time.Now()
+			var err error
+			s, err = client.Dial(ctx, endpoint)
+			cancel()
+			if err != nil {
+				return err
+			}
+			defer s.Close(context.Background())
+			fmt.Printf("Session opened in %s ms\n", ms(time.Since(start)))
+		}
+		probe = func(ctx context.Context) error { return client.Ping(ctx, s) }
+	}
+
+	fmt.Printf("PING %s (%s)\n", endpoint, method)
+	rtts := make([]time.Duration, 0, opts.Count)
+	for seq := 1; seq <= opts.Count; seq++ {
+		if seq > 1 && opts.Interval > 0 {
+			time.Sleep(opts.Interval)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+		start := time.Now()
+		err := probe(ctx)
+		rtt := time.Since(start)
+		cancel()
+
+		if err != nil {
+			fmt.Printf("seq=%d error: %v\n", seq, err)
+			continue
+		}
+		rtts = append(rtts, rtt)
+		fmt.Printf("seq=%d time=%s ms\n", seq, ms(rtt))
+	}
+
+	st := newPingStats(opts.Count, rtts)
+	fmt.Printf("--- %s ping statistics ---\n", endpoint)
+	fmt.Printf("%d requests, %d replies, %.0f%% loss\n", st.sent, st.received, st.loss())
+	if st.received == 0 {
+		return fmt.Errorf("no replies from %s", endpoint)
+	}
+	fmt.Printf("rtt min/avg/max/stddev = %s/%s/%s/%s ms\n", ms(st.min), ms(st.avg), ms(st.max), ms(st.stddev))
+	return nil
+}
+
+// newPingStats вычисляет статистику по временам полученных ответов
+func newPingStats(sent int, rtts []time.Duration) pingStats {
+	st := pingStats{sent: sent, received: len(rtts)}
+	if len(rtts) == 0 {
+		return st
+	}
+
+	st.min, st.max = rtts[0], rtts[0]
+	var sum float64
+	for _, r := range rtts {
+		st.min = min(st.min, r)
+		st.max = max(st.max, r)
+		sum += float64(r)
+	}
+	mean := sum / float64(len(rtts))
+
+	var dev float64
+	for _, r := range rtts {
+		dev += (float64(r) - mean) * (float64(r) - mean)
+	}
+	st.avg = time.Duration(mean)
+	st.stddev = time.Duration(math.Sqrt(dev / float64(len(rtts))))
+	return st
+}
+
+// loss возвращает долю потерянных запросов в процентах
+func (st pingStats) loss() float64 {
+	if st.sent == 0 {
+		return 0
+	}
+	return float64(st.sent-st.received) * 100 / float64(st.sent)
+}
+
+// ms форматирует длительность в миллисекундах с точностью до сотых
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.2f", float64(d)/float64(time.Millisecond))
+}
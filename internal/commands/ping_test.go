@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestPingStats проверяет статистику ответов ping.
+func TestPingStats(t *testing.T) {
+	ms := time.Millisecond
+	st := newPingStats(4, []time.Duration{2 * ms, 4 * ms, 6 * ms})
+	want := pingStats{sent: 4, received: 3, min: 2 * ms, avg: 4 * ms, max: 6 * ms, stddev: 1632993 * time.Nanosecond}
+	if st != want {
+		t.Errorf("newPingStats() = %+v, ожидалось %+v", st, want)
+	}
+	if st.loss() != 25 {
+		t.Errorf("loss() = %v, ожидалось 25", st.loss())
+	}
+
+	if st := newPingStats(3, nil); st.received != 0 || st.loss() != 100 {
+		t.Errorf("newPingStats() без ответов = %+v, потери %v%%", st, st.loss())
+	}
+}
+
+// TestPing проверяет ping текущего соединения и через Hello/Acknowledge.
+//
+// Основные аспекты тестирования:
+// - Каждый запрос - чтение ServerStatus.CurrentTime (i=2258).
+// - Ошибка, если ответов нет или нет соединения.
+// - Отдельная сессия и обмен Hello/Acknowledge с сервером имитации.
+func TestPing(t *testing.T) {
+	if err := Ping("", PingOptions{Count: 1}); err == nil || err.Error() != "not connected to server" {
+		t.Errorf("Ping() без соединения = %v, ожидалась ошибка", err)
+	}
+
+	s := clienttest.Attach(t)
+	s.Variable("i=2258", "CurrentTime", time.Now())
+	if err := Ping("", PingOptions{Count: 3}); err != nil {
+		t.Fatalf("Ping() получена непредвиденная ошибка = %v", err)
+	}
+	reqs := s.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("отправлено %d запросов, ожидалось 3", len(reqs))
+	}
+	r, ok := reqs[0].(*ua.ReadRequest)
+	if !ok || r.NodesToRead[0].NodeID.String() != "i=2258" {
+		t.Errorf("запрос ping = %+v, ожидалось чтение i=2258", reqs[0])
+	}
+
+	s.Err = fmt.Errorf("timeout")
+	if err := Ping("", PingOptions{Count: 2}); err == nil || err.Error() != "no replies from opc.tcp://clienttest" {
+		t.Errorf("Ping() без ответов = %v, ожидалась ошибка", err)
+	}
+
+	srv := startServer(t)
+	if err := Ping(srv.Endpoint(), PingOptions{Count: 2}); err != nil {
+		t.Errorf("Ping() в отдельной сессии получена непредвиденная ошибка = %v", err)
+	}
+	if err := Ping(srv.Endpoint(), PingOptions{Count: 2, Hello: true}); err != nil {
+		t.Errorf("Ping() Hello получена непредвиденная ошибка = %v", err)
+	}
+	if err := Ping(closedEndpoint(t), PingOptions{Count: 1, Hello: true, Timeout: time.Second}); err == nil {
+		t.Errorf("Ping() недоступного сервера должен вернуть ошибку")
+	}
+}
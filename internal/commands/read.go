@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/formatter"
+)
+
+// ReadOptions задаёт параметры вывода команды read
+type ReadOptions struct {
+	// Format - формат вывода: text или json, для списка узлов также csv
+	Format string
+	// Reversible включает обратимую JSON-форму (Part 6), пригодную для write
+	Reversible bool
+	// Parallel - число одновременных запросов при чтении списка узлов
+	Parallel int
+	// Output - файл для результатов чтения списка узлов
+	Output string
+	// Range - диапазон индексов массива (NumericRange): 2:5, 1:2,0:3
+	Range string
+}
+
+// Read читает значение узла и выводит его в выбранном формате. Диапазон
+// элементов массива задаётся opts.Range или в скобках после узла:
+// ns=2;s=Arr[2:5]. Многомерные массивы выводятся текстом по строке матрицы
+// на строку.
+func Read(nodeID string, opts ReadOptions) error {
+	if nodeID == "" {
+		return fmt.Errorf("node ID cannot be empty")
+	}
+
+	nodeID, indexRange, err := nodeIndexRange(nodeID, opts.Range)
+	if err != nil {
+		return err
+	}
+	dv, err := client.ReadRange(nodeID, indexRange)
+	if err != nil {
+		return err
+	}
+
+	enum := client.EnumType(nodeID)
+
+	switch opts.Format {
+	case "", "text":
+		fmt.Println(formatter.PrettyDataValue(dv, enum))
+	case "json":
+		enc := &formatter.JSONEncoder{Reversible: opts.Reversible, Namespaces: client.Namespaces(), Enum: enum}
+		b, err := enc.MarshalDataValue(dv)
+		if err != nil {
+			return fmt.Errorf("failed to encode value: %w", err)
+		}
+		fmt.Println(string(b))
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+	return nil
+}
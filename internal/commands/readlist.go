@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/formatter"
+)
+
+// nodeColumns - имена столбца узлов в заголовке CSV-файла списка
+var nodeColumns = []string{"NodeId", "Node", "Tag", "Path"}
+
+// ReadList читает значения узлов из файла списка: текстового, по узлу
+// на строку, или CSV со столбцом узлов. Узлы читаются пакетами по
+// ограничению MaxNodesPerRead сервера. Перечисления выводятся числами:
+// поиск типа каждого узла удвоил бы число запросов.
+func ReadList(file string, opts ReadOptions) error {
+	nodeIDs, err := readNodeList(file)
+	if err != nil {
+		return err
+	}
+	if len(nodeIDs) == 0 {
+		return fmt.Errorf("%s: no nodes", file)
+	}
+	switch opts.Format {
+	case "", "text", "csv", "json":
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+
+	results, err := client.ReadMany(nodeIDs, opts.Parallel)
+	if err != nil {
+		return err
+	}
+
+	if opts.Output == "" {
+		return printReadResults(os.Stdout, results, opts)
+	}
+	f, err := os.Create(opts.Output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", opts.Output, err)
+	}
+	if err := printReadResults(f, results, opts); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", opts.Output, err)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil || isBadStatus(r.Value.Status) {
+			failed++
+		}
+	}
+	fmt.Printf("Read %d nodes to %s", len(results), opts.Output)
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+	return nil
+}
+
+// printReadResults выводит результаты таблицей, CSV или массивом JSON.
+// Ошибка поиска узла выводится вместо статуса.
+func printReadResults(w io.Writer, results []client.ReadResult, opts ReadOptions) error {
+	if opts.Format == "json" {
+		enc := &formatter.JSONEncoder{Reversible: opts.Reversible, Namespaces: client.Namespaces()}
+		list := make([]formatter.Object, len(results))
+		for i, r := range results {
+			o := formatter.Object{{Key: "NodeId", Value: r.NodeID}}
+			if r.Err != nil {
+				o = append(o, formatter.Field{Key: "Error", Value: r.Err.Error()})
+			} else if dv, ok := enc.DataValue(r.Value).(formatter.Object); ok {
+				o = append(o, dv...)
+			}
+			list[i] = o
+		}
+		b, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode values: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+
+	header := []string{"NodeId", "Value", "Status", "SourceTimestamp"}
+	if opts.Format == "csv" {
+		header = append(header, "ServerTimestamp")
+	}
+	rows := make([][]string, len(results))
+	for i, r := range results {
+		row := []string{r.NodeID, "", "", "", ""}
+		if r.Err != nil {
+			row[2] = r.Err.Error()
+		} else {
+			row[1] = formatter.Value(r.Value.Value)
+			row[2] = formatter.StatusName(r.Value.Status)
+			row[3] = timestampText(r.Value.SourceTimestamp)
+			row[4] = timestampText(r.Value.ServerTimestamp)
+		}
+		rows[i] = row[:len(header)]
+	}
+	if opts.Format == "csv" {
+		return formatter.CSV(w, header, rows)
+	}
+	return formatter.Table(w, header, rows)
+}
+
+// readNodeList читает список узлов. В текстовом файле пустые строки и
+// строки с # пропускаются. В CSV-файле узлы берутся из столбца с именем
+// из nodeColumns, а без такого заголовка - из первого столбца.
+func readNodeList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		var list []string
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			list = append(list, line)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return list, nil
+	}
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	col := 0
+	var list []string
+	for first := true; ; first = false {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if first {
+			if i := columnIndex(rec, nodeColumns); i >= 0 {
+				col = i
+				continue
+			}
+		}
+		if col < len(rec) && strings.TrimSpace(rec[col]) != "" {
+			list = append(list, strings.TrimSpace(rec[col]))
+		}
+	}
+	return list, nil
+}
+
+// columnIndex возвращает индекс первого столбца заголовка с одним из имён
+func columnIndex(header, names []string) int {
+	for i, name := range header {
+		if containsFold(names, strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
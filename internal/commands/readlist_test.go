@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestReadList проверяет чтение значений узлов из файла списка.
+//
+// Основные аспекты тестирования:
+// - Узлы берутся из столбца Tag CSV-файла, пути просмотра разрешаются.
+// - Чтение разбивается на пакеты не больше MaxNodesPerRead сервера.
+// - Ошибки отдельных узлов выводятся в столбце Status, не прерывая чтение.
+// - В текстовом списке пропускаются пустые строки и комментарии.
+func TestReadList(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("i=11705", "MaxNodesPerRead", uint32(3))
+	s.Add("i=84", &clienttest.Node{References: []*ua.ReferenceDescription{ref("i=85", 0, "Objects")}})
+	s.Add("i=85", &clienttest.Node{References: []*ua.ReferenceDescription{ref("ns=2;s=Boiler", 2, "Boiler")}})
+	s.Add("ns=2;s=Boiler", &clienttest.Node{References: []*ua.ReferenceDescription{ref("ns=2;s=Boiler.Level", 2, "Level")}})
+	s.Variable("ns=2;s=Boiler.Level", "Level", 0.75)
+
+	csvLines := []string{"Description,Tag"}
+	for i := 0; i < 7; i++ {
+		tag := fmt.Sprintf("ns=2;s=Tag%d", i)
+		s.Variable(tag, fmt.Sprintf("Tag%d", i), int32(i))
+		csvLines = append(csvLines, fmt.Sprintf("tag %d,%s", i, tag))
+	}
+	csvLines = append(csvLines, "level,/Objects/2:Boiler/2:Level", "pump,/Objects/2:Pump", "bad,ns=x;i=1")
+
+	dir := t.TempDir()
+	tags := filepath.Join(dir, "tags.csv")
+	if err := os.WriteFile(tags, []byte(strings.Join(csvLines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "snapshot.csv")
+	if err := ReadList(tags, ReadOptions{Format: "csv", Output: out}); err != nil {
+		t.Fatalf("ReadList() получена непредвиденная ошибка = %v", err)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 11 || lines[0] != "NodeId,Value,Status,SourceTimestamp,ServerTimestamp" {
+		t.Fatalf("вывод = %q, ожидались заголовок и 10 строк", b)
+	}
+	for i, want := range []string{"ns=2;s=Tag0,0,Good,", "ns=2;s=Tag6,6,Good,", "/Objects/2:Boiler/2:Level,0.75,Good,", "/Objects/2:Pump,,browse path not found", "ns=x;i=1,,invalid node ID"} {
+		if row := lines[[]int{1, 7, 8, 9, 10}[i]]; !strings.HasPrefix(row, want) {
+			t.Errorf("строка = %q, ожидалось начало %q", row, want)
+		}
+	}
+
+	var batches []int
+	for _, r := range s.Requests() {
+		if req, ok := r.(*ua.ReadRequest); ok {
+			batches = append(batches, len(req.NodesToRead))
+		}
+	}
+	// Первый запрос читает ограничения сервера, остальные - 8 узлов пакетами по 3
+	total := 0
+	for _, n := range batches[1:] {
+		if n > 3 {
+			t.Errorf("пакет из %d узлов больше MaxNodesPerRead", n)
+		}
+		total += n
+	}
+	if len(batches) != 4 || total != 8 {
+		t.Errorf("пакеты чтения = %v, ожидались ограничения и 8 узлов в 3 пакетах", batches)
+	}
+
+	txt := filepath.Join(dir, "tags.txt")
+	if err := os.WriteFile(txt, []byte("# boiler\n\nns=2;s=Tag1\n  ns=2;s=Tag2  \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := readNodeList(txt)
+	if err != nil || strings.Join(list, " ") != "ns=2;s=Tag1 ns=2;s=Tag2" {
+		t.Errorf("readNodeList() = %q, %v, ожидались Tag1 и Tag2", list, err)
+	}
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/simulator"
+)
+
+// DefaultServePort - порт сервера имитации, если он не задан ни параметром,
+// ни в файле конфигурации
+const DefaultServePort = 4840
+
+// ServeOptions задаёт параметры команды serve
+type ServeOptions struct {
+	// Config - JSON-файл конфигурации имитатора
+	Config string
+	// Host и Port - адрес, на котором сервер принимает подключения
+	Host string
+	Port int
+	// NodeSet - файл UANodeSet, загружаемый на сервер
+	NodeSet string
+	// Interval - период обновления значений
+	Interval time.Duration
+}
+
+// Serve запускает сервер имитации и работает до прерывания (Ctrl+C).
+// Параметры командной строки дополняют и переопределяют файл конфигурации.
+func Serve(opts ServeOptions) error {
+	cfg := &simulator.Config{}
+	if opts.Config != "" {
+		var err error
+		if cfg, err = simulator.LoadConfig(opts.Config); err != nil {
+			return err
+		}
+	}
+	if opts.Host != "" {
+		cfg.Host = opts.Host
+	}
+	if opts.Port != 0 {
+		cfg.Port = opts.Port
+	}
+	if cfg.Port == 0 {
+		cfg.Port = DefaultServePort
+	}
+	if opts.NodeSet != "" {
+		cfg.NodeSet = opts.NodeSet
+	}
+	if opts.Interval > 0 {
+		cfg.Interval = simulator.Duration(opts.Interval)
+	}
+
+	s, err := simulator.Start(*cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Simulation server listening on %s\n", s.Endpoint())
+	for _, line := range s.Summary() {
+		fmt.Println("  " + line)
+	}
+	fmt.Println("Press Ctrl+C to stop")
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	<-stop
+
+	fmt.Println("Stopping server...")
+	return s.Close()
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// ServerStatus выводит состояние сервера, сведения о сборке, возможности,
+// ограничения операций и таблицу пространств имён
+func ServerStatus() error {
+	st, err := client.GetServerStatus()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("=== Server Status ===")
+	printStatusValues(st.Status, st)
+
+	fmt.Println("=== Server Capabilities ===")
+	printStatusValues(st.Capabilities, st)
+
+	fmt.Println("=== Operation Limits ===")
+	printStatusValues(st.OperationLimits, st)
+
+	fmt.Println("=== Namespaces ===")
+	printNamespaces(st.Namespaces)
+	return nil
+}
+
+// printStatusValues выводит значения с выравниванием по самому длинному имени.
+// Необязательные переменные, которых нет на сервере, пропускаются: в зависимости
+// от сервера их чтение возвращает BadNodeIdUnknown или BadAttributeIdInvalid.
+func printStatusValues(values []client.StatusValue, st *client.ServerStatus) {
+	width := 0
+	for _, v := range values {
+		width = max(width, len(v.Name)+1)
+	}
+	for _, v := range values {
+		if code := v.Value.Status; code == ua.StatusBadNodeIDUnknown || code == ua.StatusBadAttributeIDInvalid {
+			continue
+		}
+		text := statusText(v, st)
+		if strings.Contains(text, "\n") {
+			fmt.Printf("%s:\n%s\n", v.Name, text)
+			continue
+		}
+		fmt.Printf("%-*s %s\n", width, v.Name+":", text)
+
+		// Расхождение часов выводится сразу после CurrentTime
+		if v.Name == "CurrentTime" {
+			if skew, ok := st.ClockSkew(); ok {
+				fmt.Printf("%-*s %s\n", width, "ClockSkew:", clockSkewText(skew))
+			}
+		}
+	}
+}
+
+// statusText форматирует значение переменной объекта Server
+func statusText(v client.StatusValue, st *client.ServerStatus) string {
+	dv := v.Value
+	if dv.Status != ua.StatusOK || dv.Value == nil {
+		return formatter.DataValue(dv)
+	}
+
+	switch x := dv.Value.Value().(type) {
+	case int32:
+		if v.Name == "State" {
+			// Имя состояния берётся из типа ServerState сервера, как в info и read
+			return formatter.EnumValue(dv.Value, client.EnumType(ua.NewNumericNodeID(0, id.Server_ServerStatus_State).String()))
+		}
+	case time.Time:
+		if v.Name == "StartTime" {
+			if now, ok := statusTime(st, "CurrentTime"); ok && !x.IsZero() {
+				return fmt.Sprintf("%s (up %s)", formatter.Value(dv.Value), now.Sub(x).Round(time.Second))
+			}
+		}
+	case []string:
+		// Списки профилей и локалей выводятся по одному элементу на строку
+		if len(x) > 1 {
+			return "  " + strings.Join(x, "\n  ")
+		}
+	}
+
+	s := formatter.Value(dv.Value)
+	if strings.HasPrefix(v.Name, "Max") && s == "0" {
+		return "0 (no limit)"
+	}
+	return s
+}
+
+// statusTime возвращает значение переменной типа DateTime
+func statusTime(st *client.ServerStatus, name string) (time.Time, bool) {
+	for _, v := range st.Status {
+		if v.Name == name && v.Value.Status == ua.StatusOK && v.Value.Value != nil {
+			t, ok := v.Value.Value.Value().(time.Time)
+			return t, ok
+		}
+	}
+	return time.Time{}, false
+}
+
+// clockSkewText описывает расхождение часов: "+12.35 ms (server ahead)"
+func clockSkewText(d time.Duration) string {
+	switch {
+	case d > 0:
+		return fmt.Sprintf("+%s ms (server ahead)", ms(d))
+	case d < 0:
+		return fmt.Sprintf("-%s ms (server behind)", ms(-d))
+	}
+	return "0 ms"
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestServerStatus проверяет чтение и форматирование состояния сервера.
+//
+// Основные аспекты тестирования:
+// - Все переменные читаются одним запросом Read.
+// - Состояние выводится с именем из типа ServerState сервера, время запуска - с временем работы.
+// - Нулевые ограничения означают отсутствие ограничения.
+// - Расхождение часов определяется по CurrentTime.
+func TestServerStatus(t *testing.T) {
+	s := clienttest.Attach(t)
+	now := time.Now().Add(2 * time.Second)
+	state := s.Variable("i=2259", "State", int32(ua.ServerStateRunning))
+	state.Attributes[ua.AttributeIDDataType] = &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(ua.NewNumericNodeID(0, id.ServerState))}
+	s.Add("i=852", &clienttest.Node{Attributes: map[ua.AttributeID]*ua.DataValue{
+		ua.AttributeIDBrowseName: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(&ua.QualifiedName{Name: "ServerState"})},
+		ua.AttributeIDDataTypeDefinition: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(ua.NewExtensionObject(&ua.EnumDefinition{
+			Fields: []*ua.EnumField{{Value: 0, Name: "Running"}, {Value: 1, Name: "Failed"}},
+		}))},
+	}})
+	s.Variable("i=2257", "StartTime", now.Add(-90*time.Minute))
+	s.Variable("i=2258", "CurrentTime", now)
+	s.Variable("i=2261", "ProductName", "Test server")
+	s.Variable("i=2269", "ServerProfileArray", []string{"Standard", "Embedded"})
+	s.Variable("i=11705", "MaxNodesPerRead", uint32(0))
+	s.Variable("i=11710", "MaxNodesPerBrowse", uint32(500))
+	s.Variable("i=2255", "NamespaceArray", []string{"http://opcfoundation.org/UA/", "urn:test"})
+
+	st, err := client.GetServerStatus()
+	if err != nil {
+		t.Fatalf("GetServerStatus() получена непредвиденная ошибка = %v", err)
+	}
+	if n := len(s.Requests()); n != 1 {
+		t.Errorf("отправлено %d запросов, ожидался один", n)
+	}
+	if len(st.Namespaces) != 2 || st.Namespaces[1] != "urn:test" {
+		t.Errorf("Namespaces = %v", st.Namespaces)
+	}
+
+	texts := make(map[string]string)
+	for _, group := range [][]client.StatusValue{st.Status, st.Capabilities, st.OperationLimits} {
+		for _, v := range group {
+			texts[v.Name] = statusText(v, st)
+		}
+	}
+	want := map[string]string{
+		"State":              "0 (Running)",
+		"ProductName":        "Test server",
+		"ServerProfileArray": "  Standard\n  Embedded",
+		"MaxNodesPerRead":    "0 (no limit)",
+		"MaxNodesPerBrowse":  "500",
+	}
+	for name, w := range want {
+		if texts[name] != w {
+			t.Errorf("%s = %q, ожидалось %q", name, texts[name], w)
+		}
+	}
+	if got := texts["StartTime"]; len(got) < 12 || got[len(got)-12:] != "(up 1h30m0s)" {
+		t.Errorf("StartTime = %q, ожидалось время работы 1h30m0s", got)
+	}
+
+	skew, ok := st.ClockSkew()
+	if !ok || skew < time.Second || skew > 3*time.Second {
+		t.Errorf("ClockSkew() = %v, %v, ожидалось около 2s", skew, ok)
+	}
+	if got := clockSkewText(-1500 * time.Microsecond); got != "-1.50 ms (server behind)" {
+		t.Errorf("clockSkewText() = %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/formatter"
+)
+
+// DefaultRoot - узел, с которого начинается обход по умолчанию (Objects)
+const DefaultRoot = "i=85"
+
+// TreeOptions задаёт параметры команды tree
+type TreeOptions struct {
+	// Depth - глубина обхода; 0 - без ограничения
+	Depth int
+	// Classes - классы узлов через запятую (Variable,Object); пусто - все
+	Classes string
+	// Ref - тип ссылок по имени или NodeId; пусто - HierarchicalReferences
+	Ref string
+	// Parallel - число одновременных запросов к серверу
+	Parallel int
+}
+
+// Tree выводит дерево адресного пространства от узла nodeID с отступами,
+// классами узлов и типами данных переменных
+func Tree(nodeID string, opts TreeOptions) error {
+	if nodeID == "" {
+		nodeID = DefaultRoot
+	}
+	wo, err := walkOptions(opts.Depth, opts.Classes, opts.Ref, opts.Parallel)
+	if err != nil {
+		return err
+	}
+
+	root, err := client.Walk(nodeID, wo)
+	if err != nil {
+		return err
+	}
+	printTree(root, 0)
+	return nil
+}
+
+// walkOptions разбирает параметры обхода, заданные текстом
+func walkOptions(depth int, classes, ref string, parallel int) (client.WalkOptions, error) {
+	wo := client.WalkOptions{Depth: depth, Parallel: parallel}
+	if classes != "" {
+		mask, err := formatter.ParseNodeClasses(classes)
+		if err != nil {
+			return wo, err
+		}
+		wo.NodeClassMask = mask
+	}
+	if ref != "" {
+		n, err := formatter.ParseReferenceType(ref)
+		if err != nil {
+			return wo, err
+		}
+		wo.ReferenceType = n
+	}
+	return wo, nil
+}
+
+func printTree(n *client.BrowseNode, indent int) {
+	fmt.Printf("%s%s\n", strings.Repeat("  ", indent), nodeLine(n))
+	for _, c := range n.Children {
+		printTree(c, indent+1)
+	}
+}
+
+// nodeLine форматирует узел в виде "Temperature [Variable: Double] ns=2;s=Temp"
+func nodeLine(n *client.BrowseNode) string {
+	name := n.DisplayName
+	if name == "" && n.BrowseName != nil {
+		name = n.BrowseName.Name
+	}
+	class := formatter.NodeClassName(n.NodeClass)
+	if n.DataType != nil {
+		class += ": " + typeName(n.DataType)
+	}
+	return fmt.Sprintf("%s [%s] %s", name, class, n.NodeID)
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestTreeRootWithoutAttributes проверяет обход от узла, часть атрибутов
+// которого сервер не возвращает.
+//
+// Основные аспекты тестирования:
+// - DisplayName, NodeClass и DataType с плохим статусом пропускаются без паники.
+// - Без BrowseName обход завершается ошибкой.
+func TestTreeRootWithoutAttributes(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Add("ns=2;s=Root", &clienttest.Node{Attributes: map[ua.AttributeID]*ua.DataValue{
+		ua.AttributeIDBrowseName: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(&ua.QualifiedName{NamespaceIndex: 2, Name: "Root"})},
+	}})
+	s.Add("ns=2;s=Nameless", &clienttest.Node{})
+
+	if err := Tree("ns=2;s=Root", TreeOptions{Depth: 1}); err != nil {
+		t.Errorf("Tree() получена непредвиденная ошибка = %v", err)
+	}
+	if err := Tree("ns=2;s=Nameless", TreeOptions{Depth: 1}); err == nil {
+		t.Errorf("Tree() от узла без BrowseName должна вернуть ошибку")
+	}
+}
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/audit"
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// WriteOptions задаёт параметры записи в узел
+type WriteOptions struct {
+	// Attribute - имя или номер записываемого атрибута; пусто - Value
+	Attribute string
+	// Status - код статуса записываемого значения (Good, Uncertain,
+	// BadSensorFailure или число); пусто - не передаётся
+	Status string
+	// SourceTimestamp - метка времени источника значения; нулевое время -
+	// не передаётся
+	SourceTimestamp time.Time
+}
+
+// Write записывает значение в узел.
+//
+// Значение задаётся текстом, который приводится к типу текущего значения
+// узла, обратимой JSON-формой Variant ({"Type":6,"Body":42}), которую выводит
+// read --format json --reversible, или, для структур, JSON-объектом с полями
+// ({"Speed":10,"Mode":"Running"}). Перечисления принимают имя элемента
+// вместо числа. Массив записывается как [1, 2, 3] или [[1, 2], [3, 4]];
+// диапазон элементов задаётся после узла в скобках: ns=2;s=Arr[3] 42,
+// ns=2;s=Arr[2:4] "[1, 2, 3]".
+//
+// Вместе со значением можно записать код статуса и метку времени
+// источника, если сервер это допускает. Другие атрибуты (DisplayName,
+// Description, WriteMask, ...) записываются с opts.Attribute; значение
+// разбирается по типу атрибута.
+func Write(nodeID, value string, opts WriteOptions) error {
+	if nodeID == "" {
+		return fmt.Errorf("node ID cannot be empty")
+	}
+	if err := checkWritable("write"); err != nil {
+		return err
+	}
+
+	attr := ua.AttributeIDValue
+	if opts.Attribute != "" {
+		var err error
+		if attr, err = formatter.ParseAttribute(opts.Attribute); err != nil {
+			return err
+		}
+	}
+	if attr != ua.AttributeIDValue {
+		return writeAttribute(nodeID, attr, value, opts)
+	}
+
+	nodeID, indexRange := splitIndexRange(nodeID)
+	v, err := parseWriteValue(nodeID, value, indexRange)
+	if err != nil {
+		return err
+	}
+
+	dv := &ua.DataValue{EncodingMask: ua.DataValueValue, Value: v}
+	if opts.Status != "" {
+		code, err := formatter.Parse(ua.TypeIDStatusCode, opts.Status)
+		if err != nil {
+			return err
+		}
+		dv.Status = code.(ua.StatusCode)
+		dv.EncodingMask |= ua.DataValueStatusCode
+	}
+	if !opts.SourceTimestamp.IsZero() {
+		dv.SourceTimestamp = opts.SourceTimestamp
+		dv.EncodingMask |= ua.DataValueSourceTimestamp
+	}
+
+	ok, err := writeDataValue(nodeID, ua.AttributeIDValue, dv, indexRange)
+	if errors.Is(err, ua.StatusBadWriteNotSupported) && dv.EncodingMask != ua.DataValueValue {
+		return fmt.Errorf("%w: the server does not accept status codes or timestamps for %s", err, nodeID)
+	}
+	return writeResult(ok, err)
+}
+
+// writeAttribute записывает атрибут, отличный от Value. Значение задаётся
+// текстом или обратимой JSON-формой Variant.
+func writeAttribute(nodeID string, attr ua.AttributeID, value string, opts WriteOptions) error {
+	if opts.Status != "" || !opts.SourceTimestamp.IsZero() {
+		return fmt.Errorf("status and timestamp can only be written with the Value attribute")
+	}
+
+	var (
+		v   *ua.Variant
+		err error
+	)
+	if isJSONValue(value) {
+		v, err = variantValue(value)
+	} else {
+		v, err = formatter.ParseAttributeValue(attr, value)
+	}
+	if err != nil {
+		return err
+	}
+
+	dv := &ua.DataValue{EncodingMask: ua.DataValueValue, Value: v}
+	ok, err := writeDataValue(nodeID, attr, dv, "")
+	if errors.Is(err, ua.StatusBadNotWritable) {
+		return fmt.Errorf("%w: %s of %s is not writable, see WriteMask in info %s", err, formatter.AttributeName(attr), nodeID, nodeID)
+	}
+	return writeResult(ok, err)
+}
+
+// writeDataValue записывает атрибут узла. В режиме ConfirmWrites запись
+// подтверждается, с журналом аудита в него записываются прежнее и новое
+// значение; для этого текущее значение читается перед записью. Возвращает
+// false, если запись отменена.
+func writeDataValue(nodeID string, attr ua.AttributeID, dv *ua.DataValue, indexRange string) (bool, error) {
+	var current *ua.DataValue
+	if ConfirmWrites || Audit != nil {
+		var err error
+		if current, err = currentValue(nodeID, attr, indexRange); err != nil {
+			return false, err
+		}
+	}
+	if ConfirmWrites && !confirmWrite(nodeID, attr, indexRange, current, dv) {
+		return false, nil
+	}
+
+	err := client.WriteAttribute(nodeID, attr, dv, indexRange)
+	record(audit.Entry{
+		Operation:  "write",
+		Node:       nodeID,
+		Attribute:  formatter.AttributeName(attr),
+		IndexRange: indexRange,
+		Old:        auditValue(current),
+		New:        auditValue(dv),
+		Status:     auditStatus(ua.StatusOK, err),
+	})
+	return true, err
+}
+
+// writeResult выводит итог записи одного узла
+func writeResult(written bool, err error) error {
+	switch {
+	case err != nil:
+		return err
+	case !written:
+		fmt.Println("Cancelled")
+	default:
+		fmt.Println("Write successful")
+	}
+	return nil
+}
+
+// parseWriteValue преобразует введённое значение в Variant; при записи
+// диапазона indexRange значение - массив элементов диапазона
+func parseWriteValue(nodeID, value, indexRange string) (*ua.Variant, error) {
+	if isJSONValue(value) && isVariantJSON(value) {
+		return variantValue(value)
+	}
+
+	// Тип берём из текущего значения узла: так корректно обрабатываются
+	// и производные типы данных (Duration, UtcTime и т.п.)
+	current, err := client.Read(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isJSONValue(value) {
+		return structureValue(nodeID, current, value)
+	}
+	if current.Value == nil || current.Value.Type() == ua.TypeIDNull {
+		return nil, fmt.Errorf("cannot determine data type of %s, use the JSON form {\"Type\":...,\"Body\":...}", nodeID)
+	}
+
+	t, enum := current.Value.Type(), client.EnumType(nodeID)
+	if indexRange != "" || isArrayValue(current.Value) {
+		return arrayValue(nodeID, t, enum, value, indexRange)
+	}
+	return textValue(t, enum, value)
+}
+
+func isJSONValue(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), "{")
+}
+
+// variantValue разбирает обратимую JSON-форму Variant
+func variantValue(value string) (*ua.Variant, error) {
+	dec := &formatter.JSONDecoder{Namespaces: client.Namespaces(), Types: client.Types()}
+	v, err := dec.Variant([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	return v, nil
+}
+
+// structureValue разбирает JSON-объект с полями структуры того же типа,
+// что и текущее значение узла
+func structureValue(nodeID string, current *ua.DataValue, value string) (*ua.Variant, error) {
+	if current.Value == nil || current.Value.Type() == ua.TypeIDNull {
+		return nil, fmt.Errorf("cannot determine data type of %s, use the JSON form {\"Type\":...,\"Body\":...}", nodeID)
+	}
+	eo, ok := current.Value.Value().(*ua.ExtensionObject)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a structure, use the JSON form {\"Type\":...,\"Body\":...}", nodeID)
+	}
+	st, ok := eo.Value.(*datatype.Structure)
+	if !ok {
+		return nil, fmt.Errorf("data type of %s is unknown, use the JSON form {\"Type\":22,\"Body\":{...}}", nodeID)
+	}
+	dec := &formatter.JSONDecoder{Namespaces: client.Namespaces(), Types: client.Types()}
+	s, err := dec.Structure(st.Type, []byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	out := &ua.ExtensionObject{TypeID: ua.NewExpandedNodeID(st.Type.EncodingID, "", 0), Value: s}
+	out.UpdateMask()
+	return ua.NewVariant(out)
+}
+
+// textValue разбирает текстовое значение типа t. Для перечислений (enum
+// не nil) допускаются имена элементов: Running, Running_0.
+func textValue(t ua.TypeID, enum *datatype.Definition, value string) (*ua.Variant, error) {
+	if enum != nil {
+		e, err := datatype.ParseEnum(enum, value)
+		if err != nil {
+			return nil, err
+		}
+		value = strconv.Itoa(int(e.Value))
+	}
+
+	parsed, err := formatter.Parse(t, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q: %w", formatter.TypeName(t), value, err)
+	}
+	return ua.NewVariant(parsed)
+}
+
+// isVariantJSON отличает JSON-форму Variant ({"Type":..,"Body":..}) от
+// JSON-объекта с полями структуры
+func isVariantJSON(value string) bool {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		// Ошибку разбора сообщит декодер Variant
+		return true
+	}
+	_, hasType := obj["Type"]
+	_, hasBody := obj["Body"]
+	return hasType && (hasBody || len(obj) == 1)
+}
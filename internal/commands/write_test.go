@@ -0,0 +1,251 @@
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestWrite проверяет запись значения через записывающую сессию.
+//
+// Основные аспекты тестирования:
+// - Текст приводится к типу текущего значения узла.
+// - Обратимая JSON-форма задаёт тип явно.
+// - Ошибки разбора значения, неизвестного узла и сессии.
+// - JSON-объект для узла без значения - ошибка, а не паника.
+func TestWrite(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("ns=2;s=Temp", "Temp", 20.5)
+	s.Variable("ns=2;s=Mode", "Mode", int32(1))
+	s.Add("ns=2;s=Empty", &clienttest.Node{Attributes: map[ua.AttributeID]*ua.DataValue{
+		ua.AttributeIDValue: {EncodingMask: ua.DataValueStatusCode, Status: ua.StatusOK},
+	}})
+
+	if err := Write("ns=2;s=Temp", "42", WriteOptions{}); err != nil {
+		t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+	}
+	if err := Write("ns=2;s=Mode", `{"Type":7,"Body":5}`, WriteOptions{}); err != nil {
+		t.Fatalf("Write() JSON получена непредвиденная ошибка = %v", err)
+	}
+
+	writes := s.Writes()
+	if len(writes) != 2 {
+		t.Fatalf("записано %d значений, ожидалось 2", len(writes))
+	}
+	want := []struct {
+		node  string
+		value interface{}
+	}{
+		{"ns=2;s=Temp", float64(42)},
+		{"ns=2;s=Mode", uint32(5)},
+	}
+	for i, w := range want {
+		got := writes[i]
+		if got.NodeID.String() != w.node || got.AttributeID != ua.AttributeIDValue || got.Value.Value.Value() != w.value {
+			t.Errorf("запись %d = %v %v, ожидалось %s %T(%v)", i, got.NodeID, got.Value.Value.Value(), w.node, w.value, w.value)
+		}
+	}
+	if v := s.Value("ns=2;s=Temp"); v == nil || v.Value() != float64(42) {
+		t.Errorf("значение Temp после записи = %v, ожидалось 42", v)
+	}
+
+	if err := Write("ns=2;s=Temp", "abc", WriteOptions{}); err == nil || !strings.Contains(err.Error(), "invalid Double value") {
+		t.Errorf("Write(abc) = %v, ожидалась ошибка разбора", err)
+	}
+	if err := Write("ns=2;s=Empty", `{"Speed":1}`, WriteOptions{}); err == nil || !strings.Contains(err.Error(), "cannot determine data type") {
+		t.Errorf("Write() структуры в узел без значения = %v, ожидалась ошибка типа", err)
+	}
+	if err := Write("ns=2;s=Missing", "1", WriteOptions{}); err == nil {
+		t.Errorf("Write() неизвестного узла должна вернуть ошибку")
+	}
+	if n := len(s.Writes()); n != 2 {
+		t.Errorf("после ошибок записано %d значений, ожидалось 2", n)
+	}
+
+	s.Err = fmt.Errorf("connection lost")
+	if err := Write("ns=2;s=Temp", "1", WriteOptions{}); err == nil || !strings.Contains(err.Error(), "connection lost") {
+		t.Errorf("Write() = %v, ожидалась ошибка сессии", err)
+	}
+}
+
+// TestWriteArray проверяет запись и чтение массивов и диапазонов индексов.
+//
+// Основные аспекты тестирования:
+// - Массив [1, 2, 3] приводится к типу элементов текущего значения.
+// - Диапазон в скобках после узла передаётся в IndexRange.
+// - Одно значение записывается в диапазон массивом из одного элемента.
+// - Скалярное значение без диапазона для массива - ошибка.
+// - Существующий узел с NodeId вида s=Tank[1] не делится на узел и диапазон.
+// - read передаёт диапазон из скобок или --range, но не из обоих.
+func TestWriteArray(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("ns=2;s=Arr", "Arr", []int32{1, 2, 3, 4, 5, 6})
+	s.Variable("ns=2;s=Matrix", "Matrix", [][]float64{{1, 2}, {3, 4}})
+	s.Variable("ns=2;s=Tags[a]", "Tags[a]", "scalar")
+	s.Variable("ns=2;s=Tank[1]", "Tank[1]", 1.5)
+
+	tests := []struct {
+		node, value string
+		wantRange   string
+		want        interface{}
+	}{
+		{"ns=2;s=Arr[3]", "42", "3", []int32{42}},
+		{"ns=2;s=Arr[1:2]", "[7, 8]", "1:2", []int32{7, 8}},
+		{"ns=2;s=Arr", "[1, 2, 3]", "", []int32{1, 2, 3}},
+		{"ns=2;s=Matrix[0:1,1]", "[[7], [8]]", "0:1,1", [][]float64{{7}, {8}}},
+		{"ns=2;s=Matrix[1,0]", "9", "1,0", [][]float64{{9}}},
+	}
+	for _, tt := range tests {
+		if err := Write(tt.node, tt.value, WriteOptions{}); err != nil {
+			t.Fatalf("Write(%s, %s) получена непредвиденная ошибка = %v", tt.node, tt.value, err)
+		}
+		writes := s.Writes()
+		got := writes[len(writes)-1]
+		if got.IndexRange != tt.wantRange || !reflect.DeepEqual(got.Value.Value.Value(), tt.want) {
+			t.Errorf("Write(%s, %s) записано %q %#v, ожидалось %q %#v", tt.node, tt.value, got.IndexRange, got.Value.Value.Value(), tt.wantRange, tt.want)
+		}
+	}
+
+	if err := Write("ns=2;s=Arr", "5", WriteOptions{}); err == nil || !strings.Contains(err.Error(), "is an array") {
+		t.Errorf("Write() скаляра в массив = %v, ожидалась ошибка", err)
+	}
+	if err := Write("ns=2;s=Arr", "[1, x]", WriteOptions{}); err == nil || !strings.Contains(err.Error(), "invalid Int32 value") {
+		t.Errorf("Write() массива с неверным элементом = %v, ожидалась ошибка разбора", err)
+	}
+	// Скобки без числового диапазона - часть NodeId
+	if err := Write("ns=2;s=Tags[a]", "text", WriteOptions{}); err != nil {
+		t.Errorf("Write() по NodeId со скобками получена непредвиденная ошибка = %v", err)
+	}
+	// Узел, NodeId которого оканчивается диапазоном, записывается целиком
+	if err := Write("ns=2;s=Tank[1]", "2.5", WriteOptions{}); err != nil {
+		t.Errorf("Write() по NodeId с индексом получена непредвиденная ошибка = %v", err)
+	}
+	if w := s.Writes(); w[len(w)-1].NodeID.String() != "ns=2;s=Tank[1]" || w[len(w)-1].IndexRange != "" {
+		t.Errorf("Write() по NodeId с индексом записано %v [%s], ожидалось ns=2;s=Tank[1]", w[len(w)-1].NodeID, w[len(w)-1].IndexRange)
+	}
+
+	if err := Read("ns=2;s=Arr", ReadOptions{Range: "2:5"}); err != nil {
+		t.Fatalf("Read() --range получена непредвиденная ошибка = %v", err)
+	}
+	if err := Read("ns=2;s=Arr[0]", ReadOptions{}); err != nil {
+		t.Fatalf("Read() с диапазоном в скобках получена непредвиденная ошибка = %v", err)
+	}
+	var ranges []string
+	for _, r := range s.Requests() {
+		if req, ok := r.(*ua.ReadRequest); ok && req.NodesToRead[0].NodeID.String() == "ns=2;s=Arr" && req.NodesToRead[0].IndexRange != "" {
+			ranges = append(ranges, req.NodesToRead[0].IndexRange)
+		}
+	}
+	if !reflect.DeepEqual(ranges, []string{"2:5", "0"}) {
+		t.Errorf("диапазоны чтения = %v, ожидалось [2:5 0]", ranges)
+	}
+	if err := Read("ns=2;s=Arr[0]", ReadOptions{Range: "1"}); err == nil || !strings.Contains(err.Error(), "index range given twice") {
+		t.Errorf("Read() с двумя диапазонами = %v, ожидалась ошибка", err)
+	}
+	if err := Read("ns=2;s=Arr", ReadOptions{Range: "5:2"}); err == nil || !strings.Contains(err.Error(), "invalid index range") {
+		t.Errorf("Read() с неверным диапазоном = %v, ожидалась ошибка", err)
+	}
+}
+
+// TestWriteAttribute проверяет запись атрибутов и значения со статусом.
+//
+// Основные аспекты тестирования:
+// - Атрибут задаётся именем, значение разбирается по типу атрибута.
+// - Статус и метка времени передаются в DataValue вместе со значением.
+// - Отказ сервера в записи статуса поясняется.
+// - Статус нельзя записать с атрибутом, отличным от Value.
+func TestWriteAttribute(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("ns=2;s=Temp", "Temp", 20.5)
+	s.Variable("ns=2;s=Locked", "Locked", 1.5).WriteStatus = ua.StatusBadWriteNotSupported
+
+	if err := Write("ns=2;s=Temp", "Температура котла", WriteOptions{Attribute: "description"}); err != nil {
+		t.Fatalf("Write() Description получена непредвиденная ошибка = %v", err)
+	}
+	if err := Write("ns=2;s=Temp", "DisplayName, Description", WriteOptions{Attribute: "WriteMask"}); err != nil {
+		t.Fatalf("Write() WriteMask получена непредвиденная ошибка = %v", err)
+	}
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := Write("ns=2;s=Temp", "42", WriteOptions{Status: "Uncertain", SourceTimestamp: ts}); err != nil {
+		t.Fatalf("Write() со статусом получена непредвиденная ошибка = %v", err)
+	}
+
+	writes := s.Writes()
+	if len(writes) != 3 {
+		t.Fatalf("записано %d значений, ожидалось 3", len(writes))
+	}
+	if w := writes[0]; w.AttributeID != ua.AttributeIDDescription || w.Value.Value.Value().(*ua.LocalizedText).Text != "Температура котла" {
+		t.Errorf("запись Description = %d %v", w.AttributeID, w.Value.Value.Value())
+	}
+	if w := writes[1]; w.AttributeID != ua.AttributeIDWriteMask || w.Value.Value.Value() != uint32(ua.AttributeWriteMaskDisplayName|ua.AttributeWriteMaskDescription) {
+		t.Errorf("запись WriteMask = %d %v", w.AttributeID, w.Value.Value.Value())
+	}
+	dv := writes[2].Value
+	if dv.Status != ua.StatusUncertain || !dv.SourceTimestamp.Equal(ts) || dv.EncodingMask&ua.DataValueSourceTimestamp == 0 || dv.Value.Value() != float64(42) {
+		t.Errorf("запись значения со статусом = %v %v %v", dv.Value.Value(), dv.Status, dv.SourceTimestamp)
+	}
+
+	if err := Write("ns=2;s=Locked", "2", WriteOptions{Status: "Bad"}); err == nil || !strings.Contains(err.Error(), "does not accept status codes") {
+		t.Errorf("Write() со статусом без поддержки сервера = %v, ожидалось пояснение", err)
+	}
+	if err := Write("ns=2;s=Temp", "1", WriteOptions{Status: "Fine"}); err == nil || !strings.Contains(err.Error(), "unknown status code") {
+		t.Errorf("Write() с неизвестным статусом = %v, ожидалась ошибка", err)
+	}
+	if err := Write("ns=2;s=Temp", "x", WriteOptions{Attribute: "DisplayName", Status: "Good"}); err == nil {
+		t.Errorf("Write() атрибута со статусом должна вернуть ошибку")
+	}
+	if err := Write("ns=2;s=Temp", "x", WriteOptions{Attribute: "Colour"}); err == nil || !strings.Contains(err.Error(), "unknown attribute") {
+		t.Errorf("Write() неизвестного атрибута = %v, ожидалась ошибка", err)
+	}
+}
+
+// TestWriteStructureType проверяет запись структуры, описание типа которой
+// загружается с сервера.
+//
+// Основные аспекты тестирования:
+// - Ошибка загрузки типа поля возвращается, а не оставляет в кэше неполное описание.
+// - После появления типа поля структура записывается со всеми полями.
+func TestWriteStructureType(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("ns=2;s=Motor", "Motor", int32(0))
+	s.Add("ns=2;i=3001", &clienttest.Node{Attributes: map[ua.AttributeID]*ua.DataValue{
+		ua.AttributeIDBrowseName: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(&ua.QualifiedName{NamespaceIndex: 2, Name: "Motor"})},
+		ua.AttributeIDDataTypeDefinition: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(ua.NewExtensionObject(&ua.StructureDefinition{
+			DefaultEncodingID: ua.NewNumericNodeID(2, 3002),
+			BaseDataType:      ua.NewNumericNodeID(0, 22),
+			Fields: []*ua.StructureField{
+				{Name: "Speed", DataType: ua.NewNumericNodeID(0, 11), ValueRank: -1},
+				{Name: "Mode", DataType: ua.NewNumericNodeID(2, 3010), ValueRank: -1},
+			},
+		}))},
+	}})
+	value := `{"Type":22,"Body":{"TypeId":"ns=2;i=3001","Body":{"Speed":1.5,"Mode":2}}}`
+
+	if err := Write("ns=2;s=Motor", value, WriteOptions{}); err == nil || !strings.Contains(err.Error(), "Motor.Mode") {
+		t.Fatalf("Write() без типа поля = %v, ожидалась ошибка загрузки типа", err)
+	}
+
+	s.Add("ns=2;i=3010", &clienttest.Node{Attributes: map[ua.AttributeID]*ua.DataValue{
+		ua.AttributeIDBrowseName: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(&ua.QualifiedName{NamespaceIndex: 2, Name: "Mode"})},
+		ua.AttributeIDDataTypeDefinition: {EncodingMask: ua.DataValueValue, Value: ua.MustVariant(ua.NewExtensionObject(&ua.EnumDefinition{
+			Fields: []*ua.EnumField{{Value: 0, Name: "Stopped"}, {Value: 2, Name: "Running"}},
+		}))},
+	}})
+	if err := Write("ns=2;s=Motor", value, WriteOptions{}); err != nil {
+		t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+	}
+	writes := s.Writes()
+	eo, _ := writes[len(writes)-1].Value.Value.Value().(*ua.ExtensionObject)
+	if eo == nil {
+		t.Fatalf("записано %v, ожидалась структура", writes[len(writes)-1].Value.Value.Value())
+	}
+	if st, ok := eo.Value.(*datatype.Structure); !ok || len(st.Fields) != 2 {
+		t.Errorf("записана структура %#v, ожидалось два поля", eo.Value)
+	}
+}
@@ -0,0 +1,315 @@
+package commands
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexfrick92/opcli/internal/audit"
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/ua"
+)
+
+// WriteListOptions задаёт параметры записи значений из файла
+type WriteListOptions struct {
+	// DryRun только показывает изменения, не записывая их
+	DryRun bool
+	// Rollback возвращает прежние значения записанных узлов, если
+	// запись хотя бы одного узла не удалась
+	Rollback bool
+	// Yes отключает запрос подтверждения
+	Yes bool
+	// Parallel - число одновременных запросов к серверу
+	Parallel int
+}
+
+// writeRow - строка CSV-файла записи
+type writeRow struct {
+	line   int
+	nodeID string
+	value  string
+	// dataType - имя встроенного типа; пусто - тип текущего значения
+	dataType string
+}
+
+// writeChange - изменение значения узла
+type writeChange struct {
+	row      writeRow
+	old, new *ua.Variant
+}
+
+// WriteList записывает значения из CSV-файла со столбцами узла, значения и
+// необязательного типа. Перед записью читаются текущие значения и
+// выводятся изменения; узлы, значение которых не меняется, не
+// записываются. Если хотя бы одна строка не разобрана, не записывается
+// ничего.
+func WriteList(file string, opts WriteListOptions) error {
+	if !opts.DryRun {
+		if err := checkWritable("write"); err != nil {
+			return err
+		}
+	}
+	rows, err := readWriteCSV(file)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%s: no values", file)
+	}
+
+	changes, err := writeChanges(file, rows, opts.Parallel)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Printf("No changes, %d values are up to date\n", len(rows))
+		return nil
+	}
+
+	diff := make([][]string, len(changes))
+	for i, c := range changes {
+		diff[i] = []string{c.row.nodeID, formatter.Value(c.old), formatter.Value(c.new)}
+	}
+	if err := formatter.Table(os.Stdout, []string{"NodeId", "Current", "New"}, diff); err != nil {
+		return err
+	}
+	fmt.Printf("%d of %d values changed\n", len(changes), len(rows))
+	if opts.DryRun {
+		return nil
+	}
+	if !confirmed(opts.Yes, fmt.Sprintf("Write %d values?", len(changes))) {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	nodeIDs := make([]string, len(changes))
+	values := make([]*ua.Variant, len(changes))
+	for i, c := range changes {
+		nodeIDs[i], values[i] = c.row.nodeID, c.new
+	}
+	results, err := client.WriteMany(nodeIDs, values, opts.Parallel)
+	if err != nil {
+		return err
+	}
+	entries := make([]audit.Entry, len(changes))
+	for i, c := range changes {
+		entries[i] = writeEntry("write", c.row.nodeID, c.old, c.new, results[i])
+	}
+	record(entries...)
+
+	var written, failed []int
+	for i, code := range results {
+		if isBadStatus(code) {
+			failed = append(failed, i)
+		} else {
+			written = append(written, i)
+		}
+	}
+
+	// Отчёт со статусом каждой строки выводится и при успешной записи
+	header := []string{"NodeId", "Current", "New", "Status"}
+	report := make([][]string, len(changes))
+	for i := range changes {
+		report[i] = append(diff[i], formatter.StatusName(results[i]))
+	}
+	if len(failed) == 0 {
+		if err := formatter.Table(os.Stdout, header, report); err != nil {
+			return err
+		}
+		fmt.Printf("%d values written\n", len(results))
+		return nil
+	}
+	if !opts.Rollback || len(written) == 0 {
+		if err := formatter.Table(os.Stdout, header, report); err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to write %d of %d values", len(failed), len(results))
+	}
+
+	// Откат: записанным узлам возвращаются прежние значения
+	back := make([]string, len(written))
+	old := make([]*ua.Variant, len(written))
+	for j, i := range written {
+		back[j], old[j] = nodeIDs[i], changes[i].old
+	}
+	undone, err := client.WriteMany(back, old, opts.Parallel)
+	if err != nil {
+		return fmt.Errorf("failed to write %d of %d values, rollback failed: %w", len(failed), len(results), err)
+	}
+	entries = entries[:0]
+	for j, i := range written {
+		entries = append(entries, writeEntry("rollback", back[j], changes[i].new, old[j], undone[j]))
+	}
+	record(entries...)
+	header = append(header, "Rollback")
+	for i := range report {
+		report[i] = append(report[i], "")
+	}
+	notUndone := 0
+	for j, i := range written {
+		report[i][4] = formatter.StatusName(undone[j])
+		if isBadStatus(undone[j]) {
+			notUndone++
+		}
+	}
+	if err := formatter.Table(os.Stdout, header, report); err != nil {
+		return err
+	}
+	if notUndone > 0 {
+		return fmt.Errorf("failed to write %d of %d values, failed to roll back %d values", len(failed), len(results), notUndone)
+	}
+	return fmt.Errorf("failed to write %d of %d values, %d written values rolled back", len(failed), len(results), len(written))
+}
+
+// writeEntry - запись журнала аудита о записи значения узла
+func writeEntry(operation, nodeID string, old, new *ua.Variant, code ua.StatusCode) audit.Entry {
+	return audit.Entry{
+		Operation: operation,
+		Node:      nodeID,
+		Attribute: formatter.AttributeName(ua.AttributeIDValue),
+		Old:       auditVariant(old),
+		New:       auditVariant(new),
+		Status:    formatter.StatusName(code),
+	}
+}
+
+// writeChanges читает текущие значения узлов и разбирает новые. Строки,
+// которые не удалось разобрать, выводятся таблицей, и возвращается ошибка.
+func writeChanges(file string, rows []writeRow, parallel int) ([]writeChange, error) {
+	nodeIDs := make([]string, len(rows))
+	for i, r := range rows {
+		nodeIDs[i] = r.nodeID
+	}
+	current, err := client.ReadMany(nodeIDs, parallel)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		changes []writeChange
+		invalid [][]string
+	)
+	for i, r := range rows {
+		cur := current[i]
+		var v *ua.Variant
+		switch {
+		case cur.Err != nil:
+			err = cur.Err
+		case isBadStatus(cur.Value.Status):
+			// Без текущего значения запись нельзя ни проверить, ни откатить
+			err = fmt.Errorf("read failed: %s", formatter.StatusName(cur.Value.Status))
+		default:
+			v, err = rowValue(r, cur.Value)
+		}
+		if err != nil {
+			invalid = append(invalid, []string{fmt.Sprint(r.line), r.nodeID, err.Error()})
+			continue
+		}
+		old := cur.Value.Value
+		if old == nil {
+			// Пустое значение откатывается записью Variant без значения
+			old = &ua.Variant{}
+		}
+		if v.Type() != old.Type() || formatter.Value(v) != formatter.Value(old) {
+			changes = append(changes, writeChange{row: r, old: old, new: v})
+		}
+	}
+	if len(invalid) > 0 {
+		if err := formatter.Table(os.Stdout, []string{"Line", "NodeId", "Error"}, invalid); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %d invalid rows, nothing written", file, len(invalid))
+	}
+	return changes, nil
+}
+
+// rowValue разбирает значение строки. Тип берётся из столбца типа, а без
+// него - из текущего значения; имя элемента перечисления ищется, только
+// если значение не число, чтобы не запрашивать тип каждого узла.
+func rowValue(r writeRow, current *ua.DataValue) (*ua.Variant, error) {
+	switch {
+	case isJSONValue(r.value) && isVariantJSON(r.value):
+		return variantValue(r.value)
+	case isJSONValue(r.value):
+		return structureValue(r.nodeID, current, r.value)
+	case r.dataType != "":
+		t, ok := formatter.ParseTypeName(r.dataType)
+		if !ok {
+			return nil, fmt.Errorf("unknown data type: %s", r.dataType)
+		}
+		if isArrayValue(current.Value) {
+			return arrayValue(r.nodeID, t, nil, r.value, "")
+		}
+		return textValue(t, nil, r.value)
+	case current.Value == nil || current.Value.Type() == ua.TypeIDNull:
+		return nil, fmt.Errorf("cannot determine data type, add a Type column")
+	}
+
+	t := current.Value.Type()
+	parse := func(enum *datatype.Definition) (*ua.Variant, error) {
+		if isArrayValue(current.Value) {
+			return arrayValue(r.nodeID, t, enum, r.value, "")
+		}
+		return textValue(t, enum, r.value)
+	}
+	v, err := parse(nil)
+	if err != nil {
+		if enum := client.EnumType(r.nodeID); enum != nil {
+			return parse(enum)
+		}
+	}
+	return v, err
+}
+
+// readWriteCSV читает строки узел,значение[,тип]. Если первая строка -
+// заголовок, столбцы находятся по именам (NodeId, Value, Type), поэтому
+// подходит и вывод read --from --format csv.
+func readWriteCSV(path string) ([]writeRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	nodeCol, valueCol, typeCol := 0, 1, 2
+	var rows []writeRow
+	for first := true; ; first = false {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		line, _ := r.FieldPos(0)
+
+		if first && columnIndex(rec, nodeColumns) >= 0 {
+			nodeCol = columnIndex(rec, nodeColumns)
+			valueCol = columnIndex(rec, []string{"Value"})
+			typeCol = columnIndex(rec, []string{"Type", "DataType"})
+			if valueCol < 0 {
+				return nil, fmt.Errorf("%s: header must contain NodeId and Value columns", path)
+			}
+			continue
+		}
+
+		if nodeCol >= len(rec) || valueCol >= len(rec) || strings.TrimSpace(rec[nodeCol]) == "" {
+			return nil, fmt.Errorf("%s:%d: expected node and value", path, line)
+		}
+		row := writeRow{line: line, nodeID: strings.TrimSpace(rec[nodeCol]), value: rec[valueCol]}
+		if typeCol >= 0 && typeCol < len(rec) {
+			row.dataType = strings.TrimSpace(rec[typeCol])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client/clienttest"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestWriteList проверяет запись значений из CSV-файла.
+//
+// Основные аспекты тестирования:
+// - --dry-run и строки с ошибками не записывают ничего.
+// - Неизменившиеся значения не записываются.
+// - Запись разбивается на пакеты не больше MaxNodesPerWrite сервера.
+// - При отказе в записи узла записанные значения откатываются.
+// - Столбец Type задаёт тип значения.
+func TestWriteList(t *testing.T) {
+	s := clienttest.Attach(t)
+	s.Variable("i=11707", "MaxNodesPerWrite", uint32(2))
+	s.Variable("ns=2;s=Temp", "Temp", 20.5)
+	s.Variable("ns=2;s=Mode", "Mode", int32(1))
+	s.Variable("ns=2;s=Speed", "Speed", uint16(100))
+	s.Variable("ns=2;s=Locked", "Locked", int32(0)).WriteStatus = ua.StatusBadUserAccessDenied
+
+	dir := t.TempDir()
+	recipe := func(lines ...string) string {
+		t.Helper()
+		path := filepath.Join(dir, "recipe.csv")
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	value := func(nodeID string) interface{} {
+		if v := s.Value(nodeID); v != nil {
+			return v.Value()
+		}
+		return nil
+	}
+
+	if err := WriteList(recipe("ns=2;s=Temp,42", "ns=2;s=Speed,100", "ns=2;s=Mode,2"), WriteListOptions{DryRun: true}); err != nil {
+		t.Fatalf("WriteList() --dry-run получена непредвиденная ошибка = %v", err)
+	}
+	if err := WriteList(recipe("ns=2;s=Temp,42", "ns=2;s=Mode,abc"), WriteListOptions{Yes: true}); err == nil || !strings.Contains(err.Error(), "1 invalid rows") {
+		t.Errorf("WriteList() со строкой с ошибкой = %v, ожидалась ошибка 1 invalid rows", err)
+	}
+	if n := len(s.Writes()); n != 0 {
+		t.Fatalf("записано %d значений, ожидалось 0", n)
+	}
+
+	err := WriteList(recipe("ns=2;s=Temp,42", "ns=2;s=Speed,100", "ns=2;s=Mode,2", "ns=2;s=Locked,1"), WriteListOptions{Rollback: true, Yes: true})
+	if err == nil || !strings.Contains(err.Error(), "failed to write 1 of 3 values, 2 written values rolled back") {
+		t.Errorf("WriteList() с отказом в записи = %v, ожидался откат 2 значений", err)
+	}
+	if v := value("ns=2;s=Temp"); v != 20.5 {
+		t.Errorf("Temp после отката = %v, ожидалось 20.5", v)
+	}
+	if v := value("ns=2;s=Mode"); v != int32(1) {
+		t.Errorf("Mode после отката = %v, ожидалось 1", v)
+	}
+	var batches []int
+	for _, r := range s.Requests() {
+		if req, ok := r.(*ua.WriteRequest); ok {
+			batches = append(batches, len(req.NodesToWrite))
+		}
+	}
+	// Запись 3 узлов пакетами по 2 (пакеты отправляются параллельно) и
+	// откат 2 узлов одним пакетом
+	if len(batches) != 3 || batches[0]+batches[1] != 3 || batches[0] > 2 || batches[1] > 2 || batches[2] != 2 {
+		t.Errorf("пакеты записи = %v, ожидались 2 и 1 узел, затем 2 узла отката", batches)
+	}
+
+	if err := WriteList(recipe("Tag,Value,Type", "ns=2;s=Mode,7,Byte"), WriteListOptions{Yes: true}); err != nil {
+		t.Fatalf("WriteList() со столбцом Type получена непредвиденная ошибка = %v", err)
+	}
+	if v := value("ns=2;s=Mode"); v != uint8(7) {
+		t.Errorf("Mode = %#v, ожидалось uint8(7)", v)
+	}
+}
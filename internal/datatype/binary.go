@@ -0,0 +1,474 @@
+package datatype
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// maxArrayDimensions - наибольшее число размерностей поля-массива; больше
+// в реальных типах не встречается, а поток с таким числом - повреждён
+const maxArrayDimensions = 32
+
+// Decode разбирает двоичное тело структуры по её описанию
+func Decode(def *Definition, b []byte) (*Structure, error) {
+	if def.Kind != KindStructure {
+		return nil, fmt.Errorf("%s is not a structure", def.Name)
+	}
+	buf := ua.NewBuffer(b)
+	s, err := decodeStructure(buf, def)
+	if err != nil {
+		return nil, err
+	}
+	if buf.Error() != nil {
+		return nil, fmt.Errorf("decode %s: %w", def.Name, buf.Error())
+	}
+	// Лишние байты означают, что описание не совпадает с данными сервера
+	if n := buf.Len(); n > 0 {
+		return nil, fmt.Errorf("decode %s: %d bytes left after the last field", def.Name, n)
+	}
+	return s, nil
+}
+
+// Encode реализует ua.BinaryEncoder, поэтому структуру можно передать
+// в ExtensionObject для записи на сервер
+func (s *Structure) Encode() ([]byte, error) {
+	buf := ua.NewBuffer(nil)
+	if err := encodeStructure(buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), buf.Error()
+}
+
+func decodeStructure(buf *ua.Buffer, def *Definition) (*Structure, error) {
+	s := &Structure{Type: def}
+
+	switch def.StructureType {
+	case ua.StructureTypeUnion, ua.StructureTypeUnionWithSubtypedValues:
+		sw := buf.ReadUint32()
+		if sw == 0 {
+			return s, nil
+		}
+		if int(sw) > len(def.Fields) {
+			return nil, fmt.Errorf("decode %s: invalid union switch %d", def.Name, sw)
+		}
+		f := def.Fields[sw-1]
+		v, err := decodeField(buf, f)
+		if err != nil {
+			return nil, err
+		}
+		s.Fields = append(s.Fields, FieldValue{f.Name, v})
+		return s, nil
+
+	case ua.StructureTypeStructureWithOptionalFields:
+		mask := buf.ReadUint32()
+		bit := 0
+		for _, f := range def.Fields {
+			if f.IsOptional {
+				present := mask&(1<<bit) != 0
+				bit++
+				if !present {
+					continue
+				}
+			}
+			v, err := decodeField(buf, f)
+			if err != nil {
+				return nil, err
+			}
+			s.Fields = append(s.Fields, FieldValue{f.Name, v})
+		}
+		return s, nil
+	}
+
+	for _, f := range def.Fields {
+		v, err := decodeField(buf, f)
+		if err != nil {
+			return nil, err
+		}
+		s.Fields = append(s.Fields, FieldValue{f.Name, v})
+	}
+	return s, nil
+}
+
+func decodeField(buf *ua.Buffer, f *Field) (interface{}, error) {
+	if f.Type == nil {
+		return nil, fmt.Errorf("field %s has unknown data type", f.Name)
+	}
+	if f.ValueRank < 1 {
+		return decodeValue(buf, f.Type)
+	}
+
+	// Многомерный массив: сначала размерности, затем элементы подряд
+	dims := []int32{-1}
+	if f.ValueRank > 1 {
+		n := buf.ReadInt32()
+		if n < 0 {
+			return nil, nil
+		}
+		if buf.Error() != nil {
+			return nil, buf.Error()
+		}
+		if n > maxArrayDimensions {
+			return nil, fmt.Errorf("field %s: too many array dimensions (%d)", f.Name, n)
+		}
+		dims = make([]int32, n)
+		for i := range dims {
+			dims[i] = buf.ReadInt32()
+		}
+	}
+
+	// Длина считается в int64 с проверкой на каждом шаге: размерности
+	// приходят с сервера и могут быть произвольными
+	var count int64
+	if len(dims) == 1 {
+		count = int64(buf.ReadInt32())
+		if count < 0 {
+			return nil, nil
+		}
+	} else {
+		count = 1
+		for _, d := range dims {
+			if d < 0 {
+				return nil, fmt.Errorf("field %s: invalid array dimension %d", f.Name, d)
+			}
+			if count *= int64(d); count > int64(ua.MaxVariantArrayLength) {
+				break
+			}
+		}
+	}
+	if buf.Error() != nil {
+		return nil, buf.Error()
+	}
+	if count > int64(ua.MaxVariantArrayLength) {
+		return nil, fmt.Errorf("field %s: array too long (%d)", f.Name, count)
+	}
+
+	items := make([]interface{}, count)
+	for i := range items {
+		v, err := decodeValue(buf, f.Type)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = v
+	}
+	if len(dims) > 1 {
+		return nest(items, dims), nil
+	}
+	return items, nil
+}
+
+func decodeValue(buf *ua.Buffer, def *Definition) (interface{}, error) {
+	switch def.Kind {
+	case KindEnum:
+		return NewEnum(def, buf.ReadInt32()), nil
+	case KindStructure:
+		return decodeStructure(buf, def)
+	}
+
+	switch def.Builtin {
+	case ua.TypeIDBoolean:
+		return buf.ReadBool(), nil
+	case ua.TypeIDSByte:
+		return buf.ReadInt8(), nil
+	case ua.TypeIDByte:
+		return buf.ReadByte(), nil
+	case ua.TypeIDInt16:
+		return buf.ReadInt16(), nil
+	case ua.TypeIDUint16:
+		return buf.ReadUint16(), nil
+	case ua.TypeIDInt32:
+		return buf.ReadInt32(), nil
+	case ua.TypeIDUint32:
+		return buf.ReadUint32(), nil
+	case ua.TypeIDInt64:
+		return buf.ReadInt64(), nil
+	case ua.TypeIDUint64:
+		return buf.ReadUint64(), nil
+	case ua.TypeIDFloat:
+		return buf.ReadFloat32(), nil
+	case ua.TypeIDDouble:
+		return buf.ReadFloat64(), nil
+	case ua.TypeIDString:
+		return buf.ReadString(), nil
+	case ua.TypeIDDateTime:
+		return buf.ReadTime(), nil
+	case ua.TypeIDByteString:
+		return buf.ReadBytes(), nil
+	case ua.TypeIDXMLElement:
+		return ua.XMLElement(buf.ReadString()), nil
+	case ua.TypeIDStatusCode:
+		return ua.StatusCode(buf.ReadUint32()), nil
+	}
+
+	v := newBuiltin(def.Builtin)
+	if v == nil {
+		return nil, fmt.Errorf("unsupported built-in type %d in %s", def.Builtin, def.Name)
+	}
+	buf.ReadStruct(v)
+	return v, buf.Error()
+}
+
+// newBuiltin создаёт значение встроенного типа, который декодирует себя сам
+func newBuiltin(t ua.TypeID) interface{} {
+	switch t {
+	case ua.TypeIDGUID:
+		return new(ua.GUID)
+	case ua.TypeIDNodeID:
+		return new(ua.NodeID)
+	case ua.TypeIDExpandedNodeID:
+		return new(ua.ExpandedNodeID)
+	case ua.TypeIDQualifiedName:
+		return new(ua.QualifiedName)
+	case ua.TypeIDLocalizedText:
+		return new(ua.LocalizedText)
+	case ua.TypeIDExtensionObject:
+		return new(ua.ExtensionObject)
+	case ua.TypeIDDataValue:
+		return new(ua.DataValue)
+	case ua.TypeIDVariant:
+		return new(ua.Variant)
+	case ua.TypeIDDiagnosticInfo:
+		return new(ua.DiagnosticInfo)
+	}
+	return nil
+}
+
+func encodeStructure(buf *ua.Buffer, s *Structure) error {
+	def := s.Type
+	if def == nil || def.Kind != KindStructure {
+		return fmt.Errorf("structure value has no type definition")
+	}
+
+	switch def.StructureType {
+	case ua.StructureTypeUnion, ua.StructureTypeUnionWithSubtypedValues:
+		if len(s.Fields) == 0 {
+			buf.WriteUint32(0)
+			return nil
+		}
+		if len(s.Fields) > 1 {
+			return fmt.Errorf("union %s must have exactly one field", def.Name)
+		}
+		for i, f := range def.Fields {
+			if f.Name == s.Fields[0].Name {
+				buf.WriteUint32(uint32(i + 1))
+				return encodeField(buf, f, s.Fields[0].Value)
+			}
+		}
+		return fmt.Errorf("union %s has no field %s", def.Name, s.Fields[0].Name)
+
+	case ua.StructureTypeStructureWithOptionalFields:
+		var mask uint32
+		bit := 0
+		for _, f := range def.Fields {
+			if !f.IsOptional {
+				continue
+			}
+			if _, ok := s.Field(f.Name); ok {
+				mask |= 1 << bit
+			}
+			bit++
+		}
+		buf.WriteUint32(mask)
+	}
+
+	for _, f := range def.Fields {
+		v, ok := s.Field(f.Name)
+		if !ok {
+			if f.IsOptional {
+				continue
+			}
+			return fmt.Errorf("%s: missing field %s", def.Name, f.Name)
+		}
+		if err := encodeField(buf, f, v); err != nil {
+			return err
+		}
+	}
+	return buf.Error()
+}
+
+func encodeField(buf *ua.Buffer, f *Field, v interface{}) error {
+	if f.Type == nil {
+		return fmt.Errorf("field %s has unknown data type", f.Name)
+	}
+	if f.ValueRank < 1 {
+		return encodeValue(buf, f.Type, v)
+	}
+
+	if v == nil {
+		// Пустой массив (null) кодируется длиной -1 в обоих случаях
+		buf.WriteInt32(-1)
+		return nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return fmt.Errorf("field %s: expected array, got %T", f.Name, v)
+	}
+
+	if f.ValueRank > 1 {
+		dims, flat, err := flattenNested(items, int(f.ValueRank))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		buf.WriteInt32(int32(len(dims)))
+		for _, d := range dims {
+			buf.WriteInt32(d)
+		}
+		items = flat
+	} else {
+		buf.WriteInt32(int32(len(items)))
+	}
+
+	for i, item := range items {
+		if err := encodeValue(buf, f.Type, item); err != nil {
+			return fmt.Errorf("field %s[%d]: %w", f.Name, i, err)
+		}
+	}
+	return nil
+}
+
+func encodeValue(buf *ua.Buffer, def *Definition, v interface{}) error {
+	switch def.Kind {
+	case KindEnum:
+		switch x := v.(type) {
+		case Enum:
+			buf.WriteInt32(x.Value)
+		case int32:
+			buf.WriteInt32(x)
+		default:
+			return fmt.Errorf("expected %s, got %T", def.Name, v)
+		}
+		return nil
+	case KindStructure:
+		s, ok := v.(*Structure)
+		if !ok {
+			return fmt.Errorf("expected %s, got %T", def.Name, v)
+		}
+		return encodeStructure(buf, s)
+	}
+
+	ok := true
+	switch def.Builtin {
+	case ua.TypeIDBoolean:
+		var x bool
+		x, ok = v.(bool)
+		buf.WriteBool(x)
+	case ua.TypeIDSByte:
+		var x int8
+		x, ok = v.(int8)
+		buf.WriteInt8(x)
+	case ua.TypeIDByte:
+		var x uint8
+		x, ok = v.(uint8)
+		buf.WriteUint8(x)
+	case ua.TypeIDInt16:
+		var x int16
+		x, ok = v.(int16)
+		buf.WriteInt16(x)
+	case ua.TypeIDUint16:
+		var x uint16
+		x, ok = v.(uint16)
+		buf.WriteUint16(x)
+	case ua.TypeIDInt32:
+		var x int32
+		x, ok = v.(int32)
+		buf.WriteInt32(x)
+	case ua.TypeIDUint32:
+		var x uint32
+		x, ok = v.(uint32)
+		buf.WriteUint32(x)
+	case ua.TypeIDInt64:
+		var x int64
+		x, ok = v.(int64)
+		buf.WriteInt64(x)
+	case ua.TypeIDUint64:
+		var x uint64
+		x, ok = v.(uint64)
+		buf.WriteUint64(x)
+	case ua.TypeIDFloat:
+		var x float32
+		x, ok = v.(float32)
+		buf.WriteFloat32(x)
+	case ua.TypeIDDouble:
+		var x float64
+		x, ok = v.(float64)
+		buf.WriteFloat64(x)
+	case ua.TypeIDString:
+		var x string
+		x, ok = v.(string)
+		buf.WriteString(x)
+	case ua.TypeIDXMLElement:
+		var x ua.XMLElement
+		x, ok = v.(ua.XMLElement)
+		buf.WriteString(string(x))
+	case ua.TypeIDDateTime:
+		var x time.Time
+		x, ok = v.(time.Time)
+		buf.WriteTime(x)
+	case ua.TypeIDByteString:
+		var x []byte
+		x, ok = v.([]byte)
+		buf.WriteByteString(x)
+	case ua.TypeIDStatusCode:
+		var x ua.StatusCode
+		x, ok = v.(ua.StatusCode)
+		buf.WriteUint32(uint32(x))
+	default:
+		if v == nil {
+			v = newBuiltin(def.Builtin)
+		}
+		if v == nil {
+			return fmt.Errorf("unsupported built-in type %d in %s", def.Builtin, def.Name)
+		}
+		buf.WriteStruct(v)
+	}
+	if !ok {
+		return fmt.Errorf("expected %s, got %T", def.Name, v)
+	}
+	return buf.Error()
+}
+
+// nest превращает плоский список элементов в вложенные массивы
+func nest(items []interface{}, dims []int32) interface{} {
+	if len(dims) == 1 {
+		return items
+	}
+	if dims[0] == 0 {
+		return []interface{}{}
+	}
+	step := len(items) / int(dims[0])
+	out := make([]interface{}, dims[0])
+	for i := range out {
+		out[i] = nest(items[i*step:(i+1)*step], dims[1:])
+	}
+	return out
+}
+
+// flattenNested раскладывает вложенные массивы глубины rank в плоский список
+// и вычисляет размерности
+func flattenNested(items []interface{}, rank int) ([]int32, []interface{}, error) {
+	if rank == 1 {
+		return []int32{int32(len(items))}, items, nil
+	}
+	var dims []int32
+	var flat []interface{}
+	for _, item := range items {
+		inner, ok := item.([]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("expected %d-dimensional array", rank)
+		}
+		d, f, err := flattenNested(inner, rank-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if dims != nil && fmt.Sprint(dims) != fmt.Sprint(d) {
+			return nil, nil, fmt.Errorf("unbalanced multi-dimensional array")
+		}
+		dims = d
+		flat = append(flat, f...)
+	}
+	if dims == nil {
+		dims = make([]int32, rank-1)
+	}
+	return append([]int32{int32(len(items))}, dims...), flat, nil
+}
@@ -0,0 +1,184 @@
+package datatype
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+func builtin(t ua.TypeID) *Definition {
+	return &Definition{Kind: KindBuiltin, Builtin: t}
+}
+
+// testTypes возвращает описание структуры, похожей на UDT контроллера:
+// вложенная структура, перечисление, массивы и многомерный массив
+func testTypes() *Definition {
+	mode := &Definition{Name: "Mode", Kind: KindEnum, EnumValues: []EnumValue{{0, "Stopped"}, {2, "Running"}}}
+	inner := &Definition{Name: "Limits", Kind: KindStructure, Fields: []*Field{
+		{Name: "Low", Type: builtin(ua.TypeIDDouble), ValueRank: -1},
+		{Name: "High", Type: builtin(ua.TypeIDDouble), ValueRank: -1},
+	}}
+	return &Definition{Name: "Motor", Kind: KindStructure, Fields: []*Field{
+		{Name: "Name", Type: builtin(ua.TypeIDString), ValueRank: -1},
+		{Name: "Mode", Type: mode, ValueRank: -1},
+		{Name: "Limits", Type: inner, ValueRank: -1},
+		{Name: "History", Type: builtin(ua.TypeIDInt16), ValueRank: 1},
+		{Name: "Matrix", Type: builtin(ua.TypeIDByte), ValueRank: 2},
+	}}
+}
+
+// TestDecodeStructure проверяет разбор двоичного тела структуры.
+//
+// Основные аспекты тестирования:
+// - Порядок и имена полей.
+// - Перечисление получает имя из описания типа.
+// - Вложенные структуры, одномерные и многомерные массивы.
+// - Лишние байты после последнего поля - ошибка.
+func TestDecodeStructure(t *testing.T) {
+	buf := ua.NewBuffer(nil)
+	buf.WriteString("M1")
+	buf.WriteInt32(2)
+	buf.WriteFloat64(0.5)
+	buf.WriteFloat64(99.5)
+	buf.WriteInt32(3)
+	buf.WriteInt16(1)
+	buf.WriteInt16(2)
+	buf.WriteInt16(3)
+	buf.WriteInt32(2) // число размерностей
+	buf.WriteInt32(2)
+	buf.WriteInt32(1)
+	buf.WriteUint8(7)
+	buf.WriteUint8(8)
+
+	s, err := Decode(testTypes(), buf.Bytes())
+	if err != nil {
+		t.Fatalf("Decode() получена непредвиденная ошибка = %v", err)
+	}
+
+	if v, _ := s.Field("Name"); v != "M1" {
+		t.Errorf("Name = %v", v)
+	}
+	if v, _ := s.Field("Mode"); v != (Enum{2, "Running"}) {
+		t.Errorf("Mode = %v", v)
+	}
+	limits, _ := s.Field("Limits")
+	if high, _ := limits.(*Structure).Field("High"); high != 99.5 {
+		t.Errorf("Limits.High = %v", high)
+	}
+	if v, _ := s.Field("History"); !reflect.DeepEqual(v, []interface{}{int16(1), int16(2), int16(3)}) {
+		t.Errorf("History = %v", v)
+	}
+	want := []interface{}{[]interface{}{uint8(7)}, []interface{}{uint8(8)}}
+	if v, _ := s.Field("Matrix"); !reflect.DeepEqual(v, want) {
+		t.Errorf("Matrix = %v", v)
+	}
+
+	// Обратное кодирование должно дать те же байты
+	b, err := s.Encode()
+	if err != nil {
+		t.Fatalf("Encode() получена непредвиденная ошибка = %v", err)
+	}
+	if !reflect.DeepEqual(b, buf.Bytes()) {
+		t.Errorf("Encode() = %x, ожидалось %x", b, buf.Bytes())
+	}
+
+	// Байты после последнего поля - описание не совпадает с данными
+	if _, err := Decode(testTypes(), append(buf.Bytes(), 0)); err == nil {
+		t.Errorf("Decode() с лишними байтами должна вернуть ошибку")
+	}
+}
+
+// TestOptionalFieldsAndUnion проверяет маску необязательных полей и объединения.
+func TestOptionalFieldsAndUnion(t *testing.T) {
+	opt := &Definition{Name: "Opt", Kind: KindStructure, StructureType: ua.StructureTypeStructureWithOptionalFields, Fields: []*Field{
+		{Name: "A", Type: builtin(ua.TypeIDInt32), ValueRank: -1},
+		{Name: "B", Type: builtin(ua.TypeIDInt32), ValueRank: -1, IsOptional: true},
+		{Name: "C", Type: builtin(ua.TypeIDInt32), ValueRank: -1, IsOptional: true},
+	}}
+	s := &Structure{Type: opt, Fields: []FieldValue{{"A", int32(1)}, {"C", int32(3)}}}
+	b, err := s.Encode()
+	if err != nil {
+		t.Fatalf("Encode() получена непредвиденная ошибка = %v", err)
+	}
+	// маска 0b10: присутствует только второе необязательное поле
+	want := []byte{2, 0, 0, 0, 1, 0, 0, 0, 3, 0, 0, 0}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("Encode() = %v, ожидалось %v", b, want)
+	}
+	got, err := Decode(opt, b)
+	if err != nil || !reflect.DeepEqual(got.Fields, s.Fields) {
+		t.Errorf("Decode() = %v, %v", got, err)
+	}
+
+	union := &Definition{Name: "U", Kind: KindStructure, StructureType: ua.StructureTypeUnion, Fields: []*Field{
+		{Name: "Int", Type: builtin(ua.TypeIDInt32), ValueRank: -1},
+		{Name: "Text", Type: builtin(ua.TypeIDString), ValueRank: -1},
+	}}
+	u := &Structure{Type: union, Fields: []FieldValue{{"Text", "abc"}}}
+	b, err = u.Encode()
+	if err != nil {
+		t.Fatalf("Encode() получена непредвиденная ошибка = %v", err)
+	}
+	got, err = Decode(union, b)
+	if err != nil || !reflect.DeepEqual(got.Fields, u.Fields) {
+		t.Errorf("Decode() = %v, %v", got, err)
+	}
+}
+
+// TestParseEnum проверяет разбор значения перечисления по имени и числу.
+func TestParseEnum(t *testing.T) {
+	def := &Definition{Name: "State", Kind: KindEnum, EnumValues: []EnumValue{{0, "Running"}, {1, "Failed"}}}
+	for _, in := range []string{"failed", "Failed_1", "1"} {
+		e, err := ParseEnum(def, in)
+		if err != nil || e != (Enum{1, "Failed"}) {
+			t.Errorf("ParseEnum(%q) = %v, %v", in, e, err)
+		}
+	}
+	if _, err := ParseEnum(def, "Unknown"); err == nil {
+		t.Errorf("ParseEnum() должна вернуть ошибку для неизвестного имени")
+	}
+	if s := NewEnum(def, 0).String(); s != "0 (Running)" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+// TestDecodeArrayDimensions проверяет разбор размерностей многомерного поля.
+//
+// Основные аспекты тестирования:
+// - Нулевая размерность даёт пустой массив.
+// - Отрицательные размерности отклоняются.
+// - Чрезмерное число размерностей отклоняется без выделения памяти.
+func TestDecodeArrayDimensions(t *testing.T) {
+	def := &Definition{Name: "M", Kind: KindStructure, Fields: []*Field{
+		{Name: "Matrix", Type: builtin(ua.TypeIDByte), ValueRank: 2},
+	}}
+	tests := []struct {
+		name    string
+		dims    []int32
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "Нулевая размерность", dims: []int32{2, 0, 3}, want: []interface{}{}},
+		{name: "Отрицательные размерности", dims: []int32{2, -1, -3}, wantErr: true},
+		{name: "Слишком много размерностей", dims: []int32{0x7fffffff}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := ua.NewBuffer(nil)
+			for _, d := range tt.dims {
+				buf.WriteInt32(d)
+			}
+			s, err := Decode(def, buf.Bytes())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() ошибка = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if v, _ := s.Field("Matrix"); !reflect.DeepEqual(v, tt.want) {
+				t.Errorf("Matrix = %#v, ожидалось %#v", v, tt.want)
+			}
+		})
+	}
+}
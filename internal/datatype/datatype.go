@@ -0,0 +1,205 @@
+// Package datatype описывает пользовательские типы данных сервера (структуры
+// и перечисления) и их двоичную кодировку (Part 6, 5.2.6-5.2.7)
+package datatype
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// Kind - вид типа данных
+type Kind int
+
+const (
+	// KindBuiltin - встроенный тип OPC UA или его простой подтип (Duration, UtcTime)
+	KindBuiltin Kind = iota
+	// KindStructure - структура
+	KindStructure
+	// KindEnum - перечисление, кодируется как Int32
+	KindEnum
+)
+
+// Definition описывает тип данных сервера
+type Definition struct {
+	// NodeID - узел DataType; для типов из словаря старого формата может быть nil
+	NodeID *ua.NodeID
+	Name   string
+	Kind   Kind
+
+	// Builtin - встроенный тип, которым кодируется значение (для KindBuiltin)
+	Builtin ua.TypeID
+
+	// EncodingID - узел двоичной кодировки структуры (Default Binary)
+	EncodingID    *ua.NodeID
+	StructureType ua.StructureType
+	Fields        []*Field
+
+	// EnumValues - значения перечисления
+	EnumValues []EnumValue
+}
+
+// Field - поле структуры
+type Field struct {
+	Name string
+	Type *Definition
+	// ValueRank: -1 для скаляра, 1 и более для массивов
+	ValueRank  int32
+	IsOptional bool
+}
+
+// EnumValue - элемент перечисления
+type EnumValue struct {
+	Value int64
+	Name  string
+}
+
+// Resolver загружает описания типов данных
+type Resolver interface {
+	// DataType возвращает описание типа по NodeId узла DataType
+	DataType(id *ua.NodeID) (*Definition, error)
+	// Encoding возвращает описание структуры по NodeId её двоичной кодировки
+	Encoding(id *ua.NodeID) (*Definition, error)
+}
+
+// Builtin возвращает описание для типов пространства имён 0, которые
+// кодируются встроенными типами: сами встроенные типы (i=1..25),
+// абстрактные числовые типы и Enumeration
+func Builtin(n *ua.NodeID) (*Definition, bool) {
+	if n == nil || n.Namespace() != 0 || n.Type() == ua.NodeIDTypeString {
+		return nil, false
+	}
+
+	i := n.IntID()
+	switch {
+	case i >= 1 && i <= 25:
+		return &Definition{NodeID: n, Name: id.Name(i), Kind: KindBuiltin, Builtin: ua.TypeID(i)}, true
+	case i == id.Number || i == id.Integer || i == id.UInteger:
+		// Абстрактные типы кодируются как Variant
+		return &Definition{NodeID: n, Name: id.Name(i), Kind: KindBuiltin, Builtin: ua.TypeIDVariant}, true
+	case i == id.Enumeration:
+		return &Definition{NodeID: n, Name: id.Name(i), Kind: KindEnum}, true
+	}
+	return nil, false
+}
+
+// Enum - значение перечисления
+type Enum struct {
+	Value int32
+	Name  string
+}
+
+// String возвращает значение в виде "2 (Running)"
+func (e Enum) String() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%d", e.Value)
+	}
+	return fmt.Sprintf("%d (%s)", e.Value, e.Name)
+}
+
+// NewEnum создаёт значение перечисления, подставляя имя из описания типа
+func NewEnum(def *Definition, v int32) Enum {
+	e := Enum{Value: v}
+	if def != nil {
+		for _, ev := range def.EnumValues {
+			if ev.Value == int64(v) {
+				e.Name = ev.Name
+				break
+			}
+		}
+	}
+	return e
+}
+
+// ParseEnum находит значение перечисления по имени ("Running"),
+// по записи вида "Running_2" или по числу
+func ParseEnum(def *Definition, s string) (Enum, error) {
+	for _, ev := range def.EnumValues {
+		if strings.EqualFold(ev.Name, s) || strings.EqualFold(fmt.Sprintf("%s_%d", ev.Name, ev.Value), s) {
+			return Enum{Value: int32(ev.Value), Name: ev.Name}, nil
+		}
+	}
+	var n int32
+	if _, err := fmt.Sscan(s, &n); err != nil {
+		return Enum{}, fmt.Errorf("unknown value %q of enumeration %s", s, def.Name)
+	}
+	return NewEnum(def, n), nil
+}
+
+// Structure - значение структуры. Fields содержит только присутствующие поля
+// в порядке описания типа.
+type Structure struct {
+	Type   *Definition
+	Fields []FieldValue
+}
+
+// FieldValue - значение поля структуры. Массивы представлены как []interface{},
+// вложенные структуры - как *Structure, перечисления - как Enum.
+type FieldValue struct {
+	Name  string
+	Value interface{}
+}
+
+// Field возвращает значение поля по имени
+func (s *Structure) Field(name string) (interface{}, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// RawBody хранит тело ExtensionObject в двоичной кодировке без разбора.
+// Используется для структур, тип которых ещё не загружен с сервера.
+type RawBody []byte
+
+// Encode реализует ua.BinaryEncoder
+func (b RawBody) Encode() ([]byte, error) {
+	return b, nil
+}
+
+// Decode реализует ua.BinaryDecoder. gopcua передаёт тело ExtensionObject
+// целиком, поэтому оно копируется без разбора.
+func (b *RawBody) Decode(data []byte) (int, error) {
+	*b = append(RawBody(nil), data...)
+	return len(data), nil
+}
+
+// Enumerate заменяет целые числа (скаляр или массив) значениями перечисления.
+// Значения других типов возвращаются без изменений.
+func Enumerate(def *Definition, v interface{}) interface{} {
+	switch x := v.(type) {
+	case int8:
+		return NewEnum(def, int32(x))
+	case uint8:
+		return NewEnum(def, int32(x))
+	case int16:
+		return NewEnum(def, int32(x))
+	case uint16:
+		return NewEnum(def, int32(x))
+	case int32:
+		return NewEnum(def, x)
+	case uint32:
+		return NewEnum(def, int32(x))
+	case int64:
+		return NewEnum(def, int32(x))
+	case uint64:
+		return NewEnum(def, int32(x))
+	case []int32:
+		out := make([]interface{}, len(x))
+		for i, n := range x {
+			out[i] = NewEnum(def, n)
+		}
+		return out
+	case []uint32:
+		out := make([]interface{}, len(x))
+		for i, n := range x {
+			out[i] = NewEnum(def, int32(n))
+		}
+		return out
+	}
+	return v
+}
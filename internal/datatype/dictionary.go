@@ -0,0 +1,155 @@
+package datatype
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// typeDictionary - словарь типов в формате OPC Binary (Part 3, Annex C),
+// который серверы до версии 1.04 публикуют вместо DataTypeDefinition
+type typeDictionary struct {
+	StructuredTypes []structuredType `xml:"StructuredType"`
+	EnumeratedTypes []enumeratedType `xml:"EnumeratedType"`
+}
+
+type structuredType struct {
+	Name   string        `xml:"Name,attr"`
+	Fields []binaryField `xml:"Field"`
+}
+
+type binaryField struct {
+	Name        string `xml:"Name,attr"`
+	TypeName    string `xml:"TypeName,attr"`
+	LengthField string `xml:"LengthField,attr"`
+	SwitchField string `xml:"SwitchField,attr"`
+	SwitchValue string `xml:"SwitchValue,attr"`
+	Length      int    `xml:"Length,attr"`
+}
+
+type enumeratedType struct {
+	Name   string `xml:"Name,attr"`
+	Values []struct {
+		Name  string `xml:"Name,attr"`
+		Value int64  `xml:"Value,attr"`
+	} `xml:"EnumeratedValue"`
+}
+
+// binarySchemaTypes сопоставляет имена типов OPC Binary встроенным типам
+var binarySchemaTypes = map[string]ua.TypeID{
+	"Boolean":         ua.TypeIDBoolean,
+	"SByte":           ua.TypeIDSByte,
+	"Byte":            ua.TypeIDByte,
+	"Int16":           ua.TypeIDInt16,
+	"UInt16":          ua.TypeIDUint16,
+	"Int32":           ua.TypeIDInt32,
+	"UInt32":          ua.TypeIDUint32,
+	"Int64":           ua.TypeIDInt64,
+	"UInt64":          ua.TypeIDUint64,
+	"Float":           ua.TypeIDFloat,
+	"Double":          ua.TypeIDDouble,
+	"String":          ua.TypeIDString,
+	"CharArray":       ua.TypeIDString,
+	"DateTime":        ua.TypeIDDateTime,
+	"Guid":            ua.TypeIDGUID,
+	"ByteString":      ua.TypeIDByteString,
+	"XmlElement":      ua.TypeIDXMLElement,
+	"NodeId":          ua.TypeIDNodeID,
+	"ExpandedNodeId":  ua.TypeIDExpandedNodeID,
+	"StatusCode":      ua.TypeIDStatusCode,
+	"QualifiedName":   ua.TypeIDQualifiedName,
+	"LocalizedText":   ua.TypeIDLocalizedText,
+	"ExtensionObject": ua.TypeIDExtensionObject,
+	"DataValue":       ua.TypeIDDataValue,
+	"Variant":         ua.TypeIDVariant,
+	"DiagnosticInfo":  ua.TypeIDDiagnosticInfo,
+}
+
+// ParseDictionary разбирает словарь типов OPC Binary и возвращает описания
+// всех структур и перечислений по их именам.
+//
+// Поля-длины (NoOfX) становятся массивами, а битовые поля-переключатели -
+// признаками необязательных полей, как в DataTypeDefinition.
+func ParseDictionary(data []byte) (map[string]*Definition, error) {
+	var dict typeDictionary
+	if err := xml.Unmarshal(data, &dict); err != nil {
+		return nil, fmt.Errorf("invalid type dictionary: %w", err)
+	}
+
+	defs := make(map[string]*Definition)
+	for _, et := range dict.EnumeratedTypes {
+		def := &Definition{Name: et.Name, Kind: KindEnum}
+		for _, v := range et.Values {
+			def.EnumValues = append(def.EnumValues, EnumValue{Value: v.Value, Name: v.Name})
+		}
+		defs[et.Name] = def
+	}
+	for _, st := range dict.StructuredTypes {
+		defs[st.Name] = &Definition{Name: st.Name, Kind: KindStructure}
+	}
+
+	for _, st := range dict.StructuredTypes {
+		if err := fillStructure(defs[st.Name], st, defs); err != nil {
+			return nil, err
+		}
+	}
+	return defs, nil
+}
+
+func fillStructure(def *Definition, st structuredType, defs map[string]*Definition) error {
+	lengthFields := make(map[string]bool)
+	switchFields := make(map[string]bool)
+	union := false
+	for _, f := range st.Fields {
+		if f.LengthField != "" {
+			lengthFields[f.LengthField] = true
+		}
+		if f.SwitchField != "" {
+			switchFields[f.SwitchField] = true
+			if f.SwitchValue != "" {
+				union = true
+			}
+		}
+	}
+
+	switch {
+	case union:
+		def.StructureType = ua.StructureTypeUnion
+	case len(switchFields) > 0:
+		def.StructureType = ua.StructureTypeStructureWithOptionalFields
+	}
+
+	for _, f := range st.Fields {
+		typeName := localName(f.TypeName)
+		// Служебные поля: длины массивов, биты маски и поле выбора объединения
+		// уже учтены в кодировке массивов и необязательных полей
+		if lengthFields[f.Name] || switchFields[f.Name] || typeName == "Bit" {
+			continue
+		}
+
+		field := &Field{Name: f.Name, ValueRank: -1, IsOptional: f.SwitchField != "" && !union}
+		if f.LengthField != "" {
+			field.ValueRank = 1
+		}
+
+		if t, ok := binarySchemaTypes[typeName]; ok {
+			field.Type = &Definition{Name: typeName, Kind: KindBuiltin, Builtin: t}
+		} else if nested, ok := defs[typeName]; ok {
+			field.Type = nested
+		} else {
+			return fmt.Errorf("%s.%s: unknown type %s", st.Name, f.Name, f.TypeName)
+		}
+		def.Fields = append(def.Fields, field)
+	}
+	return nil
+}
+
+// localName убирает префикс пространства имён XML (opc:Int32 -> Int32)
+func localName(s string) string {
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package datatype
+
+import (
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+const testDictionary = `<?xml version="1.0" encoding="utf-8"?>
+<opc:TypeDictionary xmlns:opc="http://opcfoundation.org/BinarySchema/"
+    xmlns:ua="http://opcfoundation.org/UA/" xmlns:tns="urn:plc"
+    DefaultByteOrder="LittleEndian" TargetNamespace="urn:plc">
+  <opc:Import Namespace="http://opcfoundation.org/UA/" />
+  <opc:EnumeratedType Name="Mode" LengthInBits="32">
+    <opc:EnumeratedValue Name="Off" Value="0" />
+    <opc:EnumeratedValue Name="Auto" Value="1" />
+  </opc:EnumeratedType>
+  <opc:StructuredType Name="Recipe" BaseType="ua:ExtensionObject">
+    <opc:Field TypeName="opc:Bit" Name="CommentSpecified" />
+    <opc:Field Length="31" TypeName="opc:Bit" Name="Reserved1" />
+    <opc:Field TypeName="opc:CharArray" Name="Name" />
+    <opc:Field TypeName="tns:Mode" Name="Mode" />
+    <opc:Field TypeName="opc:Int32" Name="NoOfSteps" />
+    <opc:Field LengthField="NoOfSteps" TypeName="opc:Double" Name="Steps" />
+    <opc:Field SwitchField="CommentSpecified" TypeName="ua:LocalizedText" Name="Comment" />
+  </opc:StructuredType>
+</opc:TypeDictionary>`
+
+// TestParseDictionary проверяет преобразование словаря OPC Binary в описания типов.
+//
+// Основные аспекты тестирования:
+// - Поля-длины превращаются в массивы и не попадают в список полей.
+// - Битовые поля становятся маской необязательных полей.
+// - Ссылки tns: на другие типы словаря и перечисления.
+func TestParseDictionary(t *testing.T) {
+	defs, err := ParseDictionary([]byte(testDictionary))
+	if err != nil {
+		t.Fatalf("ParseDictionary() получена непредвиденная ошибка = %v", err)
+	}
+
+	recipe, ok := defs["Recipe"]
+	if !ok {
+		t.Fatalf("тип Recipe не найден")
+	}
+	if recipe.StructureType != ua.StructureTypeStructureWithOptionalFields {
+		t.Errorf("StructureType = %v", recipe.StructureType)
+	}
+
+	var names []string
+	for _, f := range recipe.Fields {
+		names = append(names, f.Name)
+	}
+	if got := len(names); got != 4 {
+		t.Fatalf("поля = %v, ожидалось Name, Mode, Steps, Comment", names)
+	}
+	if recipe.Fields[1].Type.Kind != KindEnum || len(recipe.Fields[1].Type.EnumValues) != 2 {
+		t.Errorf("поле Mode должно быть перечислением: %+v", recipe.Fields[1].Type)
+	}
+	if recipe.Fields[2].ValueRank != 1 || recipe.Fields[2].Type.Builtin != ua.TypeIDDouble {
+		t.Errorf("поле Steps должно быть массивом Double: %+v", recipe.Fields[2])
+	}
+	if !recipe.Fields[3].IsOptional {
+		t.Errorf("поле Comment должно быть необязательным")
+	}
+}
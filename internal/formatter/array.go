@@ -0,0 +1,223 @@
+package formatter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/ua"
+)
+
+// ParseArray разбирает массив значений типа t, введённый текстом: [1, 2, 3]
+// или многомерный [[1, 2], [3, 4]]. Элементы разбирает elem (nil - Parse);
+// элементы с запятыми и скобками записываются в кавычках: ["a, b", "c"].
+// Возвращает срез (для многомерного - срез срезов) типа, которым gopcua
+// представляет t.
+func ParseArray(t ua.TypeID, s string, elem func(string) (interface{}, error)) (interface{}, error) {
+	leaf, ok := goType(t)
+	if !ok {
+		return nil, fmt.Errorf("arrays of %s cannot be entered as text", TypeName(t))
+	}
+	if elem == nil {
+		elem = func(s string) (interface{}, error) { return Parse(t, s) }
+	}
+
+	p := &arrayParser{s: s}
+	p.space()
+	items, err := p.array()
+	if err != nil {
+		return nil, err
+	}
+	if p.space(); p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q after array", p.s[p.pos:])
+	}
+
+	// Глубина вложенности определяется по первым элементам; типы срезов
+	// строятся от элемента наружу. Массив Byte в gopcua - ua.ByteArray:
+	// []byte кодируется как ByteString.
+	depth := 1
+	for x := interface{}(items); ; depth++ {
+		inner, ok := x.([]interface{})
+		if !ok || len(inner) == 0 {
+			break
+		}
+		if _, ok := inner[0].([]interface{}); !ok {
+			break
+		}
+		x = inner[0]
+	}
+	types := make([]reflect.Type, depth+1)
+	types[0] = leaf
+	for i := 1; i <= depth; i++ {
+		if i == 1 && t == ua.TypeIDByte {
+			types[i] = reflect.TypeOf(ua.ByteArray{})
+			continue
+		}
+		types[i] = reflect.SliceOf(types[i-1])
+	}
+
+	v, err := buildArray(items, depth, types, elem)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// buildArray преобразует разобранные элементы уровня level в срез
+// types[level]; на уровне 0 элементы - строки значений
+func buildArray(x interface{}, level int, types []reflect.Type, elem func(string) (interface{}, error)) (reflect.Value, error) {
+	if level == 0 {
+		s, ok := x.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("array dimensions do not match")
+		}
+		v, err := elem(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || !rv.Type().ConvertibleTo(types[0]) {
+			return reflect.Value{}, fmt.Errorf("invalid %s value %q", types[0], s)
+		}
+		return rv.Convert(types[0]), nil
+	}
+
+	items, ok := x.([]interface{})
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("array dimensions do not match")
+	}
+	out := reflect.MakeSlice(types[level], len(items), len(items))
+	for i, item := range items {
+		v, err := buildArray(item, level-1, types, elem)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.Index(i).Set(v)
+	}
+	// Длины вложенных массивов одного уровня должны совпадать
+	if level > 1 {
+		for i := 1; i < len(items); i++ {
+			if out.Index(i).Len() != out.Index(0).Len() {
+				return reflect.Value{}, fmt.Errorf("array dimensions do not match")
+			}
+		}
+	}
+	return out, nil
+}
+
+// arrayParser разбирает текст массива на вложенные []interface{} со
+// строками значений
+type arrayParser struct {
+	s   string
+	pos int
+}
+
+func (p *arrayParser) space() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *arrayParser) array() ([]interface{}, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '[' {
+		return nil, fmt.Errorf("array must start with [")
+	}
+	p.pos++
+	items := []interface{}{}
+	p.space()
+	if p.pos < len(p.s) && p.s[p.pos] == ']' {
+		p.pos++
+		return items, nil
+	}
+	for {
+		p.space()
+		var (
+			item interface{}
+			err  error
+		)
+		switch {
+		case p.pos < len(p.s) && p.s[p.pos] == '[':
+			item, err = p.array()
+		case p.pos < len(p.s) && p.s[p.pos] == '"':
+			item, err = p.quoted()
+		default:
+			end := strings.IndexAny(p.s[p.pos:], ",]")
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] at end of array")
+			}
+			item = strings.TrimSpace(p.s[p.pos : p.pos+end])
+			p.pos += end
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		p.space()
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("missing ] at end of array")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return items, nil
+		default:
+			return nil, fmt.Errorf("expected , or ] at %q", p.s[p.pos:])
+		}
+	}
+}
+
+// quoted разбирает строку в кавычках с экранированием, как в Go и JSON
+func (p *arrayParser) quoted() (string, error) {
+	for end := p.pos + 1; end < len(p.s); end++ {
+		switch p.s[end] {
+		case '\\':
+			end++
+		case '"':
+			s, err := strconv.Unquote(p.s[p.pos : end+1])
+			if err != nil {
+				return "", fmt.Errorf("invalid string %s: %w", p.s[p.pos:end+1], err)
+			}
+			p.pos = end + 1
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("missing closing quote")
+}
+
+// PrettyDataValue - то же, что EnumDataValue, для вывода значения отдельно
+// от таблиц: многомерный массив выводится по строке матрицы на строку
+func PrettyDataValue(dv *ua.DataValue, def *datatype.Definition) string {
+	if dv == nil || dv.Value == nil || len(dv.Value.ArrayDimensions()) < 2 {
+		return EnumDataValue(dv, def)
+	}
+	s := matrixText(dv.Value.Value(), def, "")
+	if dv.Status != ua.StatusOK {
+		s += fmt.Sprintf(" [%s]", StatusName(dv.Status))
+	}
+	return s
+}
+
+// matrixText выводит вложенные массивы с переносом строки между
+// элементами внешних измерений и выравниванием по открывающей скобке
+func matrixText(v interface{}, def *datatype.Definition, indent string) string {
+	items, ok := sliceItems(v)
+	if !ok || len(items) == 0 {
+		return text(v)
+	}
+	if _, nested := sliceItems(items[0]); !nested {
+		if def != nil {
+			return text(datatype.Enumerate(def, v))
+		}
+		return text(v)
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = matrixText(item, def, indent+" ")
+	}
+	return "[" + strings.Join(parts, ",\n"+indent+" ") + "]"
+}
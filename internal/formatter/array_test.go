@@ -0,0 +1,79 @@
+package formatter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestParseArray проверяет разбор массивов, введённых текстом.
+//
+// Основные аспекты тестирования:
+// - Одномерные и многомерные массивы получают тип элементов gopcua.
+// - Строки в кавычках могут содержать запятые и скобки.
+// - Массив Byte разбирается в ua.ByteArray, а не в ByteString.
+// - Ошибки: непрямоугольная матрица, неверный элемент, лишний текст.
+func TestParseArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     ua.TypeID
+		input   string
+		want    interface{}
+		wantErr string
+	}{
+		{"Int32", ua.TypeIDInt32, "[1, 2, 3]", []int32{1, 2, 3}, ""},
+		{"Пустой", ua.TypeIDDouble, "[]", []float64{}, ""},
+		{"Матрица", ua.TypeIDDouble, "[[1, 2.5], [3, 4]]", [][]float64{{1, 2.5}, {3, 4}}, ""},
+		{"Строки", ua.TypeIDString, `["a, b", c, "[d]"]`, []string{"a, b", "c", "[d]"}, ""},
+		{"Byte", ua.TypeIDByte, "[1, 255]", ua.ByteArray{1, 255}, ""},
+		{"Непрямоугольная матрица", ua.TypeIDInt32, "[[1, 2], [3]]", nil, "array dimensions do not match"},
+		{"Разная вложенность", ua.TypeIDInt32, "[[1], 2]", nil, "array dimensions do not match"},
+		{"Неверный элемент", ua.TypeIDInt32, "[1, x]", nil, "invalid syntax"},
+		{"Текст после массива", ua.TypeIDInt32, "[1] 2", nil, "unexpected"},
+		{"Без скобки", ua.TypeIDInt32, "[1, 2", nil, "missing ]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseArray(tt.typ, tt.input, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseArray(%q) ошибка = %v, ожидалась %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArray(%q) получена непредвиденная ошибка = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseArray(%q) = %#v, ожидалось %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPrettyDataValue проверяет вывод многомерных массивов по строкам.
+//
+// Основные аспекты тестирования:
+// - Матрица выводится по строке на строку, трёхмерный массив - блоками.
+// - Одномерный массив выводится в одну строку, как Value.
+func TestPrettyDataValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"Одномерный", []int32{1, 2, 3}, "[1, 2, 3]"},
+		{"Матрица", [][]int32{{1, 2, 3}, {4, 5, 6}}, "[[1, 2, 3],\n [4, 5, 6]]"},
+		{"Трёхмерный", [][][]int32{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}}, "[[[1, 2],\n  [3, 4]],\n [[5, 6],\n  [7, 8]]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dv := &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(tt.value)}
+			if got := PrettyDataValue(dv, nil); got != tt.want {
+				t.Errorf("PrettyDataValue() = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package formatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// accessLevelFlags - биты AccessLevel и UserAccessLevel (Part 3, 8.57)
+var accessLevelFlags = []string{
+	"CurrentRead", "CurrentWrite", "HistoryRead", "HistoryWrite",
+	"SemanticChange", "StatusWrite", "TimestampWrite",
+}
+
+// eventNotifierFlags - биты EventNotifier (Part 3, 8.59); бит 1 зарезервирован
+var eventNotifierFlags = []string{"SubscribeToEvents", "", "HistoryRead", "HistoryWrite"}
+
+// writeMaskFlags - биты WriteMask и UserWriteMask (Part 3, 8.60)
+var writeMaskFlags = []string{
+	"AccessLevel", "ArrayDimensions", "BrowseName", "ContainsNoLoops", "DataType",
+	"Description", "DisplayName", "EventNotifier", "Executable", "Historizing",
+	"InverseName", "IsAbstract", "MinimumSamplingInterval", "NodeClass", "NodeId",
+	"Symmetric", "UserAccessLevel", "UserExecutable", "UserWriteMask", "ValueRank",
+	"WriteMask", "ValueForVariableType", "DataTypeDefinition", "RolePermissions",
+	"AccessRestrictions", "AccessLevelEx",
+}
+
+// AttributeName возвращает имя атрибута в написании спецификации (NodeId, DataType)
+func AttributeName(a ua.AttributeID) string {
+	return fieldName(strings.TrimPrefix(a.String(), "AttributeID"))
+}
+
+// NodeClassName возвращает имя класса узла (Variable, Object, ...)
+func NodeClassName(c ua.NodeClass) string {
+	return strings.TrimPrefix(c.String(), "NodeClass")
+}
+
+// AccessLevel раскладывает AccessLevel на флаги: "CurrentRead, CurrentWrite"
+func AccessLevel(v uint8) string {
+	return flags(uint32(v), accessLevelFlags)
+}
+
+// EventNotifier раскладывает EventNotifier на флаги
+func EventNotifier(v uint8) string {
+	return flags(uint32(v), eventNotifierFlags)
+}
+
+// WriteMask раскладывает WriteMask на имена атрибутов, доступных для записи
+func WriteMask(v uint32) string {
+	return flags(v, writeMaskFlags)
+}
+
+// flags перечисляет установленные биты через запятую. Биты без имени
+// выводятся номером, нулевое значение - как None.
+func flags(v uint32, names []string) string {
+	if v == 0 {
+		return "None"
+	}
+	var parts []string
+	for bit := uint(0); bit < 32; bit++ {
+		if v&(1<<bit) == 0 {
+			continue
+		}
+		if int(bit) < len(names) && names[bit] != "" {
+			parts = append(parts, names[bit])
+		} else {
+			parts = append(parts, "Bit"+strconv.Itoa(int(bit)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseAttribute разбирает имя атрибута (DisplayName, writemask) или его номер
+func ParseAttribute(s string) (ua.AttributeID, error) {
+	for a := ua.AttributeIDNodeID; a <= ua.AttributeIDAccessLevelEx; a++ {
+		if strings.EqualFold(AttributeName(a), s) {
+			return a, nil
+		}
+	}
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil && n >= uint64(ua.AttributeIDNodeID) && n <= uint64(ua.AttributeIDAccessLevelEx) {
+		return ua.AttributeID(n), nil
+	}
+	return 0, fmt.Errorf("unknown attribute: %s", s)
+}
+
+// ParseAttributeValue разбирает значение атрибута a, введённое текстом.
+// Битовые атрибуты принимают число или имена флагов через запятую
+// ("CurrentRead, CurrentWrite"), DataType - NodeId или имя встроенного типа,
+// ArrayDimensions - массив [2, 3]. Value разбирается по типу значения узла,
+// а не здесь.
+func ParseAttributeValue(a ua.AttributeID, s string) (*ua.Variant, error) {
+	var (
+		v   interface{}
+		err error
+	)
+	switch a {
+	case ua.AttributeIDBrowseName:
+		v, err = Parse(ua.TypeIDQualifiedName, s)
+	case ua.AttributeIDDisplayName, ua.AttributeIDDescription, ua.AttributeIDInverseName:
+		v, err = Parse(ua.TypeIDLocalizedText, s)
+	case ua.AttributeIDWriteMask, ua.AttributeIDUserWriteMask:
+		v, err = parseFlags(s, writeMaskFlags, 32)
+	case ua.AttributeIDAccessLevel, ua.AttributeIDUserAccessLevel:
+		var n uint32
+		n, err = parseFlags(s, accessLevelFlags, 8)
+		v = uint8(n)
+	case ua.AttributeIDEventNotifier:
+		var n uint32
+		n, err = parseFlags(s, eventNotifierFlags, 8)
+		v = uint8(n)
+	case ua.AttributeIDIsAbstract, ua.AttributeIDSymmetric, ua.AttributeIDContainsNoLoops,
+		ua.AttributeIDHistorizing, ua.AttributeIDExecutable, ua.AttributeIDUserExecutable:
+		v, err = Parse(ua.TypeIDBoolean, s)
+	case ua.AttributeIDMinimumSamplingInterval:
+		v, err = Parse(ua.TypeIDDouble, s)
+	case ua.AttributeIDValueRank:
+		v, err = Parse(ua.TypeIDInt32, s)
+	case ua.AttributeIDDataType:
+		if t, ok := ParseTypeName(s); ok {
+			v = ua.NewNumericNodeID(0, uint32(t))
+		} else {
+			v, err = Parse(ua.TypeIDNodeID, s)
+		}
+	case ua.AttributeIDArrayDimensions:
+		v, err = ParseArray(ua.TypeIDUint32, s, nil)
+	default:
+		return nil, fmt.Errorf("%s cannot be entered as text, use the JSON form", AttributeName(a))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q: %w", AttributeName(a), s, err)
+	}
+	return ua.NewVariant(v)
+}
+
+// parseFlags разбирает число или имена флагов через запятую в битовую маску
+// размером bits; None - нулевое значение
+func parseFlags(s string, names []string, bits int) (uint32, error) {
+	if n, err := parseUint(s, bits); err == nil {
+		return uint32(n), nil
+	}
+	var v uint32
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.EqualFold(name, "None") {
+			continue
+		}
+		bit := -1
+		for i, flag := range names {
+			if flag != "" && strings.EqualFold(flag, name) {
+				bit = i
+				break
+			}
+		}
+		if bit < 0 {
+			return 0, fmt.Errorf("unknown flag %s", name)
+		}
+		v |= 1 << uint(bit)
+	}
+	return v, nil
+}
+
+// referenceTypes - стандартные типы ссылок, которые можно указать по имени
+var referenceTypes = []uint32{
+	id.References, id.NonHierarchicalReferences, id.HierarchicalReferences,
+	id.HasChild, id.Organizes, id.HasEventSource, id.HasModellingRule,
+	id.HasEncoding, id.HasDescription, id.HasTypeDefinition, id.GeneratesEvent,
+	id.Aggregates, id.HasSubtype, id.HasProperty, id.HasComponent, id.HasNotifier,
+	id.HasOrderedComponent, id.HasInterface, id.HasAddIn,
+}
+
+// ParseReferenceType разбирает тип ссылки, заданный именем
+// (HierarchicalReferences) или NodeId
+func ParseReferenceType(s string) (*ua.NodeID, error) {
+	for _, ref := range referenceTypes {
+		if strings.EqualFold(id.Name(ref), s) {
+			return ua.NewNumericNodeID(0, ref), nil
+		}
+	}
+	n, err := ResolveNodeID(s)
+	if err != nil {
+		return nil, fmt.Errorf("unknown reference type %s: %w", s, err)
+	}
+	return n, nil
+}
+
+// ParseNodeClasses разбирает список классов узлов через запятую
+// ("Variable,Object") в маску NodeClassMask
+func ParseNodeClasses(s string) (uint32, error) {
+	var mask uint32
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var found bool
+		for c := ua.NodeClassObject; c <= ua.NodeClassView; c <<= 1 {
+			if strings.EqualFold(NodeClassName(c), name) {
+				mask |= uint32(c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown node class: %s", name)
+		}
+	}
+	return mask, nil
+}
@@ -0,0 +1,113 @@
+package formatter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestAttributeFlags проверяет расшифровку битовых атрибутов узла.
+//
+// Основные аспекты тестирования:
+// - Раскладка AccessLevel, EventNotifier и WriteMask на имена флагов.
+// - Нулевое значение и биты без имени.
+// - Имена атрибутов и классов узлов в написании спецификации.
+func TestAttributeFlags(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{AccessLevel(0x05), "CurrentRead, HistoryRead"},
+		{AccessLevel(0), "None"},
+		{AccessLevel(0x80), "Bit7"},
+		{EventNotifier(0x05), "SubscribeToEvents, HistoryRead"},
+		{WriteMask(uint32(ua.AttributeWriteMaskDisplayName | ua.AttributeWriteMaskNodeID)), "DisplayName, NodeId"},
+		{AttributeName(ua.AttributeIDNodeID), "NodeId"},
+		{AttributeName(ua.AttributeIDMinimumSamplingInterval), "MinimumSamplingInterval"},
+		{NodeClassName(ua.NodeClassVariableType), "VariableType"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("получено %q, ожидалось %q", tt.got, tt.want)
+		}
+	}
+}
+
+// TestParseWalkFilters проверяет разбор фильтров обхода адресного пространства.
+func TestParseWalkFilters(t *testing.T) {
+	mask, err := ParseNodeClasses("Variable, object")
+	if err != nil || mask != uint32(ua.NodeClassVariable|ua.NodeClassObject) {
+		t.Errorf("ParseNodeClasses() = %d, %v", mask, err)
+	}
+	if _, err := ParseNodeClasses("Tag"); err == nil {
+		t.Errorf("ParseNodeClasses() должна вернуть ошибку для неизвестного класса")
+	}
+
+	ref, err := ParseReferenceType("hierarchicalreferences")
+	if err != nil || ref.IntID() != 33 {
+		t.Errorf("ParseReferenceType() = %v, %v", ref, err)
+	}
+	if ref, err := ParseReferenceType("ns=2;i=4001"); err != nil || ref.Namespace() != 2 {
+		t.Errorf("ParseReferenceType() для NodeId = %v, %v", ref, err)
+	}
+}
+
+// TestParseAttributeValue проверяет разбор значений атрибутов для записи.
+//
+// Основные аспекты тестирования:
+// - Имена атрибутов без учёта регистра и номера атрибутов.
+// - Битовые атрибуты принимают число и имена флагов, None - ноль.
+// - DataType принимает имя встроенного типа и NodeId.
+// - Ошибки: неизвестный атрибут и флаг, атрибут без текстовой формы.
+func TestParseAttributeValue(t *testing.T) {
+	if a, err := ParseAttribute("displayname"); err != nil || a != ua.AttributeIDDisplayName {
+		t.Errorf("ParseAttribute(displayname) = %v, %v", a, err)
+	}
+	if a, err := ParseAttribute("6"); err != nil || a != ua.AttributeIDWriteMask {
+		t.Errorf("ParseAttribute(6) = %v, %v", a, err)
+	}
+	if _, err := ParseAttribute("Colour"); err == nil {
+		t.Errorf("ParseAttribute() должна вернуть ошибку для неизвестного атрибута")
+	}
+
+	tests := []struct {
+		name    string
+		attr    ua.AttributeID
+		input   string
+		want    interface{}
+		wantErr string
+	}{
+		{"Description", ua.AttributeIDDescription, "Температура котла", ua.NewLocalizedText("Температура котла"), ""},
+		{"BrowseName", ua.AttributeIDBrowseName, "2:Boiler", &ua.QualifiedName{NamespaceIndex: 2, Name: "Boiler"}, ""},
+		{"WriteMask именами", ua.AttributeIDWriteMask, "DisplayName, description", uint32(ua.AttributeWriteMaskDisplayName | ua.AttributeWriteMaskDescription), ""},
+		{"WriteMask числом", ua.AttributeIDWriteMask, "0x60", uint32(0x60), ""},
+		{"AccessLevel", ua.AttributeIDAccessLevel, "CurrentRead, StatusWrite", uint8(0x21), ""},
+		{"AccessLevel None", ua.AttributeIDAccessLevel, "None", uint8(0), ""},
+		{"EventNotifier", ua.AttributeIDEventNotifier, "SubscribeToEvents", uint8(1), ""},
+		{"Historizing", ua.AttributeIDHistorizing, "true", true, ""},
+		{"DataType именем", ua.AttributeIDDataType, "Double", ua.NewNumericNodeID(0, 11), ""},
+		{"DataType NodeId", ua.AttributeIDDataType, "ns=2;i=3001", ua.MustParseNodeID("ns=2;i=3001"), ""},
+		{"ArrayDimensions", ua.AttributeIDArrayDimensions, "[2, 3]", []uint32{2, 3}, ""},
+		{"Неизвестный флаг", ua.AttributeIDAccessLevel, "CurrentRead, Delete", nil, "unknown flag Delete"},
+		{"Неверное число", ua.AttributeIDValueRank, "one", nil, "invalid ValueRank value"},
+		{"Без текстовой формы", ua.AttributeIDRolePermissions, "[]", nil, "use the JSON form"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAttributeValue(tt.attr, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseAttributeValue(%q) ошибка = %v, ожидалась %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAttributeValue(%q) получена непредвиденная ошибка = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got.Value(), tt.want) {
+				t.Errorf("ParseAttributeValue(%q) = %#v, ожидалось %#v", tt.input, got.Value(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Package formatter отвечает за представление значений OPC UA в консоли
+package formatter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/ua"
+)
+
+// Value возвращает текстовое представление значения Variant
+func Value(v *ua.Variant) string {
+	return EnumValue(v, nil)
+}
+
+// EnumValue возвращает текстовое представление значения Variant, выводя
+// целые числа элементами перечисления def ("2 (Running)"). При def == nil
+// совпадает с Value.
+func EnumValue(v *ua.Variant, def *datatype.Definition) string {
+	if v == nil || v.Value() == nil {
+		return "null"
+	}
+	if def != nil {
+		return text(datatype.Enumerate(def, v.Value()))
+	}
+	return text(v.Value())
+}
+
+// DataValue возвращает текстовое представление DataValue вместе со статусом,
+// если он отличается от Good
+func DataValue(dv *ua.DataValue) string {
+	return EnumDataValue(dv, nil)
+}
+
+// EnumDataValue - то же, что DataValue, для значения-перечисления def
+func EnumDataValue(dv *ua.DataValue, def *datatype.Definition) string {
+	if dv == nil {
+		return "null"
+	}
+	s := EnumValue(dv.Value, def)
+	if dv.Status != ua.StatusOK {
+		s += fmt.Sprintf(" [%s]", StatusName(dv.Status))
+	}
+	return s
+}
+
+// StatusName возвращает символическое имя кода статуса (например BadNodeIdUnknown)
+func StatusName(code ua.StatusCode) string {
+	d, ok := ua.StatusCodes[code]
+	if !ok {
+		// Младшие биты содержат флаги, символическое имя определяется старшими
+		d, ok = ua.StatusCodes[code&0xFFFF0000]
+	}
+	if !ok {
+		return fmt.Sprintf("0x%08X", uint32(code))
+	}
+	// gopcua хранит имена в виде StatusBadNodeIDUnknown
+	return fieldName(strings.TrimPrefix(d.Name, "Status"))
+}
+
+// text форматирует одиночное значение или массив встроенного типа
+func text(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return x
+	case []byte:
+		return base64.StdEncoding.EncodeToString(x)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano)
+	case *ua.LocalizedText:
+		if x == nil {
+			return ""
+		}
+		return x.Text
+	case *ua.QualifiedName:
+		if x == nil {
+			return ""
+		}
+		if x.NamespaceIndex == 0 {
+			return x.Name
+		}
+		return fmt.Sprintf("%d:%s", x.NamespaceIndex, x.Name)
+	case *ua.NodeID:
+		if x == nil {
+			return ""
+		}
+		return x.String()
+	case *ua.ExpandedNodeID:
+		if x == nil {
+			return ""
+		}
+		return expandedNodeIDString(x, nil)
+	case ua.StatusCode:
+		return StatusName(x)
+	case *ua.Variant:
+		return Value(x)
+	case *ua.DataValue:
+		return DataValue(x)
+	case *ua.ExtensionObject:
+		if x == nil || x.Value == nil {
+			return "ExtensionObject{}"
+		}
+		switch b := x.Value.(type) {
+		case *datatype.Structure:
+			return structureText(b, "")
+		case *datatype.RawBody:
+			return fmt.Sprintf("ExtensionObject(%s, %d bytes)", x.TypeID, len(*b))
+		case *ua.EUInformation:
+			// Единицы измерения свойства EngineeringUnits
+			if b.DisplayName != nil && b.DisplayName.Text != "" {
+				return b.DisplayName.Text
+			}
+			return fmt.Sprintf("UnitId %d", b.UnitID)
+		case *ua.Range:
+			return fmt.Sprintf("[%s, %s]", text(b.Low), text(b.High))
+		}
+		return fmt.Sprintf("%+v", x.Value)
+	case *datatype.Structure:
+		return structureText(x, "")
+	case datatype.Enum:
+		return x.String()
+	}
+
+	if items, ok := sliceItems(v); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = text(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// structureText выводит структуру с именами полей, по одному полю на строку;
+// вложенные структуры выводятся с отступом
+func structureText(s *datatype.Structure, indent string) string {
+	var sb strings.Builder
+	name := "Structure"
+	if s.Type != nil && s.Type.Name != "" {
+		name = s.Type.Name
+	}
+	sb.WriteString(name + " {\n")
+	for _, f := range s.Fields {
+		sb.WriteString(indent + "  " + f.Name + ": ")
+		if nested, ok := f.Value.(*datatype.Structure); ok {
+			sb.WriteString(structureText(nested, indent+"  "))
+		} else {
+			sb.WriteString(text(f.Value))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(indent + "}")
+	return sb.String()
+}
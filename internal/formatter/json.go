@@ -0,0 +1,479 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/ua"
+)
+
+// JSONEncoder кодирует значения OPC UA в JSON согласно Part 6, 5.4.
+//
+// Обратимая форма сохраняет идентификаторы типов, и из неё значение можно
+// восстановить через JSONDecoder. Необратимая форма предназначена для чтения
+// человеком и сторонними системами: Variant заменяется своим значением,
+// LocalizedText - текстом, а индексы пространств имён - их URI.
+//
+// NodeId и ExpandedNodeId в обеих формах записываются строками
+// (ns=2;s=Tag, nsu=http://vendor/;s=Tag, svr=1;i=85).
+type JSONEncoder struct {
+	// Reversible включает обратимую форму
+	Reversible bool
+	// Namespaces - таблица пространств имён сервера, используется в необратимой форме
+	Namespaces []string
+	// Enum - перечисление, которым описано значение Variant. В необратимой
+	// форме целые числа записываются строками вида Running_2.
+	Enum *datatype.Definition
+}
+
+// MarshalVariant кодирует Variant в JSON
+func (e *JSONEncoder) MarshalVariant(v *ua.Variant) ([]byte, error) {
+	return marshalIndent(e.Variant(v))
+}
+
+// MarshalDataValue кодирует DataValue в JSON
+func (e *JSONEncoder) MarshalDataValue(dv *ua.DataValue) ([]byte, error) {
+	return marshalIndent(e.DataValue(dv))
+}
+
+// Variant возвращает JSON-представление Variant, пригодное для json.Marshal
+func (e *JSONEncoder) Variant(v *ua.Variant) interface{} {
+	if v == nil || v.Type() == ua.TypeIDNull || v.Value() == nil {
+		return nil
+	}
+	if !e.Reversible {
+		if e.Enum != nil {
+			return e.Any(datatype.Enumerate(e.Enum, v.Value()))
+		}
+		return e.Any(v.Value())
+	}
+
+	o := Object{{"Type", uint8(v.Type())}}
+	if dims := v.ArrayDimensions(); len(dims) > 1 {
+		o = append(o, Field{"Body", e.Any(flatten(v.Value()))})
+		o = append(o, Field{"Dimensions", dims})
+	} else {
+		o = append(o, Field{"Body", e.Any(v.Value())})
+	}
+	return o
+}
+
+// DataValue возвращает JSON-представление DataValue. Поля со значениями
+// по умолчанию опускаются.
+func (e *JSONEncoder) DataValue(dv *ua.DataValue) interface{} {
+	if dv == nil {
+		return nil
+	}
+	var o Object
+	if dv.Value != nil && dv.Value.Value() != nil {
+		o = append(o, Field{"Value", e.Variant(dv.Value)})
+	}
+	if dv.Status != ua.StatusOK {
+		o = append(o, Field{"Status", e.Any(dv.Status)})
+	}
+	if !dv.SourceTimestamp.IsZero() {
+		o = append(o, Field{"SourceTimestamp", dateTime(dv.SourceTimestamp)})
+	}
+	if dv.SourcePicoseconds != 0 {
+		o = append(o, Field{"SourcePicoseconds", dv.SourcePicoseconds})
+	}
+	if !dv.ServerTimestamp.IsZero() {
+		o = append(o, Field{"ServerTimestamp", dateTime(dv.ServerTimestamp)})
+	}
+	if dv.ServerPicoseconds != 0 {
+		o = append(o, Field{"ServerPicoseconds", dv.ServerPicoseconds})
+	}
+	if o == nil {
+		return Object{}
+	}
+	return o
+}
+
+// Any возвращает JSON-представление произвольного значения встроенного
+// типа OPC UA, массива или структуры
+func (e *JSONEncoder) Any(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case bool, int8, uint8, int16, uint16, int32, uint32, string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float32:
+		return float(float64(x), x)
+	case float64:
+		return float(x, x)
+	case time.Time:
+		return dateTime(x)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(x)
+	case ua.XMLElement:
+		return string(x)
+	case *ua.GUID:
+		if x == nil {
+			return nil
+		}
+		return x.String()
+	case *ua.NodeID:
+		if x == nil {
+			return nil
+		}
+		return e.nodeID(x)
+	case *ua.ExpandedNodeID:
+		if x == nil {
+			return nil
+		}
+		if e.Reversible {
+			return expandedNodeIDString(x, nil)
+		}
+		return expandedNodeIDString(x, e.Namespaces)
+	case ua.StatusCode:
+		if e.Reversible {
+			return uint32(x)
+		}
+		return Object{{"Code", uint32(x)}, {"Symbol", StatusName(x)}}
+	case *ua.QualifiedName:
+		if x == nil {
+			return nil
+		}
+		return e.qualifiedName(x)
+	case *ua.LocalizedText:
+		if x == nil {
+			return nil
+		}
+		if !e.Reversible {
+			return x.Text
+		}
+		var o Object
+		if x.Locale != "" {
+			o = append(o, Field{"Locale", x.Locale})
+		}
+		if x.Text != "" {
+			o = append(o, Field{"Text", x.Text})
+		}
+		if o == nil {
+			return Object{}
+		}
+		return o
+	case *ua.ExtensionObject:
+		if x == nil {
+			return nil
+		}
+		return e.extensionObject(x)
+	case *ua.DataValue:
+		return e.DataValue(x)
+	case *ua.Variant:
+		return e.Variant(x)
+	case *ua.DiagnosticInfo:
+		if x == nil {
+			return nil
+		}
+		return e.diagnosticInfo(x)
+	case *datatype.Structure:
+		if x == nil {
+			return nil
+		}
+		o := Object{}
+		for _, f := range x.Fields {
+			o = append(o, Field{f.Name, e.Any(f.Value)})
+		}
+		return o
+	case datatype.Enum:
+		// Part 6: в обратимой форме перечисление - число,
+		// в необратимой - строка вида Running_2
+		if e.Reversible || x.Name == "" {
+			return x.Value
+		}
+		return fmt.Sprintf("%s_%d", x.Name, x.Value)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = e.Any(rv.Index(i).Interface())
+		}
+		return items
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return e.Any(rv.Elem().Interface())
+	case reflect.Struct:
+		return e.structure(rv)
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int:
+		return rv.Int()
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint:
+		return rv.Uint()
+	case reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return float(rv.Float(), rv.Float())
+	case reflect.String:
+		return rv.String()
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// nodeID кодирует NodeId строкой; в необратимой форме индекс пространства
+// имён заменяется его URI
+func (e *JSONEncoder) nodeID(n *ua.NodeID) string {
+	if e.Reversible || n.Namespace() == 0 {
+		return n.String()
+	}
+	if uri, ok := namespaceURI(e.Namespaces, n.Namespace()); ok {
+		return "nsu=" + uri + ";" + identifier(n)
+	}
+	return n.String()
+}
+
+func (e *JSONEncoder) qualifiedName(q *ua.QualifiedName) Object {
+	o := Object{{"Name", q.Name}}
+	if q.NamespaceIndex == 0 {
+		return o
+	}
+	if !e.Reversible {
+		if uri, ok := namespaceURI(e.Namespaces, q.NamespaceIndex); ok {
+			return append(o, Field{"Uri", uri})
+		}
+	}
+	return append(o, Field{"Uri", q.NamespaceIndex})
+}
+
+// extensionObject кодирует ExtensionObject. Известные и разобранные по
+// описанию типа структуры кодируются как JSON-объект, неразобранные - как
+// двоичное тело в base64 (Encoding = 1) или XML (Encoding = 2).
+func (e *JSONEncoder) extensionObject(x *ua.ExtensionObject) interface{} {
+	var body interface{}
+	encoding := 0
+	switch b := x.Value.(type) {
+	case nil:
+	case datatype.RawBody:
+		body, encoding = base64.StdEncoding.EncodeToString(b), ua.ExtensionObjectBinary
+	case *datatype.RawBody:
+		body, encoding = base64.StdEncoding.EncodeToString(*b), ua.ExtensionObjectBinary
+	case *ua.XMLElement:
+		body, encoding = string(*b), ua.ExtensionObjectXML
+	default:
+		body = e.Any(b)
+	}
+	if !e.Reversible {
+		return body
+	}
+
+	o := Object{}
+	if x.TypeID != nil && x.TypeID.NodeID != nil {
+		o = append(o, Field{"TypeId", e.Any(x.TypeID)})
+	}
+	if encoding != 0 {
+		o = append(o, Field{"Encoding", encoding})
+	}
+	if body != nil {
+		o = append(o, Field{"Body", body})
+	}
+	return o
+}
+
+// structure кодирует структуру gopcua как JSON-объект с именами полей OPC UA
+func (e *JSONEncoder) structure(rv reflect.Value) Object {
+	t := rv.Type()
+	o := Object{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Name == "EncodingMask" {
+			continue
+		}
+		o = append(o, Field{fieldName(f.Name), e.Any(rv.Field(i).Interface())})
+	}
+	return o
+}
+
+func (e *JSONEncoder) diagnosticInfo(d *ua.DiagnosticInfo) Object {
+	o := Object{}
+	if d.Has(ua.DiagnosticInfoSymbolicID) {
+		o = append(o, Field{"SymbolicId", d.SymbolicID})
+	}
+	if d.Has(ua.DiagnosticInfoNamespaceURI) {
+		o = append(o, Field{"NamespaceUri", d.NamespaceURI})
+	}
+	if d.Has(ua.DiagnosticInfoLocale) {
+		o = append(o, Field{"Locale", d.Locale})
+	}
+	if d.Has(ua.DiagnosticInfoLocalizedText) {
+		o = append(o, Field{"LocalizedText", d.LocalizedText})
+	}
+	if d.Has(ua.DiagnosticInfoAdditionalInfo) {
+		o = append(o, Field{"AdditionalInfo", d.AdditionalInfo})
+	}
+	if d.Has(ua.DiagnosticInfoInnerStatusCode) {
+		o = append(o, Field{"InnerStatusCode", e.Any(d.InnerStatusCode)})
+	}
+	if d.Has(ua.DiagnosticInfoInnerDiagnosticInfo) && d.InnerDiagnosticInfo != nil {
+		o = append(o, Field{"InnerDiagnosticInfo", e.diagnosticInfo(d.InnerDiagnosticInfo)})
+	}
+	return o
+}
+
+// Field - поле JSON-объекта
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Object - JSON-объект с сохранением порядка полей
+type Object []Field
+
+// MarshalJSON реализует json.Marshaler
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Get возвращает значение поля по имени
+func (o Object) Get(key string) (interface{}, bool) {
+	for _, f := range o {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+func marshalIndent(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// float кодирует число с плавающей точкой; NaN и бесконечности,
+// которых нет в JSON, записываются строками
+func float(f float64, orig interface{}) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return orig
+}
+
+// dateTime кодирует время в ISO 8601 (UTC)
+func dateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// fieldName переводит имя поля gopcua в имя поля OPC UA (NodeID -> NodeId)
+func fieldName(name string) string {
+	r := strings.NewReplacer("GUID", "Guid", "URI", "Uri", "URL", "Url", "ID", "Id")
+	return r.Replace(name)
+}
+
+func namespaceURI(ns []string, idx uint16) (string, bool) {
+	if int(idx) < len(ns) && ns[idx] != "" {
+		return ns[idx], true
+	}
+	return "", false
+}
+
+// identifier возвращает часть NodeId без пространства имён (i=85, s=Tag)
+func identifier(n *ua.NodeID) string {
+	s := n.String()
+	if i := strings.Index(s, ";"); i >= 0 && strings.HasPrefix(s, "ns=") {
+		return s[i+1:]
+	}
+	return s
+}
+
+// expandedNodeIDString форматирует ExpandedNodeId. Если передана таблица
+// пространств имён, индекс заменяется на URI.
+func expandedNodeIDString(x *ua.ExpandedNodeID, ns []string) string {
+	var sb strings.Builder
+	if x.ServerIndex > 0 {
+		fmt.Fprintf(&sb, "svr=%d;", x.ServerIndex)
+	}
+	switch {
+	case x.NamespaceURI != "":
+		sb.WriteString("nsu=" + x.NamespaceURI + ";" + identifier(x.NodeID))
+	case ns != nil && x.NodeID.Namespace() != 0:
+		if uri, ok := namespaceURI(ns, x.NodeID.Namespace()); ok {
+			sb.WriteString("nsu=" + uri + ";" + identifier(x.NodeID))
+		} else {
+			sb.WriteString(x.NodeID.String())
+		}
+	default:
+		sb.WriteString(x.NodeID.String())
+	}
+	return sb.String()
+}
+
+// sliceItems раскладывает срез (кроме ByteString) на элементы
+func sliceItems(v interface{}) ([]interface{}, bool) {
+	if _, ok := v.([]byte); ok {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, true
+}
+
+// flatten превращает многомерный массив в одномерный в порядке строк
+func flatten(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() != reflect.Slice || rv.Type().Elem().Elem().Kind() == reflect.Uint8 {
+		return v
+	}
+	var out []interface{}
+	for i := 0; i < rv.Len(); i++ {
+		inner := flatten(rv.Index(i).Interface())
+		items, _ := sliceItems(inner)
+		out = append(out, items...)
+	}
+	return out
+}
@@ -0,0 +1,610 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/ua"
+)
+
+// JSONDecoder восстанавливает значения OPC UA из обратимой JSON-формы
+// (Part 6, 5.4), созданной JSONEncoder
+type JSONDecoder struct {
+	// Namespaces - таблица пространств имён сервера для разбора nsu= в NodeId
+	Namespaces []string
+	// Types загружает описания пользовательских структур для ExtensionObject
+	// с телом в виде JSON-объекта; если не задан, такие тела не поддерживаются
+	Types datatype.Resolver
+}
+
+// Variant разбирает Variant вида {"Type":6,"Body":42}
+func (d *JSONDecoder) Variant(data []byte) (*ua.Variant, error) {
+	raw, err := unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return d.variant(raw)
+}
+
+// DataValue разбирает DataValue вида {"Value":{...},"Status":0,"SourceTimestamp":"..."}
+func (d *JSONDecoder) DataValue(data []byte) (*ua.DataValue, error) {
+	raw, err := unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return d.dataValue(raw)
+}
+
+func unmarshal(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return raw, nil
+}
+
+func (d *JSONDecoder) variant(raw interface{}) (*ua.Variant, error) {
+	if raw == nil {
+		return ua.NewVariant(nil)
+	}
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("variant must be a JSON object with Type and Body")
+	}
+	if len(obj) == 0 {
+		return ua.NewVariant(nil)
+	}
+
+	n, err := jsonUint(obj["Type"], 8)
+	if err != nil {
+		return nil, fmt.Errorf("variant Type: %w", err)
+	}
+	typeID := ua.TypeID(n)
+	if typeID == ua.TypeIDNull {
+		return ua.NewVariant(nil)
+	}
+	elemType, ok := goType(typeID)
+	if !ok {
+		return nil, fmt.Errorf("unsupported variant type %d", n)
+	}
+
+	body, ok := obj["Body"]
+	if !ok {
+		return nil, fmt.Errorf("variant has no Body")
+	}
+
+	items, isArray := body.([]interface{})
+	if !isArray {
+		v, err := d.Builtin(typeID, body)
+		if err != nil {
+			return nil, err
+		}
+		return ua.NewVariant(v)
+	}
+
+	arr := reflect.MakeSlice(reflect.SliceOf(elemType), len(items), len(items))
+	for i, item := range items {
+		v, err := d.Builtin(typeID, item)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		if v != nil {
+			arr.Index(i).Set(reflect.ValueOf(v))
+		}
+	}
+
+	value := arr.Interface()
+	if rawDims, ok := obj["Dimensions"].([]interface{}); ok && len(rawDims) > 1 {
+		dims := make([]int, len(rawDims))
+		for i, rd := range rawDims {
+			n, err := jsonUint(rd, 31)
+			if err != nil {
+				return nil, fmt.Errorf("variant Dimensions: %w", err)
+			}
+			dims[i] = int(n)
+		}
+		if value, err = reshape(arr, dims); err != nil {
+			return nil, err
+		}
+	}
+	return ua.NewVariant(value)
+}
+
+func (d *JSONDecoder) dataValue(raw interface{}) (*ua.DataValue, error) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("data value must be a JSON object")
+	}
+	dv := &ua.DataValue{}
+	if v, ok := obj["Value"]; ok {
+		variant, err := d.variant(v)
+		if err != nil {
+			return nil, err
+		}
+		dv.Value = variant
+	}
+	if v, ok := obj["Status"]; ok {
+		code, err := statusCode(v)
+		if err != nil {
+			return nil, fmt.Errorf("Status: %w", err)
+		}
+		dv.Status = code
+	}
+	for _, ts := range []struct {
+		key string
+		dst *time.Time
+	}{{"SourceTimestamp", &dv.SourceTimestamp}, {"ServerTimestamp", &dv.ServerTimestamp}} {
+		if v, ok := obj[ts.key]; ok {
+			t, err := d.Builtin(ua.TypeIDDateTime, v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ts.key, err)
+			}
+			*ts.dst = t.(time.Time)
+		}
+	}
+	for _, ps := range []struct {
+		key string
+		dst *uint16
+	}{{"SourcePicoseconds", &dv.SourcePicoseconds}, {"ServerPicoseconds", &dv.ServerPicoseconds}} {
+		if v, ok := obj[ps.key]; ok {
+			n, err := jsonUint(v, 16)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ps.key, err)
+			}
+			*ps.dst = uint16(n)
+		}
+	}
+	dv.UpdateMask()
+	return dv, nil
+}
+
+// Builtin разбирает одиночное значение встроенного типа из результата
+// json.Decoder с включённым UseNumber
+func (d *JSONDecoder) Builtin(t ua.TypeID, raw interface{}) (interface{}, error) {
+	switch t {
+	case ua.TypeIDBoolean:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected boolean, got %v", raw)
+		}
+		return b, nil
+	case ua.TypeIDSByte:
+		n, err := jsonInt(raw, 8)
+		return int8(n), err
+	case ua.TypeIDByte:
+		n, err := jsonUint(raw, 8)
+		return uint8(n), err
+	case ua.TypeIDInt16:
+		n, err := jsonInt(raw, 16)
+		return int16(n), err
+	case ua.TypeIDUint16:
+		n, err := jsonUint(raw, 16)
+		return uint16(n), err
+	case ua.TypeIDInt32:
+		n, err := jsonInt(raw, 32)
+		return int32(n), err
+	case ua.TypeIDUint32:
+		n, err := jsonUint(raw, 32)
+		return uint32(n), err
+	case ua.TypeIDInt64:
+		return jsonInt(raw, 64)
+	case ua.TypeIDUint64:
+		return jsonUint(raw, 64)
+	case ua.TypeIDFloat:
+		f, err := jsonFloat(raw, 32)
+		return float32(f), err
+	case ua.TypeIDDouble:
+		return jsonFloat(raw, 64)
+	case ua.TypeIDString:
+		return jsonString(raw)
+	case ua.TypeIDXMLElement:
+		s, err := jsonString(raw)
+		return ua.XMLElement(s), err
+	case ua.TypeIDDateTime:
+		s, err := jsonString(raw)
+		if err != nil {
+			return nil, err
+		}
+		ts, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date time %q", s)
+		}
+		return ts, nil
+	case ua.TypeIDGUID:
+		s, err := jsonString(raw)
+		if err != nil {
+			return nil, err
+		}
+		g := ua.NewGUID(s)
+		if g == nil {
+			return nil, fmt.Errorf("invalid Guid value %q", s)
+		}
+		return g, nil
+	case ua.TypeIDByteString:
+		if raw == nil {
+			return []byte(nil), nil
+		}
+		s, err := jsonString(raw)
+		if err != nil {
+			return nil, err
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 byte string: %w", err)
+		}
+		return b, nil
+	case ua.TypeIDNodeID:
+		s, err := jsonString(raw)
+		if err != nil {
+			return nil, err
+		}
+		x, err := d.expandedNodeID(s)
+		if err != nil {
+			return nil, err
+		}
+		return x.NodeID, nil
+	case ua.TypeIDExpandedNodeID:
+		s, err := jsonString(raw)
+		if err != nil {
+			return nil, err
+		}
+		return d.expandedNodeID(s)
+	case ua.TypeIDStatusCode:
+		return statusCode(raw)
+	case ua.TypeIDQualifiedName:
+		return d.qualifiedName(raw)
+	case ua.TypeIDLocalizedText:
+		switch x := raw.(type) {
+		case string:
+			return ua.NewLocalizedText(x), nil
+		case map[string]interface{}:
+			text, _ := x["Text"].(string)
+			locale, _ := x["Locale"].(string)
+			return ua.NewLocalizedTextWithLocale(text, locale), nil
+		}
+		return nil, fmt.Errorf("expected localized text, got %v", raw)
+	case ua.TypeIDExtensionObject:
+		return d.extensionObject(raw)
+	case ua.TypeIDDataValue:
+		return d.dataValue(raw)
+	case ua.TypeIDVariant:
+		return d.variant(raw)
+	}
+	return nil, fmt.Errorf("decoding of %s is not supported", TypeName(t))
+}
+
+// expandedNodeID разбирает строковую форму NodeId/ExpandedNodeId,
+// в том числе svr= и nsu=
+func (d *JSONDecoder) expandedNodeID(s string) (*ua.ExpandedNodeID, error) {
+	var server uint32
+	if strings.HasPrefix(s, "svr=") {
+		p := strings.SplitN(s, ";", 2)
+		n, err := strconv.ParseUint(strings.TrimPrefix(p[0], "svr="), 10, 32)
+		if err != nil || len(p) != 2 {
+			return nil, fmt.Errorf("invalid expanded node ID: %s", s)
+		}
+		server, s = uint32(n), p[1]
+	}
+	x, err := ua.ParseExpandedNodeID(s, d.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+	x.ServerIndex = server
+	return x, nil
+}
+
+func (d *JSONDecoder) qualifiedName(raw interface{}) (*ua.QualifiedName, error) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected qualified name object, got %v", raw)
+	}
+	name, _ := obj["Name"].(string)
+	q := &ua.QualifiedName{Name: name}
+	switch uri := obj["Uri"].(type) {
+	case nil:
+	case string:
+		idx := -1
+		for i, ns := range d.Namespaces {
+			if ns == uri {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown namespace URI %q", uri)
+		}
+		q.NamespaceIndex = uint16(idx)
+	default:
+		n, err := jsonUint(uri, 16)
+		if err != nil {
+			return nil, err
+		}
+		q.NamespaceIndex = uint16(n)
+	}
+	return q, nil
+}
+
+func (d *JSONDecoder) extensionObject(raw interface{}) (*ua.ExtensionObject, error) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected extension object, got %v", raw)
+	}
+	s, err := jsonString(obj["TypeId"])
+	if err != nil {
+		return nil, fmt.Errorf("extension object TypeId: %w", err)
+	}
+	typeID, err := d.expandedNodeID(s)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := uint64(0)
+	if v, ok := obj["Encoding"]; ok {
+		if encoding, err = jsonUint(v, 8); err != nil {
+			return nil, fmt.Errorf("extension object Encoding: %w", err)
+		}
+	}
+
+	eo := &ua.ExtensionObject{TypeID: typeID}
+	switch body := obj["Body"]; {
+	case body == nil:
+	case encoding == ua.ExtensionObjectBinary:
+		s, err := jsonString(body)
+		if err != nil {
+			return nil, err
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %w", err)
+		}
+		eo.Value = datatype.RawBody(b)
+	case encoding == ua.ExtensionObjectXML:
+		s, err := jsonString(body)
+		if err != nil {
+			return nil, err
+		}
+		x := ua.XMLElement(s)
+		eo.Value = &x
+	default:
+		def, err := d.structureType(typeID.NodeID)
+		if err != nil {
+			return nil, fmt.Errorf("extension object %s: %w", s, err)
+		}
+		st, err := d.structure(def, body)
+		if err != nil {
+			return nil, err
+		}
+		eo.TypeID = ua.NewExpandedNodeID(def.EncodingID, "", 0)
+		eo.Value = st
+	}
+	eo.UpdateMask()
+	return eo, nil
+}
+
+// Structure разбирает JSON-объект с полями структуры ({"Speed":10,"Mode":"Running"})
+// по описанию её типа
+func (d *JSONDecoder) Structure(def *datatype.Definition, data []byte) (*datatype.Structure, error) {
+	raw, err := unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return d.structure(def, raw)
+}
+
+// structureType находит описание структуры по TypeId, который может быть
+// как узлом кодировки, так и узлом DataType
+func (d *JSONDecoder) structureType(typeID *ua.NodeID) (*datatype.Definition, error) {
+	if d.Types == nil {
+		return nil, fmt.Errorf("JSON bodies require data type definitions, use Encoding 1 with a base64 body")
+	}
+	def, err := d.Types.Encoding(typeID)
+	if err != nil {
+		if def, err = d.Types.DataType(typeID); err != nil {
+			return nil, err
+		}
+	}
+	if def.Kind != datatype.KindStructure || def.EncodingID == nil {
+		return nil, fmt.Errorf("%s is not a structure with binary encoding", def.Name)
+	}
+	return def, nil
+}
+
+func (d *JSONDecoder) structure(def *datatype.Definition, raw interface{}) (*datatype.Structure, error) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected JSON object, got %v", def.Name, raw)
+	}
+
+	union := def.StructureType == ua.StructureTypeUnion || def.StructureType == ua.StructureTypeUnionWithSubtypedValues
+	if sw, ok := obj["SwitchField"]; ok && union {
+		// Обратимая форма объединения: {"SwitchField":2,"Value":...}
+		n, err := jsonUint(sw, 32)
+		if err != nil || n > uint64(len(def.Fields)) {
+			return nil, fmt.Errorf("%s: invalid SwitchField %v", def.Name, sw)
+		}
+		if n == 0 {
+			return &datatype.Structure{Type: def}, nil
+		}
+		obj = map[string]interface{}{def.Fields[n-1].Name: obj["Value"]}
+	}
+
+	known := make(map[string]bool, len(def.Fields))
+	s := &datatype.Structure{Type: def}
+	for _, f := range def.Fields {
+		known[f.Name] = true
+		rv, ok := obj[f.Name]
+		if !ok {
+			if f.IsOptional || union {
+				continue
+			}
+			return nil, fmt.Errorf("%s: missing field %s", def.Name, f.Name)
+		}
+		v, err := d.field(f, rv)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", def.Name, f.Name, err)
+		}
+		s.Fields = append(s.Fields, datatype.FieldValue{Name: f.Name, Value: v})
+	}
+	for name := range obj {
+		if !known[name] {
+			return nil, fmt.Errorf("%s has no field %s", def.Name, name)
+		}
+	}
+	if union && len(s.Fields) > 1 {
+		return nil, fmt.Errorf("union %s must have exactly one field", def.Name)
+	}
+	return s, nil
+}
+
+func (d *JSONDecoder) field(f *datatype.Field, raw interface{}) (interface{}, error) {
+	if f.ValueRank < 1 {
+		return d.fieldValue(f.Type, raw)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	return d.fieldArray(f.Type, raw, int(f.ValueRank))
+}
+
+// fieldArray разбирает массив поля; многомерные массивы задаются вложенными
+func (d *JSONDecoder) fieldArray(def *datatype.Definition, raw interface{}, rank int) ([]interface{}, error) {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected array, got %v", raw)
+	}
+	out := make([]interface{}, len(items))
+	for i, item := range items {
+		var err error
+		if rank > 1 {
+			out[i], err = d.fieldArray(def, item, rank-1)
+		} else {
+			out[i], err = d.fieldValue(def, item)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+func (d *JSONDecoder) fieldValue(def *datatype.Definition, raw interface{}) (interface{}, error) {
+	switch def.Kind {
+	case datatype.KindStructure:
+		return d.structure(def, raw)
+	case datatype.KindEnum:
+		if s, ok := raw.(string); ok {
+			return datatype.ParseEnum(def, s)
+		}
+		n, err := jsonInt(raw, 32)
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewEnum(def, int32(n)), nil
+	}
+	return d.Builtin(def.Builtin, raw)
+}
+
+func statusCode(raw interface{}) (ua.StatusCode, error) {
+	if obj, ok := raw.(map[string]interface{}); ok {
+		raw = obj["Code"]
+	}
+	n, err := jsonUint(raw, 32)
+	return ua.StatusCode(n), err
+}
+
+func jsonString(raw interface{}) (string, error) {
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("expected string, got %v", raw)
+	}
+	return s, nil
+}
+
+// jsonInt разбирает целое число; 64-битные значения в JSON передаются строками
+func jsonInt(raw interface{}, bits int) (int64, error) {
+	switch x := raw.(type) {
+	case json.Number:
+		return strconv.ParseInt(x.String(), 10, bits)
+	case string:
+		return strconv.ParseInt(x, 10, bits)
+	}
+	return 0, fmt.Errorf("expected integer, got %v", raw)
+}
+
+func jsonUint(raw interface{}, bits int) (uint64, error) {
+	switch x := raw.(type) {
+	case json.Number:
+		return strconv.ParseUint(x.String(), 10, bits)
+	case string:
+		return strconv.ParseUint(x, 10, bits)
+	}
+	return 0, fmt.Errorf("expected unsigned integer, got %v", raw)
+}
+
+func jsonFloat(raw interface{}, bits int) (float64, error) {
+	switch x := raw.(type) {
+	case json.Number:
+		return strconv.ParseFloat(x.String(), bits)
+	case string:
+		switch x {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+	}
+	return 0, fmt.Errorf("expected number, got %v", raw)
+}
+
+// reshape превращает одномерный массив в многомерный по заданным размерностям
+func reshape(flat reflect.Value, dims []int) (interface{}, error) {
+	total := 1
+	for _, d := range dims {
+		if d < 0 {
+			return nil, fmt.Errorf("invalid dimension %d", d)
+		}
+		// произведение перестаёт расти, как только превысило число
+		// элементов, чтобы не переполниться на больших размерностях
+		if total <= flat.Len() {
+			total *= d
+		} else if d == 0 {
+			total = 0
+		}
+	}
+	if total != flat.Len() {
+		return nil, fmt.Errorf("dimensions %v do not match %d elements", dims, flat.Len())
+	}
+	return reshapeLevel(flat, dims).Interface(), nil
+}
+
+func reshapeLevel(flat reflect.Value, dims []int) reflect.Value {
+	if len(dims) == 1 {
+		return flat
+	}
+	if dims[0] == 0 {
+		// пустой массив того же типа, что дала бы ненулевая размерность
+		t := flat.Type()
+		for range dims[1:] {
+			t = reflect.SliceOf(t)
+		}
+		return reflect.MakeSlice(t, 0, 0)
+	}
+	step := flat.Len() / dims[0]
+	inner := reshapeLevel(flat.Slice(0, step), dims[1:])
+	out := reflect.MakeSlice(reflect.SliceOf(inner.Type()), dims[0], dims[0])
+	for i := 0; i < dims[0]; i++ {
+		out.Index(i).Set(reshapeLevel(flat.Slice(i*step, (i+1)*step), dims[1:]))
+	}
+	return out
+}
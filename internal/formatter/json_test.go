@@ -0,0 +1,371 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/datatype"
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestJSONEncoderVariant проверяет кодирование Variant в обратимой и
+// необратимой формах Part 6.
+//
+// Основные аспекты тестирования:
+// - Сохранение идентификатора типа в обратимой форме.
+// - Кодирование Int64 строкой и специальных значений Double.
+// - Строковая форма NodeId с заменой индекса пространства имён на URI.
+// - LocalizedText и QualifiedName в обеих формах.
+// - Многомерные массивы: плоское тело с Dimensions либо вложенные массивы.
+func TestJSONEncoderVariant(t *testing.T) {
+	ns := []string{"http://opcfoundation.org/UA/", "urn:server", "http://vendor.com/UA/"}
+
+	tests := []struct {
+		name       string
+		value      interface{}
+		reversible bool
+		want       string
+	}{
+		{
+			name:       "Int32 в обратимой форме",
+			value:      int32(42),
+			reversible: true,
+			want:       `{"Type":6,"Body":42}`,
+		},
+		{
+			name:  "Int32 в необратимой форме",
+			value: int32(42),
+			want:  `42`,
+		},
+		{
+			name:       "Int64 кодируется строкой",
+			value:      int64(9007199254740993),
+			reversible: true,
+			want:       `{"Type":8,"Body":"9007199254740993"}`,
+		},
+		{
+			name:       "NaN кодируется строкой",
+			value:      math.NaN(),
+			reversible: true,
+			want:       `{"Type":11,"Body":"NaN"}`,
+		},
+		{
+			name:       "DateTime в формате ISO 8601",
+			value:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			reversible: true,
+			want:       `{"Type":13,"Body":"2026-01-02T03:04:05Z"}`,
+		},
+		{
+			name:       "NodeId в обратимой форме сохраняет индекс",
+			value:      ua.NewStringNodeID(2, "Tag"),
+			reversible: true,
+			want:       `{"Type":17,"Body":"ns=2;s=Tag"}`,
+		},
+		{
+			name:  "NodeId в необратимой форме содержит URI",
+			value: ua.NewStringNodeID(2, "Tag"),
+			want:  `"nsu=http://vendor.com/UA/;s=Tag"`,
+		},
+		{
+			name:       "LocalizedText в обратимой форме",
+			value:      ua.NewLocalizedTextWithLocale("Pump", "en"),
+			reversible: true,
+			want:       `{"Type":21,"Body":{"Locale":"en","Text":"Pump"}}`,
+		},
+		{
+			name:  "LocalizedText в необратимой форме",
+			value: ua.NewLocalizedTextWithLocale("Pump", "en"),
+			want:  `"Pump"`,
+		},
+		{
+			name:  "QualifiedName в необратимой форме содержит URI",
+			value: &ua.QualifiedName{NamespaceIndex: 2, Name: "Motor"},
+			want:  `{"Name":"Motor","Uri":"http://vendor.com/UA/"}`,
+		},
+		{
+			name:  "StatusCode в необратимой форме содержит символ",
+			value: ua.StatusBadNodeIDUnknown,
+			want:  `{"Code":2150891520,"Symbol":"BadNodeIdUnknown"}`,
+		},
+		{
+			name:       "Многомерный массив в обратимой форме",
+			value:      [][]int16{{1, 2, 3}, {4, 5, 6}},
+			reversible: true,
+			want:       `{"Type":4,"Body":[1,2,3,4,5,6],"Dimensions":[2,3]}`,
+		},
+		{
+			name:  "Многомерный массив в необратимой форме",
+			value: [][]int16{{1, 2, 3}, {4, 5, 6}},
+			want:  `[[1,2,3],[4,5,6]]`,
+		},
+		{
+			name:       "Известная структура кодируется объектом",
+			value:      ua.NewExtensionObject(&ua.Range{Low: 0, High: 100}),
+			reversible: true,
+			want:       `{"Type":22,"Body":{"TypeId":"i=886","Body":{"Low":0,"High":100}}}`,
+		},
+		{
+			name: "Неизвестная структура кодируется base64",
+			value: &ua.ExtensionObject{
+				EncodingMask: ua.ExtensionObjectBinary,
+				TypeID:       ua.NewStringExpandedNodeID(2, "UDT"),
+				Value:        datatype.RawBody{1, 2, 3},
+			},
+			reversible: true,
+			want:       `{"Type":22,"Body":{"TypeId":"ns=2;s=UDT","Encoding":1,"Body":"AQID"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := &JSONEncoder{Reversible: tt.reversible, Namespaces: ns}
+			b, err := json.Marshal(enc.Variant(ua.MustVariant(tt.value)))
+			if err != nil {
+				t.Fatalf("ошибка кодирования: %v", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("получено %s, ожидалось %s", b, tt.want)
+			}
+		})
+	}
+}
+
+// TestJSONRoundTrip проверяет, что значение в обратимой форме восстанавливается
+// JSONDecoder без потери типа.
+func TestJSONRoundTrip(t *testing.T) {
+	values := []interface{}{
+		true,
+		int8(-5),
+		uint16(65535),
+		int64(-9007199254740993),
+		uint64(18446744073709551615),
+		float32(1.5),
+		3.25,
+		"Pump 1",
+		time.Date(2026, 1, 1, 0, 0, 0, 123000000, time.UTC),
+		[]byte{0xde, 0xad},
+		ua.NewNumericNodeID(0, 2258),
+		ua.NewStringNodeID(3, "Temp"),
+		ua.NewLocalizedTextWithLocale("Ein", "de"),
+		&ua.QualifiedName{NamespaceIndex: 2, Name: "Motor"},
+		ua.StatusBadTimeout,
+		[]int32{1, 2, 3},
+		[][]float64{{1, 2}, {3, 4}, {5, 6}},
+		[]string{"a", "b"},
+	}
+
+	enc := &JSONEncoder{Reversible: true}
+	dec := &JSONDecoder{}
+	for _, v := range values {
+		in := ua.MustVariant(v)
+		b, err := enc.MarshalVariant(in)
+		if err != nil {
+			t.Fatalf("%T: ошибка кодирования: %v", v, err)
+		}
+		out, err := dec.Variant(b)
+		if err != nil {
+			t.Fatalf("%T: ошибка декодирования %s: %v", v, b, err)
+		}
+		if out.Type() != in.Type() {
+			t.Errorf("%T: тип %v, ожидался %v", v, out.Type(), in.Type())
+		}
+		if id, ok := v.(*ua.NodeID); ok {
+			// gopcua выбирает компактную кодировку NodeId при разборе строки
+			if !id.Equal(out.NodeID()) {
+				t.Errorf("NodeId %v, ожидался %v", out.NodeID(), id)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(out.Value(), in.Value()) {
+			t.Errorf("%T: значение %#v, ожидалось %#v", v, out.Value(), in.Value())
+		}
+	}
+}
+
+// TestJSONDecoderDataValue проверяет разбор DataValue со статусом и меткой времени.
+func TestJSONDecoderDataValue(t *testing.T) {
+	dec := &JSONDecoder{}
+	dv, err := dec.DataValue([]byte(`{"Value":{"Type":11,"Body":1.5},"Status":1073741824,"SourceTimestamp":"2026-01-01T00:00:00Z"}`))
+	if err != nil {
+		t.Fatalf("ошибка декодирования: %v", err)
+	}
+	if dv.Value.Float() != 1.5 {
+		t.Errorf("значение %v, ожидалось 1.5", dv.Value.Value())
+	}
+	if dv.Status != ua.StatusUncertain {
+		t.Errorf("статус %v, ожидался Uncertain", dv.Status)
+	}
+	if !dv.SourceTimestamp.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("метка времени %v", dv.SourceTimestamp)
+	}
+	if !dv.Has(ua.DataValueStatusCode) || !dv.Has(ua.DataValueSourceTimestamp) {
+		t.Errorf("маска кодирования не обновлена: %x", dv.EncodingMask)
+	}
+}
+
+// TestJSONDecoderInvalid проверяет отказ от разбора повреждённых значений.
+//
+// Основные аспекты тестирования:
+// - Неверная запись Guid - ошибка, а не nil-значение.
+func TestJSONDecoderInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "Неверный Guid", input: `{"Type":14,"Body":"not-a-guid"}`, wantErr: `invalid Guid value "not-a-guid"`},
+	}
+	dec := &JSONDecoder{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := dec.Variant([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Variant() = %v, %v, ожидалась ошибка %q", v, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestJSONDecoderDimensions проверяет разбор Dimensions многомерного массива.
+//
+// Основные аспекты тестирования:
+// - Нулевая размерность даёт пустой массив без деления на ноль.
+// - Отрицательные размерности отклоняются.
+// - Размерности, не совпадающие с числом элементов, отклоняются.
+func TestJSONDecoderDimensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "Нулевая первая размерность", input: `{"Type":6,"Body":[],"Dimensions":[0,3]}`, want: [][]int32{}},
+		{name: "Нулевая вторая размерность", input: `{"Type":6,"Body":[],"Dimensions":[3,0]}`, want: [][]int32{{}, {}, {}}},
+		{name: "Отрицательные размерности", input: `{"Type":6,"Body":[1,2,3],"Dimensions":[-1,-3]}`, wantErr: true},
+		{name: "Несовпадение с числом элементов", input: `{"Type":6,"Body":[1,2,3],"Dimensions":[2,2]}`, wantErr: true},
+		{name: "Переполнение произведения", input: `{"Type":6,"Body":[1,2,3],"Dimensions":[2147483647,2147483647,2147483647]}`, wantErr: true},
+	}
+	dec := &JSONDecoder{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := dec.Variant([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Variant() ошибка = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(v.Value(), tt.want) {
+				t.Errorf("Variant() = %#v, ожидалось %#v", v.Value(), tt.want)
+			}
+		})
+	}
+
+	// Размерности могут прийти не только из JSON: reshape проверяет их сам
+	if _, err := reshape(reflect.ValueOf([]int32{1, 2, 3}), []int{-1, -3}); err == nil {
+		t.Errorf("reshape([-1 -3]) должна вернуть ошибку")
+	}
+}
+
+// fakeTypes - описание типов без обращения к серверу
+type fakeTypes map[string]*datatype.Definition
+
+func (f fakeTypes) DataType(id *ua.NodeID) (*datatype.Definition, error) {
+	if def, ok := f[id.String()]; ok {
+		return def, nil
+	}
+	return nil, fmt.Errorf("unknown data type %s", id)
+}
+
+func (f fakeTypes) Encoding(id *ua.NodeID) (*datatype.Definition, error) {
+	for _, def := range f {
+		if def.EncodingID.Equal(id) {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown encoding %s", id)
+}
+
+// TestJSONStructure проверяет кодирование разобранной структуры и обратное
+// преобразование JSON-объекта с полями в структуру для записи.
+func TestJSONStructure(t *testing.T) {
+	mode := &datatype.Definition{Name: "Mode", Kind: datatype.KindEnum, EnumValues: []datatype.EnumValue{{Value: 2, Name: "Running"}}}
+	def := &datatype.Definition{
+		NodeID:     ua.NewNumericNodeID(2, 3001),
+		Name:       "Motor",
+		Kind:       datatype.KindStructure,
+		EncodingID: ua.NewNumericNodeID(2, 5001),
+		Fields: []*datatype.Field{
+			{Name: "Speed", Type: &datatype.Definition{Kind: datatype.KindBuiltin, Builtin: ua.TypeIDDouble}, ValueRank: -1},
+			{Name: "Mode", Type: mode, ValueRank: -1},
+		},
+	}
+	types := fakeTypes{def.NodeID.String(): def}
+
+	dec := &JSONDecoder{Types: types}
+	s, err := dec.Structure(def, []byte(`{"Speed":12.5,"Mode":"Running"}`))
+	if err != nil {
+		t.Fatalf("Structure() получена непредвиденная ошибка = %v", err)
+	}
+	eo := &ua.ExtensionObject{TypeID: ua.NewExpandedNodeID(def.EncodingID, "", 0), Value: s}
+	eo.UpdateMask()
+
+	enc := &JSONEncoder{}
+	b, _ := json.Marshal(enc.Any(eo))
+	if string(b) != `{"Speed":12.5,"Mode":"Running_2"}` {
+		t.Errorf("необратимая форма = %s", b)
+	}
+
+	enc.Reversible = true
+	b, _ = json.Marshal(enc.Variant(ua.MustVariant(eo)))
+	want := `{"Type":22,"Body":{"TypeId":"ns=2;i=5001","Body":{"Speed":12.5,"Mode":2}}}`
+	if string(b) != want {
+		t.Fatalf("обратимая форма = %s, ожидалось %s", b, want)
+	}
+
+	v, err := dec.Variant(b)
+	if err != nil {
+		t.Fatalf("Variant() получена непредвиденная ошибка = %v", err)
+	}
+	out, ok := v.ExtensionObject().Value.(*datatype.Structure)
+	if !ok || !reflect.DeepEqual(out.Fields, s.Fields) {
+		t.Errorf("структура после обратного преобразования = %+v", v.ExtensionObject().Value)
+	}
+
+	if _, err := dec.Structure(def, []byte(`{"Speed":1}`)); err == nil {
+		t.Errorf("Structure() должна вернуть ошибку при отсутствии обязательного поля")
+	}
+}
+
+// TestEnumValue проверяет вывод целых значений переменной-перечисления.
+//
+// Основные аспекты тестирования:
+// - Текстовый вывод "2 (Running)" для скаляра и массива.
+// - Необратимая JSON-форма Name_Value и число в обратимой форме.
+// - Значение без имени в описании выводится числом.
+func TestEnumValue(t *testing.T) {
+	def := &datatype.Definition{Name: "ServerState", Kind: datatype.KindEnum, EnumValues: []datatype.EnumValue{{Value: 0, Name: "Running"}, {Value: 2, Name: "NoConfiguration"}}}
+
+	if s := EnumValue(ua.MustVariant(int32(2)), def); s != "2 (NoConfiguration)" {
+		t.Errorf("EnumValue() = %q", s)
+	}
+	if s := EnumValue(ua.MustVariant([]uint32{0, 5}), def); s != "[0 (Running), 5]" {
+		t.Errorf("EnumValue() для массива = %q", s)
+	}
+	if s := EnumDataValue(&ua.DataValue{Value: ua.MustVariant(int32(0)), Status: ua.StatusBadNoCommunication}, def); s != "0 (Running) [BadNoCommunication]" {
+		t.Errorf("EnumDataValue() = %q", s)
+	}
+
+	enc := &JSONEncoder{Enum: def}
+	b, _ := json.Marshal(enc.Variant(ua.MustVariant(int32(0))))
+	if string(b) != `"Running_0"` {
+		t.Errorf("необратимая форма = %s", b)
+	}
+	enc.Reversible = true
+	b, _ = json.Marshal(enc.Variant(ua.MustVariant(int32(0))))
+	if string(b) != `{"Type":6,"Body":0}` {
+		t.Errorf("обратимая форма = %s", b)
+	}
+}
@@ -0,0 +1,138 @@
+package formatter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// ResolveNodeID разбирает NodeId, введённый пользователем: в значениях типа
+// NodeId, атрибуте DataType и типах ссылок. Без подключения к серверу
+// принимается только форма ns=<индекс>;...; пакет client заменяет его своим
+// разбором, который понимает и nsu=<URI>;..., и пути просмотра.
+var ResolveNodeID = ua.ParseNodeID
+
+// Parse разбирает текстовое значение, введённое пользователем, в значение
+// встроенного типа t. Это обратная операция к Value для скалярных значений.
+func Parse(t ua.TypeID, s string) (interface{}, error) {
+	switch t {
+	case ua.TypeIDBoolean:
+		return strconv.ParseBool(s)
+	case ua.TypeIDSByte:
+		n, err := parseInt(s, 8)
+		return int8(n), err
+	case ua.TypeIDByte:
+		n, err := parseUint(s, 8)
+		return uint8(n), err
+	case ua.TypeIDInt16:
+		n, err := parseInt(s, 16)
+		return int16(n), err
+	case ua.TypeIDUint16:
+		n, err := parseUint(s, 16)
+		return uint16(n), err
+	case ua.TypeIDInt32:
+		n, err := parseInt(s, 32)
+		return int32(n), err
+	case ua.TypeIDUint32:
+		n, err := parseUint(s, 32)
+		return uint32(n), err
+	case ua.TypeIDInt64:
+		return parseInt(s, 64)
+	case ua.TypeIDUint64:
+		return parseUint(s, 64)
+	case ua.TypeIDFloat:
+		f, err := strconv.ParseFloat(s, 32)
+		return float32(f), err
+	case ua.TypeIDDouble:
+		return strconv.ParseFloat(s, 64)
+	case ua.TypeIDString:
+		return s, nil
+	case ua.TypeIDXMLElement:
+		return ua.XMLElement(s), nil
+	case ua.TypeIDDateTime:
+		return time.Parse(time.RFC3339Nano, s)
+	case ua.TypeIDGUID:
+		// NewGUID возвращает nil для неверной записи, а не ошибку
+		g := ua.NewGUID(s)
+		if g == nil {
+			return nil, fmt.Errorf("invalid Guid value %q", s)
+		}
+		return g, nil
+	case ua.TypeIDByteString:
+		return base64.StdEncoding.DecodeString(s)
+	case ua.TypeIDNodeID:
+		return ResolveNodeID(s)
+	case ua.TypeIDLocalizedText:
+		return ua.NewLocalizedText(s), nil
+	case ua.TypeIDQualifiedName:
+		q := &ua.QualifiedName{Name: s}
+		if i := strings.Index(s, ":"); i > 0 {
+			if ns, err := strconv.ParseUint(s[:i], 10, 16); err == nil {
+				q.NamespaceIndex, q.Name = uint16(ns), s[i+1:]
+			}
+		}
+		return q, nil
+	case ua.TypeIDStatusCode:
+		for code := range ua.StatusCodes {
+			if strings.EqualFold(StatusName(code), s) {
+				return code, nil
+			}
+		}
+		n, err := parseUint(s, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unknown status code %q", s)
+		}
+		return ua.StatusCode(n), nil
+	}
+	return nil, fmt.Errorf("values of type %s cannot be entered as text, use the JSON form", TypeName(t))
+}
+
+// parseInt разбирает целое число в десятичной записи или шестнадцатеричной
+// с префиксом 0x. Основание 0 из strconv не подходит: ведущий ноль в нём
+// означает восьмеричную запись, и уставка 010 записалась бы как 8.
+func parseInt(s string, bits int) (int64, error) {
+	s, base := numberBase(s)
+	return strconv.ParseInt(s, base, bits)
+}
+
+// parseUint - то же, что parseInt, для беззнаковых чисел
+func parseUint(s string, bits int) (uint64, error) {
+	s, base := numberBase(s)
+	return strconv.ParseUint(s, base, bits)
+}
+
+// numberBase отделяет префикс 0x (после знака) и возвращает основание
+func numberBase(s string) (string, int) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(digits) > 2 && (digits[:2] == "0x" || digits[:2] == "0X") {
+		return s[:len(s)-len(digits)] + digits[2:], 16
+	}
+	return s, 10
+}
+
+// timeLayouts - форматы абсолютного времени; время без зоны - местное
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseTime разбирает абсолютное время: RFC 3339 или местное время
+// 2006-01-02 15:04:05
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", s)
+}
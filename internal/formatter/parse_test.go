@@ -0,0 +1,50 @@
+package formatter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestParse проверяет разбор текстовых значений встроенных типов.
+//
+// Основные аспекты тестирования:
+// - Значения приводятся к Go-типу, соответствующему типу OPC UA.
+// - Целые числа десятичные, шестнадцатеричные только с префиксом 0x.
+// - Неверная запись Guid - ошибка, а не nil-значение.
+// - Типы без текстовой формы требуют JSON.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     ua.TypeID
+		input   string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "Int16", typ: ua.TypeIDInt16, input: "-7", want: int16(-7)},
+		{name: "Double", typ: ua.TypeIDDouble, input: "1.5", want: 1.5},
+		{name: "StatusCode по имени", typ: ua.TypeIDStatusCode, input: "BadTimeout", want: ua.StatusBadTimeout},
+		{name: "Guid", typ: ua.TypeIDGUID, input: "72962B91-FA75-4AE6-8D28-B404DC7DAF63", want: ua.NewGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63")},
+		{name: "Неверный Guid", typ: ua.TypeIDGUID, input: "not-a-guid", wantErr: true},
+		{name: "Ведущий ноль - десятичное число", typ: ua.TypeIDInt32, input: "010", want: int32(10)},
+		{name: "Шестнадцатеричное число", typ: ua.TypeIDUint16, input: "0xFF", want: uint16(255)},
+		{name: "Отрицательное шестнадцатеричное", typ: ua.TypeIDInt64, input: "-0x10", want: int64(-16)},
+		{name: "Разделители разрядов", typ: ua.TypeIDInt32, input: "1_000", wantErr: true},
+		{name: "Двоичная запись", typ: ua.TypeIDByte, input: "0b101", wantErr: true},
+		{name: "Восьмеричная запись", typ: ua.TypeIDUint32, input: "0o17", wantErr: true},
+		{name: "Неверное число", typ: ua.TypeIDInt32, input: "abc", wantErr: true},
+		{name: "Тип без текстовой формы", typ: ua.TypeIDExtensionObject, input: "{}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.typ, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() ошибка = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, ожидалось %#v", got, tt.want)
+			}
+		})
+	}
+}
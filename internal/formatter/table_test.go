@@ -0,0 +1,35 @@
+package formatter
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestTable проверяет табличный вывод и CSV.
+//
+// Основные аспекты тестирования:
+// - Столбцы выравниваются по самому широкому значению.
+// - Переводы строк в значениях не ломают таблицу.
+// - CSV экранирует запятые и кавычки.
+func TestTable(t *testing.T) {
+	header := []string{"Time", "Value"}
+	rows := [][]string{{"10:00", "1.5"}, {"10:00:01", "a\nb"}}
+
+	var buf bytes.Buffer
+	if err := Table(&buf, header, rows); err != nil {
+		t.Fatalf("Table() получена непредвиденная ошибка = %v", err)
+	}
+	want := "Time      Value\n10:00     1.5\n10:00:01  a b\n"
+	if buf.String() != want {
+		t.Errorf("Table() = %q, ожидалось %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := CSV(&buf, header, [][]string{{"10:00", `1,5 "bar"`}}); err != nil {
+		t.Fatalf("CSV() получена непредвиденная ошибка = %v", err)
+	}
+	want = "Time,Value\n10:00,\"1,5 \"\"bar\"\"\"\n"
+	if buf.String() != want {
+		t.Errorf("CSV() = %q, ожидалось %q", buf.String(), want)
+	}
+}
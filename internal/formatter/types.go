@@ -0,0 +1,70 @@
+package formatter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// builtinTypes описывает встроенные типы OPC UA (Part 6, 5.1.2):
+// имя типа и соответствующий тип Go в gopcua
+var builtinTypes = map[ua.TypeID]struct {
+	name string
+	typ  reflect.Type
+}{
+	ua.TypeIDBoolean:         {"Boolean", reflect.TypeOf(false)},
+	ua.TypeIDSByte:           {"SByte", reflect.TypeOf(int8(0))},
+	ua.TypeIDByte:            {"Byte", reflect.TypeOf(uint8(0))},
+	ua.TypeIDInt16:           {"Int16", reflect.TypeOf(int16(0))},
+	ua.TypeIDUint16:          {"UInt16", reflect.TypeOf(uint16(0))},
+	ua.TypeIDInt32:           {"Int32", reflect.TypeOf(int32(0))},
+	ua.TypeIDUint32:          {"UInt32", reflect.TypeOf(uint32(0))},
+	ua.TypeIDInt64:           {"Int64", reflect.TypeOf(int64(0))},
+	ua.TypeIDUint64:          {"UInt64", reflect.TypeOf(uint64(0))},
+	ua.TypeIDFloat:           {"Float", reflect.TypeOf(float32(0))},
+	ua.TypeIDDouble:          {"Double", reflect.TypeOf(float64(0))},
+	ua.TypeIDString:          {"String", reflect.TypeOf("")},
+	ua.TypeIDDateTime:        {"DateTime", reflect.TypeOf(time.Time{})},
+	ua.TypeIDGUID:            {"Guid", reflect.TypeOf(new(ua.GUID))},
+	ua.TypeIDByteString:      {"ByteString", reflect.TypeOf([]byte{})},
+	ua.TypeIDXMLElement:      {"XmlElement", reflect.TypeOf(ua.XMLElement(""))},
+	ua.TypeIDNodeID:          {"NodeId", reflect.TypeOf(new(ua.NodeID))},
+	ua.TypeIDExpandedNodeID:  {"ExpandedNodeId", reflect.TypeOf(new(ua.ExpandedNodeID))},
+	ua.TypeIDStatusCode:      {"StatusCode", reflect.TypeOf(ua.StatusCode(0))},
+	ua.TypeIDQualifiedName:   {"QualifiedName", reflect.TypeOf(new(ua.QualifiedName))},
+	ua.TypeIDLocalizedText:   {"LocalizedText", reflect.TypeOf(new(ua.LocalizedText))},
+	ua.TypeIDExtensionObject: {"ExtensionObject", reflect.TypeOf(new(ua.ExtensionObject))},
+	ua.TypeIDDataValue:       {"DataValue", reflect.TypeOf(new(ua.DataValue))},
+	ua.TypeIDVariant:         {"Variant", reflect.TypeOf(new(ua.Variant))},
+	ua.TypeIDDiagnosticInfo:  {"DiagnosticInfo", reflect.TypeOf(new(ua.DiagnosticInfo))},
+}
+
+// TypeName возвращает имя встроенного типа OPC UA (Double, LocalizedText, ...)
+func TypeName(t ua.TypeID) string {
+	if b, ok := builtinTypes[t]; ok {
+		return b.name
+	}
+	if t == ua.TypeIDNull {
+		return "Null"
+	}
+	return fmt.Sprintf("Type%d", uint8(t))
+}
+
+// ParseTypeName находит встроенный тип по имени без учёта регистра
+func ParseTypeName(name string) (ua.TypeID, bool) {
+	for id, b := range builtinTypes {
+		if strings.EqualFold(b.name, name) {
+			return id, true
+		}
+	}
+	return ua.TypeIDNull, false
+}
+
+// goType возвращает тип Go, которым gopcua представляет встроенный тип
+func goType(t ua.TypeID) (reflect.Type, bool) {
+	b, ok := builtinTypes[t]
+	return b.typ, ok
+}
@@ -0,0 +1,248 @@
+package nodeset
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// DiffOptions задаёт параметры сравнения наборов узлов
+type DiffOptions struct {
+	// Values включает сравнение значений переменных
+	Values bool
+}
+
+// Change - изменение атрибута, ссылки или значения узла. Для добавленной
+// ссылки Old пуст, для удалённой - New.
+type Change struct {
+	Attribute string `json:"attribute"`
+	Old       string `json:"old,omitempty"`
+	New       string `json:"new,omitempty"`
+}
+
+// NodeDiff - различие одного узла. NodeID записывается с URI пространства
+// имён (nsu=...), чтобы не зависеть от таблиц пространств имён серверов.
+type NodeDiff struct {
+	NodeID     string   `json:"nodeId"`
+	BrowseName string   `json:"browseName"`
+	NodeClass  string   `json:"nodeClass"`
+	Changes    []Change `json:"changes,omitempty"`
+}
+
+// Diff - результат сравнения наборов узлов
+type Diff struct {
+	Added   []NodeDiff `json:"added"`
+	Removed []NodeDiff `json:"removed"`
+	Changed []NodeDiff `json:"changed"`
+}
+
+// Empty сообщает, что наборы совпадают
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare сравнивает наборы узлов a и b. Узлы сопоставляются по NodeId
+// с учётом URI пространств имён; атрибуты сравниваются в том виде, в каком
+// они записываются в UANodeSet, поэтому отсутствующий атрибут равен
+// значению по умолчанию.
+func Compare(a, b *NodeSet, opts DiffOptions) (*Diff, error) {
+	left, err := canonical(a)
+	if err != nil {
+		return nil, err
+	}
+	right, err := canonical(b)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diff{}
+	for _, key := range sortedKeys(left) {
+		l := left[key]
+		r, ok := right[key]
+		if !ok {
+			d.Removed = append(d.Removed, nodeDiff(key, l))
+			continue
+		}
+		if changes := compareNodes(l, r, opts); len(changes) > 0 {
+			nd := nodeDiff(key, r)
+			nd.Changes = changes
+			d.Changed = append(d.Changed, nd)
+		}
+	}
+	for _, key := range sortedKeys(right) {
+		if _, ok := left[key]; !ok {
+			d.Added = append(d.Added, nodeDiff(key, right[key]))
+		}
+	}
+	return d, nil
+}
+
+// canonical преобразует узлы в элементы UANodeSet, в которых все NodeId
+// записаны с URI пространств имён
+func canonical(set *NodeSet) (map[string]*xmlNode, error) {
+	// Стандартные типы данных и ссылок записываются именами, как в Aliases
+	typeName := func(n *ua.NodeID) string {
+		if n != nil && n.Namespace() == 0 && n.Type() != ua.NodeIDTypeString {
+			if name := id.Name(n.IntID()); name != "" {
+				return name
+			}
+		}
+		return nsuNodeID(set, n)
+	}
+	res := make(map[string]*xmlNode, len(set.Nodes))
+	for _, n := range set.Nodes {
+		x, err := node(n, typeName)
+		if err != nil {
+			return nil, err
+		}
+		x.NodeID = nsuNodeID(set, n.NodeID)
+		if n.ParentNodeID != nil {
+			x.ParentNodeID = nsuNodeID(set, n.ParentNodeID)
+		}
+		if n.BrowseName != nil && n.BrowseName.NamespaceIndex != 0 {
+			x.BrowseName = namespaceURI(set, n.BrowseName.NamespaceIndex) + ":" + n.BrowseName.Name
+		}
+		for i, r := range n.References {
+			x.References[i].Target = nsuExpandedNodeID(set, r.Target)
+		}
+		res[x.NodeID] = x
+	}
+	return res, nil
+}
+
+// compareNodes сравнивает атрибуты, ссылки и значения двух узлов
+func compareNodes(l, r *xmlNode, opts DiffOptions) []Change {
+	var changes []Change
+	if l.XMLName.Local != r.XMLName.Local {
+		changes = append(changes, Change{"NodeClass", className(l), className(r)})
+	}
+
+	lv, rv := reflect.ValueOf(l).Elem(), reflect.ValueOf(r).Elem()
+	t := lv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch f.Name {
+		case "XMLName", "NodeID", "References", "Value":
+			continue
+		}
+		if o, n := fieldText(lv.Field(i)), fieldText(rv.Field(i)); o != n {
+			// Имя атрибута берётся из схемы UANodeSet (ParentNodeId, DataType)
+			name := strings.Split(f.Tag.Get("xml"), ",")[0]
+			changes = append(changes, Change{name, o, n})
+		}
+	}
+
+	lrefs, rrefs := referenceSet(l), referenceSet(r)
+	for _, ref := range sortedKeys(lrefs) {
+		if !rrefs[ref] {
+			changes = append(changes, Change{Attribute: "Reference", Old: ref})
+		}
+	}
+	for _, ref := range sortedKeys(rrefs) {
+		if !lrefs[ref] {
+			changes = append(changes, Change{Attribute: "Reference", New: ref})
+		}
+	}
+
+	if opts.Values {
+		if o, n := valueText(l), valueText(r); o != n {
+			changes = append(changes, Change{"Value", o, n})
+		}
+	}
+	return changes
+}
+
+func nodeDiff(key string, x *xmlNode) NodeDiff {
+	return NodeDiff{NodeID: key, BrowseName: x.BrowseName, NodeClass: className(x)}
+}
+
+func className(x *xmlNode) string {
+	return strings.TrimPrefix(x.XMLName.Local, "UA")
+}
+
+// fieldText возвращает текст атрибута: строку, разыменованный указатель
+// или текст LocalizedText
+func fieldText(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		if t, ok := v.Interface().(*xmlText); ok {
+			if t.Locale != "" {
+				return t.Locale + ":" + t.Text
+			}
+			return t.Text
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func referenceSet(x *xmlNode) map[string]bool {
+	res := make(map[string]bool, len(x.References))
+	for _, r := range x.References {
+		dir := "->"
+		if r.IsForward != nil && !*r.IsForward {
+			dir = "<-"
+		}
+		res[fmt.Sprintf("%s %s %s", r.ReferenceType, dir, r.Target)] = true
+	}
+	return res
+}
+
+func valueText(x *xmlNode) string {
+	if x.Value == nil {
+		return ""
+	}
+	return x.Value.Inner
+}
+
+func namespaceURI(set *NodeSet, ns uint16) string {
+	if ns == 0 {
+		return "http://opcfoundation.org/UA/"
+	}
+	if int(ns) <= len(set.NamespaceURIs) {
+		return set.NamespaceURIs[ns-1]
+	}
+	return fmt.Sprintf("ns%d", ns)
+}
+
+// nsuNodeID записывает NodeId с URI пространства имён вместо индекса;
+// узлы пространства имён 0 записываются как есть
+func nsuNodeID(set *NodeSet, n *ua.NodeID) string {
+	if n == nil {
+		return ""
+	}
+	if n.Namespace() == 0 {
+		return n.String()
+	}
+	s := n.String()
+	if i := strings.Index(s, ";"); i >= 0 {
+		s = s[i+1:]
+	}
+	return "nsu=" + namespaceURI(set, n.Namespace()) + ";" + s
+}
+
+func nsuExpandedNodeID(set *NodeSet, n *ua.ExpandedNodeID) string {
+	if n == nil || n.NodeID == nil {
+		return ""
+	}
+	s := nsuNodeID(set, n.NodeID)
+	if n.ServerIndex != 0 {
+		s = fmt.Sprintf("svr=%d;%s", n.ServerIndex, s)
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
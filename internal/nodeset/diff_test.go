@@ -0,0 +1,121 @@
+package nodeset
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+func testSet(ns string, nsIndex uint16) *NodeSet {
+	uris := []string{ns}
+	if nsIndex == 2 {
+		uris = []string{"urn:server", ns}
+	}
+	temp := ua.NewStringNodeID(nsIndex, "Boiler.Temp")
+	return &NodeSet{
+		NamespaceURIs: uris,
+		Nodes: []*Node{{
+			NodeID:      temp,
+			NodeClass:   ua.NodeClassVariable,
+			BrowseName:  &ua.QualifiedName{NamespaceIndex: nsIndex, Name: "Temp"},
+			DisplayName: &ua.LocalizedText{Text: "Temp"},
+			Attributes: map[ua.AttributeID]*ua.Variant{
+				ua.AttributeIDDataType:    ua.MustVariant(ua.NewNumericNodeID(0, 11)),
+				ua.AttributeIDAccessLevel: ua.MustVariant(uint8(1)),
+			},
+			References: []Reference{
+				{ReferenceType: ua.NewNumericNodeID(0, 47), Target: ua.NewExpandedNodeID(ua.NewStringNodeID(nsIndex, "Boiler"), "", 0)},
+			},
+			Value: ua.MustVariant(20.5),
+		}},
+	}
+}
+
+// TestReadRoundTrip проверяет, что записанный набор узлов читается без потерь.
+func TestReadRoundTrip(t *testing.T) {
+	set := testSet("urn:plant", 1)
+	var buf bytes.Buffer
+	if err := Write(&buf, set); err != nil {
+		t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() получена непредвиденная ошибка = %v", err)
+	}
+	if len(got.Nodes) != 1 || !reflect.DeepEqual(got.NamespaceURIs, set.NamespaceURIs) {
+		t.Fatalf("Read() = %+v", got)
+	}
+	n := got.Nodes[0]
+	if n.NodeID.String() != "ns=1;s=Boiler.Temp" || n.Attributes[ua.AttributeIDDataType].NodeID().IntID() != 11 {
+		t.Errorf("узел прочитан неверно: %+v", n)
+	}
+	if n.Value == nil || n.Value.Value() != 20.5 {
+		t.Errorf("значение = %v", n.Value)
+	}
+
+	d, err := Compare(set, got, DiffOptions{Values: true})
+	if err != nil || !d.Empty() {
+		t.Errorf("Compare() после чтения = %+v, %v", d, err)
+	}
+}
+
+// TestCompare проверяет сравнение наборов узлов.
+//
+// Основные аспекты тестирования:
+// - Узлы сопоставляются по URI пространства имён, а не по индексу.
+// - Изменения атрибутов, ссылок и значений.
+// - Добавленные и удалённые узлы.
+// - Значения сравниваются только по запросу.
+func TestCompare(t *testing.T) {
+	a := testSet("urn:plant", 1)
+	b := testSet("urn:plant", 2)
+
+	d, err := Compare(a, b, DiffOptions{Values: true})
+	if err != nil || !d.Empty() {
+		t.Fatalf("наборы с разными индексами пространств имён должны совпадать: %+v, %v", d, err)
+	}
+
+	n := b.Nodes[0]
+	n.Attributes[ua.AttributeIDDataType] = ua.MustVariant(ua.NewNumericNodeID(0, 10))
+	n.References = append(n.References, Reference{
+		ReferenceType: ua.NewNumericNodeID(0, 40),
+		Target:        ua.NewExpandedNodeID(ua.NewNumericNodeID(0, 2368), "", 0),
+		IsForward:     true,
+	})
+	n.Value = ua.MustVariant(float32(21))
+	b.Nodes = append(b.Nodes, &Node{
+		NodeID:     ua.NewStringNodeID(2, "Boiler.Level"),
+		NodeClass:  ua.NodeClassVariable,
+		BrowseName: &ua.QualifiedName{NamespaceIndex: 2, Name: "Level"},
+	})
+	a.Nodes = append(a.Nodes, &Node{
+		NodeID:     ua.NewStringNodeID(1, "Boiler.Old"),
+		NodeClass:  ua.NodeClassObject,
+		BrowseName: &ua.QualifiedName{NamespaceIndex: 1, Name: "Old"},
+	})
+
+	d, err = Compare(a, b, DiffOptions{})
+	if err != nil {
+		t.Fatalf("Compare() получена непредвиденная ошибка = %v", err)
+	}
+	if len(d.Added) != 1 || d.Added[0].NodeID != "nsu=urn:plant;s=Boiler.Level" {
+		t.Errorf("Added = %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].NodeClass != "Object" {
+		t.Errorf("Removed = %+v", d.Removed)
+	}
+	want := []Change{
+		{Attribute: "DataType", Old: "Double", New: "Float"},
+		{Attribute: "Reference", New: "HasTypeDefinition -> i=2368"},
+	}
+	if len(d.Changed) != 1 || !reflect.DeepEqual(d.Changed[0].Changes, want) {
+		t.Errorf("Changed = %+v, ожидалось %+v", d.Changed, want)
+	}
+
+	d, _ = Compare(a, b, DiffOptions{Values: true})
+	if changes := d.Changed[0].Changes; changes[len(changes)-1].Attribute != "Value" {
+		t.Errorf("изменение значения не найдено: %+v", changes)
+	}
+}
@@ -0,0 +1,320 @@
+// Package nodeset записывает узлы адресного пространства в формате UANodeSet
+// (Part 6, приложение F), который понимают SDK и инструменты моделирования
+package nodeset
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+const (
+	nodeSetNamespace = "http://opcfoundation.org/UA/2011/03/UANodeSet.xsd"
+	typesNamespace   = "http://opcfoundation.org/UA/2008/02/Types.xsd"
+)
+
+// NodeSet - набор узлов для экспорта. Индексы пространств имён в NodeId
+// узлов соответствуют позициям в NamespaceURIs, начиная с 1.
+type NodeSet struct {
+	NamespaceURIs []string
+	Nodes         []*Node
+	// LastModified - время снимка; нулевое значение не записывается
+	LastModified time.Time
+}
+
+// Node - узел со своими атрибутами и ссылками
+type Node struct {
+	NodeID      *ua.NodeID
+	NodeClass   ua.NodeClass
+	BrowseName  *ua.QualifiedName
+	DisplayName *ua.LocalizedText
+	Description *ua.LocalizedText
+	// ParentNodeID - родитель по иерархической ссылке
+	ParentNodeID *ua.NodeID
+	// Attributes - атрибуты, специфичные для класса узла (EventNotifier,
+	// DataType, ValueRank, AccessLevel, IsAbstract, InverseName и т.п.)
+	Attributes map[ua.AttributeID]*ua.Variant
+	References []Reference
+	// Value - текущее значение переменной; nil - не записывается
+	Value *ua.Variant
+}
+
+// Reference - ссылка узла
+type Reference struct {
+	ReferenceType *ua.NodeID
+	Target        *ua.ExpandedNodeID
+	IsForward     bool
+}
+
+// elementNames - элементы UANodeSet для классов узлов
+var elementNames = map[ua.NodeClass]string{
+	ua.NodeClassObject:        "UAObject",
+	ua.NodeClassVariable:      "UAVariable",
+	ua.NodeClassMethod:        "UAMethod",
+	ua.NodeClassObjectType:    "UAObjectType",
+	ua.NodeClassVariableType:  "UAVariableType",
+	ua.NodeClassReferenceType: "UAReferenceType",
+	ua.NodeClassDataType:      "UADataType",
+	ua.NodeClassView:          "UAView",
+}
+
+type xmlNodeSet struct {
+	XMLName       xml.Name   `xml:"UANodeSet"`
+	Xmlns         string     `xml:"xmlns,attr"`
+	Uax           string     `xml:"xmlns:uax,attr"`
+	LastModified  string     `xml:"LastModified,attr,omitempty"`
+	NamespaceURIs []string   `xml:"NamespaceUris>Uri,omitempty"`
+	Aliases       []xmlAlias `xml:"Aliases>Alias,omitempty"`
+	Nodes         []*xmlNode
+}
+
+type xmlAlias struct {
+	Alias  string `xml:"Alias,attr"`
+	NodeID string `xml:",chardata"`
+}
+
+type xmlNode struct {
+	XMLName                 xml.Name
+	NodeID                  string         `xml:"NodeId,attr"`
+	BrowseName              string         `xml:"BrowseName,attr"`
+	ParentNodeID            string         `xml:"ParentNodeId,attr,omitempty"`
+	DataType                string         `xml:"DataType,attr,omitempty"`
+	ValueRank               *int32         `xml:"ValueRank,attr,omitempty"`
+	ArrayDimensions         string         `xml:"ArrayDimensions,attr,omitempty"`
+	AccessLevel             *uint8         `xml:"AccessLevel,attr,omitempty"`
+	UserAccessLevel         *uint8         `xml:"UserAccessLevel,attr,omitempty"`
+	MinimumSamplingInterval *float64       `xml:"MinimumSamplingInterval,attr,omitempty"`
+	Historizing             *bool          `xml:"Historizing,attr,omitempty"`
+	EventNotifier           *uint8         `xml:"EventNotifier,attr,omitempty"`
+	IsAbstract              *bool          `xml:"IsAbstract,attr,omitempty"`
+	Symmetric               *bool          `xml:"Symmetric,attr,omitempty"`
+	ContainsNoLoops         *bool          `xml:"ContainsNoLoops,attr,omitempty"`
+	Executable              *bool          `xml:"Executable,attr,omitempty"`
+	UserExecutable          *bool          `xml:"UserExecutable,attr,omitempty"`
+	WriteMask               *uint32        `xml:"WriteMask,attr,omitempty"`
+	UserWriteMask           *uint32        `xml:"UserWriteMask,attr,omitempty"`
+	DisplayName             *xmlText       `xml:"DisplayName"`
+	Description             *xmlText       `xml:"Description,omitempty"`
+	References              []xmlReference `xml:"References>Reference,omitempty"`
+	// InverseName в схеме UAReferenceType следует после References
+	InverseName *xmlText  `xml:"InverseName,omitempty"`
+	Value       *xmlValue `xml:"Value,omitempty"`
+}
+
+type xmlText struct {
+	Locale string `xml:"Locale,attr,omitempty"`
+	Text   string `xml:",chardata"`
+}
+
+type xmlReference struct {
+	ReferenceType string `xml:"ReferenceType,attr"`
+	IsForward     *bool  `xml:"IsForward,attr,omitempty"`
+	Target        string `xml:",chardata"`
+}
+
+type xmlValue struct {
+	Inner string `xml:",innerxml"`
+}
+
+// Write записывает набор узлов в формате UANodeSet. Типы данных и ссылок
+// из пространства имён 0 записываются через псевдонимы (Aliases).
+func Write(w io.Writer, set *NodeSet) error {
+	aliases := make(map[string]string)
+	alias := func(n *ua.NodeID) string {
+		if n == nil {
+			return ""
+		}
+		if n.Namespace() == 0 && n.Type() != ua.NodeIDTypeString {
+			if name := id.Name(n.IntID()); name != "" {
+				aliases[name] = n.String()
+				return name
+			}
+		}
+		return n.String()
+	}
+
+	doc := &xmlNodeSet{
+		Xmlns:         nodeSetNamespace,
+		Uax:           typesNamespace,
+		NamespaceURIs: set.NamespaceURIs,
+	}
+	if !set.LastModified.IsZero() {
+		doc.LastModified = set.LastModified.UTC().Format(time.RFC3339)
+	}
+
+	for _, n := range set.Nodes {
+		x, err := node(n, alias)
+		if err != nil {
+			return err
+		}
+		doc.Nodes = append(doc.Nodes, x)
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		doc.Aliases = append(doc.Aliases, xmlAlias{Alias: name, NodeID: aliases[name]})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode nodeset: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// node преобразует узел в элемент UANodeSet. Атрибуты со значениями
+// по умолчанию схемы не записываются.
+func node(n *Node, alias func(*ua.NodeID) string) (*xmlNode, error) {
+	name, ok := elementNames[n.NodeClass]
+	if !ok {
+		return nil, fmt.Errorf("node %s: unsupported node class %d", n.NodeID, n.NodeClass)
+	}
+
+	x := &xmlNode{
+		XMLName:     xml.Name{Local: name},
+		NodeID:      n.NodeID.String(),
+		BrowseName:  browseName(n.BrowseName),
+		DisplayName: text(n.DisplayName),
+		Description: text(n.Description),
+	}
+	if x.DisplayName == nil {
+		x.DisplayName = &xmlText{}
+	}
+	if n.ParentNodeID != nil {
+		x.ParentNodeID = n.ParentNodeID.String()
+	}
+
+	for a, v := range n.Attributes {
+		if v == nil || v.Value() == nil {
+			continue
+		}
+		switch a {
+		case ua.AttributeIDDataType:
+			if dt := v.NodeID(); dt != nil && !(dt.Namespace() == 0 && dt.IntID() == id.BaseDataType) {
+				x.DataType = alias(dt)
+			}
+		case ua.AttributeIDValueRank:
+			if r := int32(v.Int()); r != -1 {
+				x.ValueRank = &r
+			}
+		case ua.AttributeIDArrayDimensions:
+			if dims, ok := v.Value().([]uint32); ok && len(dims) > 0 {
+				parts := make([]string, len(dims))
+				for i, d := range dims {
+					parts[i] = fmt.Sprint(d)
+				}
+				x.ArrayDimensions = strings.Join(parts, ",")
+			}
+		case ua.AttributeIDAccessLevel:
+			x.AccessLevel = byteAttr(v, 1)
+		case ua.AttributeIDUserAccessLevel:
+			x.UserAccessLevel = byteAttr(v, 1)
+		case ua.AttributeIDEventNotifier:
+			x.EventNotifier = byteAttr(v, 0)
+		case ua.AttributeIDMinimumSamplingInterval:
+			if f, ok := v.Value().(float64); ok && f != 0 {
+				x.MinimumSamplingInterval = &f
+			}
+		case ua.AttributeIDHistorizing:
+			x.Historizing = boolAttr(v)
+		case ua.AttributeIDIsAbstract:
+			x.IsAbstract = boolAttr(v)
+		case ua.AttributeIDSymmetric:
+			x.Symmetric = boolAttr(v)
+		case ua.AttributeIDContainsNoLoops:
+			x.ContainsNoLoops = boolAttr(v)
+		case ua.AttributeIDExecutable:
+			x.Executable = boolAttr(v)
+		case ua.AttributeIDUserExecutable:
+			x.UserExecutable = boolAttr(v)
+		case ua.AttributeIDWriteMask:
+			x.WriteMask = uint32Attr(v)
+		case ua.AttributeIDUserWriteMask:
+			x.UserWriteMask = uint32Attr(v)
+		case ua.AttributeIDInverseName:
+			x.InverseName = text(v.LocalizedText())
+		}
+	}
+
+	for _, r := range n.References {
+		ref := xmlReference{ReferenceType: alias(r.ReferenceType), Target: expandedNodeID(r.Target)}
+		if !r.IsForward {
+			f := false
+			ref.IsForward = &f
+		}
+		x.References = append(x.References, ref)
+	}
+
+	if n.Value != nil {
+		if inner, ok := Value(n.Value); ok {
+			x.Value = &xmlValue{Inner: inner}
+		}
+	}
+	return x, nil
+}
+
+func browseName(qn *ua.QualifiedName) string {
+	if qn == nil {
+		return ""
+	}
+	if qn.NamespaceIndex == 0 {
+		return qn.Name
+	}
+	return fmt.Sprintf("%d:%s", qn.NamespaceIndex, qn.Name)
+}
+
+func text(lt *ua.LocalizedText) *xmlText {
+	if lt == nil || lt.Text == "" {
+		return nil
+	}
+	return &xmlText{Locale: lt.Locale, Text: lt.Text}
+}
+
+func expandedNodeID(n *ua.ExpandedNodeID) string {
+	if n == nil || n.NodeID == nil {
+		return ""
+	}
+	if n.ServerIndex != 0 {
+		return fmt.Sprintf("svr=%d;%s", n.ServerIndex, n.NodeID)
+	}
+	return n.NodeID.String()
+}
+
+func byteAttr(v *ua.Variant, def uint8) *uint8 {
+	b := uint8(v.Uint())
+	if b == def {
+		return nil
+	}
+	return &b
+}
+
+func uint32Attr(v *ua.Variant) *uint32 {
+	n := uint32(v.Uint())
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+func boolAttr(v *ua.Variant) *bool {
+	b, ok := v.Value().(bool)
+	if !ok || !b {
+		return nil
+	}
+	return &b
+}
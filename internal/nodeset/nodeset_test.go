@@ -0,0 +1,136 @@
+package nodeset
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// TestWrite проверяет запись узлов в формате UANodeSet.
+//
+// Основные аспекты тестирования:
+// - Пространства имён и псевдонимы для типов из пространства имён 0.
+// - Атрибуты переменной; значения по умолчанию не записываются.
+// - Обратные ссылки с IsForward="false" и текущее значение.
+// - Результат является корректным XML.
+func TestWrite(t *testing.T) {
+	boiler := ua.NewStringNodeID(1, "Boiler")
+	set := &NodeSet{
+		NamespaceURIs: []string{"urn:plant"},
+		Nodes: []*Node{
+			{
+				NodeID:      boiler,
+				NodeClass:   ua.NodeClassObject,
+				BrowseName:  &ua.QualifiedName{NamespaceIndex: 1, Name: "Boiler"},
+				DisplayName: &ua.LocalizedText{Text: "Boiler"},
+				References: []Reference{
+					{ReferenceType: ua.NewNumericNodeID(0, 35), Target: ua.NewExpandedNodeID(ua.NewNumericNodeID(0, 85), "", 0)},
+					{ReferenceType: ua.NewNumericNodeID(0, 40), Target: ua.NewExpandedNodeID(ua.NewNumericNodeID(0, 58), "", 0), IsForward: true},
+				},
+			},
+			{
+				NodeID:       ua.NewStringNodeID(1, "Boiler.Temp"),
+				NodeClass:    ua.NodeClassVariable,
+				BrowseName:   &ua.QualifiedName{NamespaceIndex: 1, Name: "Temp"},
+				DisplayName:  &ua.LocalizedText{Locale: "en", Text: "Temperature <C>"},
+				ParentNodeID: boiler,
+				Attributes: map[ua.AttributeID]*ua.Variant{
+					ua.AttributeIDDataType:    ua.MustVariant(ua.NewNumericNodeID(0, 11)),
+					ua.AttributeIDValueRank:   ua.MustVariant(int32(-1)),
+					ua.AttributeIDAccessLevel: ua.MustVariant(uint8(3)),
+					ua.AttributeIDHistorizing: ua.MustVariant(false),
+				},
+				Value: ua.MustVariant(71.5),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, set); err != nil {
+		t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<Uri>urn:plant</Uri>`,
+		`<Alias Alias="Double">i=11</Alias>`,
+		`<Alias Alias="Organizes">i=35</Alias>`,
+		`<UAObject NodeId="ns=1;s=Boiler" BrowseName="1:Boiler">`,
+		`<Reference ReferenceType="Organizes" IsForward="false">i=85</Reference>`,
+		`<Reference ReferenceType="HasTypeDefinition">i=58</Reference>`,
+		`<UAVariable NodeId="ns=1;s=Boiler.Temp" BrowseName="1:Temp" ParentNodeId="ns=1;s=Boiler" DataType="Double" AccessLevel="3">`,
+		`<DisplayName Locale="en">Temperature &lt;C&gt;</DisplayName>`,
+		`<Value><uax:Double>71.5</uax:Double></Value>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("в результате нет %s:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"ValueRank", "Historizing"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("атрибут %s со значением по умолчанию не должен записываться", unwanted)
+		}
+	}
+
+	var doc struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil || doc.XMLName.Local != "UANodeSet" {
+		t.Errorf("результат не является документом UANodeSet: %v", err)
+	}
+}
+
+// TestWriteReferenceType проверяет порядок элементов узла ReferenceType:
+// по схеме UANodeSet.xsd InverseName записывается после References.
+func TestWriteReferenceType(t *testing.T) {
+	set := &NodeSet{
+		NamespaceURIs: []string{"urn:plant"},
+		Nodes: []*Node{{
+			NodeID:      ua.NewNumericNodeID(1, 4001),
+			NodeClass:   ua.NodeClassReferenceType,
+			BrowseName:  &ua.QualifiedName{NamespaceIndex: 1, Name: "Feeds"},
+			DisplayName: &ua.LocalizedText{Text: "Feeds"},
+			References: []Reference{
+				{ReferenceType: ua.NewNumericNodeID(0, 45), Target: ua.NewExpandedNodeID(ua.NewNumericNodeID(0, 33), "", 0)},
+			},
+			Attributes: map[ua.AttributeID]*ua.Variant{
+				ua.AttributeIDInverseName: ua.MustVariant(&ua.LocalizedText{EncodingMask: ua.LocalizedTextText, Text: "FedBy"}),
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, set); err != nil {
+		t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+	}
+	out := buf.String()
+	refs, inverse := strings.Index(out, "<References>"), strings.Index(out, "<InverseName>FedBy</InverseName>")
+	if refs < 0 || inverse < 0 || inverse < refs {
+		t.Errorf("InverseName должен следовать после References:\n%s", out)
+	}
+}
+
+// TestValue проверяет XML-кодирование значений встроенных типов.
+func TestValue(t *testing.T) {
+	tests := []struct {
+		v    *ua.Variant
+		want string
+	}{
+		{ua.MustVariant([]int32{1, 2}), `<uax:ListOfInt32><uax:Int32>1</uax:Int32><uax:Int32>2</uax:Int32></uax:ListOfInt32>`},
+		{ua.MustVariant(&ua.LocalizedText{Text: "a&b"}), `<uax:LocalizedText><uax:Text>a&amp;b</uax:Text></uax:LocalizedText>`},
+		{ua.MustVariant([]byte{1, 2}), `<uax:ByteString>AQI=</uax:ByteString>`},
+		{ua.MustVariant(ua.NewNumericNodeID(2, 7)), `<uax:NodeId><uax:Identifier>ns=2;i=7</uax:Identifier></uax:NodeId>`},
+	}
+	for _, tt := range tests {
+		got, ok := Value(tt.v)
+		if !ok || got != tt.want {
+			t.Errorf("Value(%v) = %s, %v, ожидалось %s", tt.v.Value(), got, ok, tt.want)
+		}
+	}
+	if _, ok := Value(ua.MustVariant(&ua.ExtensionObject{})); ok {
+		t.Errorf("Value() не должна кодировать ExtensionObject")
+	}
+}
@@ -0,0 +1,390 @@
+package nodeset
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// xmlElement - произвольный XML-элемент; используется для разбора значений
+type xmlElement struct {
+	XMLName  xml.Name
+	Content  string       `xml:",chardata"`
+	Children []xmlElement `xml:",any"`
+}
+
+type xmlInput struct {
+	NamespaceURIs []string   `xml:"NamespaceUris>Uri"`
+	Aliases       []xmlAlias `xml:"Aliases>Alias"`
+	LastModified  string     `xml:"LastModified,attr"`
+	Nodes         []xmlNode  `xml:",any"`
+}
+
+// Read разбирает документ UANodeSet. Псевдонимы типов заменяются их NodeId,
+// значения переменных восстанавливаются для тех же встроенных типов,
+// которые записывает Write.
+func Read(r io.Reader) (*NodeSet, error) {
+	var doc xmlInput
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse nodeset: %w", err)
+	}
+
+	aliases := make(map[string]string, len(doc.Aliases))
+	for _, a := range doc.Aliases {
+		aliases[a.Alias] = strings.TrimSpace(a.NodeID)
+	}
+	resolve := func(s string) (*ua.NodeID, error) {
+		if target, ok := aliases[s]; ok {
+			s = target
+		}
+		return ua.ParseNodeID(s)
+	}
+
+	set := &NodeSet{NamespaceURIs: doc.NamespaceURIs}
+	if doc.LastModified != "" {
+		set.LastModified, _ = time.Parse(time.RFC3339, doc.LastModified)
+	}
+
+	classes := make(map[string]ua.NodeClass, len(elementNames))
+	for c, name := range elementNames {
+		classes[name] = c
+	}
+
+	for _, x := range doc.Nodes {
+		class, ok := classes[x.XMLName.Local]
+		if !ok {
+			continue
+		}
+		n, err := readNode(&x, class, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", x.NodeID, err)
+		}
+		set.Nodes = append(set.Nodes, n)
+	}
+	return set, nil
+}
+
+func readNode(x *xmlNode, class ua.NodeClass, resolve func(string) (*ua.NodeID, error)) (*Node, error) {
+	nodeID, err := ua.ParseNodeID(x.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{
+		NodeID:      nodeID,
+		NodeClass:   class,
+		BrowseName:  parseBrowseName(x.BrowseName),
+		DisplayName: localizedText(x.DisplayName),
+		Description: localizedText(x.Description),
+		Attributes:  make(map[ua.AttributeID]*ua.Variant),
+	}
+	if x.ParentNodeID != "" {
+		if n.ParentNodeID, err = resolve(x.ParentNodeID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Атрибуты записываются так же, как их читает сервер; значения
+	// по умолчанию схемы восстанавливаются явно
+	set := func(a ua.AttributeID, v interface{}) {
+		n.Attributes[a] = ua.MustVariant(v)
+	}
+	switch class {
+	case ua.NodeClassVariable, ua.NodeClassVariableType:
+		dataType := "i=24"
+		if x.DataType != "" {
+			dataType = x.DataType
+		}
+		dt, err := resolve(dataType)
+		if err != nil {
+			return nil, err
+		}
+		set(ua.AttributeIDDataType, dt)
+		set(ua.AttributeIDValueRank, int32Or(x.ValueRank, -1))
+		if x.ArrayDimensions != "" {
+			var dims []uint32
+			for _, d := range strings.Split(x.ArrayDimensions, ",") {
+				v, err := strconv.ParseUint(strings.TrimSpace(d), 10, 32)
+				if err != nil {
+					return nil, fmt.Errorf("invalid ArrayDimensions: %s", x.ArrayDimensions)
+				}
+				dims = append(dims, uint32(v))
+			}
+			set(ua.AttributeIDArrayDimensions, dims)
+		}
+	}
+	switch class {
+	case ua.NodeClassVariable:
+		set(ua.AttributeIDAccessLevel, uint8Or(x.AccessLevel, 1))
+		set(ua.AttributeIDUserAccessLevel, uint8Or(x.UserAccessLevel, 1))
+		set(ua.AttributeIDHistorizing, boolOr(x.Historizing))
+		if x.MinimumSamplingInterval != nil {
+			set(ua.AttributeIDMinimumSamplingInterval, *x.MinimumSamplingInterval)
+		}
+	case ua.NodeClassObject:
+		set(ua.AttributeIDEventNotifier, uint8Or(x.EventNotifier, 0))
+	case ua.NodeClassView:
+		set(ua.AttributeIDEventNotifier, uint8Or(x.EventNotifier, 0))
+		set(ua.AttributeIDContainsNoLoops, boolOr(x.ContainsNoLoops))
+	case ua.NodeClassMethod:
+		set(ua.AttributeIDExecutable, boolOr(x.Executable))
+		set(ua.AttributeIDUserExecutable, boolOr(x.UserExecutable))
+	case ua.NodeClassReferenceType:
+		set(ua.AttributeIDSymmetric, boolOr(x.Symmetric))
+		if lt := localizedText(x.InverseName); lt != nil {
+			set(ua.AttributeIDInverseName, lt)
+		}
+	}
+	switch class {
+	case ua.NodeClassObjectType, ua.NodeClassVariableType, ua.NodeClassReferenceType, ua.NodeClassDataType:
+		set(ua.AttributeIDIsAbstract, boolOr(x.IsAbstract))
+	}
+	if x.WriteMask != nil {
+		set(ua.AttributeIDWriteMask, *x.WriteMask)
+	}
+	if x.UserWriteMask != nil {
+		set(ua.AttributeIDUserWriteMask, *x.UserWriteMask)
+	}
+
+	for _, r := range x.References {
+		refType, err := resolve(r.ReferenceType)
+		if err != nil {
+			return nil, err
+		}
+		target, err := parseExpandedNodeID(strings.TrimSpace(r.Target))
+		if err != nil {
+			return nil, err
+		}
+		n.References = append(n.References, Reference{
+			ReferenceType: refType,
+			Target:        target,
+			IsForward:     r.IsForward == nil || *r.IsForward,
+		})
+	}
+
+	// Содержимое Value разбирается отдельно: элемент записывается как innerxml
+	var value xmlElement
+	if x.Value != nil && xml.Unmarshal([]byte("<Value>"+x.Value.Inner+"</Value>"), &value) == nil && len(value.Children) == 1 {
+		if v, err := parseValue(&value.Children[0]); err == nil {
+			n.Value = v
+		}
+	}
+	return n, nil
+}
+
+func parseBrowseName(s string) *ua.QualifiedName {
+	if i := strings.Index(s, ":"); i > 0 {
+		if ns, err := strconv.ParseUint(s[:i], 10, 16); err == nil {
+			return &ua.QualifiedName{NamespaceIndex: uint16(ns), Name: s[i+1:]}
+		}
+	}
+	return &ua.QualifiedName{Name: s}
+}
+
+func parseExpandedNodeID(s string) (*ua.ExpandedNodeID, error) {
+	var server uint32
+	if strings.HasPrefix(s, "svr=") {
+		i := strings.Index(s, ";")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid node ID: %s", s)
+		}
+		v, err := strconv.ParseUint(s[4:i], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node ID: %s", s)
+		}
+		server, s = uint32(v), s[i+1:]
+	}
+	n, err := ua.ParseNodeID(s)
+	if err != nil {
+		return nil, err
+	}
+	return ua.NewExpandedNodeID(n, "", server), nil
+}
+
+func localizedText(x *xmlText) *ua.LocalizedText {
+	if x == nil || x.Text == "" {
+		return nil
+	}
+	lt := &ua.LocalizedText{Locale: x.Locale, Text: x.Text}
+	lt.UpdateMask()
+	return lt
+}
+
+func int32Or(p *int32, def int32) int32 {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+func uint8Or(p *uint8, def uint8) uint8 {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+func boolOr(p *bool) bool {
+	return p != nil && *p
+}
+
+// parseValue восстанавливает Variant из XML-представления (Part 6, 5.3)
+func parseValue(e *xmlElement) (*ua.Variant, error) {
+	name := e.XMLName.Local
+	if strings.HasPrefix(name, "ListOf") {
+		items := make([]interface{}, 0, len(e.Children))
+		for i := range e.Children {
+			v, err := parseScalar(&e.Children[i])
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return typedSlice(strings.TrimPrefix(name, "ListOf"), items)
+	}
+	v, err := parseScalar(e)
+	if err != nil {
+		return nil, err
+	}
+	return ua.NewVariant(v)
+}
+
+// parseScalar разбирает одиночное значение встроенного типа
+func parseScalar(e *xmlElement) (interface{}, error) {
+	s := strings.TrimSpace(e.Content)
+	child := func(name string) string {
+		for _, c := range e.Children {
+			if c.XMLName.Local == name {
+				return strings.TrimSpace(c.Content)
+			}
+		}
+		return ""
+	}
+
+	switch e.XMLName.Local {
+	case "Boolean":
+		return strconv.ParseBool(s)
+	case "SByte":
+		v, err := strconv.ParseInt(s, 10, 8)
+		return int8(v), err
+	case "Byte":
+		v, err := strconv.ParseUint(s, 10, 8)
+		return uint8(v), err
+	case "Int16":
+		v, err := strconv.ParseInt(s, 10, 16)
+		return int16(v), err
+	case "UInt16":
+		v, err := strconv.ParseUint(s, 10, 16)
+		return uint16(v), err
+	case "Int32":
+		v, err := strconv.ParseInt(s, 10, 32)
+		return int32(v), err
+	case "UInt32":
+		v, err := strconv.ParseUint(s, 10, 32)
+		return uint32(v), err
+	case "Int64":
+		return strconv.ParseInt(s, 10, 64)
+	case "UInt64":
+		return strconv.ParseUint(s, 10, 64)
+	case "Float":
+		v, err := parseFloat(s, 32)
+		return float32(v), err
+	case "Double":
+		return parseFloat(s, 64)
+	case "String":
+		return e.Content, nil
+	case "DateTime":
+		return time.Parse(time.RFC3339Nano, s)
+	case "ByteString":
+		return base64.StdEncoding.DecodeString(s)
+	case "Guid":
+		return ua.NewGUID(child("String")), nil
+	case "NodeId":
+		return ua.ParseNodeID(child("Identifier"))
+	case "StatusCode":
+		v, err := strconv.ParseUint(child("Code"), 10, 32)
+		return ua.StatusCode(v), err
+	case "QualifiedName":
+		ns, err := strconv.ParseUint(child("NamespaceIndex"), 10, 16)
+		if child("NamespaceIndex") == "" {
+			ns, err = 0, nil
+		}
+		return &ua.QualifiedName{NamespaceIndex: uint16(ns), Name: child("Name")}, err
+	case "LocalizedText":
+		lt := &ua.LocalizedText{Locale: child("Locale"), Text: child("Text")}
+		lt.UpdateMask()
+		return lt, nil
+	}
+	return nil, fmt.Errorf("unsupported value type: %s", e.XMLName.Local)
+}
+
+func parseFloat(s string, bits int) (float64, error) {
+	switch s {
+	case "INF":
+		s = "+Inf"
+	case "-INF":
+		s = "-Inf"
+	}
+	return strconv.ParseFloat(s, bits)
+}
+
+// typedSlice собирает срез нужного типа для Variant
+func typedSlice(name string, items []interface{}) (*ua.Variant, error) {
+	var out interface{}
+	switch name {
+	case "Boolean":
+		out = convertSlice[bool](items)
+	case "SByte":
+		out = convertSlice[int8](items)
+	case "Byte":
+		out = convertSlice[uint8](items)
+	case "Int16":
+		out = convertSlice[int16](items)
+	case "UInt16":
+		out = convertSlice[uint16](items)
+	case "Int32":
+		out = convertSlice[int32](items)
+	case "UInt32":
+		out = convertSlice[uint32](items)
+	case "Int64":
+		out = convertSlice[int64](items)
+	case "UInt64":
+		out = convertSlice[uint64](items)
+	case "Float":
+		out = convertSlice[float32](items)
+	case "Double":
+		out = convertSlice[float64](items)
+	case "String":
+		out = convertSlice[string](items)
+	case "DateTime":
+		out = convertSlice[time.Time](items)
+	case "ByteString":
+		out = convertSlice[[]byte](items)
+	case "Guid":
+		out = convertSlice[*ua.GUID](items)
+	case "NodeId":
+		out = convertSlice[*ua.NodeID](items)
+	case "StatusCode":
+		out = convertSlice[ua.StatusCode](items)
+	case "QualifiedName":
+		out = convertSlice[*ua.QualifiedName](items)
+	case "LocalizedText":
+		out = convertSlice[*ua.LocalizedText](items)
+	default:
+		return nil, fmt.Errorf("unsupported value type: ListOf%s", name)
+	}
+	return ua.NewVariant(out)
+}
+
+func convertSlice[T any](items []interface{}) []T {
+	out := make([]T, len(items))
+	for i, v := range items {
+		out[i] = v.(T)
+	}
+	return out
+}
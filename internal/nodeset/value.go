@@ -0,0 +1,156 @@
+package nodeset
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+)
+
+// Value кодирует значение Variant в XML (Part 6, 5.3) для элемента Value.
+// Поддерживаются скаляры и одномерные массивы встроенных типов; для прочих
+// значений (структуры, многомерные массивы) возвращается false.
+func Value(v *ua.Variant) (string, bool) {
+	if v == nil || v.Value() == nil {
+		return "", false
+	}
+	if dims := v.ArrayDimensions(); len(dims) > 1 {
+		return "", false
+	}
+
+	rv := reflect.ValueOf(v.Value())
+	if rv.Kind() == reflect.Slice && v.Type() != ua.TypeIDByteString {
+		name, ok := elementName(v.Type())
+		if !ok {
+			return "", false
+		}
+		var b strings.Builder
+		b.WriteString("<uax:ListOf" + name + ">")
+		for i := 0; i < rv.Len(); i++ {
+			item, ok := scalar(v.Type(), rv.Index(i).Interface())
+			if !ok {
+				return "", false
+			}
+			b.WriteString(item)
+		}
+		b.WriteString("</uax:ListOf" + name + ">")
+		return b.String(), true
+	}
+	return scalar(v.Type(), v.Value())
+}
+
+// elementName возвращает имя XML-элемента встроенного типа
+func elementName(t ua.TypeID) (string, bool) {
+	switch t {
+	case ua.TypeIDBoolean:
+		return "Boolean", true
+	case ua.TypeIDSByte:
+		return "SByte", true
+	case ua.TypeIDByte:
+		return "Byte", true
+	case ua.TypeIDInt16:
+		return "Int16", true
+	case ua.TypeIDUint16:
+		return "UInt16", true
+	case ua.TypeIDInt32:
+		return "Int32", true
+	case ua.TypeIDUint32:
+		return "UInt32", true
+	case ua.TypeIDInt64:
+		return "Int64", true
+	case ua.TypeIDUint64:
+		return "UInt64", true
+	case ua.TypeIDFloat:
+		return "Float", true
+	case ua.TypeIDDouble:
+		return "Double", true
+	case ua.TypeIDString:
+		return "String", true
+	case ua.TypeIDDateTime:
+		return "DateTime", true
+	case ua.TypeIDGUID:
+		return "Guid", true
+	case ua.TypeIDByteString:
+		return "ByteString", true
+	case ua.TypeIDNodeID:
+		return "NodeId", true
+	case ua.TypeIDStatusCode:
+		return "StatusCode", true
+	case ua.TypeIDQualifiedName:
+		return "QualifiedName", true
+	case ua.TypeIDLocalizedText:
+		return "LocalizedText", true
+	}
+	return "", false
+}
+
+// scalar кодирует одиночное значение встроенного типа
+func scalar(t ua.TypeID, v interface{}) (string, bool) {
+	name, ok := elementName(t)
+	if !ok {
+		return "", false
+	}
+
+	var body string
+	switch x := v.(type) {
+	case bool:
+		body = strconv.FormatBool(x)
+	case int8, uint8, int16, uint16, int32, uint32, int64, uint64:
+		body = fmt.Sprint(x)
+	case float32:
+		body = float(float64(x), 32)
+	case float64:
+		body = float(x, 64)
+	case string:
+		body = escape(x)
+	case time.Time:
+		body = x.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		body = base64.StdEncoding.EncodeToString(x)
+	case *ua.GUID:
+		body = element("String", x.String())
+	case *ua.NodeID:
+		body = element("Identifier", x.String())
+	case ua.StatusCode:
+		body = element("Code", strconv.FormatUint(uint64(x), 10))
+	case *ua.QualifiedName:
+		body = element("NamespaceIndex", strconv.Itoa(int(x.NamespaceIndex))) + element("Name", x.Name)
+	case *ua.LocalizedText:
+		if x.Locale != "" {
+			body = element("Locale", x.Locale)
+		}
+		body += element("Text", x.Text)
+	default:
+		return "", false
+	}
+	return "<uax:" + name + ">" + body + "</uax:" + name + ">", true
+}
+
+// float записывает числа с плавающей точкой; специальные значения - в форме XML Schema
+func float(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+func element(name, value string) string {
+	return "<uax:" + name + ">" + escape(value) + "</uax:" + name + ">"
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
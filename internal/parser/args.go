@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/formatter"
+)
+
+// splitArgs разбивает строку ввода на аргументы по пробелам с учётом кавычек.
+// Одинарные и двойные кавычки позволяют передавать значения с пробелами
+// и JSON: write ns=2;s=Name "Pump 1", write ns=2;s=Tag '{"Type":6,"Body":1}'.
+func splitArgs(input string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range input {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in input")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// cmdArgs - разобранные аргументы команды: позиционные аргументы и флаги
+type cmdArgs struct {
+	positional []string
+	flags      map[string]string
+}
+
+// parseFlags отделяет флаги вида --name value от позиционных аргументов.
+// valueFlags перечисляет флаги, принимающие значение; остальные флаги
+// считаются булевыми. Поддерживается также форма --name=value.
+func parseFlags(args []string, valueFlags ...string) (*cmdArgs, error) {
+	takesValue := make(map[string]bool, len(valueFlags))
+	for _, f := range valueFlags {
+		takesValue[f] = true
+	}
+
+	res := &cmdArgs{flags: make(map[string]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") || len(arg) == 2 {
+			res.positional = append(res.positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg[2:], "=")
+		if takesValue[name] && !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag --%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if !takesValue[name] && !hasValue {
+			value = "true"
+		}
+		res.flags[name] = value
+	}
+	return res, nil
+}
+
+// has сообщает, был ли передан флаг
+func (a *cmdArgs) has(name string) bool {
+	_, ok := a.flags[name]
+	return ok
+}
+
+// get возвращает значение флага или значение по умолчанию
+func (a *cmdArgs) get(name, def string) string {
+	if v, ok := a.flags[name]; ok {
+		return v
+	}
+	return def
+}
+
+// only проверяет, что переданы только флаги из списка допустимых
+func (a *cmdArgs) only(names ...string) error {
+	allowed := make(map[string]bool, len(names))
+	for _, n := range names {
+		allowed[n] = true
+	}
+	for name := range a.flags {
+		if !allowed[name] {
+			return fmt.Errorf("unknown flag: --%s", name)
+		}
+	}
+	return nil
+}
+
+// getInt возвращает целое значение флага или значение по умолчанию
+func (a *cmdArgs) getInt(name string, def int) (int, error) {
+	v, ok := a.flags[name]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid value for --%s: %s", name, v)
+	}
+	return n, nil
+}
+
+// parseTime разбирает момент времени: now, смещение от текущего времени
+// (-1h, now-30m, -2d), RFC 3339 или местное время 2006-01-02 15:04:05
+func parseTime(s string, now time.Time) (time.Time, error) {
+	rel := strings.TrimPrefix(s, "now")
+	switch {
+	case rel == "":
+		return now, nil
+	case rel[0] == '-' || rel[0] == '+':
+		d, err := parseOffset(rel)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time: %s", s)
+		}
+		return now.Add(d), nil
+	}
+
+	return formatter.ParseTime(s)
+}
+
+// parseOffset разбирает смещение time.ParseDuration, дополненное днями: -2d
+func parseOffset(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
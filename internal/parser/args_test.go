@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSplitArgs проверяет разбиение строки ввода на аргументы с учётом кавычек.
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "Аргументы разделяются пробелами",
+			input: "read  ns=2;s=Tag   --format json",
+			want:  []string{"read", "ns=2;s=Tag", "--format", "json"},
+		},
+		{
+			name:  "Двойные кавычки сохраняют пробелы",
+			input: `write ns=2;s=Name "Pump 1"`,
+			want:  []string{"write", "ns=2;s=Name", "Pump 1"},
+		},
+		{
+			name:  "Одинарные кавычки сохраняют двойные",
+			input: `write i=1 '{"Type":1}'`,
+			want:  []string{"write", "i=1", `{"Type":1}`},
+		},
+		{
+			name:  "Пустые кавычки дают пустой аргумент",
+			input: `write i=1 ""`,
+			want:  []string{"write", "i=1", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitArgs(tt.input)
+			if err != nil {
+				t.Fatalf("splitArgs() получена непредвиденная ошибка = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArgs(%q) = %q, ожидалось %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseFlags проверяет отделение флагов от позиционных аргументов.
+func TestParseFlags(t *testing.T) {
+	a, err := parseFlags([]string{"i=2258", "--format", "json", "--reversible", "--max=10"}, "format", "max")
+	if err != nil {
+		t.Fatalf("parseFlags() получена непредвиденная ошибка = %v", err)
+	}
+	if !reflect.DeepEqual(a.positional, []string{"i=2258"}) {
+		t.Errorf("позиционные аргументы = %q", a.positional)
+	}
+	if a.get("format", "") != "json" || a.get("max", "") != "10" || !a.has("reversible") {
+		t.Errorf("флаги разобраны неверно: %v", a.flags)
+	}
+	if err := a.only("format", "max"); err == nil {
+		t.Errorf("only() должна вернуть ошибку для флага --reversible")
+	}
+
+	if _, err := parseFlags([]string{"--format"}, "format"); err == nil {
+		t.Errorf("parseFlags() должна вернуть ошибку для флага без значения")
+	}
+}
+
+// TestParseTime проверяет разбор моментов времени для команд history.
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "now", want: now},
+		{in: "-1h", want: now.Add(-time.Hour)},
+		{in: "now-30m", want: now.Add(-30 * time.Minute)},
+		{in: "+15s", want: now.Add(15 * time.Second)},
+		{in: "-2d", want: now.Add(-48 * time.Hour)},
+		{in: "2024-04-30T08:15:00Z", want: time.Date(2024, 4, 30, 8, 15, 0, 0, time.UTC)},
+		{in: "2024-04-30T08:15:00.5+02:00", want: time.Date(2024, 4, 30, 6, 15, 0, 5e8, time.UTC)},
+		{in: "2024-04-30 08:15:00", want: time.Date(2024, 4, 30, 8, 15, 0, 0, time.Local)},
+		{in: "2024-04-30 08:15", want: time.Date(2024, 4, 30, 8, 15, 0, 0, time.Local)},
+		{in: "2024-04-30", want: time.Date(2024, 4, 30, 0, 0, 0, 0, time.Local)},
+		{in: "yesterday", wantErr: true},
+		{in: "-1x", wantErr: true},
+		{in: "now+", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTime(%q) ошибка = %v, ожидалась ошибка %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q) = %v, ожидалось %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/commands"
+	"github.com/alexfrick92/opcli/internal/simulator"
+)
+
+// session - сервер имитации и выполнение команд opcli против него
+type session struct {
+	t   *testing.T
+	srv *simulator.Server
+}
+
+// newSession запускает сервер имитации на свободном порту localhost.
+// Клиент отключается, а сервер останавливается после теста.
+func newSession(t *testing.T) *session {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("интеграционный тест пропущен в режиме -short")
+	}
+	srv, err := simulator.Start(simulator.Config{Host: "localhost"})
+	if err != nil {
+		t.Fatalf("simulator.Start() получена непредвиденная ошибка = %v", err)
+	}
+	t.Cleanup(func() {
+		client.Disconnect()
+		srv.Close()
+	})
+	return &session{t: t, srv: srv}
+}
+
+// run выполняет команду через Execute и возвращает её вывод
+func (s *session) run(input string) (string, error) {
+	s.t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		s.t.Fatalf("os.Pipe() получена непредвиденная ошибка = %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out <- buf.String()
+	}()
+
+	err = Execute(input)
+
+	os.Stdout = stdout
+	w.Close()
+	return <-out, err
+}
+
+// ok выполняет команду, которая должна завершиться без ошибки
+func (s *session) ok(input string) string {
+	s.t.Helper()
+	out, err := s.run(input)
+	if err != nil {
+		s.t.Fatalf("%s: получена непредвиденная ошибка = %v", input, err)
+	}
+	return out
+}
+
+// fails выполняет команду, которая должна вернуть ошибку, содержащую want
+func (s *session) fails(input, want string) {
+	s.t.Helper()
+	if _, err := s.run(input); err == nil || !strings.Contains(err.Error(), want) {
+		s.t.Errorf("%s: получено %v, ожидалась ошибка %q", input, err, want)
+	}
+}
+
+// node возвращает NodeId узла имитатора
+func (s *session) node(path string) string {
+	return fmt.Sprintf("ns=%d;s=Simulation.%s", s.srv.Namespace(), path)
+}
+
+// TestIntegration проверяет команды opcli против сервера имитации,
+// запущенного в том же процессе.
+//
+// Основные аспекты тестирования:
+// - Подключение и вывод информации о сервере.
+// - Чтение, запись и просмотр атрибутов узлов.
+// - Чтение списка узлов и запись значений из файла с откатом.
+// - Ошибки сервера: неизвестный узел, запись без прав, неверный тип.
+// - Чтение и изменение архива значений.
+// - Отключение и команды без соединения.
+// - Ошибка подключения к недоступному серверу.
+func TestIntegration(t *testing.T) {
+	s := newSession(t)
+
+	s.fails("read "+s.node("Setpoint"), "not connected to server")
+
+	out := s.ok("connect " + s.srv.Endpoint())
+	for _, want := range []string{"Successfully connected!", "Product:      opcli simulator", "State:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("connect: вывод не содержит %q:\n%s", want, out)
+		}
+	}
+
+	if out := s.ok("read " + s.node("Setpoint")); strings.TrimSpace(out) != "20" {
+		t.Errorf("read Setpoint = %q, ожидалось 20", out)
+	}
+	if out := s.ok("read nsu=" + simulator.DefaultNamespace + ";s=Simulation.Setpoint"); strings.TrimSpace(out) != "20" {
+		t.Errorf("read по URI пространства имён = %q, ожидалось 20", out)
+	}
+	if out := s.ok("read /Objects/1:Simulation/1:Setpoint"); strings.TrimSpace(out) != "20" {
+		t.Errorf("read по пути просмотра = %q, ожидалось 20", out)
+	}
+	if out := s.ok("read Objects.Server.NamespaceArray"); !strings.Contains(out, simulator.DefaultNamespace) {
+		t.Errorf("read Objects.Server.NamespaceArray = %q, ожидалось %s", out, simulator.DefaultNamespace)
+	}
+	if out := s.ok("namespaces"); !strings.Contains(out, simulator.DefaultNamespace) {
+		t.Errorf("namespaces: вывод не содержит %s:\n%s", simulator.DefaultNamespace, out)
+	}
+	if out := s.ok("read " + s.node("Label") + " --format json"); !strings.Contains(out, `"Value": "opcli"`) {
+		t.Errorf("read Label --format json = %q", out)
+	}
+	if out := s.ok("read " + s.node("Missing")); !strings.Contains(out, "BadNodeIdUnknown") {
+		t.Errorf("read неизвестного узла = %q, ожидался BadNodeIdUnknown", out)
+	}
+	tags := filepath.Join(t.TempDir(), "tags.txt")
+	if err := os.WriteFile(tags, []byte(s.node("Setpoint")+"\n/Objects/1:Simulation/1:Label\n"+s.node("Missing")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out = s.ok("read --from " + tags + " --format csv")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 4 || !strings.HasPrefix(lines[1], s.node("Setpoint")+",20,Good,") ||
+		!strings.HasPrefix(lines[2], "/Objects/1:Simulation/1:Label,opcli,Good,") || !strings.Contains(lines[3], "BadNodeIdUnknown") {
+		t.Errorf("read --from = %q, ожидались Setpoint, Label и неизвестный узел", out)
+	}
+
+	s.ok("write " + s.node("Setpoint") + " 42.5")
+	if out := s.ok("read " + s.node("Setpoint")); strings.TrimSpace(out) != "42.5" {
+		t.Errorf("read Setpoint после записи = %q, ожидалось 42.5", out)
+	}
+	out = s.ok("history raw " + s.node("Setpoint") + " --from -1m --format csv")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 3 ||
+		lines[0] != "SourceTimestamp,ServerTimestamp,Value,Status" || !strings.Contains(lines[1], ",20,Good") || !strings.Contains(lines[2], ",42.5,Good") {
+		t.Errorf("history raw Setpoint = %q, ожидались значения 20 и 42.5", out)
+	}
+	if out := s.ok("history at " + s.node("Setpoint") + " now"); !strings.Contains(out, "42.5") {
+		t.Errorf("history at Setpoint = %q, ожидалось 42.5", out)
+	}
+	out = s.ok("history agg " + s.node("Setpoint") + " --agg Min,Max,Count --from -1m --format csv")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 ||
+		lines[0] != "Timestamp,Minimum,Maximum,Count" || !strings.HasSuffix(lines[1], ",20,42.5,2") {
+		t.Errorf("history agg Setpoint = %q, ожидались 20, 42.5 и 2", out)
+	}
+	if out := s.ok("history aggregates"); !strings.Contains(out, "Average") || !strings.Contains(out, "i=2342") {
+		t.Errorf("history aggregates = %q, ожидалась функция Average", out)
+	}
+	if out := s.ok(`history events i=2253 --where "Severity >= 500 and Message like 'Pump%'"`); !strings.Contains(out, "No events") {
+		t.Errorf("history events = %q, ожидалось No events", out)
+	}
+	if out := s.ok("history events " + s.node("Events") + " --select Time,Message --format csv"); strings.TrimSpace(out) != "Time,Message" {
+		t.Errorf("history events --format csv = %q, ожидался только заголовок", out)
+	}
+	if out := s.ok("history modified " + s.node("Setpoint")); !strings.Contains(out, "No values") {
+		t.Errorf("history modified Setpoint = %q, ожидалось No values", out)
+	}
+	if out := s.ok("history insert " + s.node("Setpoint") + " 2024-01-01T00:00:00Z=1 2024-01-01T00:01:00Z=2 --yes"); !strings.Contains(out, "2 values inserted") {
+		t.Errorf("history insert Setpoint = %q", out)
+	}
+	s.fails("history insert "+s.node("Setpoint")+" 2024-01-01T00:00:00Z=3 --yes", "failed to insert 1 of 1 values")
+	s.ok("history replace " + s.node("Setpoint") + " 2024-01-01T00:01:00Z=5 --yes")
+	s.ok("history delete " + s.node("Setpoint") + " --at 2024-01-01T00:00:00Z --yes")
+	out = s.ok("history raw " + s.node("Setpoint") + " --from 2024-01-01 --to 2024-01-02 --format csv")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "2024-01-01T00:01:00Z,") || !strings.Contains(lines[1], ",5,Good") {
+		t.Errorf("history raw после изменения архива = %q, ожидалось значение 5", out)
+	}
+	out = s.ok("history modified " + s.node("Setpoint") + " --from 2024-01-01 --to 2024-01-02")
+	for _, want := range []string{"Insert", "Replace", "Delete"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("history modified: вывод не содержит %q:\n%s", want, out)
+		}
+	}
+	s.ok("write " + s.node("Enabled") + " false")
+	recipe := filepath.Join(t.TempDir(), "recipe.csv")
+	if err := os.WriteFile(recipe, []byte(s.node("Enabled")+",true\n"+s.node("Mode")+",3\n"+s.node("Sine")+",1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.fails("write --from "+recipe+" --rollback --yes", "failed to write 1 of 3 values, 2 written values rolled back")
+	if out := s.ok("read " + s.node("Mode")); strings.TrimSpace(out) != "1" {
+		t.Errorf("read Mode после отката = %q, ожидалось 1", out)
+	}
+	if out := s.ok("write --from " + recipe + " --dry-run"); !strings.Contains(out, "values changed") {
+		t.Errorf("write --from --dry-run = %q, ожидался список изменений", out)
+	}
+	ok := filepath.Join(t.TempDir(), "ok.csv")
+	if err := os.WriteFile(ok, []byte(s.node("Enabled")+",true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out := s.ok("write --from " + ok + " --yes"); !strings.Contains(out, "Status") || !strings.Contains(out, "Good") || !strings.Contains(out, "1 values written") {
+		t.Errorf("write --from = %q, ожидался отчёт со статусом каждой строки", out)
+	}
+	s.fails("write "+s.node("Sine")+" 1", "StatusBadUserAccessDenied")
+	s.fails("write "+s.node("Mode")+" abc", `invalid Int32 value "abc"`)
+
+	s.ok("write --attr Description " + s.node("Mode") + ` "Operating mode"`)
+	s.fails("write --attr DisplayName "+s.node("Mode")+" Betrieb", "DisplayName of "+s.node("Mode")+" is not writable")
+	s.ok("write " + s.node("Mode") + " 2 --status UncertainLastUsableValue --ts 2026-01-01T00:00:00Z")
+	if out := s.ok("read " + s.node("Mode")); strings.TrimSpace(out) != "2 [UncertainLastUsableValue]" {
+		t.Errorf("read Mode после записи со статусом = %q, ожидалось 2 [UncertainLastUsableValue]", out)
+	}
+	confirm := commands.Confirm
+	commands.ConfirmWrites, commands.Confirm = true, func(string) bool { return false }
+	out = s.ok("write --attr Description " + s.node("Mode") + " Betriebsart")
+	if !strings.Contains(out, s.node("Mode")+" Description: Operating mode -> Betriebsart") || !strings.Contains(out, "Cancelled") {
+		t.Errorf("write с подтверждением = %q, ожидались прежнее и новое значение и отмена", out)
+	}
+	commands.ConfirmWrites, commands.Confirm = false, confirm
+	commands.ReadOnly = true
+	s.fails("write "+s.node("Mode")+" 3", "write is not allowed in read-only mode")
+	s.fails("history delete "+s.node("Mode")+" --at 2026-01-01T00:00:00Z --yes", "history delete is not allowed in read-only mode")
+	commands.ReadOnly = false
+	out = s.ok("info " + s.node("Mode"))
+	for _, want := range []string{"NodeClass:                Variable", "DataType:                 Int32 (i=6)", "AccessLevel:              CurrentRead, CurrentWrite",
+		"Description:              Operating mode", "WriteMask:                Description"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("info: вывод не содержит %q:\n%s", want, out)
+		}
+	}
+
+	if out := s.ok("disconnect"); !strings.Contains(out, "Disconnecting...") {
+		t.Errorf("disconnect: вывод = %q", out)
+	}
+	s.fails("read "+s.node("Setpoint"), "not connected to server")
+	s.fails("info "+s.node("Mode"), "not connected to server")
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("net.Listen() получена непредвиденная ошибка = %v", err)
+	}
+	closed := "opc.tcp://" + l.Addr().String()
+	l.Close()
+	s.fails("connect "+closed, "failed to connect")
+	if client.GetSession() != nil {
+		t.Errorf("GetSession() != nil после неудачного подключения")
+	}
+}
@@ -4,16 +4,44 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
+	"github.com/alexfrick92/opcli/internal/audit"
 	"github.com/alexfrick92/opcli/internal/commands"
 )
 
 var connectCommand = commands.Connect
 var disconnectCommand = commands.Disconnect
+var readCommand = commands.Read
+var readListCommand = commands.ReadList
+var writeCommand = commands.Write
+var writeListCommand = commands.WriteList
+var infoCommand = commands.Info
+var treeCommand = commands.Tree
+var findCommand = commands.Find
+var exportNodeSetCommand = commands.ExportNodeSet
+var diffCommand = commands.Diff
+var serveCommand = commands.Serve
+var pingCommand = commands.Ping
+var serverStatusCommand = commands.ServerStatus
+var namespacesCommand = commands.Namespaces
+var historyRawCommand = commands.HistoryRaw
+var historyModifiedCommand = commands.HistoryModified
+var historyAtCommand = commands.HistoryAt
+var historyAggCommand = commands.HistoryAggregate
+var historyAggregatesCommand = commands.HistoryAggregates
+var historyEventsCommand = commands.HistoryEvents
+var historyInsertCommand = commands.HistoryInsert
+var historyReplaceCommand = commands.HistoryReplace
+var historyDeleteCommand = commands.HistoryDelete
+var historyDeleteAtCommand = commands.HistoryDeleteAt
 
 // Execute выполняет команду из пользовательского ввода
 func Execute(input string) error {
-	parts := strings.Fields(input)
+	parts, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
 	if len(parts) == 0 {
 		return nil
 	}
@@ -28,6 +56,28 @@ func Execute(input string) error {
 		return handleConnect(args)
 	case "disconnect":
 		return handleDisconnect()
+	case "read":
+		return handleRead(args)
+	case "write":
+		return handleWrite(args)
+	case "info", "describe":
+		return handleInfo(args)
+	case "tree":
+		return handleTree(args)
+	case "find":
+		return handleFind(args)
+	case "export":
+		return handleExport(args)
+	case "diff":
+		return handleDiff(args)
+	case "ping":
+		return handlePing(args)
+	case "server":
+		return handleServer(args)
+	case "namespaces":
+		return handleNamespaces(args)
+	case "history":
+		return handleHistory(args)
 	case "exit", "quit":
 		return fmt.Errorf("exit")
 	default:
@@ -42,6 +92,42 @@ func PrintHelp() {
 	fmt.Println("Available commands:")
 	fmt.Println("  connect <endpoint>  - Connect to OPC UA server")
 	fmt.Println("  disconnect          - Disconnect from server")
+	fmt.Println("  ping [endpoint] [-c N] [-i <duration>] [--timeout <duration>] [--hello]")
+	fmt.Println("                      - Measure server round-trip time (default: current connection)")
+	fmt.Println("  server status       - Show server status, capabilities and namespaces")
+	fmt.Println("  namespaces          - Show namespace table with indexes")
+	fmt.Println("  read <nodeid>[<range>] [--range <range>] [--format text|json] [--reversible]")
+	fmt.Println("                      - Read node value or array elements (range: 2:5, 1:2,0:3)")
+	fmt.Println("  read --from <file> [--format text|csv|json] [--parallel N] [-o <file>]")
+	fmt.Println("                      - Read nodes listed in a text or CSV file in batches")
+	fmt.Println("  write <nodeid>[<range>] <value>")
+	fmt.Println("                      - Write node value (text, [array] or reversible JSON variant)")
+	fmt.Println("  write [--attr <name>] [--status <code>] [--ts <time>] <nodeid> <value>")
+	fmt.Println("                      - Write an attribute (DisplayName, WriteMask, ...) or a value with status and timestamp")
+	fmt.Println("  write --from <csv> [--dry-run] [--rollback] [--yes] [--parallel N]")
+	fmt.Println("                      - Write values from a CSV file (node,value[,type]) after preview")
+	fmt.Println("  info <nodeid>       - Show node attributes and properties (alias: describe)")
+	fmt.Println("  history raw|modified <nodeid> [--from <time>] [--to <time>] [--max N] [--format table|csv]")
+	fmt.Println("                      - Read archived values (default: last hour)")
+	fmt.Println("  history at <nodeid> <time>[,<time>...] [--format table|csv]")
+	fmt.Println("                      - Read archived values at given times")
+	fmt.Println("  history agg <nodeid> --agg <name>[,<name>...] [--interval <duration>] [--from <time>] [--to <time>] [--format table|csv]")
+	fmt.Println("                      - Read aggregates (Average, Minimum, Count, ...) per interval")
+	fmt.Println("  history aggregates  - Show aggregate functions supported by the server")
+	fmt.Println("  history events <notifier> [--select <field>,...] [--where <condition>] [--from <time>] [--to <time>] [--max N] [--format table|csv]")
+	fmt.Println("                      - Read archived events and alarms (default: last hour)")
+	fmt.Println("  history insert|replace <nodeid> [<time>=<value>...] [--file <csv>] [--yes]")
+	fmt.Println("                      - Write values to the archive (CSV: timestamp,value[,status])")
+	fmt.Println("  history delete <nodeid> --from <time> --to <time> | --at <time>[,<time>...] [--yes]")
+	fmt.Println("                      - Delete archived values")
+	fmt.Println("  tree [nodeid] [--depth N] [--class Variable,Object] [--ref <type>] [--parallel N]")
+	fmt.Println("                      - Show address space tree (default root: Objects)")
+	fmt.Println("  find [root] [--name <pattern>] [--regex] [--type <type>] [--datatype <type>] [--max N]")
+	fmt.Println("                      - Find nodes by name, type or data type")
+	fmt.Println("  export nodeset [root] -o <file> [--values]")
+	fmt.Println("                      - Save address space as NodeSet2 XML")
+	fmt.Println("  diff <snapshot|session> <snapshot|session> [root] [--format text|json] [--values]")
+	fmt.Println("                      - Compare address space snapshots")
 	fmt.Println("  help                - Show this help")
 	fmt.Println("  exit, quit          - Exit the program")
 }
@@ -57,8 +143,599 @@ func handleDisconnect() error {
 	return disconnectCommand()
 }
 
+func handleRead(args []string) error {
+	const usage = "usage: read <nodeid> [--range <range>] [--format text|json] [--reversible] | read --from <file> [--format text|csv|json] [--parallel N] [-o <file>]"
+
+	// -o - короткая форма --output
+	rest := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "-o" {
+			arg = "--output"
+		}
+		rest[i] = arg
+	}
+
+	a, err := parseFlags(rest, "format", "from", "parallel", "output", "range")
+	if err != nil {
+		return err
+	}
+	if a.has("from") {
+		if err := a.only("from", "format", "reversible", "parallel", "output"); err != nil {
+			return err
+		}
+		if len(a.positional) != 0 {
+			return fmt.Errorf(usage)
+		}
+		opts := commands.ReadOptions{
+			Format:     a.get("format", "text"),
+			Reversible: a.has("reversible"),
+			Output:     a.get("output", ""),
+		}
+		if opts.Parallel, err = a.getInt("parallel", 0); err != nil {
+			return err
+		}
+		return readListCommand(a.get("from", ""), opts)
+	}
+
+	if err := a.only("format", "reversible", "range"); err != nil {
+		return err
+	}
+	if len(a.positional) != 1 {
+		return fmt.Errorf(usage)
+	}
+	return readCommand(a.positional[0], commands.ReadOptions{
+		Format:     a.get("format", "text"),
+		Reversible: a.has("reversible"),
+		Range:      a.get("range", ""),
+	})
+}
+
+func handleWrite(args []string) error {
+	const usage = "usage: write [--attr <name>] [--status <code>] [--ts <time>] <nodeid> <value> | write --from <csv> [--dry-run] [--rollback] [--yes] [--parallel N]"
+
+	// Значение может начинаться с "-", поэтому флаги разбираются, только
+	// если задан --from или параметр записи одного узла
+	flagged := false
+	for _, arg := range args {
+		switch name, _, _ := strings.Cut(arg, "="); name {
+		case "--from", "--attr", "--status", "--ts":
+			flagged = true
+		}
+	}
+	if !flagged {
+		if len(args) != 2 {
+			return fmt.Errorf(usage)
+		}
+		return writeCommand(args[0], args[1], commands.WriteOptions{})
+	}
+
+	a, err := parseFlags(args, "from", "parallel", "attr", "status", "ts")
+	if err != nil {
+		return err
+	}
+	if !a.has("from") {
+		if err := a.only("attr", "status", "ts"); err != nil {
+			return err
+		}
+		if len(a.positional) != 2 {
+			return fmt.Errorf(usage)
+		}
+		opts := commands.WriteOptions{Attribute: a.get("attr", ""), Status: a.get("status", "")}
+		if a.has("ts") {
+			if opts.SourceTimestamp, err = parseTime(a.get("ts", ""), nowFunc()); err != nil {
+				return err
+			}
+		}
+		return writeCommand(a.positional[0], a.positional[1], opts)
+	}
+
+	if err := a.only("from", "dry-run", "rollback", "yes", "parallel"); err != nil {
+		return err
+	}
+	if len(a.positional) != 0 {
+		return fmt.Errorf(usage)
+	}
+	opts := commands.WriteListOptions{DryRun: a.has("dry-run"), Rollback: a.has("rollback"), Yes: a.has("yes")}
+	if opts.Parallel, err = a.getInt("parallel", 0); err != nil {
+		return err
+	}
+	return writeListCommand(a.get("from", ""), opts)
+}
+
+func handleInfo(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: info <nodeid>")
+	}
+	return infoCommand(args[0])
+}
+
+// defaultTreeDepth - глубина tree, если --depth не задан
+const defaultTreeDepth = 3
+
+func handleTree(args []string) error {
+	a, err := parseFlags(args, "depth", "class", "ref", "parallel")
+	if err != nil {
+		return err
+	}
+	if err := a.only("depth", "class", "ref", "parallel"); err != nil {
+		return err
+	}
+	if len(a.positional) > 1 {
+		return fmt.Errorf("usage: tree [nodeid] [--depth N] [--class Variable,Object] [--ref <type>] [--parallel N]")
+	}
+
+	opts := commands.TreeOptions{Classes: a.get("class", ""), Ref: a.get("ref", "")}
+	if opts.Depth, err = a.getInt("depth", defaultTreeDepth); err != nil {
+		return err
+	}
+	if opts.Parallel, err = a.getInt("parallel", 0); err != nil {
+		return err
+	}
+
+	nodeID := commands.DefaultRoot
+	if len(a.positional) == 1 {
+		nodeID = a.positional[0]
+	}
+	return treeCommand(nodeID, opts)
+}
+
+// defaultFindMax - наибольшее число результатов find, если --max не задан
+const defaultFindMax = 100
+
+func handleFind(args []string) error {
+	a, err := parseFlags(args, "name", "type", "datatype", "max", "depth", "parallel")
+	if err != nil {
+		return err
+	}
+	if err := a.only("name", "regex", "type", "datatype", "max", "depth", "parallel"); err != nil {
+		return err
+	}
+	if len(a.positional) > 1 {
+		return fmt.Errorf("usage: find [root] [--name <pattern>] [--regex] [--type <type>] [--datatype <type>] [--max N]")
+	}
+
+	opts := commands.FindOptions{
+		Name:     a.get("name", ""),
+		Regex:    a.has("regex"),
+		Type:     a.get("type", ""),
+		DataType: a.get("datatype", ""),
+	}
+	if opts.Max, err = a.getInt("max", defaultFindMax); err != nil {
+		return err
+	}
+	if opts.Depth, err = a.getInt("depth", 0); err != nil {
+		return err
+	}
+	if opts.Parallel, err = a.getInt("parallel", 0); err != nil {
+		return err
+	}
+
+	root := commands.DefaultRoot
+	if len(a.positional) == 1 {
+		root = a.positional[0]
+	}
+	return findCommand(root, opts)
+}
+
+func handleExport(args []string) error {
+	const usage = "usage: export nodeset [root] -o <file> [--values] [--parallel N]"
+	if len(args) == 0 || args[0] != "nodeset" {
+		return fmt.Errorf(usage)
+	}
+
+	// -o - короткая форма --output
+	rest := make([]string, len(args)-1)
+	for i, arg := range args[1:] {
+		if arg == "-o" {
+			arg = "--output"
+		}
+		rest[i] = arg
+	}
+
+	a, err := parseFlags(rest, "output", "parallel")
+	if err != nil {
+		return err
+	}
+	if err := a.only("output", "values", "parallel"); err != nil {
+		return err
+	}
+	if len(a.positional) > 1 || a.get("output", "") == "" {
+		return fmt.Errorf(usage)
+	}
+
+	opts := commands.ExportOptions{Output: a.get("output", ""), Values: a.has("values")}
+	if opts.Parallel, err = a.getInt("parallel", 0); err != nil {
+		return err
+	}
+
+	root := commands.DefaultRoot
+	if len(a.positional) == 1 {
+		root = a.positional[0]
+	}
+	return exportNodeSetCommand(root, opts)
+}
+
+func handleDiff(args []string) error {
+	a, err := parseFlags(args, "format", "parallel")
+	if err != nil {
+		return err
+	}
+	if err := a.only("format", "values", "parallel"); err != nil {
+		return err
+	}
+	if len(a.positional) < 2 || len(a.positional) > 3 {
+		return fmt.Errorf("usage: diff <snapshotA|session> <snapshotB|session> [root] [--format text|json] [--values]")
+	}
+
+	opts := commands.DiffOptions{Format: a.get("format", "text"), Values: a.has("values")}
+	if opts.Parallel, err = a.getInt("parallel", 0); err != nil {
+		return err
+	}
+
+	root := commands.DefaultRoot
+	if len(a.positional) == 3 {
+		root = a.positional[2]
+	}
+	return diffCommand(a.positional[0], a.positional[1], root, opts)
+}
+
+func handleServer(args []string) error {
+	if len(args) != 1 || args[0] != "status" {
+		return fmt.Errorf("usage: server status")
+	}
+	return serverStatusCommand()
+}
+
+func handleNamespaces(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: namespaces")
+	}
+	return namespacesCommand()
+}
+
+// defaultHistoryMax - наибольшее число значений history, если --max не задан
+const defaultHistoryMax = 1000
+
+// nowFunc возвращает текущее время; подменяется в тестах
+var nowFunc = time.Now
+
+func handleHistory(args []string) error {
+	const usage = "usage: history raw|modified|at|agg|events|insert|replace|delete <nodeid> ... | history aggregates"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	switch args[0] {
+	case "raw", "modified":
+		return handleHistoryRaw(args[0], args[1:])
+	case "at":
+		return handleHistoryAt(args[1:])
+	case "agg":
+		return handleHistoryAgg(args[1:])
+	case "events":
+		return handleHistoryEvents(args[1:])
+	case "insert", "replace":
+		return handleHistoryUpdate(args[0], args[1:])
+	case "delete":
+		return handleHistoryDelete(args[1:])
+	case "aggregates":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: history aggregates")
+		}
+		return historyAggregatesCommand()
+	}
+	return fmt.Errorf(usage)
+}
+
+func handleHistoryRaw(sub string, args []string) error {
+	a, err := parseFlags(args, "from", "to", "max", "format")
+	if err != nil {
+		return err
+	}
+	if err := a.only("from", "to", "max", "format"); err != nil {
+		return err
+	}
+	if len(a.positional) != 1 {
+		return fmt.Errorf("usage: history %s <nodeid> [--from <time>] [--to <time>] [--max N] [--format table|csv]", sub)
+	}
+
+	now := nowFunc()
+	opts := commands.HistoryOptions{Format: a.get("format", "table")}
+	if opts.Start, err = parseTime(a.get("from", "-1h"), now); err != nil {
+		return err
+	}
+	if opts.End, err = parseTime(a.get("to", "now"), now); err != nil {
+		return err
+	}
+	if opts.Max, err = a.getInt("max", defaultHistoryMax); err != nil {
+		return err
+	}
+
+	if sub == "modified" {
+		return historyModifiedCommand(a.positional[0], opts)
+	}
+	return historyRawCommand(a.positional[0], opts)
+}
+
+func handleHistoryAt(args []string) error {
+	a, err := parseFlags(args, "format")
+	if err != nil {
+		return err
+	}
+	if err := a.only("format"); err != nil {
+		return err
+	}
+	if len(a.positional) < 2 {
+		return fmt.Errorf("usage: history at <nodeid> <time>[,<time>...] [--format table|csv]")
+	}
+
+	// Моменты времени перечисляются через запятую или отдельными аргументами
+	now := nowFunc()
+	var times []time.Time
+	for _, arg := range a.positional[1:] {
+		for _, s := range strings.Split(arg, ",") {
+			t, err := parseTime(strings.TrimSpace(s), now)
+			if err != nil {
+				return err
+			}
+			times = append(times, t)
+		}
+	}
+	return historyAtCommand(a.positional[0], times, commands.HistoryOptions{Format: a.get("format", "table")})
+}
+
+func handleHistoryAgg(args []string) error {
+	a, err := parseFlags(args, "agg", "interval", "from", "to", "format")
+	if err != nil {
+		return err
+	}
+	if err := a.only("agg", "interval", "from", "to", "format"); err != nil {
+		return err
+	}
+	if len(a.positional) != 1 || a.get("agg", "") == "" {
+		return fmt.Errorf("usage: history agg <nodeid> --agg <name>[,<name>...] [--interval <duration>] [--from <time>] [--to <time>] [--format table|csv]")
+	}
+
+	var aggs []string
+	for _, name := range strings.Split(a.get("agg", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			aggs = append(aggs, name)
+		}
+	}
+	// Без --interval сервер вычисляет одно значение на весь интервал
+	var interval time.Duration
+	if a.has("interval") {
+		if interval, err = parseOffset(a.get("interval", "")); err != nil || interval <= 0 {
+			return fmt.Errorf("invalid value for --interval: %s", a.get("interval", ""))
+		}
+	}
+
+	now := nowFunc()
+	opts := commands.HistoryOptions{Format: a.get("format", "table")}
+	if opts.Start, err = parseTime(a.get("from", "-1h"), now); err != nil {
+		return err
+	}
+	if opts.End, err = parseTime(a.get("to", "now"), now); err != nil {
+		return err
+	}
+	return historyAggCommand(a.positional[0], aggs, interval, opts)
+}
+
+func handleHistoryEvents(args []string) error {
+	a, err := parseFlags(args, "select", "where", "from", "to", "max", "format")
+	if err != nil {
+		return err
+	}
+	if err := a.only("select", "where", "from", "to", "max", "format"); err != nil {
+		return err
+	}
+	if len(a.positional) != 1 {
+		return fmt.Errorf("usage: history events <notifier> [--select <field>,...] [--where <condition>] [--from <time>] [--to <time>] [--max N] [--format table|csv]")
+	}
+
+	var fields []string
+	for _, f := range strings.Split(a.get("select", ""), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+
+	now := nowFunc()
+	opts := commands.HistoryOptions{Format: a.get("format", "table")}
+	if opts.Start, err = parseTime(a.get("from", "-1h"), now); err != nil {
+		return err
+	}
+	if opts.End, err = parseTime(a.get("to", "now"), now); err != nil {
+		return err
+	}
+	if opts.Max, err = a.getInt("max", defaultHistoryMax); err != nil {
+		return err
+	}
+	return historyEventsCommand(a.positional[0], fields, a.get("where", ""), opts)
+}
+
+func handleHistoryUpdate(sub string, args []string) error {
+	a, err := parseFlags(args, "file")
+	if err != nil {
+		return err
+	}
+	if err := a.only("file", "yes"); err != nil {
+		return err
+	}
+	if len(a.positional) == 0 || len(a.positional) == 1 && !a.has("file") {
+		return fmt.Errorf("usage: history %s <nodeid> [<time>=<value>...] [--file <csv>] [--yes]", sub)
+	}
+
+	now := nowFunc()
+	var values []commands.HistoryValue
+	for _, arg := range a.positional[1:] {
+		ts, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("invalid value %q, expected <time>=<value>", arg)
+		}
+		t, err := parseTime(ts, now)
+		if err != nil {
+			return err
+		}
+		values = append(values, commands.HistoryValue{Time: t, Value: value})
+	}
+
+	opts := commands.HistoryUpdateOptions{File: a.get("file", ""), Yes: a.has("yes")}
+	if sub == "replace" {
+		return historyReplaceCommand(a.positional[0], values, opts)
+	}
+	return historyInsertCommand(a.positional[0], values, opts)
+}
+
+func handleHistoryDelete(args []string) error {
+	const usage = "usage: history delete <nodeid> --from <time> --to <time> | --at <time>[,<time>...] [--yes]"
+	a, err := parseFlags(args, "from", "to", "at")
+	if err != nil {
+		return err
+	}
+	if err := a.only("from", "to", "at", "yes"); err != nil {
+		return err
+	}
+	// Интервал задаётся явно: удаление всего архива по умолчанию опасно
+	interval := a.has("from") && a.has("to")
+	if len(a.positional) != 1 || interval == a.has("at") || a.has("at") && (a.has("from") || a.has("to")) {
+		return fmt.Errorf(usage)
+	}
+
+	now := nowFunc()
+	opts := commands.HistoryUpdateOptions{Yes: a.has("yes")}
+	if a.has("at") {
+		var times []time.Time
+		for _, s := range strings.Split(a.get("at", ""), ",") {
+			t, err := parseTime(strings.TrimSpace(s), now)
+			if err != nil {
+				return err
+			}
+			times = append(times, t)
+		}
+		return historyDeleteAtCommand(a.positional[0], times, opts)
+	}
+
+	start, err := parseTime(a.get("from", ""), now)
+	if err != nil {
+		return err
+	}
+	end, err := parseTime(a.get("to", ""), now)
+	if err != nil {
+		return err
+	}
+	return historyDeleteCommand(a.positional[0], start, end, opts)
+}
+
+func handlePing(args []string) error {
+	const usage = "usage: ping [endpoint] [-c N] [-i <duration>] [--timeout <duration>] [--hello]"
+
+	// -c и -i - короткие формы --count и --interval, как у системного ping
+	short := map[string]string{"-c": "--count", "-i": "--interval"}
+	rest := make([]string, len(args))
+	for i, arg := range args {
+		if long, ok := short[arg]; ok {
+			arg = long
+		}
+		rest[i] = arg
+	}
+
+	a, err := parseFlags(rest, "count", "interval", "timeout")
+	if err != nil {
+		return err
+	}
+	if err := a.only("count", "interval", "timeout", "hello"); err != nil {
+		return err
+	}
+	if len(a.positional) > 1 {
+		return fmt.Errorf(usage)
+	}
+
+	opts := commands.PingOptions{Interval: time.Second, Hello: a.has("hello")}
+	if opts.Count, err = a.getInt("count", commands.DefaultPingCount); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		name string
+		dst  *time.Duration
+	}{{"interval", &opts.Interval}, {"timeout", &opts.Timeout}} {
+		if !a.has(f.name) {
+			continue
+		}
+		if *f.dst, err = time.ParseDuration(a.get(f.name, "")); err != nil || *f.dst < 0 {
+			return fmt.Errorf("invalid value for --%s: %s", f.name, a.get(f.name, ""))
+		}
+	}
+
+	endpoint := ""
+	if len(a.positional) == 1 {
+		endpoint = a.positional[0]
+	}
+	return pingCommand(endpoint, opts)
+}
+
+// Serve обрабатывает команду запуска сервера имитации: opcli serve [флаги].
+// Команда доступна только из командной строки, так как работает до прерывания.
+func Serve(args []string) error {
+	const usage = "usage: opcli serve [--port N] [--host <name>] [--config <file>] [--nodeset <file>] [--interval <duration>]"
+	a, err := parseFlags(args, "port", "host", "config", "nodeset", "interval")
+	if err != nil {
+		return err
+	}
+	if err := a.only("port", "host", "config", "nodeset", "interval"); err != nil {
+		return err
+	}
+	if len(a.positional) != 0 {
+		return fmt.Errorf(usage)
+	}
+
+	opts := commands.ServeOptions{
+		Config:  a.get("config", ""),
+		Host:    a.get("host", ""),
+		NodeSet: a.get("nodeset", ""),
+	}
+	if opts.Port, err = a.getInt("port", 0); err != nil {
+		return err
+	}
+	if a.has("interval") {
+		if opts.Interval, err = time.ParseDuration(a.get("interval", "")); err != nil || opts.Interval <= 0 {
+			return fmt.Errorf("invalid value for --interval: %s", a.get("interval", ""))
+		}
+	}
+	return serveCommand(opts)
+}
+
 // ParseStartupArgs обрабатывает аргументы командной строки при запуске
 func ParseStartupArgs(args []string) error {
+	// Флаги режима допускаются в любом месте после имени программы
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case i > 0 && arg == "--read-only":
+			commands.ReadOnly = true
+		case i > 0 && arg == "--confirm-writes":
+			commands.ConfirmWrites = true
+		case i > 0 && arg == "--audit-log":
+			if i+1 >= len(args) {
+				return fmt.Errorf("flag --audit-log requires a value")
+			}
+			i++
+			log, err := audit.Open(args[i])
+			if err != nil {
+				return err
+			}
+			commands.Audit = log
+			fmt.Printf("Audit log: %s\n", args[i])
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	args = rest
+	if commands.ReadOnly {
+		fmt.Println("Read-only mode: writes and history updates are disabled")
+	} else if commands.ConfirmWrites {
+		fmt.Println("Every write must be confirmed")
+	}
+
 	// Если передан IP-адрес, подключаемся с портом по умолчанию
 	if len(args) == 2 && isIPv4(args[1]) {
 		endpoint := fmt.Sprintf("opc.tcp://%s:4840", args[1])
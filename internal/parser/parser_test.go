@@ -2,7 +2,13 @@ package parser
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/commands"
 )
 
 // Mock variables for connectCommand and disconnectCommand
@@ -12,6 +18,39 @@ var (
 	mockConnectError    error
 	mockDisconnectCalled bool
 	mockDisconnectError  error
+	mockReadNodeID       string
+	mockReadOptions      commands.ReadOptions
+	mockReadFile         string
+	mockWriteNodeID      string
+	mockWriteValue       string
+	mockWriteOptions     commands.WriteOptions
+	mockWriteFile        string
+	mockWriteListOptions commands.WriteListOptions
+	mockInfoNodeID       string
+	mockTreeNodeID       string
+	mockTreeOptions      commands.TreeOptions
+	mockFindRoot         string
+	mockFindOptions      commands.FindOptions
+	mockExportRoot       string
+	mockExportOptions    commands.ExportOptions
+	mockDiffArgs         []string
+	mockDiffOptions      commands.DiffOptions
+	mockServeOptions     commands.ServeOptions
+	mockPingEndpoint     string
+	mockPingOptions      commands.PingOptions
+	mockServerStatusCalled bool
+	mockNamespacesCalled   bool
+	mockHistoryKind        string
+	mockHistoryNodeID      string
+	mockHistoryOptions     commands.HistoryOptions
+	mockHistoryTimes       []time.Time
+	mockHistoryAggs        []string
+	mockHistoryInterval    time.Duration
+	mockHistoryAggregatesCalled bool
+	mockHistoryFields      []string
+	mockHistoryWhere       string
+	mockHistoryValues      []commands.HistoryValue
+	mockHistoryUpdateOptions commands.HistoryUpdateOptions
 )
 
 // mockConnect is a mock implementation for connectCommand
@@ -27,6 +66,181 @@ func mockDisconnect() error {
 	return mockDisconnectError
 }
 
+// mockRead is a mock implementation for readCommand
+func mockRead(nodeID string, opts commands.ReadOptions) error {
+	mockReadNodeID = nodeID
+	mockReadOptions = opts
+	return nil
+}
+
+// mockReadList is a mock implementation for readListCommand
+func mockReadList(file string, opts commands.ReadOptions) error {
+	mockReadFile = file
+	mockReadOptions = opts
+	return nil
+}
+
+// mockWrite is a mock implementation for writeCommand
+func mockWrite(nodeID, value string, opts commands.WriteOptions) error {
+	mockWriteNodeID = nodeID
+	mockWriteValue = value
+	mockWriteOptions = opts
+	return nil
+}
+
+// mockWriteList is a mock implementation for writeListCommand
+func mockWriteList(file string, opts commands.WriteListOptions) error {
+	mockWriteFile = file
+	mockWriteListOptions = opts
+	return nil
+}
+
+// mockInfo is a mock implementation for infoCommand
+func mockInfo(nodeID string) error {
+	mockInfoNodeID = nodeID
+	return nil
+}
+
+// mockTree is a mock implementation for treeCommand
+func mockTree(nodeID string, opts commands.TreeOptions) error {
+	mockTreeNodeID = nodeID
+	mockTreeOptions = opts
+	return nil
+}
+
+// mockFind is a mock implementation for findCommand
+func mockFind(root string, opts commands.FindOptions) error {
+	mockFindRoot = root
+	mockFindOptions = opts
+	return nil
+}
+
+// mockExportNodeSet is a mock implementation for exportNodeSetCommand
+func mockExportNodeSet(root string, opts commands.ExportOptions) error {
+	mockExportRoot = root
+	mockExportOptions = opts
+	return nil
+}
+
+// mockDiff is a mock implementation for diffCommand
+func mockDiff(a, b, root string, opts commands.DiffOptions) error {
+	mockDiffArgs = []string{a, b, root}
+	mockDiffOptions = opts
+	return nil
+}
+
+// mockServe is a mock implementation for serveCommand
+func mockServe(opts commands.ServeOptions) error {
+	mockServeOptions = opts
+	return nil
+}
+
+// mockPing is a mock implementation for pingCommand
+func mockPing(endpoint string, opts commands.PingOptions) error {
+	mockPingEndpoint = endpoint
+	mockPingOptions = opts
+	return nil
+}
+
+// mockServerStatus is a mock implementation for serverStatusCommand
+func mockServerStatus() error {
+	mockServerStatusCalled = true
+	return nil
+}
+
+// mockNamespaces is a mock implementation for namespacesCommand
+func mockNamespaces() error {
+	mockNamespacesCalled = true
+	return nil
+}
+
+// mockHistoryRaw is a mock implementation for historyRawCommand
+func mockHistoryRaw(nodeID string, opts commands.HistoryOptions) error {
+	mockHistoryKind = "raw"
+	mockHistoryNodeID = nodeID
+	mockHistoryOptions = opts
+	return nil
+}
+
+// mockHistoryModified is a mock implementation for historyModifiedCommand
+func mockHistoryModified(nodeID string, opts commands.HistoryOptions) error {
+	mockHistoryKind = "modified"
+	mockHistoryNodeID = nodeID
+	mockHistoryOptions = opts
+	return nil
+}
+
+// mockHistoryAt is a mock implementation for historyAtCommand
+func mockHistoryAt(nodeID string, times []time.Time, opts commands.HistoryOptions) error {
+	mockHistoryKind = "at"
+	mockHistoryNodeID = nodeID
+	mockHistoryTimes = times
+	mockHistoryOptions = opts
+	return nil
+}
+
+// mockHistoryAgg is a mock implementation for historyAggCommand
+func mockHistoryAgg(nodeID string, aggregates []string, interval time.Duration, opts commands.HistoryOptions) error {
+	mockHistoryKind = "agg"
+	mockHistoryNodeID = nodeID
+	mockHistoryAggs = aggregates
+	mockHistoryInterval = interval
+	mockHistoryOptions = opts
+	return nil
+}
+
+// mockHistoryAggregates is a mock implementation for historyAggregatesCommand
+func mockHistoryAggregates() error {
+	mockHistoryAggregatesCalled = true
+	return nil
+}
+
+// mockHistoryEvents is a mock implementation for historyEventsCommand
+func mockHistoryEvents(notifier string, fields []string, where string, opts commands.HistoryOptions) error {
+	mockHistoryKind = "events"
+	mockHistoryNodeID = notifier
+	mockHistoryFields = fields
+	mockHistoryWhere = where
+	mockHistoryOptions = opts
+	return nil
+}
+
+// mockHistoryInsert is a mock implementation for historyInsertCommand
+func mockHistoryInsert(nodeID string, values []commands.HistoryValue, opts commands.HistoryUpdateOptions) error {
+	mockHistoryKind = "insert"
+	mockHistoryNodeID = nodeID
+	mockHistoryValues = values
+	mockHistoryUpdateOptions = opts
+	return nil
+}
+
+// mockHistoryReplace is a mock implementation for historyReplaceCommand
+func mockHistoryReplace(nodeID string, values []commands.HistoryValue, opts commands.HistoryUpdateOptions) error {
+	mockHistoryKind = "replace"
+	mockHistoryNodeID = nodeID
+	mockHistoryValues = values
+	mockHistoryUpdateOptions = opts
+	return nil
+}
+
+// mockHistoryDelete is a mock implementation for historyDeleteCommand
+func mockHistoryDelete(nodeID string, start, end time.Time, opts commands.HistoryUpdateOptions) error {
+	mockHistoryKind = "delete"
+	mockHistoryNodeID = nodeID
+	mockHistoryTimes = []time.Time{start, end}
+	mockHistoryUpdateOptions = opts
+	return nil
+}
+
+// mockHistoryDeleteAt is a mock implementation for historyDeleteAtCommand
+func mockHistoryDeleteAt(nodeID string, times []time.Time, opts commands.HistoryUpdateOptions) error {
+	mockHistoryKind = "delete at"
+	mockHistoryNodeID = nodeID
+	mockHistoryTimes = times
+	mockHistoryUpdateOptions = opts
+	return nil
+}
+
 // resetMocks resets the state of all mock variables
 func resetMocks() {
 	mockConnectCalled = false
@@ -34,6 +248,39 @@ func resetMocks() {
 	mockConnectError = nil
 	mockDisconnectCalled = false
 	mockDisconnectError = nil
+	mockReadNodeID = ""
+	mockReadOptions = commands.ReadOptions{}
+	mockReadFile = ""
+	mockWriteNodeID = ""
+	mockWriteValue = ""
+	mockWriteOptions = commands.WriteOptions{}
+	mockWriteFile = ""
+	mockWriteListOptions = commands.WriteListOptions{}
+	mockInfoNodeID = ""
+	mockTreeNodeID = ""
+	mockTreeOptions = commands.TreeOptions{}
+	mockFindRoot = ""
+	mockFindOptions = commands.FindOptions{}
+	mockExportRoot = ""
+	mockExportOptions = commands.ExportOptions{}
+	mockDiffArgs = nil
+	mockDiffOptions = commands.DiffOptions{}
+	mockServeOptions = commands.ServeOptions{}
+	mockPingEndpoint = ""
+	mockPingOptions = commands.PingOptions{}
+	mockServerStatusCalled = false
+	mockNamespacesCalled = false
+	mockHistoryKind = ""
+	mockHistoryNodeID = ""
+	mockHistoryOptions = commands.HistoryOptions{}
+	mockHistoryTimes = nil
+	mockHistoryAggs = nil
+	mockHistoryInterval = 0
+	mockHistoryAggregatesCalled = false
+	mockHistoryFields = nil
+	mockHistoryWhere = ""
+	mockHistoryValues = nil
+	mockHistoryUpdateOptions = commands.HistoryUpdateOptions{}
 }
 
 // TestExecute проверяет функцию Execute для различных входных данных.
@@ -49,9 +296,57 @@ func TestExecute(t *testing.T) {
 	// Сохраняем оригинальные функции и восстанавливаем их после выполнения всех тестов
 	oldConnectCommand := connectCommand
 	oldDisconnectCommand := disconnectCommand
+	oldReadCommand := readCommand
+	oldReadListCommand := readListCommand
+	oldWriteCommand := writeCommand
+	oldWriteListCommand := writeListCommand
+	oldInfoCommand := infoCommand
+	oldTreeCommand := treeCommand
+	oldFindCommand := findCommand
+	oldExportNodeSetCommand := exportNodeSetCommand
+	oldDiffCommand := diffCommand
+	oldPingCommand := pingCommand
+	oldServerStatusCommand := serverStatusCommand
+	oldNamespacesCommand := namespacesCommand
+	oldHistoryRawCommand := historyRawCommand
+	oldHistoryModifiedCommand := historyModifiedCommand
+	oldHistoryAtCommand := historyAtCommand
+	oldHistoryAggCommand := historyAggCommand
+	oldHistoryAggregatesCommand := historyAggregatesCommand
+	oldHistoryEventsCommand := historyEventsCommand
+	oldHistoryInsertCommand := historyInsertCommand
+	oldHistoryReplaceCommand := historyReplaceCommand
+	oldHistoryDeleteCommand := historyDeleteCommand
+	oldHistoryDeleteAtCommand := historyDeleteAtCommand
+	oldNowFunc := nowFunc
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	nowFunc = func() time.Time { return now }
 	defer func() {
 		connectCommand = oldConnectCommand
 		disconnectCommand = oldDisconnectCommand
+		readCommand = oldReadCommand
+		readListCommand = oldReadListCommand
+		writeCommand = oldWriteCommand
+		writeListCommand = oldWriteListCommand
+		infoCommand = oldInfoCommand
+		treeCommand = oldTreeCommand
+		findCommand = oldFindCommand
+		exportNodeSetCommand = oldExportNodeSetCommand
+		diffCommand = oldDiffCommand
+		pingCommand = oldPingCommand
+		serverStatusCommand = oldServerStatusCommand
+		namespacesCommand = oldNamespacesCommand
+		historyRawCommand = oldHistoryRawCommand
+		historyModifiedCommand = oldHistoryModifiedCommand
+		historyAtCommand = oldHistoryAtCommand
+		historyAggCommand = oldHistoryAggCommand
+		historyAggregatesCommand = oldHistoryAggregatesCommand
+		historyEventsCommand = oldHistoryEventsCommand
+		historyInsertCommand = oldHistoryInsertCommand
+		historyReplaceCommand = oldHistoryReplaceCommand
+		historyDeleteCommand = oldHistoryDeleteCommand
+		historyDeleteAtCommand = oldHistoryDeleteAtCommand
+		nowFunc = oldNowFunc
 	}()
 
 	tests := []struct {
@@ -161,6 +456,615 @@ func TestExecute(t *testing.T) {
 			wantErr: true,
 			errMsg:  "mock disconnect failed",
 		},
+		{
+			name:    "Команда read без аргументов должна вернуть ошибку использования",
+			input:   "read",
+			wantErr: true,
+			errMsg:  "usage: read <nodeid> [--range <range>] [--format text|json] [--reversible] | read --from <file> [--format text|csv|json] [--parallel N] [-o <file>]",
+		},
+		{
+			name:    "Команда read с неизвестным флагом должна вернуть ошибку",
+			input:   "read i=2258 --foo",
+			wantErr: true,
+			errMsg:  "unknown flag: --foo",
+		},
+		{
+			name:  "Команда read с флагами должна передать параметры вывода",
+			input: "read ns=2;s=Tag --format json --reversible --range 1:2,0:3",
+			setupMocks: func() {
+				readCommand = mockRead
+			},
+			checkMocks: func(t *testing.T) {
+				if mockReadNodeID != "ns=2;s=Tag" {
+					t.Errorf("mockRead вызван с неверным узлом: %s", mockReadNodeID)
+				}
+				if mockReadOptions.Format != "json" || !mockReadOptions.Reversible || mockReadOptions.Range != "1:2,0:3" {
+					t.Errorf("mockRead вызван с неверными параметрами: %+v", mockReadOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда read --from должна передать файл списка и параметры",
+			input: "read --from tags.csv --format csv --parallel 8 -o snapshot.csv",
+			setupMocks: func() {
+				readListCommand = mockReadList
+			},
+			checkMocks: func(t *testing.T) {
+				if mockReadFile != "tags.csv" {
+					t.Errorf("mockReadList вызван с неверным файлом: %s", mockReadFile)
+				}
+				want := commands.ReadOptions{Format: "csv", Parallel: 8, Output: "snapshot.csv"}
+				if mockReadOptions != want {
+					t.Errorf("mockReadList вызван с параметрами %+v, ожидалось %+v", mockReadOptions, want)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда read --from с узлом должна вернуть ошибку использования",
+			input:   "read i=2258 --from tags.txt",
+			wantErr: true,
+			errMsg:  "usage: read <nodeid> [--range <range>] [--format text|json] [--reversible] | read --from <file> [--format text|csv|json] [--parallel N] [-o <file>]",
+		},
+		{
+			name:    "Команда read с -o без --from должна вернуть ошибку",
+			input:   "read i=2258 -o out.csv",
+			wantErr: true,
+			errMsg:  "unknown flag: --output",
+		},
+		{
+			name:    "Команда write без значения должна вернуть ошибку использования",
+			input:   "write ns=2;s=Tag",
+			wantErr: true,
+			errMsg:  "usage: write [--attr <name>] [--status <code>] [--ts <time>] <nodeid> <value> | write --from <csv> [--dry-run] [--rollback] [--yes] [--parallel N]",
+		},
+		{
+			name:  "Команда write с отрицательным значением не должна разбирать его как флаг",
+			input: "write ns=2;s=Tag -5",
+			setupMocks: func() {
+				writeCommand = mockWrite
+			},
+			checkMocks: func(t *testing.T) {
+				if mockWriteValue != "-5" {
+					t.Errorf("mockWrite вызван с неверным значением: %s", mockWriteValue)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда write --attr должна передать атрибут",
+			input: "write --attr Description ns=2;s=Tag Температура",
+			setupMocks: func() {
+				writeCommand = mockWrite
+			},
+			checkMocks: func(t *testing.T) {
+				if mockWriteNodeID != "ns=2;s=Tag" || mockWriteValue != "Температура" || mockWriteOptions.Attribute != "Description" {
+					t.Errorf("mockWrite вызван с %s %s %+v", mockWriteNodeID, mockWriteValue, mockWriteOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда write --status --ts должна передать статус и метку времени",
+			input: "write ns=2;s=Tag -5 --status Uncertain --ts 2026-01-01T00:00:00Z",
+			setupMocks: func() {
+				writeCommand = mockWrite
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.WriteOptions{Status: "Uncertain", SourceTimestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+				if mockWriteValue != "-5" || mockWriteOptions.Status != want.Status || !mockWriteOptions.SourceTimestamp.Equal(want.SourceTimestamp) {
+					t.Errorf("mockWrite вызван с %s %+v, ожидалось -5 %+v", mockWriteValue, mockWriteOptions, want)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда write --ts должна отсчитывать относительное время от текущего",
+			input: "write ns=2;s=Tag 1 --ts now-1h",
+			setupMocks: func() {
+				writeCommand = mockWrite
+			},
+			checkMocks: func(t *testing.T) {
+				if want := now.Add(-time.Hour); !mockWriteOptions.SourceTimestamp.Equal(want) {
+					t.Errorf("mockWrite вызван с меткой времени %v, ожидалось %v", mockWriteOptions.SourceTimestamp, want)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда write с неверным временем должна вернуть ошибку",
+			input:   "write ns=2;s=Tag 1 --ts yesterday",
+			wantErr: true,
+			errMsg:  "invalid time: yesterday",
+		},
+		{
+			name:    "Команда write --attr без значения должна вернуть ошибку использования",
+			input:   "write --attr DisplayName ns=2;s=Tag",
+			wantErr: true,
+			errMsg:  "usage: write [--attr <name>] [--status <code>] [--ts <time>] <nodeid> <value> | write --from <csv> [--dry-run] [--rollback] [--yes] [--parallel N]",
+		},
+		{
+			name:  "Команда write --from должна передать файл и параметры",
+			input: "write --from recipe.csv --dry-run --rollback --yes --parallel 2",
+			setupMocks: func() {
+				writeListCommand = mockWriteList
+			},
+			checkMocks: func(t *testing.T) {
+				if mockWriteFile != "recipe.csv" {
+					t.Errorf("mockWriteList вызван с неверным файлом: %s", mockWriteFile)
+				}
+				want := commands.WriteListOptions{DryRun: true, Rollback: true, Yes: true, Parallel: 2}
+				if mockWriteListOptions != want {
+					t.Errorf("mockWriteList вызван с параметрами %+v, ожидалось %+v", mockWriteListOptions, want)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда write --from с узлом должна вернуть ошибку использования",
+			input:   "write ns=2;s=Tag --from recipe.csv",
+			wantErr: true,
+			errMsg:  "usage: write [--attr <name>] [--status <code>] [--ts <time>] <nodeid> <value> | write --from <csv> [--dry-run] [--rollback] [--yes] [--parallel N]",
+		},
+		{
+			name:  "Команда write должна передать JSON-значение в кавычках целиком",
+			input: `write ns=2;s=Tag '{"Type":6, "Body":42}'`,
+			setupMocks: func() {
+				writeCommand = mockWrite
+			},
+			checkMocks: func(t *testing.T) {
+				if mockWriteNodeID != "ns=2;s=Tag" {
+					t.Errorf("mockWrite вызван с неверным узлом: %s", mockWriteNodeID)
+				}
+				if mockWriteValue != `{"Type":6, "Body":42}` {
+					t.Errorf("mockWrite вызван с неверным значением: %s", mockWriteValue)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда info без аргументов должна вернуть ошибку использования",
+			input:   "info",
+			wantErr: true,
+			errMsg:  "usage: info <nodeid>",
+		},
+		{
+			name:  "Команда describe должна вызвать mockInfo",
+			input: "describe ns=2;s=Tag",
+			setupMocks: func() {
+				infoCommand = mockInfo
+			},
+			checkMocks: func(t *testing.T) {
+				if mockInfoNodeID != "ns=2;s=Tag" {
+					t.Errorf("mockInfo вызван с неверным узлом: %s", mockInfoNodeID)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда tree без аргументов должна начать с Objects на глубину по умолчанию",
+			input: "tree",
+			setupMocks: func() {
+				treeCommand = mockTree
+			},
+			checkMocks: func(t *testing.T) {
+				if mockTreeNodeID != "i=85" || mockTreeOptions.Depth != 3 {
+					t.Errorf("mockTree вызван с неверными параметрами: %s %+v", mockTreeNodeID, mockTreeOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда tree должна передать фильтры",
+			input: "tree ns=2;s=Line1 --depth 5 --class Variable,Object --ref Organizes",
+			setupMocks: func() {
+				treeCommand = mockTree
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.TreeOptions{Depth: 5, Classes: "Variable,Object", Ref: "Organizes"}
+				if mockTreeNodeID != "ns=2;s=Line1" || mockTreeOptions != want {
+					t.Errorf("mockTree вызван с неверными параметрами: %s %+v", mockTreeNodeID, mockTreeOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда tree с нечисловой глубиной должна вернуть ошибку",
+			input:   "tree --depth many",
+			wantErr: true,
+			errMsg:  "invalid value for --depth: many",
+		},
+		{
+			name:  "Команда find должна передать условия поиска",
+			input: "find ns=2;s=Plant --name 'Temp*' --type BaseAnalogType --datatype Double --max 10",
+			setupMocks: func() {
+				findCommand = mockFind
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.FindOptions{Name: "Temp*", Type: "BaseAnalogType", DataType: "Double", Max: 10}
+				if mockFindRoot != "ns=2;s=Plant" || mockFindOptions != want {
+					t.Errorf("mockFind вызван с неверными параметрами: %s %+v", mockFindRoot, mockFindOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда find без корня должна искать от Objects с ограничением по умолчанию",
+			input: "find --name ^Temp --regex",
+			setupMocks: func() {
+				findCommand = mockFind
+			},
+			checkMocks: func(t *testing.T) {
+				if mockFindRoot != "i=85" || !mockFindOptions.Regex || mockFindOptions.Max != 100 {
+					t.Errorf("mockFind вызван с неверными параметрами: %s %+v", mockFindRoot, mockFindOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда export nodeset должна передать корень и файл",
+			input: "export nodeset ns=2;s=Plant -o plant.xml --values",
+			setupMocks: func() {
+				exportNodeSetCommand = mockExportNodeSet
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.ExportOptions{Output: "plant.xml", Values: true}
+				if mockExportRoot != "ns=2;s=Plant" || mockExportOptions != want {
+					t.Errorf("mockExportNodeSet вызван с неверными параметрами: %s %+v", mockExportRoot, mockExportOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда export без файла должна вернуть ошибку использования",
+			input:   "export nodeset i=85",
+			wantErr: true,
+			errMsg:  "usage: export nodeset [root] -o <file> [--values] [--parallel N]",
+		},
+		{
+			name:  "Команда diff должна передать снимки и корень по умолчанию",
+			input: "diff before.xml session --format json",
+			setupMocks: func() {
+				diffCommand = mockDiff
+			},
+			checkMocks: func(t *testing.T) {
+				want := []string{"before.xml", "session", commands.DefaultRoot}
+				if !reflect.DeepEqual(mockDiffArgs, want) || mockDiffOptions.Format != "json" || mockDiffOptions.Values {
+					t.Errorf("mockDiff вызван с неверными параметрами: %v %+v", mockDiffArgs, mockDiffOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда diff должна передать корень и --values",
+			input: "diff a.xml b.xml ns=2;s=Plant --values",
+			setupMocks: func() {
+				diffCommand = mockDiff
+			},
+			checkMocks: func(t *testing.T) {
+				want := []string{"a.xml", "b.xml", "ns=2;s=Plant"}
+				if !reflect.DeepEqual(mockDiffArgs, want) || mockDiffOptions.Format != "text" || !mockDiffOptions.Values {
+					t.Errorf("mockDiff вызван с неверными параметрами: %v %+v", mockDiffArgs, mockDiffOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда diff с одним снимком должна вернуть ошибку использования",
+			input:   "diff a.xml",
+			wantErr: true,
+			errMsg:  "usage: diff <snapshotA|session> <snapshotB|session> [root] [--format text|json] [--values]",
+		},
+		{
+			name:  "Команда ping без параметров должна использовать значения по умолчанию",
+			input: "ping",
+			setupMocks: func() {
+				pingCommand = mockPing
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.PingOptions{Count: commands.DefaultPingCount, Interval: time.Second}
+				if mockPingEndpoint != "" || mockPingOptions != want {
+					t.Errorf("mockPing вызван с неверными параметрами: %q %+v", mockPingEndpoint, mockPingOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда ping должна принять короткие формы -c и -i",
+			input: "ping opc.tcp://plc:4840 -c 10 -i 200ms --timeout 2s --hello",
+			setupMocks: func() {
+				pingCommand = mockPing
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.PingOptions{Count: 10, Interval: 200 * time.Millisecond, Timeout: 2 * time.Second, Hello: true}
+				if mockPingEndpoint != "opc.tcp://plc:4840" || mockPingOptions != want {
+					t.Errorf("mockPing вызван с неверными параметрами: %q %+v", mockPingEndpoint, mockPingOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда server status должна вызвать mockServerStatus",
+			input: "server status",
+			setupMocks: func() {
+				serverStatusCommand = mockServerStatus
+			},
+			checkMocks: func(t *testing.T) {
+				if !mockServerStatusCalled {
+					t.Errorf("mockServerStatus не был вызван")
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда server без подкоманды должна вернуть ошибку использования",
+			input:   "server",
+			wantErr: true,
+			errMsg:  "usage: server status",
+		},
+		{
+			name:  "Команда namespaces должна вызвать mockNamespaces",
+			input: "namespaces",
+			setupMocks: func() {
+				namespacesCommand = mockNamespaces
+			},
+			checkMocks: func(t *testing.T) {
+				if !mockNamespacesCalled {
+					t.Errorf("mockNamespaces не был вызван")
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда namespaces с аргументом должна вернуть ошибку использования",
+			input:   "namespaces 1",
+			wantErr: true,
+			errMsg:  "usage: namespaces",
+		},
+		{
+			name:  "Команда history raw без интервала должна читать последний час",
+			input: "history raw ns=2;s=Tag",
+			setupMocks: func() {
+				historyRawCommand = mockHistoryRaw
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.HistoryOptions{Start: now.Add(-time.Hour), End: now, Max: defaultHistoryMax, Format: "table"}
+				if mockHistoryKind != "raw" || mockHistoryNodeID != "ns=2;s=Tag" || mockHistoryOptions != want {
+					t.Errorf("mockHistoryRaw вызван с неверными параметрами: %s %q %+v", mockHistoryKind, mockHistoryNodeID, mockHistoryOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда history modified должна принять интервал, --max и --format",
+			input: "history modified ns=2;s=Tag --from -2d --to 2024-05-01T10:00:00Z --max 10 --format csv",
+			setupMocks: func() {
+				historyModifiedCommand = mockHistoryModified
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.HistoryOptions{
+					Start:  now.Add(-48 * time.Hour),
+					End:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+					Max:    10,
+					Format: "csv",
+				}
+				if mockHistoryKind != "modified" || !mockHistoryOptions.Start.Equal(want.Start) || !mockHistoryOptions.End.Equal(want.End) ||
+					mockHistoryOptions.Max != want.Max || mockHistoryOptions.Format != want.Format {
+					t.Errorf("mockHistoryModified вызван с неверными параметрами: %s %+v", mockHistoryKind, mockHistoryOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда history at должна принять моменты через запятую и отдельными аргументами",
+			input: "history at ns=2;s=Tag -1h,-30m now",
+			setupMocks: func() {
+				historyAtCommand = mockHistoryAt
+			},
+			checkMocks: func(t *testing.T) {
+				want := []time.Time{now.Add(-time.Hour), now.Add(-30 * time.Minute), now}
+				if mockHistoryKind != "at" || !reflect.DeepEqual(mockHistoryTimes, want) || mockHistoryOptions.Format != "table" {
+					t.Errorf("mockHistoryAt вызван с неверными параметрами: %s %v %+v", mockHistoryKind, mockHistoryTimes, mockHistoryOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда history at без моментов времени должна вернуть ошибку использования",
+			input:   "history at ns=2;s=Tag",
+			wantErr: true,
+			errMsg:  "usage: history at <nodeid> <time>[,<time>...] [--format table|csv]",
+		},
+		{
+			name:    "Команда history с неверным временем должна вернуть ошибку",
+			input:   "history raw ns=2;s=Tag --from yesterday",
+			wantErr: true,
+			errMsg:  "invalid time: yesterday",
+		},
+		{
+			name:  "Команда history agg должна передать функции, интервал и период",
+			input: "history agg ns=2;s=Tag --agg Average,Min,Max,Count --interval 5m --from -8h --to now --format csv",
+			setupMocks: func() {
+				historyAggCommand = mockHistoryAgg
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.HistoryOptions{Start: now.Add(-8 * time.Hour), End: now, Format: "csv"}
+				if mockHistoryKind != "agg" || mockHistoryNodeID != "ns=2;s=Tag" || mockHistoryOptions != want ||
+					mockHistoryInterval != 5*time.Minute || !reflect.DeepEqual(mockHistoryAggs, []string{"Average", "Min", "Max", "Count"}) {
+					t.Errorf("mockHistoryAgg вызван с неверными параметрами: %q %v %v %+v", mockHistoryNodeID, mockHistoryAggs, mockHistoryInterval, mockHistoryOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда history agg без --interval должна запросить один интервал",
+			input: "history agg ns=2;s=Tag --agg Average",
+			setupMocks: func() {
+				historyAggCommand = mockHistoryAgg
+			},
+			checkMocks: func(t *testing.T) {
+				if mockHistoryInterval != 0 || !mockHistoryOptions.Start.Equal(now.Add(-time.Hour)) || mockHistoryOptions.Format != "table" {
+					t.Errorf("mockHistoryAgg вызван с неверными параметрами: %v %+v", mockHistoryInterval, mockHistoryOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда history agg без --agg должна вернуть ошибку использования",
+			input:   "history agg ns=2;s=Tag --interval 5m",
+			wantErr: true,
+			errMsg:  "usage: history agg <nodeid> --agg <name>[,<name>...] [--interval <duration>] [--from <time>] [--to <time>] [--format table|csv]",
+		},
+		{
+			name:    "Команда history agg с неверным интервалом должна вернуть ошибку",
+			input:   "history agg ns=2;s=Tag --agg Average --interval 0s",
+			wantErr: true,
+			errMsg:  "invalid value for --interval: 0s",
+		},
+		{
+			name:  "Команда history aggregates должна вызвать historyAggregatesCommand",
+			input: "history aggregates",
+			setupMocks: func() {
+				historyAggregatesCommand = mockHistoryAggregates
+			},
+			checkMocks: func(t *testing.T) {
+				if !mockHistoryAggregatesCalled {
+					t.Error("mockHistoryAggregates не был вызван")
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда history events должна передать поля и условие отбора",
+			input: `history events i=2253 --select Time,Severity,Message --where "Severity >= 500 and Message like 'Pump%'" --from -1d --max 50`,
+			setupMocks: func() {
+				historyEventsCommand = mockHistoryEvents
+			},
+			checkMocks: func(t *testing.T) {
+				want := commands.HistoryOptions{Start: now.Add(-24 * time.Hour), End: now, Max: 50, Format: "table"}
+				if mockHistoryKind != "events" || mockHistoryNodeID != "i=2253" || mockHistoryOptions != want ||
+					!reflect.DeepEqual(mockHistoryFields, []string{"Time", "Severity", "Message"}) || mockHistoryWhere != "Severity >= 500 and Message like 'Pump%'" {
+					t.Errorf("mockHistoryEvents вызван с неверными параметрами: %q %v %q %+v", mockHistoryNodeID, mockHistoryFields, mockHistoryWhere, mockHistoryOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда history events без --select должна передать пустой список полей",
+			input: "history events i=2253",
+			setupMocks: func() {
+				historyEventsCommand = mockHistoryEvents
+			},
+			checkMocks: func(t *testing.T) {
+				if mockHistoryFields != nil || mockHistoryWhere != "" || mockHistoryOptions.Max != defaultHistoryMax {
+					t.Errorf("mockHistoryEvents вызван с неверными параметрами: %v %q %+v", mockHistoryFields, mockHistoryWhere, mockHistoryOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда history events без узла должна вернуть ошибку использования",
+			input:   "history events --where x",
+			wantErr: true,
+			errMsg:  "usage: history events <notifier> [--select <field>,...] [--where <condition>] [--from <time>] [--to <time>] [--max N] [--format table|csv]",
+		},
+		{
+			name:  "Команда history insert должна передать значения из аргументов",
+			input: "history insert ns=2;s=Tag -2h=1.5 2024-05-01T10:30:00Z=2 --file gap.csv",
+			setupMocks: func() {
+				historyInsertCommand = mockHistoryInsert
+			},
+			checkMocks: func(t *testing.T) {
+				want := []commands.HistoryValue{
+					{Time: now.Add(-2 * time.Hour), Value: "1.5"},
+					{Time: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC), Value: "2"},
+				}
+				if mockHistoryKind != "insert" || mockHistoryNodeID != "ns=2;s=Tag" || !reflect.DeepEqual(mockHistoryValues, want) ||
+					mockHistoryUpdateOptions != (commands.HistoryUpdateOptions{File: "gap.csv"}) {
+					t.Errorf("mockHistoryInsert вызван с неверными параметрами: %q %v %+v", mockHistoryNodeID, mockHistoryValues, mockHistoryUpdateOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда history replace с --file должна передать файл и --yes",
+			input: "history replace ns=2;s=Tag --file gap.csv --yes",
+			setupMocks: func() {
+				historyReplaceCommand = mockHistoryReplace
+			},
+			checkMocks: func(t *testing.T) {
+				if mockHistoryKind != "replace" || mockHistoryValues != nil ||
+					mockHistoryUpdateOptions != (commands.HistoryUpdateOptions{File: "gap.csv", Yes: true}) {
+					t.Errorf("mockHistoryReplace вызван с неверными параметрами: %v %+v", mockHistoryValues, mockHistoryUpdateOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда history insert без значений должна вернуть ошибку использования",
+			input:   "history insert ns=2;s=Tag",
+			wantErr: true,
+			errMsg:  "usage: history insert <nodeid> [<time>=<value>...] [--file <csv>] [--yes]",
+		},
+		{
+			name:    "Команда history insert со значением без метки времени должна вернуть ошибку",
+			input:   "history insert ns=2;s=Tag 1.5",
+			wantErr: true,
+			errMsg:  `invalid value "1.5", expected <time>=<value>`,
+		},
+		{
+			name:  "Команда history delete должна передать интервал",
+			input: "history delete ns=2;s=Tag --from -3h --to -2h --yes",
+			setupMocks: func() {
+				historyDeleteCommand = mockHistoryDelete
+			},
+			checkMocks: func(t *testing.T) {
+				want := []time.Time{now.Add(-3 * time.Hour), now.Add(-2 * time.Hour)}
+				if mockHistoryKind != "delete" || !reflect.DeepEqual(mockHistoryTimes, want) || !mockHistoryUpdateOptions.Yes {
+					t.Errorf("mockHistoryDelete вызван с неверными параметрами: %v %+v", mockHistoryTimes, mockHistoryUpdateOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Команда history delete --at должна передать метки времени",
+			input: "history delete ns=2;s=Tag --at -1h,-30m",
+			setupMocks: func() {
+				historyDeleteAtCommand = mockHistoryDeleteAt
+			},
+			checkMocks: func(t *testing.T) {
+				want := []time.Time{now.Add(-time.Hour), now.Add(-30 * time.Minute)}
+				if mockHistoryKind != "delete at" || !reflect.DeepEqual(mockHistoryTimes, want) || mockHistoryUpdateOptions.Yes {
+					t.Errorf("mockHistoryDeleteAt вызван с неверными параметрами: %v %+v", mockHistoryTimes, mockHistoryUpdateOptions)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Команда history delete без интервала должна вернуть ошибку использования",
+			input:   "history delete ns=2;s=Tag --from -1h",
+			wantErr: true,
+			errMsg:  "usage: history delete <nodeid> --from <time> --to <time> | --at <time>[,<time>...] [--yes]",
+		},
+		{
+			name:    "Команда history без подкоманды должна вернуть ошибку использования",
+			input:   "history",
+			wantErr: true,
+			errMsg:  "usage: history raw|modified|at|agg|events|insert|replace|delete <nodeid> ... | history aggregates",
+		},
+		{
+			name:    "Команда ping с неверным интервалом должна вернуть ошибку",
+			input:   "ping -i soon",
+			wantErr: true,
+			errMsg:  "invalid value for --interval: soon",
+		},
+		{
+			name:    "Незакрытая кавычка должна вернуть ошибку",
+			input:   `write ns=2;s=Tag "abc`,
+			wantErr: true,
+			errMsg:  "unterminated quote in input",
+		},
 	}
 
 	for _, tt := range tests {
@@ -200,12 +1104,20 @@ func TestExecute(t *testing.T) {
 // - Обработка запуска без функциональных аргументов.
 // - Обработка неполных аргументов для команды `connect`.
 // - Корректная обработка аргументов для `connect` и IP-адресов с использованием заглушек.
+// - Флаги --read-only и --confirm-writes в любом месте включают режимы команд.
+// - Флаг --audit-log открывает журнал аудита.
 func TestParseStartupArgs(t *testing.T) {
 	// Сохраняем оригинальные функции и восстанавливаем их после выполнения всех тестов
 	oldConnectCommand := connectCommand
 	defer func() {
 		connectCommand = oldConnectCommand
+		commands.ReadOnly, commands.ConfirmWrites = false, false
+		if commands.Audit != nil {
+			commands.Audit.Close()
+			commands.Audit = nil
+		}
 	}()
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
 
 	tests := []struct {
 		name                 string
@@ -290,11 +1202,72 @@ func TestParseStartupArgs(t *testing.T) {
 			wantErr: true,
 			errMsg:  "mock startup connect with endpoint failed",
 		},
+		{
+			name: "Запуск с --read-only должен включить режим только чтения и подключиться",
+			args: []string{"opcli", "--read-only", "connect", "opc.tcp://plant:4840"},
+			setupMocks: func() {
+				connectCommand = mockConnect
+				mockConnectError = nil
+			},
+			checkMocks: func(t *testing.T) {
+				if !commands.ReadOnly || commands.ConfirmWrites {
+					t.Errorf("ReadOnly = %v, ConfirmWrites = %v, ожидался только режим чтения", commands.ReadOnly, commands.ConfirmWrites)
+				}
+				if mockConnectEndpoint != "opc.tcp://plant:4840" {
+					t.Errorf("mockConnect вызван с неверным эндпоинтом: %s", mockConnectEndpoint)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "Запуск с --confirm-writes после IP-адреса должен включить подтверждение записи",
+			args: []string{"opcli", "127.0.0.1", "--confirm-writes"},
+			setupMocks: func() {
+				connectCommand = mockConnect
+				mockConnectError = nil
+			},
+			checkMocks: func(t *testing.T) {
+				if commands.ReadOnly || !commands.ConfirmWrites {
+					t.Errorf("ReadOnly = %v, ConfirmWrites = %v, ожидалось только подтверждение записи", commands.ReadOnly, commands.ConfirmWrites)
+				}
+				if mockConnectEndpoint != "opc.tcp://127.0.0.1:4840" {
+					t.Errorf("mockConnect вызван с неверным эндпоинтом: %s", mockConnectEndpoint)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "Запуск с --audit-log должен открыть журнал аудита",
+			args: []string{"opcli", "--audit-log", auditLog, "127.0.0.1"},
+			setupMocks: func() {
+				connectCommand = mockConnect
+				mockConnectError = nil
+			},
+			checkMocks: func(t *testing.T) {
+				if commands.Audit == nil {
+					t.Errorf("журнал аудита не открыт")
+				}
+				if _, err := os.Stat(auditLog); err != nil {
+					t.Errorf("файл журнала не создан: %v", err)
+				}
+				if mockConnectEndpoint != "opc.tcp://127.0.0.1:4840" {
+					t.Errorf("mockConnect вызван с неверным эндпоинтом: %s", mockConnectEndpoint)
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Запуск с --audit-log без файла должен вернуть ошибку",
+			args:    []string{"opcli", "--audit-log"},
+			wantErr: true,
+			errMsg:  "flag --audit-log requires a value",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			resetMocks() // Сброс моков перед каждым подтестом
+			commands.ReadOnly, commands.ConfirmWrites = false, false
 			if tt.setupMocks != nil {
 				tt.setupMocks()
 			}
@@ -320,6 +1293,83 @@ func TestParseStartupArgs(t *testing.T) {
 	}
 }
 
+// TestServe проверяет разбор аргументов команды `opcli serve`.
+//
+// Основные аспекты тестирования:
+// - Передача параметров командной строки в serveCommand.
+// - Ошибки для неверного интервала, неизвестных параметров и лишних аргументов.
+func TestServe(t *testing.T) {
+	oldServeCommand := serveCommand
+	serveCommand = mockServe
+	defer func() {
+		serveCommand = oldServeCommand
+	}()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    commands.ServeOptions
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "Без параметров порт берётся по умолчанию",
+			args: []string{},
+			want: commands.ServeOptions{},
+		},
+		{
+			name: "Все параметры передаются в serveCommand",
+			args: []string{"--port", "4841", "--host", "0.0.0.0", "--config", "sim.json", "--nodeset", "plant.xml", "--interval", "250ms"},
+			want: commands.ServeOptions{Port: 4841, Host: "0.0.0.0", Config: "sim.json", NodeSet: "plant.xml", Interval: 250 * time.Millisecond},
+		},
+		{
+			name:    "Неверный интервал должен вернуть ошибку",
+			args:    []string{"--interval", "fast"},
+			wantErr: true,
+			errMsg:  "invalid value for --interval: fast",
+		},
+		{
+			name:    "Нулевой интервал должен вернуть ошибку",
+			args:    []string{"--interval", "0s"},
+			wantErr: true,
+			errMsg:  "invalid value for --interval: 0s",
+		},
+		{
+			name:    "Неизвестный параметр должен вернуть ошибку",
+			args:    []string{"--values"},
+			wantErr: true,
+		},
+		{
+			name:    "Лишний аргумент должен вернуть ошибку использования",
+			args:    []string{"extra"},
+			wantErr: true,
+			errMsg:  "usage: opcli serve [--port N] [--host <name>] [--config <file>] [--nodeset <file>] [--interval <duration>]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetMocks()
+			err := Serve(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Serve() ожидалась ошибка, получено nil")
+				}
+				if tt.errMsg != "" && err.Error() != tt.errMsg {
+					t.Errorf("Serve() получено неожиданное сообщение об ошибке = %v, ожидалось %v", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Serve() получена непредвиденная ошибка = %v", err)
+			}
+			if mockServeOptions != tt.want {
+				t.Errorf("mockServe вызван с неверными параметрами: %+v, ожидалось %+v", mockServeOptions, tt.want)
+			}
+		})
+	}
+}
+
 // TestIsIPv4 проверяет вспомогательную функцию isIPv4.
 // Эта функция является неэкспортированной, но её логика достаточно важна и самодостаточна,
 // чтобы быть протестированной напрямую.
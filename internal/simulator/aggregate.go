@@ -0,0 +1,167 @@
+package simulator
+
+import (
+	"math"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// aggregate вычисляет значение функции агрегирования по значениям архива
+// за один интервал. Значения без числового представления пропускаются.
+type aggregate func(values []*ua.DataValue) (*ua.Variant, ua.StatusCode)
+
+// aggregates - функции агрегирования Part 13, которые вычисляет имитатор.
+// Они же перечисляются в Server.ServerCapabilities.AggregateFunctions.
+var aggregates = map[uint32]aggregate{
+	id.AggregateFunction_Average: func(values []*ua.DataValue) (*ua.Variant, ua.StatusCode) {
+		nums := numbers(values)
+		if len(nums) == 0 {
+			return nil, ua.StatusBadNoData
+		}
+		var sum float64
+		for _, x := range nums {
+			sum += x
+		}
+		return ua.MustVariant(sum / float64(len(nums))), ua.StatusOK
+	},
+	id.AggregateFunction_Minimum: func(values []*ua.DataValue) (*ua.Variant, ua.StatusCode) {
+		return extremum(values, math.Min)
+	},
+	id.AggregateFunction_Maximum: func(values []*ua.DataValue) (*ua.Variant, ua.StatusCode) {
+		return extremum(values, math.Max)
+	},
+	id.AggregateFunction_Range: func(values []*ua.DataValue) (*ua.Variant, ua.StatusCode) {
+		lo, code := extremum(values, math.Min)
+		if code != ua.StatusOK {
+			return nil, code
+		}
+		hi, _ := extremum(values, math.Max)
+		return ua.MustVariant(hi.Value().(float64) - lo.Value().(float64)), ua.StatusOK
+	},
+	id.AggregateFunction_Count: func(values []*ua.DataValue) (*ua.Variant, ua.StatusCode) {
+		return ua.MustVariant(int32(len(values))), ua.StatusOK
+	},
+	id.AggregateFunction_Start: func(values []*ua.DataValue) (*ua.Variant, ua.StatusCode) {
+		if len(values) == 0 {
+			return nil, ua.StatusBadNoData
+		}
+		return values[0].Value, ua.StatusOK
+	},
+	id.AggregateFunction_End: func(values []*ua.DataValue) (*ua.Variant, ua.StatusCode) {
+		if len(values) == 0 {
+			return nil, ua.StatusBadNoData
+		}
+		return values[len(values)-1].Value, ua.StatusOK
+	},
+}
+
+// readProcessed делит интервал на отрезки ProcessingInterval (0 - один
+// отрезок на весь интервал) и вычисляет функцию для каждого. Метка времени
+// результата - начало отрезка.
+func readProcessed(values []*ua.DataValue, d *ua.ReadProcessedDetails, agg *ua.NodeID) *ua.HistoryReadResult {
+	f, ok := aggregates[agg.IntID()]
+	if !ok || agg.Namespace() != 0 {
+		return &ua.HistoryReadResult{StatusCode: ua.StatusBadAggregateNotSupported}
+	}
+	from, to := d.StartTime, d.EndTime
+	backward := to.Before(from)
+	if backward {
+		from, to = to, from
+	}
+	step := time.Duration(d.ProcessingInterval * float64(time.Millisecond))
+	if step <= 0 {
+		step = to.Sub(from)
+	}
+	if step <= 0 || to.Sub(from)/step >= historyLimit {
+		return &ua.HistoryReadResult{StatusCode: ua.StatusBadInvalidArgument}
+	}
+
+	out := []*ua.DataValue{}
+	for start := from; start.Before(to); start = start.Add(step) {
+		end := start.Add(step)
+		var in []*ua.DataValue
+		for _, v := range values {
+			if !v.SourceTimestamp.Before(start) && v.SourceTimestamp.Before(end) {
+				in = append(in, v)
+			}
+		}
+		v, code := f(in)
+		dv := &ua.DataValue{
+			EncodingMask:    ua.DataValueStatusCode | ua.DataValueSourceTimestamp | ua.DataValueServerTimestamp,
+			Status:          code,
+			SourceTimestamp: start,
+			ServerTimestamp: start,
+		}
+		if v != nil {
+			dv.EncodingMask |= ua.DataValueValue
+			dv.Value = v
+		}
+		out = append(out, dv)
+	}
+	if backward {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+	return historyResult(&ua.HistoryData{DataValues: out}, nil)
+}
+
+func extremum(values []*ua.DataValue, pick func(a, b float64) float64) (*ua.Variant, ua.StatusCode) {
+	nums := numbers(values)
+	if len(nums) == 0 {
+		return nil, ua.StatusBadNoData
+	}
+	x := nums[0]
+	for _, y := range nums[1:] {
+		x = pick(x, y)
+	}
+	return ua.MustVariant(x), ua.StatusOK
+}
+
+// numbers возвращает числовые значения; логические считаются 0 и 1
+func numbers(values []*ua.DataValue) []float64 {
+	var out []float64
+	for _, v := range values {
+		if v.Value == nil {
+			continue
+		}
+		if x, ok := number(v.Value.Value()); ok {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// number приводит число или логическое значение к float64
+func number(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	case int16:
+		return float64(x), true
+	case uint16:
+		return float64(x), true
+	case int8:
+		return float64(x), true
+	case byte:
+		return float64(x), true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
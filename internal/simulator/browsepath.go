@@ -0,0 +1,110 @@
+package simulator
+
+import (
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/gopcua/opcua/uasc"
+)
+
+// translateBrowsePaths обрабатывает запрос TranslateBrowsePathsToNodeIds
+// (Part 4, 5.8.4): сервер gopcua отвечает на него BadServiceUnsupported.
+// Каждый шаг пути выполняется просмотром пространства имён текущих узлов.
+func (s *Server) translateBrowsePaths(sc *uasc.SecureChannel, r ua.Request, reqID uint32) (ua.Response, error) {
+	req, ok := r.(*ua.TranslateBrowsePathsToNodeIDsRequest)
+	if !ok {
+		return nil, ua.StatusBadRequestTypeInvalid
+	}
+
+	results := make([]*ua.BrowsePathResult, len(req.BrowsePaths))
+	for i, p := range req.BrowsePaths {
+		results[i] = s.translateBrowsePath(p)
+	}
+
+	return &ua.TranslateBrowsePathsToNodeIDsResponse{
+		ResponseHeader: &ua.ResponseHeader{
+			Timestamp:          time.Now(),
+			RequestHandle:      req.RequestHeader.RequestHandle,
+			ServiceResult:      ua.StatusOK,
+			ServiceDiagnostics: &ua.DiagnosticInfo{},
+			StringTable:        []string{},
+			AdditionalHeader:   ua.NewExtensionObject(nil),
+		},
+		Results:         results,
+		DiagnosticInfos: []*ua.DiagnosticInfo{},
+	}, nil
+}
+
+func (s *Server) translateBrowsePath(p *ua.BrowsePath) *ua.BrowsePathResult {
+	res := &ua.BrowsePathResult{Targets: []*ua.BrowsePathTarget{}}
+	switch {
+	case p.RelativePath == nil || len(p.RelativePath.Elements) == 0:
+		res.StatusCode = ua.StatusBadNothingToDo
+		return res
+	case s.srv.Node(p.StartingNode) == nil && s.ns.node(p.StartingNode) == nil:
+		res.StatusCode = ua.StatusBadNodeIDUnknown
+		return res
+	}
+
+	nodes := []*ua.NodeID{p.StartingNode}
+	for _, e := range p.RelativePath.Elements {
+		if e.TargetName == nil || e.TargetName.Name == "" {
+			res.StatusCode = ua.StatusBadBrowseNameInvalid
+			return res
+		}
+		var next []*ua.NodeID
+		for _, n := range nodes {
+			next = append(next, s.browseName(n, e)...)
+		}
+		if len(next) == 0 {
+			res.StatusCode = ua.StatusBadNoMatch
+			return res
+		}
+		nodes = next
+	}
+
+	res.StatusCode = ua.StatusOK
+	for _, n := range nodes {
+		res.Targets = append(res.Targets, &ua.BrowsePathTarget{
+			TargetID:           ua.NewExpandedNodeID(n, "", 0),
+			RemainingPathIndex: ^uint32(0),
+		})
+	}
+	return res
+}
+
+// browseName возвращает узлы, на которые n ссылается по ссылкам элемента e
+// и имя просмотра которых совпадает с e.TargetName. Тип ссылок проверяется
+// здесь же: пространство имён 0 сервера gopcua не находит подтипы
+// HierarchicalReferences, кроме Organizes.
+func (s *Server) browseName(n *ua.NodeID, e *ua.RelativePathElement) []*ua.NodeID {
+	ns, err := s.srv.Namespace(int(n.Namespace()))
+	if err != nil {
+		return nil
+	}
+	dir := ua.BrowseDirectionForward
+	if e.IsInverse {
+		dir = ua.BrowseDirectionInverse
+	}
+	br := ns.Browse(&ua.BrowseDescription{
+		NodeID:          n,
+		BrowseDirection: dir,
+		ReferenceTypeID: ua.NewNumericNodeID(0, 0),
+		ResultMask:      uint32(ua.BrowseResultMaskAll),
+	})
+	if br == nil || br.StatusCode != ua.StatusOK {
+		return nil
+	}
+
+	var out []*ua.NodeID
+	for _, r := range br.References {
+		bn := r.BrowseName
+		if !matchesRefType(r.ReferenceTypeID, e.ReferenceTypeID, e.IncludeSubtypes) {
+			continue
+		}
+		if bn != nil && r.NodeID != nil && bn.NamespaceIndex == e.TargetName.NamespaceIndex && bn.Name == e.TargetName.Name {
+			out = append(out, r.NodeID.NodeID)
+		}
+	}
+	return out
+}
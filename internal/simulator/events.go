@@ -0,0 +1,286 @@
+package simulator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// simEvent - событие в архиве имитатора: номер и поля BaseEventType по именам
+type simEvent struct {
+	seq    uint32
+	fields map[string]*ua.Variant
+}
+
+func (s *Server) newEvent(seq uint32, t time.Time, message string, severity uint16) *simEvent {
+	eventID := make([]byte, 8)
+	binary.BigEndian.PutUint64(eventID, uint64(seq))
+	return &simEvent{seq: seq, fields: map[string]*ua.Variant{
+		"EventId":     ua.MustVariant(eventID),
+		"EventType":   ua.MustVariant(ua.NewNumericNodeID(0, id.BaseEventType)),
+		"SourceNode":  ua.MustVariant(s.events.object.id),
+		"SourceName":  ua.MustVariant(rootName),
+		"Time":        ua.MustVariant(t),
+		"ReceiveTime": ua.MustVariant(t),
+		"Message":     ua.MustVariant(&ua.LocalizedText{EncodingMask: ua.LocalizedTextText, Text: message}),
+		"Severity":    ua.MustVariant(severity),
+	}}
+}
+
+// isNotifier сообщает, что узел хранит архив событий имитатора: это объект
+// Events и объект Server, через который доступны события всего сервера
+func (s *Server) isNotifier(n *ua.NodeID) bool {
+	return n.Namespace() == 0 && n.IntID() == id.Server || n.String() == s.events.object.id.String()
+}
+
+// readEvents возвращает события за интервал, удовлетворяющие условию
+// фильтра. Точка продолжения - номер следующего события.
+func (s *Server) readEvents(d *ua.ReadEventDetails, cp []byte) *ua.HistoryReadResult {
+	if d.Filter == nil || len(d.Filter.SelectClauses) == 0 {
+		return &ua.HistoryReadResult{StatusCode: ua.StatusBadEventFilterInvalid}
+	}
+	if code := checkFilter(d.Filter.WhereClause); code != ua.StatusOK {
+		return &ua.HistoryReadResult{StatusCode: code}
+	}
+	from, to := d.StartTime, d.EndTime
+	backward := !to.IsZero() && to.Before(from)
+	if backward {
+		from, to = to, from
+	}
+	var next uint64
+	if len(cp) > 0 {
+		if len(cp) != 8 {
+			return &ua.HistoryReadResult{StatusCode: ua.StatusBadContinuationPointInvalid}
+		}
+		next = binary.BigEndian.Uint64(cp)
+	}
+
+	s.mu.Lock()
+	log := append([]*simEvent(nil), s.events.log...)
+	s.mu.Unlock()
+
+	var matched []*simEvent
+	for _, ev := range log {
+		t := ev.fields["Time"].Value().(time.Time)
+		switch {
+		case t.Before(from) || !to.IsZero() && t.After(to):
+			continue
+		case len(cp) > 0 && !backward && uint64(ev.seq) < next:
+			continue
+		case len(cp) > 0 && backward && uint64(ev.seq) > next:
+			continue
+		case !matchFilter(d.Filter.WhereClause, ev):
+			continue
+		}
+		matched = append(matched, ev)
+	}
+	if backward {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+
+	var nextCP []byte
+	if max := int(d.NumValuesPerNode); max > 0 && len(matched) > max {
+		nextCP = make([]byte, 8)
+		binary.BigEndian.PutUint64(nextCP, uint64(matched[max].seq))
+		matched = matched[:max]
+	}
+
+	events := make([]*ua.HistoryEventFieldList, len(matched))
+	for i, ev := range matched {
+		fields := make([]*ua.Variant, len(d.Filter.SelectClauses))
+		for j, sel := range d.Filter.SelectClauses {
+			fields[j] = ev.field(sel)
+			if fields[j] == nil {
+				fields[j] = &ua.Variant{}
+			}
+		}
+		events[i] = &ua.HistoryEventFieldList{EventFields: fields}
+	}
+	return historyResult(&ua.HistoryEvent{Events: events}, nextCP)
+}
+
+// field возвращает поле события по операнду или nil, если поля нет
+func (ev *simEvent) field(op *ua.SimpleAttributeOperand) *ua.Variant {
+	if op == nil || len(op.BrowsePath) != 1 || op.BrowsePath[0].NamespaceIndex != 0 {
+		return nil
+	}
+	return ev.fields[op.BrowsePath[0].Name]
+}
+
+// checkFilter проверяет, что условие использует только поддерживаемые
+// операторы и операнды
+func checkFilter(cf *ua.ContentFilter) ua.StatusCode {
+	if cf == nil {
+		return ua.StatusOK
+	}
+	for _, el := range cf.Elements {
+		switch el.FilterOperator {
+		case ua.FilterOperatorAnd, ua.FilterOperatorOr, ua.FilterOperatorNot, ua.FilterOperatorEquals,
+			ua.FilterOperatorGreaterThan, ua.FilterOperatorLessThan, ua.FilterOperatorGreaterThanOrEqual,
+			ua.FilterOperatorLessThanOrEqual, ua.FilterOperatorLike, ua.FilterOperatorIsNull, ua.FilterOperatorOfType:
+		default:
+			return ua.StatusBadFilterOperatorUnsupported
+		}
+		for _, o := range el.FilterOperands {
+			if o == nil {
+				return ua.StatusBadFilterOperandInvalid
+			}
+			switch v := o.Value.(type) {
+			case *ua.ElementOperand:
+				if int(v.Index) >= len(cf.Elements) {
+					return ua.StatusBadFilterOperandInvalid
+				}
+			case *ua.LiteralOperand, *ua.SimpleAttributeOperand:
+			default:
+				return ua.StatusBadFilterOperandInvalid
+			}
+		}
+	}
+	return ua.StatusOK
+}
+
+// matchFilter вычисляет условие для события; пустое условие выполняется
+func matchFilter(cf *ua.ContentFilter, ev *simEvent) bool {
+	if cf == nil || len(cf.Elements) == 0 {
+		return true
+	}
+	b, _ := evalElement(cf, 0, ev, 0).(bool)
+	return b
+}
+
+// evalElement вычисляет элемент условия i. depth ограничивает вложенность
+// на случай циклических ссылок между элементами.
+func evalElement(cf *ua.ContentFilter, i int, ev *simEvent, depth int) interface{} {
+	if depth > len(cf.Elements) {
+		return nil
+	}
+	el := cf.Elements[i]
+	args := make([]interface{}, len(el.FilterOperands))
+	for j, o := range el.FilterOperands {
+		switch v := o.Value.(type) {
+		case *ua.ElementOperand:
+			args[j] = evalElement(cf, int(v.Index), ev, depth+1)
+		case *ua.LiteralOperand:
+			if v.Value != nil {
+				args[j] = v.Value.Value()
+			}
+		case *ua.SimpleAttributeOperand:
+			if f := ev.field(v); f != nil {
+				args[j] = f.Value()
+			}
+		}
+	}
+	arg := func(j int) interface{} {
+		if j < len(args) {
+			return args[j]
+		}
+		return nil
+	}
+	truth := func(j int) bool {
+		b, _ := arg(j).(bool)
+		return b
+	}
+
+	switch el.FilterOperator {
+	case ua.FilterOperatorAnd:
+		return truth(0) && truth(1)
+	case ua.FilterOperatorOr:
+		return truth(0) || truth(1)
+	case ua.FilterOperatorNot:
+		return !truth(0)
+	case ua.FilterOperatorIsNull:
+		return arg(0) == nil
+	case ua.FilterOperatorOfType:
+		t, ok := arg(0).(*ua.NodeID)
+		et, _ := ev.fields["EventType"].Value().(*ua.NodeID)
+		return ok && (t.String() == et.String() || t.Namespace() == 0 && t.IntID() == id.BaseEventType)
+	case ua.FilterOperatorLike:
+		return like(text(arg(0)), text(arg(1)))
+	case ua.FilterOperatorEquals:
+		c, ok := compare(arg(0), arg(1))
+		return ok && c == 0
+	case ua.FilterOperatorGreaterThan:
+		c, ok := compare(arg(0), arg(1))
+		return ok && c > 0
+	case ua.FilterOperatorLessThan:
+		c, ok := compare(arg(0), arg(1))
+		return ok && c < 0
+	case ua.FilterOperatorGreaterThanOrEqual:
+		c, ok := compare(arg(0), arg(1))
+		return ok && c >= 0
+	case ua.FilterOperatorLessThanOrEqual:
+		c, ok := compare(arg(0), arg(1))
+		return ok && c <= 0
+	}
+	return nil
+}
+
+// compare сравнивает значения: числа - численно, время - по моменту,
+// прочие - по текстовому представлению
+func compare(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if x, ok := a.(time.Time); ok {
+		y, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		return x.Compare(y), true
+	}
+	return strings.Compare(text(a), text(b)), true
+}
+
+func text(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case *ua.LocalizedText:
+		return x.Text
+	case *ua.QualifiedName:
+		return x.Name
+	case *ua.NodeID:
+		return x.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// like сравнивает строку с шаблоном Like (Part 4, 7.7.3): % - любая
+// последовательность символов, _ - один символ
+func like(s, pattern string) bool {
+	var re strings.Builder
+	re.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	ok, _ := regexp.MatchString(re.String(), s)
+	return ok
+}
@@ -0,0 +1,184 @@
+package simulator
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/gopcua/opcua/uasc"
+)
+
+// historyLimit - наибольшее число значений в архиве одной переменной;
+// при периоде обновления 1s это около трёх часов
+const historyLimit = 10000
+
+// archive добавляет значение в архив переменной по метке времени, вытесняя
+// самые старые. Вызывается под мьютексом пространства имён.
+func (n *node) archive(dv *ua.DataValue) {
+	v := *dv
+	v.ServerTimestamp = v.SourceTimestamp
+	v.EncodingMask |= ua.DataValueServerTimestamp
+	// Новые значения обычно позже всех, но HistoryUpdate вставляет и прошлые
+	i := sort.Search(len(n.history), func(k int) bool { return n.history[k].SourceTimestamp.After(v.SourceTimestamp) })
+	n.history = append(n.history, nil)
+	copy(n.history[i+1:], n.history[i:])
+	n.history[i] = &v
+	if over := len(n.history) - historyLimit; over > 0 {
+		n.history = append(n.history[:0:0], n.history[over:]...)
+	}
+}
+
+// historyRead обрабатывает запрос HistoryRead (Part 11, 6.4): сервер
+// gopcua не поддерживает архив. Поддерживаются чтение значений за интервал,
+// на заданные моменты времени, агрегированных значений и событий.
+func (s *Server) historyRead(sc *uasc.SecureChannel, r ua.Request, reqID uint32) (ua.Response, error) {
+	req, ok := r.(*ua.HistoryReadRequest)
+	if !ok {
+		return nil, ua.StatusBadRequestTypeInvalid
+	}
+
+	var details interface{}
+	if req.HistoryReadDetails != nil {
+		details = req.HistoryReadDetails.Value
+	}
+	results := make([]*ua.HistoryReadResult, len(req.NodesToRead))
+	for i, rv := range req.NodesToRead {
+		if req.ReleaseContinuationPoints {
+			// Точки продолжения не хранятся на сервере, освобождать нечего
+			results[i] = &ua.HistoryReadResult{StatusCode: ua.StatusOK}
+			continue
+		}
+		results[i] = s.readHistory(rv, details, i)
+	}
+
+	return &ua.HistoryReadResponse{
+		ResponseHeader: &ua.ResponseHeader{
+			Timestamp:          time.Now(),
+			RequestHandle:      req.RequestHeader.RequestHandle,
+			ServiceResult:      ua.StatusOK,
+			ServiceDiagnostics: &ua.DiagnosticInfo{},
+			StringTable:        []string{},
+			AdditionalHeader:   ua.NewExtensionObject(nil),
+		},
+		Results:         results,
+		DiagnosticInfos: []*ua.DiagnosticInfo{},
+	}, nil
+}
+
+// readHistory читает архив узла rv; i - номер узла в запросе, по нему
+// выбирается функция агрегирования ReadProcessedDetails
+func (s *Server) readHistory(rv *ua.HistoryReadValueID, details interface{}, i int) *ua.HistoryReadResult {
+	if d, ok := details.(*ua.ReadEventDetails); ok {
+		if !s.isNotifier(rv.NodeID) {
+			return &ua.HistoryReadResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+		}
+		return s.readEvents(d, rv.ContinuationPoint)
+	}
+
+	n := s.ns.node(rv.NodeID)
+	switch {
+	case n == nil:
+		return &ua.HistoryReadResult{StatusCode: ua.StatusBadNodeIDUnknown}
+	case n.class != ua.NodeClassVariable:
+		return &ua.HistoryReadResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+	}
+
+	s.ns.mu.RLock()
+	values := append([]*ua.DataValue(nil), n.history...)
+	modified := append([]*modifiedValue(nil), n.modified...)
+	s.ns.mu.RUnlock()
+
+	switch d := details.(type) {
+	case *ua.ReadRawModifiedDetails:
+		if d.IsReadModified {
+			return readModified(modified, d)
+		}
+		return readRaw(values, d, rv.ContinuationPoint)
+	case *ua.ReadAtTimeDetails:
+		return historyResult(&ua.HistoryData{DataValues: readAtTime(values, d.ReqTimes)}, nil)
+	case *ua.ReadProcessedDetails:
+		if i >= len(d.AggregateType) {
+			return &ua.HistoryReadResult{StatusCode: ua.StatusBadAggregateListMismatch}
+		}
+		return readProcessed(values, d, d.AggregateType[i])
+	}
+	return &ua.HistoryReadResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+}
+
+// readRaw возвращает значения за интервал. Если EndTime раньше StartTime,
+// значения идут в обратном порядке. Точка продолжения - метка времени
+// следующего значения, поэтому сервер не хранит состояние чтения.
+func readRaw(values []*ua.DataValue, d *ua.ReadRawModifiedDetails, cp []byte) *ua.HistoryReadResult {
+	from, to := d.StartTime, d.EndTime
+	backward := !to.IsZero() && to.Before(from)
+	if backward {
+		from, to = to, from
+	}
+	if len(cp) > 0 {
+		if len(cp) != 8 {
+			return &ua.HistoryReadResult{StatusCode: ua.StatusBadContinuationPointInvalid}
+		}
+		next := time.Unix(0, int64(binary.BigEndian.Uint64(cp)))
+		if backward {
+			to = next
+		} else {
+			from = next
+		}
+	}
+
+	var out []*ua.DataValue
+	for _, v := range values {
+		ts := v.SourceTimestamp
+		if ts.Before(from) || !to.IsZero() && ts.After(to) {
+			continue
+		}
+		out = append(out, v)
+	}
+	if backward {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+
+	var next []byte
+	if max := int(d.NumValuesPerNode); max > 0 && len(out) > max {
+		next = make([]byte, 8)
+		binary.BigEndian.PutUint64(next, uint64(out[max].SourceTimestamp.UnixNano()))
+		out = out[:max]
+	}
+	if out == nil {
+		out = []*ua.DataValue{}
+	}
+	return historyResult(&ua.HistoryData{DataValues: out}, next)
+}
+
+// readAtTime возвращает значения на моменты times. Между значениями архива
+// используется ступенчатая интерполяция: действует предыдущее значение.
+func readAtTime(values []*ua.DataValue, times []time.Time) []*ua.DataValue {
+	out := make([]*ua.DataValue, len(times))
+	for i, t := range times {
+		// Индекс первого значения позже t
+		j := sort.Search(len(values), func(k int) bool { return values[k].SourceTimestamp.After(t) })
+		if j == 0 {
+			out[i] = &ua.DataValue{
+				EncodingMask:    ua.DataValueStatusCode | ua.DataValueSourceTimestamp,
+				Status:          ua.StatusBadNoData,
+				SourceTimestamp: t,
+			}
+			continue
+		}
+		v := *values[j-1]
+		v.SourceTimestamp, v.ServerTimestamp = t, t
+		out[i] = &v
+	}
+	return out
+}
+
+func historyResult(data interface{}, cp []byte) *ua.HistoryReadResult {
+	return &ua.HistoryReadResult{
+		StatusCode:        ua.StatusOK,
+		ContinuationPoint: cp,
+		HistoryData:       ua.NewExtensionObject(data),
+	}
+}
@@ -0,0 +1,223 @@
+package simulator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/gopcua/opcua/uasc"
+)
+
+// modifiedValue - изменение архива: вставленное значение либо прежняя
+// версия заменённого или удалённого значения
+type modifiedValue struct {
+	value *ua.DataValue
+	info  *ua.ModificationInfo
+}
+
+// historyUpdate обрабатывает запрос HistoryUpdate (Part 11, 6.8):
+// вставку, замену и удаление значений архива переменных. Каждое изменение
+// запоминается и читается через ReadRawModifiedDetails с IsReadModified.
+func (s *Server) historyUpdate(sc *uasc.SecureChannel, r ua.Request, reqID uint32) (ua.Response, error) {
+	req, ok := r.(*ua.HistoryUpdateRequest)
+	if !ok {
+		return nil, ua.StatusBadRequestTypeInvalid
+	}
+
+	results := make([]*ua.HistoryUpdateResult, len(req.HistoryUpdateDetails))
+	for i, eo := range req.HistoryUpdateDetails {
+		var details interface{}
+		if eo != nil {
+			details = eo.Value
+		}
+		results[i] = s.updateHistory(details)
+	}
+
+	return &ua.HistoryUpdateResponse{
+		ResponseHeader: &ua.ResponseHeader{
+			Timestamp:          time.Now(),
+			RequestHandle:      req.RequestHeader.RequestHandle,
+			ServiceResult:      ua.StatusOK,
+			ServiceDiagnostics: &ua.DiagnosticInfo{},
+			StringTable:        []string{},
+			AdditionalHeader:   ua.NewExtensionObject(nil),
+		},
+		Results:         results,
+		DiagnosticInfos: []*ua.DiagnosticInfo{},
+	}, nil
+}
+
+func (s *Server) updateHistory(details interface{}) *ua.HistoryUpdateResult {
+	var nodeID *ua.NodeID
+	switch d := details.(type) {
+	case *ua.UpdateDataDetails:
+		nodeID = d.NodeID
+	case *ua.DeleteRawModifiedDetails:
+		nodeID = d.NodeID
+	case *ua.DeleteAtTimeDetails:
+		nodeID = d.NodeID
+	default:
+		return &ua.HistoryUpdateResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+	}
+	n := s.ns.node(nodeID)
+	switch {
+	case n == nil:
+		return &ua.HistoryUpdateResult{StatusCode: ua.StatusBadNodeIDUnknown}
+	case n.class != ua.NodeClassVariable:
+		return &ua.HistoryUpdateResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+	}
+
+	s.ns.mu.Lock()
+	defer s.ns.mu.Unlock()
+	now := time.Now()
+	switch d := details.(type) {
+	case *ua.UpdateDataDetails:
+		return n.updateData(d, now)
+	case *ua.DeleteRawModifiedDetails:
+		return n.deleteRaw(d, now)
+	case *ua.DeleteAtTimeDetails:
+		return n.deleteAtTime(d.ReqTimes, now)
+	}
+	return &ua.HistoryUpdateResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+}
+
+// updateData вставляет или заменяет значения. Вставка отклоняется, если
+// значение с той же меткой времени есть, замена - если его нет.
+func (n *node) updateData(d *ua.UpdateDataDetails, now time.Time) *ua.HistoryUpdateResult {
+	var updateType ua.HistoryUpdateType
+	switch d.PerformInsertReplace {
+	case ua.PerformUpdateTypeInsert:
+		updateType = ua.HistoryUpdateTypeInsert
+	case ua.PerformUpdateTypeReplace:
+		updateType = ua.HistoryUpdateTypeReplace
+	default:
+		return &ua.HistoryUpdateResult{StatusCode: ua.StatusBadHistoryOperationUnsupported}
+	}
+
+	results := make([]ua.StatusCode, len(d.UpdateValues))
+	for i, v := range d.UpdateValues {
+		j, found := n.findHistory(v.SourceTimestamp)
+		switch {
+		case v.Value != nil && uint32(v.Value.Type()) != n.dataType.IntID():
+			results[i] = ua.StatusBadTypeMismatch
+		case v.Value == nil && v.Status&0x80000000 == 0:
+			// Без значения можно записать только плохое значение
+			results[i] = ua.StatusBadInvalidArgument
+		case updateType == ua.HistoryUpdateTypeInsert && found:
+			results[i] = ua.StatusBadEntryExists
+		case updateType == ua.HistoryUpdateTypeReplace && !found:
+			results[i] = ua.StatusBadNoEntryExists
+		case found:
+			n.modify(n.history[j], updateType, now)
+			n.history = append(n.history[:j], n.history[j+1:]...)
+			n.archive(v)
+			results[i] = ua.StatusGoodEntryReplaced
+		default:
+			n.archive(v)
+			n.modify(v, updateType, now)
+			results[i] = ua.StatusGoodEntryInserted
+		}
+	}
+	return &ua.HistoryUpdateResult{StatusCode: ua.StatusOK, OperationResults: results}
+}
+
+// deleteRaw удаляет значения за интервал [StartTime, EndTime), а с
+// IsDeleteModified - сведения об изменениях значений за интервал
+func (n *node) deleteRaw(d *ua.DeleteRawModifiedDetails, now time.Time) *ua.HistoryUpdateResult {
+	in := func(dv *ua.DataValue) bool {
+		return !dv.SourceTimestamp.Before(d.StartTime) && dv.SourceTimestamp.Before(d.EndTime)
+	}
+	if d.IsDeleteModified {
+		kept := n.modified[:0]
+		for _, m := range n.modified {
+			if !in(m.value) {
+				kept = append(kept, m)
+			}
+		}
+		n.modified = kept
+		return &ua.HistoryUpdateResult{StatusCode: ua.StatusOK}
+	}
+
+	kept := n.history[:0]
+	for _, v := range n.history {
+		if in(v) {
+			n.modify(v, ua.HistoryUpdateTypeDelete, now)
+			continue
+		}
+		kept = append(kept, v)
+	}
+	n.history = kept
+	return &ua.HistoryUpdateResult{StatusCode: ua.StatusOK}
+}
+
+// deleteAtTime удаляет значения с заданными метками времени
+func (n *node) deleteAtTime(times []time.Time, now time.Time) *ua.HistoryUpdateResult {
+	results := make([]ua.StatusCode, len(times))
+	for i, t := range times {
+		j, found := n.findHistory(t)
+		if !found {
+			results[i] = ua.StatusBadNoEntryExists
+			continue
+		}
+		n.modify(n.history[j], ua.HistoryUpdateTypeDelete, now)
+		n.history = append(n.history[:j], n.history[j+1:]...)
+	}
+	return &ua.HistoryUpdateResult{StatusCode: ua.StatusOK, OperationResults: results}
+}
+
+// findHistory возвращает индекс значения архива с меткой времени t
+func (n *node) findHistory(t time.Time) (int, bool) {
+	i := sort.Search(len(n.history), func(k int) bool { return !n.history[k].SourceTimestamp.Before(t) })
+	return i, i < len(n.history) && n.history[i].SourceTimestamp.Equal(t)
+}
+
+// modify запоминает изменение значения архива, вытесняя самые старые
+func (n *node) modify(dv *ua.DataValue, updateType ua.HistoryUpdateType, now time.Time) {
+	v := *dv
+	v.ServerTimestamp = v.SourceTimestamp
+	v.EncodingMask |= ua.DataValueServerTimestamp
+	n.modified = append(n.modified, &modifiedValue{
+		value: &v,
+		info:  &ua.ModificationInfo{ModificationTime: now, UpdateType: updateType},
+	})
+	if over := len(n.modified) - historyLimit; over > 0 {
+		n.modified = append(n.modified[:0:0], n.modified[over:]...)
+	}
+}
+
+// readModified возвращает изменения значений с метками времени в интервале,
+// упорядоченные по метке времени, а при равных метках - по порядку
+// изменений. Изменений не больше historyLimit, поэтому они возвращаются
+// без точки продолжения.
+func readModified(modified []*modifiedValue, d *ua.ReadRawModifiedDetails) *ua.HistoryReadResult {
+	from, to := d.StartTime, d.EndTime
+	backward := !to.IsZero() && to.Before(from)
+	if backward {
+		from, to = to, from
+	}
+
+	var out []*modifiedValue
+	for _, m := range modified {
+		ts := m.value.SourceTimestamp
+		if ts.Before(from) || !to.IsZero() && ts.After(to) {
+			continue
+		}
+		out = append(out, m)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if backward {
+			return out[i].value.SourceTimestamp.After(out[j].value.SourceTimestamp)
+		}
+		return out[i].value.SourceTimestamp.Before(out[j].value.SourceTimestamp)
+	})
+	if max := int(d.NumValuesPerNode); max > 0 && len(out) > max {
+		out = out[:max]
+	}
+
+	data := &ua.HistoryModifiedData{DataValues: []*ua.DataValue{}, ModificationInfos: []*ua.ModificationInfo{}}
+	for _, m := range out {
+		data.DataValues = append(data.DataValues, m.value)
+		data.ModificationInfos = append(data.ModificationInfos, m.info)
+	}
+	return historyResult(data, nil)
+}
@@ -0,0 +1,237 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+	"github.com/gopcua/opcua/uasc"
+)
+
+// argument - входной или выходной аргумент метода
+type argument struct {
+	name string
+	typ  ua.TypeID
+}
+
+// method - метод имитатора. call получает аргументы уже проверенных типов.
+type method struct {
+	node   *node
+	object *node
+	in     []argument
+	out    []argument
+	call   func(args []*ua.Variant) ([]*ua.Variant, ua.StatusCode)
+}
+
+// eventNodes - переменные с последним событием имитатора.
+//
+// Сервер gopcua не поддерживает подписку на события (EventFilter), поэтому
+// событие публикуется как набор переменных, изменения которых можно
+// отслеживать обычной подпиской на данные.
+type eventNodes struct {
+	object   *node
+	message  *node
+	severity *node
+	time     *node
+	count    *node
+	total    uint32
+	// log - архив событий для HistoryRead, не больше historyLimit
+	log []*simEvent
+}
+
+// buildEvents создаёт объект Events с полями последнего события
+func (s *Server) buildEvents(root *node) {
+	obj := s.object(rootName+".Events", "Events")
+	obj.eventNotifier = uint8(ua.EventNotifierTypeHistoryRead)
+	obj = s.ns.add(obj, root, id.HasComponent)
+	field := func(name string, v interface{}) *node {
+		return s.ns.add(s.variable(rootName+".Events."+name, name, ua.MustVariant(v), false), obj, id.HasComponent)
+	}
+	s.events = eventNodes{
+		object:   obj,
+		message:  field("Message", ""),
+		severity: field("Severity", uint16(0)),
+		time:     field("Time", time.Time{}),
+		count:    field("Count", uint32(0)),
+	}
+}
+
+// raise публикует событие и сохраняет его в архиве событий
+func (s *Server) raise(message string, severity uint16) {
+	now := time.Now()
+	s.mu.Lock()
+	s.events.total++
+	total := s.events.total
+	s.events.log = append(s.events.log, s.newEvent(total, now, message, severity))
+	if over := len(s.events.log) - historyLimit; over > 0 {
+		s.events.log = append(s.events.log[:0:0], s.events.log[over:]...)
+	}
+	s.mu.Unlock()
+
+	s.ns.set(s.events.message, ua.MustVariant(message))
+	s.ns.set(s.events.severity, ua.MustVariant(severity))
+	s.ns.set(s.events.time, ua.MustVariant(now))
+	s.ns.set(s.events.count, ua.MustVariant(total))
+}
+
+// buildMethods создаёт объект Methods с методами Add, Reset и RaiseEvent
+func (s *Server) buildMethods(root *node) {
+	obj := s.ns.add(s.object(rootName+".Methods", "Methods"), root, id.HasComponent)
+
+	s.method(obj, "Add",
+		[]argument{{"A", ua.TypeIDDouble}, {"B", ua.TypeIDDouble}},
+		[]argument{{"Sum", ua.TypeIDDouble}},
+		func(args []*ua.Variant) ([]*ua.Variant, ua.StatusCode) {
+			sum := args[0].Value().(float64) + args[1].Value().(float64)
+			return []*ua.Variant{ua.MustVariant(sum)}, ua.StatusOK
+		})
+
+	s.method(obj, "Reset", nil, nil,
+		func([]*ua.Variant) ([]*ua.Variant, ua.StatusCode) {
+			s.reset()
+			return nil, ua.StatusOK
+		})
+
+	s.method(obj, "RaiseEvent",
+		[]argument{{"Message", ua.TypeIDString}, {"Severity", ua.TypeIDUint16}},
+		nil,
+		func(args []*ua.Variant) ([]*ua.Variant, ua.StatusCode) {
+			s.raise(args[0].Value().(string), args[1].Value().(uint16))
+			return nil, ua.StatusOK
+		})
+}
+
+func (s *Server) object(path, name string) *node {
+	return &node{
+		id:      ua.NewStringNodeID(s.ns.ID(), path),
+		class:   ua.NodeClassObject,
+		name:    name,
+		typeDef: ua.NewNumericNodeID(0, id.BaseObjectType),
+	}
+}
+
+// method создаёт узел метода со свойствами InputArguments и OutputArguments
+func (s *Server) method(obj *node, name string, in, out []argument, call func([]*ua.Variant) ([]*ua.Variant, ua.StatusCode)) {
+	path := obj.id.StringID() + "." + name
+	n := s.ns.add(&node{
+		id:    ua.NewStringNodeID(s.ns.ID(), path),
+		class: ua.NodeClassMethod,
+		name:  name,
+	}, obj, id.HasComponent)
+
+	for _, p := range []struct {
+		name string
+		args []argument
+	}{{"InputArguments", in}, {"OutputArguments", out}} {
+		if len(p.args) == 0 {
+			continue
+		}
+		list := make([]*ua.ExtensionObject, len(p.args))
+		for i, a := range p.args {
+			list[i] = ua.NewExtensionObject(&ua.Argument{
+				Name:            a.name,
+				DataType:        ua.NewNumericNodeID(0, uint32(a.typ)),
+				ValueRank:       -1,
+				ArrayDimensions: []uint32{},
+				Description:     &ua.LocalizedText{},
+			})
+		}
+		prop := s.variable(path+"."+p.name, p.name, ua.MustVariant(list), false)
+		prop.typeDef = ua.NewNumericNodeID(0, id.PropertyType)
+		prop.dataType = ua.NewNumericNodeID(0, id.Argument)
+		prop.valueRank = 1
+		s.ns.add(prop, n, id.HasProperty)
+	}
+
+	s.methods[n.id.String()] = &method{node: n, object: obj, in: in, out: out, call: call}
+}
+
+// call обрабатывает запрос Call (Part 4, 5.11.2)
+func (s *Server) call(sc *uasc.SecureChannel, r ua.Request, reqID uint32) (ua.Response, error) {
+	req, ok := r.(*ua.CallRequest)
+	if !ok {
+		return nil, ua.StatusBadRequestTypeInvalid
+	}
+
+	results := make([]*ua.CallMethodResult, len(req.MethodsToCall))
+	for i, c := range req.MethodsToCall {
+		results[i] = s.callMethod(c)
+	}
+
+	return &ua.CallResponse{
+		ResponseHeader: &ua.ResponseHeader{
+			Timestamp:          time.Now(),
+			RequestHandle:      req.RequestHeader.RequestHandle,
+			ServiceResult:      ua.StatusOK,
+			ServiceDiagnostics: &ua.DiagnosticInfo{},
+			StringTable:        []string{},
+			AdditionalHeader:   ua.NewExtensionObject(nil),
+		},
+		Results:         results,
+		DiagnosticInfos: []*ua.DiagnosticInfo{},
+	}, nil
+}
+
+func (s *Server) callMethod(c *ua.CallMethodRequest) *ua.CallMethodResult {
+	res := &ua.CallMethodResult{
+		InputArgumentResults:         []ua.StatusCode{},
+		InputArgumentDiagnosticInfos: []*ua.DiagnosticInfo{},
+		OutputArguments:              []*ua.Variant{},
+	}
+
+	m, ok := s.methods[c.MethodID.String()]
+	switch {
+	case !ok:
+		res.StatusCode = ua.StatusBadMethodInvalid
+		return res
+	case !c.ObjectID.Equal(m.object.id):
+		res.StatusCode = ua.StatusBadMethodInvalid
+		return res
+	case len(c.InputArguments) < len(m.in):
+		res.StatusCode = ua.StatusBadArgumentsMissing
+		return res
+	case len(c.InputArguments) > len(m.in):
+		res.StatusCode = ua.StatusBadTooManyArguments
+		return res
+	}
+
+	res.InputArgumentResults = make([]ua.StatusCode, len(m.in))
+	for i, a := range m.in {
+		if v := c.InputArguments[i]; v == nil || v.Type() != a.typ {
+			res.InputArgumentResults[i] = ua.StatusBadTypeMismatch
+			res.StatusCode = ua.StatusBadInvalidArgument
+		}
+	}
+	if res.StatusCode != ua.StatusOK {
+		return res
+	}
+	res.InputArgumentResults = []ua.StatusCode{}
+
+	out, status := m.call(c.InputArguments)
+	res.StatusCode = status
+	if out != nil {
+		res.OutputArguments = out
+	}
+	return res
+}
+
+// methodSignature описывает метод для вывода при запуске: Add(A Double, B Double) -> Sum Double
+func methodSignature(m *method) string {
+	args := func(list []argument) string {
+		s := ""
+		for i, a := range list {
+			if i > 0 {
+				s += ", "
+			}
+			s += a.name + " " + formatter.TypeName(a.typ)
+		}
+		return s
+	}
+	sig := fmt.Sprintf("%s(%s)", m.node.name, args(m.in))
+	if len(m.out) > 0 {
+		sig += " -> " + args(m.out)
+	}
+	return sig
+}
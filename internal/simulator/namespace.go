@@ -0,0 +1,377 @@
+package simulator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/server/attrs"
+	"github.com/gopcua/opcua/ua"
+)
+
+// node - узел пространства имён имитатора
+type node struct {
+	id       *ua.NodeID
+	class    ua.NodeClass
+	name     string
+	typeDef  *ua.NodeID
+	dataType *ua.NodeID
+	// valueRank - -1 для скаляров, 1 для одномерных массивов
+	valueRank int32
+	writable  bool
+	// description - атрибут Description; у уставок записывается клиентом,
+	// защищён мьютексом пространства имён
+	description *ua.LocalizedText
+	// eventNotifier - атрибут EventNotifier объекта
+	eventNotifier uint8
+	refs          []*ua.ReferenceDescription
+
+	// value - текущее значение переменной; защищено мьютексом пространства имён
+	value *ua.DataValue
+	// history - архив значений переменной по меткам времени, не больше
+	// historyLimit; защищён мьютексом пространства имён
+	history []*ua.DataValue
+	// modified - изменения архива через HistoryUpdate в порядке выполнения,
+	// не больше historyLimit; защищены мьютексом пространства имён
+	modified []*modifiedValue
+	// proxy - представление узла для пакета server, который обращается
+	// к узлам чужих пространств имён при просмотре и добавлении ссылок
+	proxy *server.Node
+}
+
+// namespace реализует server.NameSpace для узлов имитатора.
+//
+// Собственная реализация нужна потому, что NodeNameSpace из gopcua
+// возвращает атрибут DataType как ExpandedNodeID и подставляет его же
+// вместо TypeDefinition при просмотре.
+type namespace struct {
+	srv *server.Server
+	uri string
+	idx uint16
+
+	mu    sync.RWMutex
+	nodes map[string]*node
+	// interval - период обновления значений, он же MinimumSamplingInterval
+	interval time.Duration
+}
+
+func newNamespace(srv *server.Server, uri string, interval time.Duration) *namespace {
+	ns := &namespace{srv: srv, uri: uri, nodes: make(map[string]*node), interval: interval}
+	srv.AddNamespace(ns)
+	return ns
+}
+
+// Name возвращает URI пространства имён
+func (ns *namespace) Name() string { return ns.uri }
+
+// ID возвращает индекс пространства имён на сервере
+func (ns *namespace) ID() uint16 { return ns.idx }
+
+// SetID задаёт индекс пространства имён; вызывается сервером
+func (ns *namespace) SetID(idx uint16) { ns.idx = idx }
+
+// AddNode не поддерживается: узлы имитатора создаются только им самим
+func (ns *namespace) AddNode(n *server.Node) *server.Node { return n }
+
+// Node возвращает представление узла для пакета server
+func (ns *namespace) Node(nodeID *ua.NodeID) *server.Node {
+	if n := ns.node(nodeID); n != nil {
+		return n.proxy
+	}
+	return nil
+}
+
+// Objects возвращает корневую папку имитатора
+func (ns *namespace) Objects() *server.Node {
+	return ns.Node(ua.NewStringNodeID(ns.idx, rootName))
+}
+
+// Root не определён: корень адресного пространства находится в пространстве имён 0
+func (ns *namespace) Root() *server.Node { return nil }
+
+func (ns *namespace) node(nodeID *ua.NodeID) *node {
+	if nodeID == nil {
+		return nil
+	}
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	return ns.nodes[nodeID.String()]
+}
+
+// add регистрирует узел и связывает его с родителем ссылкой refType
+func (ns *namespace) add(n *node, parent *node, refType uint32) *node {
+	proxy := server.Attributes{
+		ua.AttributeIDNodeClass:   server.DataValueFromValue(uint32(n.class)),
+		ua.AttributeIDBrowseName:  server.DataValueFromValue(ns.browseName(n)),
+		ua.AttributeIDDisplayName: server.DataValueFromValue(attrs.DisplayName(n.name, "")),
+	}
+	if n.typeDef != nil {
+		// server.Node.DataType() используется пакетом server как TypeDefinition
+		proxy[ua.AttributeIDDataType] = server.DataValueFromValue(expanded(n.typeDef))
+		n.refs = append(n.refs, &ua.ReferenceDescription{
+			ReferenceTypeID: ua.NewNumericNodeID(0, id.HasTypeDefinition),
+			IsForward:       true,
+			NodeID:          expanded(n.typeDef),
+			BrowseName:      &ua.QualifiedName{},
+			DisplayName:     &ua.LocalizedText{},
+			NodeClass:       typeClass(n.class),
+			TypeDefinition:  ua.NewTwoByteExpandedNodeID(0),
+		})
+	}
+	n.proxy = server.NewNode(n.id, proxy, nil, nil)
+
+	ns.mu.Lock()
+	ns.nodes[n.id.String()] = n
+	ns.mu.Unlock()
+
+	if parent != nil {
+		parent.refs = append(parent.refs, ns.reference(n, refType, true))
+		n.refs = append(n.refs, ns.reference(parent, refType, false))
+	}
+	return n
+}
+
+func (ns *namespace) reference(target *node, refType uint32, forward bool) *ua.ReferenceDescription {
+	return &ua.ReferenceDescription{
+		ReferenceTypeID: ua.NewNumericNodeID(0, refType),
+		IsForward:       forward,
+		NodeID:          ua.NewExpandedNodeID(target.id, "", 0),
+		BrowseName:      ns.browseName(target),
+		DisplayName:     attrs.DisplayName(target.name, ""),
+		NodeClass:       target.class,
+		TypeDefinition:  expanded(target.typeDef),
+	}
+}
+
+// expanded возвращает ExpandedNodeID; у методов определения типа нет
+func expanded(n *ua.NodeID) *ua.ExpandedNodeID {
+	if n == nil {
+		return ua.NewTwoByteExpandedNodeID(0)
+	}
+	return ua.NewExpandedNodeID(n, "", 0)
+}
+
+func (ns *namespace) browseName(n *node) *ua.QualifiedName {
+	return &ua.QualifiedName{NamespaceIndex: n.id.Namespace(), Name: n.name}
+}
+
+func typeClass(c ua.NodeClass) ua.NodeClass {
+	if c == ua.NodeClassVariable {
+		return ua.NodeClassVariableType
+	}
+	return ua.NodeClassObjectType
+}
+
+// Browse возвращает ссылки узла с учётом направления, типа ссылки
+// и маски классов узлов
+func (ns *namespace) Browse(bd *ua.BrowseDescription) *ua.BrowseResult {
+	n := ns.node(bd.NodeID)
+	if n == nil {
+		return &ua.BrowseResult{StatusCode: ua.StatusBadNodeIDUnknown}
+	}
+
+	refs := make([]*ua.ReferenceDescription, 0, len(n.refs))
+	for _, r := range n.refs {
+		switch {
+		case bd.BrowseDirection == ua.BrowseDirectionForward && !r.IsForward,
+			bd.BrowseDirection == ua.BrowseDirectionInverse && r.IsForward:
+			continue
+		case !matchesRefType(r.ReferenceTypeID, bd.ReferenceTypeID, bd.IncludeSubtypes):
+			continue
+		case bd.NodeClassMask != 0 && bd.NodeClassMask&uint32(r.NodeClass) == 0:
+			continue
+		}
+		refs = append(refs, r)
+	}
+	return &ua.BrowseResult{StatusCode: ua.StatusOK, References: refs}
+}
+
+// supertypes - цепочки супертипов ссылок, которые использует имитатор,
+// и иерархических ссылок пространства имён 0 для TranslateBrowsePathsToNodeIds
+var supertypes = map[uint32][]uint32{
+	id.Organizes:           {id.HierarchicalReferences, id.References},
+	id.HasComponent:        {id.Aggregates, id.HasChild, id.HierarchicalReferences, id.References},
+	id.HasOrderedComponent: {id.HasComponent, id.Aggregates, id.HasChild, id.HierarchicalReferences, id.References},
+	id.HasProperty:         {id.Aggregates, id.HasChild, id.HierarchicalReferences, id.References},
+	id.HasSubtype:          {id.HasChild, id.HierarchicalReferences, id.References},
+	id.HasEventSource:      {id.HierarchicalReferences, id.References},
+	id.HasNotifier:         {id.HasEventSource, id.HierarchicalReferences, id.References},
+	id.HasTypeDefinition:   {id.NonHierarchicalReferences, id.References},
+}
+
+func matchesRefType(ref, want *ua.NodeID, subtypes bool) bool {
+	if want == nil || (want.Namespace() == 0 && want.IntID() == 0) || ref.Equal(want) {
+		return true
+	}
+	if !subtypes || want.Namespace() != 0 {
+		return false
+	}
+	for _, t := range supertypes[ref.IntID()] {
+		if t == want.IntID() {
+			return true
+		}
+	}
+	return false
+}
+
+// Attribute возвращает значение атрибута узла
+func (ns *namespace) Attribute(nodeID *ua.NodeID, attr ua.AttributeID) *ua.DataValue {
+	n := ns.node(nodeID)
+	if n == nil {
+		return statusValue(ua.StatusBadNodeIDUnknown)
+	}
+
+	var v interface{}
+	switch attr {
+	case ua.AttributeIDNodeID:
+		v = n.id
+	case ua.AttributeIDNodeClass:
+		v = int32(n.class)
+	case ua.AttributeIDBrowseName:
+		v = ns.browseName(n)
+	case ua.AttributeIDDisplayName:
+		v = attrs.DisplayName(n.name, "")
+	case ua.AttributeIDDescription:
+		ns.mu.RLock()
+		text := n.description
+		ns.mu.RUnlock()
+		if text == nil {
+			text = &ua.LocalizedText{}
+		}
+		v = text
+	case ua.AttributeIDWriteMask, ua.AttributeIDUserWriteMask:
+		v = n.writeMask()
+	}
+
+	switch n.class {
+	case ua.NodeClassObject:
+		if attr == ua.AttributeIDEventNotifier {
+			v = n.eventNotifier
+		}
+	case ua.NodeClassVariable:
+		switch attr {
+		case ua.AttributeIDValue:
+			ns.mu.RLock()
+			dv := *n.value
+			ns.mu.RUnlock()
+			dv.ServerTimestamp = time.Now()
+			dv.EncodingMask |= ua.DataValueServerTimestamp
+			return &dv
+		case ua.AttributeIDDataType:
+			v = n.dataType
+		case ua.AttributeIDValueRank:
+			v = n.valueRank
+		case ua.AttributeIDArrayDimensions:
+			if n.valueRank > 0 {
+				v = []uint32{0}
+			} else {
+				v = []uint32{}
+			}
+		case ua.AttributeIDAccessLevel, ua.AttributeIDUserAccessLevel:
+			v = n.accessLevel()
+		case ua.AttributeIDMinimumSamplingInterval:
+			v = float64(ns.interval / time.Millisecond)
+		case ua.AttributeIDHistorizing:
+			v = true
+		}
+	case ua.NodeClassMethod:
+		if attr == ua.AttributeIDExecutable || attr == ua.AttributeIDUserExecutable {
+			v = true
+		}
+	}
+
+	if v == nil {
+		return statusValue(ua.StatusBadAttributeIDInvalid)
+	}
+	return &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(v)}
+}
+
+// accessLevel - уставки записываются вместе со статусом и меткой времени
+func (n *node) accessLevel() uint8 {
+	if n.writable {
+		return uint8(ua.AccessLevelTypeCurrentRead | ua.AccessLevelTypeCurrentWrite | ua.AccessLevelTypeHistoryRead | ua.AccessLevelTypeHistoryWrite |
+			ua.AccessLevelTypeStatusWrite | ua.AccessLevelTypeTimestampWrite)
+	}
+	return uint8(ua.AccessLevelTypeCurrentRead | ua.AccessLevelTypeHistoryRead | ua.AccessLevelTypeHistoryWrite)
+}
+
+// writeMask - у уставок записывается Description
+func (n *node) writeMask() uint32 {
+	if n.writable {
+		return uint32(ua.AttributeWriteMaskDescription)
+	}
+	return 0
+}
+
+// SetAttribute записывает значение или описание переменной. Записываются
+// только уставки; тип значения должен совпадать с типом данных переменной.
+// Статус и метка времени источника записываются вместе со значением.
+func (ns *namespace) SetAttribute(nodeID *ua.NodeID, attr ua.AttributeID, val *ua.DataValue) ua.StatusCode {
+	n := ns.node(nodeID)
+	switch {
+	case n == nil:
+		return ua.StatusBadNodeIDUnknown
+	case n.class != ua.NodeClassVariable || (attr != ua.AttributeIDValue && attr != ua.AttributeIDDescription):
+		return ua.StatusBadNotWritable
+	case !n.writable:
+		return ua.StatusBadUserAccessDenied
+	case val == nil || val.Value == nil:
+		return ua.StatusBadTypeMismatch
+	case attr == ua.AttributeIDDescription:
+		text, ok := val.Value.Value().(*ua.LocalizedText)
+		if !ok {
+			return ua.StatusBadTypeMismatch
+		}
+		ns.mu.Lock()
+		n.description = text
+		ns.mu.Unlock()
+		return ua.StatusOK
+	case uint32(val.Value.Type()) != n.dataType.IntID():
+		return ua.StatusBadTypeMismatch
+	}
+
+	dv := &ua.DataValue{
+		EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+		Value:           val.Value,
+		Status:          val.Status,
+		SourceTimestamp: val.SourceTimestamp,
+	}
+	if val.Status != ua.StatusOK {
+		dv.EncodingMask |= ua.DataValueStatusCode
+	}
+	if dv.SourceTimestamp.IsZero() {
+		dv.SourceTimestamp = time.Now()
+	}
+	ns.store(n, dv)
+	return ua.StatusOK
+}
+
+// set сохраняет новое значение переменной с текущим временем
+func (ns *namespace) set(n *node, v *ua.Variant) {
+	ns.store(n, &ua.DataValue{
+		EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+		Value:           v,
+		SourceTimestamp: time.Now(),
+	})
+}
+
+// store сохраняет значение переменной в текущем значении и архиве
+// и оповещает подписки
+func (ns *namespace) store(n *node, dv *ua.DataValue) {
+	ns.mu.Lock()
+	n.value = dv
+	n.archive(n.value)
+	ns.mu.Unlock()
+	if ns.srv.MonitoredItemService != nil {
+		ns.srv.ChangeNotification(n.id)
+	}
+}
+
+func statusValue(code ua.StatusCode) *ua.DataValue {
+	return &ua.DataValue{
+		EncodingMask:    ua.DataValueServerTimestamp | ua.DataValueStatusCode,
+		ServerTimestamp: time.Now(),
+		Status:          code,
+	}
+}
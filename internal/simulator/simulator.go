@@ -0,0 +1,540 @@
+// Package simulator запускает встроенный OPC UA сервер с имитацией данных:
+// переменные (синус, пила, случайное значение, счётчик, уставки), методы
+// и события. Сервер используется командой serve и интеграционными тестами.
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/formatter"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/schema"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/ua"
+)
+
+const (
+	// DefaultNamespace - URI пространства имён имитатора
+	DefaultNamespace = "urn:opcli:simulator"
+	// DefaultInterval - период обновления значений
+	DefaultInterval = time.Second
+
+	rootName      = "Simulation"
+	defaultPeriod = 10 * time.Second
+)
+
+// Виды имитируемых переменных
+const (
+	KindSine     = "sine"
+	KindRamp     = "ramp"
+	KindRandom   = "random"
+	KindCounter  = "counter"
+	KindSetpoint = "setpoint"
+)
+
+// Duration - интервал времени, который в JSON задаётся строкой ("500ms",
+// "10s") или числом секунд
+type Duration time.Duration
+
+// UnmarshalJSON разбирает интервал из строки или числа секунд
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = Duration(v)
+		return nil
+	}
+	var sec float64
+	if err := json.Unmarshal(data, &sec); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = Duration(sec * float64(time.Second))
+	return nil
+}
+
+// MarshalJSON записывает интервал строкой
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Variable описывает имитируемую переменную
+type Variable struct {
+	Name string `json:"name"`
+	// Kind - вид: sine, ramp, random, counter или setpoint
+	Kind string `json:"kind"`
+	// Type - встроенный тип уставки (Double, Int32, Boolean, String, ...);
+	// по умолчанию Double
+	Type string `json:"type,omitempty"`
+	// Min и Max - диапазон значений sine, ramp и random; для counter Max
+	// задаёт значение, после которого счёт начинается с Min
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+	// Period - период sine и ramp; по умолчанию 10s
+	Period Duration `json:"period,omitempty"`
+	// Value - начальное значение уставки
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Config задаёт параметры сервера имитации
+type Config struct {
+	// Host - имя узла в адресе сервера; по умолчанию localhost
+	Host string `json:"host,omitempty"`
+	// Port - TCP-порт; 0 - свободный порт, выбранный системой
+	Port int `json:"port,omitempty"`
+	// Namespace - URI пространства имён имитатора
+	Namespace string `json:"namespace,omitempty"`
+	// Interval - период обновления значений
+	Interval Duration `json:"interval,omitempty"`
+	// EventInterval - период генерации событий; 0 - только по вызову RaiseEvent
+	EventInterval Duration `json:"eventInterval,omitempty"`
+	// Variables - имитируемые переменные; пустой список - DefaultVariables
+	Variables []Variable `json:"variables,omitempty"`
+	// NodeSet - файл UANodeSet, узлы которого загружаются на сервер
+	NodeSet string `json:"nodeset,omitempty"`
+}
+
+// DefaultVariables возвращает набор переменных по умолчанию
+func DefaultVariables() []Variable {
+	return []Variable{
+		{Name: "Sine", Kind: KindSine, Min: -1, Max: 1},
+		{Name: "Ramp", Kind: KindRamp, Min: 0, Max: 100},
+		{Name: "Random", Kind: KindRandom, Min: 0, Max: 100},
+		{Name: "Counter", Kind: KindCounter},
+		{Name: "Setpoint", Kind: KindSetpoint, Type: "Double", Value: 20.0},
+		{Name: "Enabled", Kind: KindSetpoint, Type: "Boolean", Value: true},
+		{Name: "Mode", Kind: KindSetpoint, Type: "Int32", Value: 1},
+		{Name: "Label", Kind: KindSetpoint, Type: "String", Value: "opcli"},
+	}
+}
+
+// LoadConfig читает конфигурацию имитатора из JSON-файла
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Server - запущенный сервер имитации
+type Server struct {
+	srv      *server.Server
+	ns       *namespace
+	endpoint string
+
+	mu      sync.Mutex
+	vars    []*simVar
+	methods map[string]*method
+	events  eventNodes
+	start   time.Time
+	rnd     *rand.Rand
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// simVar - имитируемая переменная и её состояние
+type simVar struct {
+	Variable
+	node    *node
+	counter uint32
+}
+
+// Start создаёт и запускает сервер имитации
+func Start(cfg Config) (*Server, error) {
+	if cfg.Host == "" {
+		cfg.Host = "localhost"
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultNamespace
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = Duration(DefaultInterval)
+	}
+	if len(cfg.Variables) == 0 {
+		cfg.Variables = DefaultVariables()
+	}
+	if cfg.Port == 0 {
+		port, err := freePort(cfg.Host)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Port = port
+	}
+
+	srv := server.New(
+		server.EndPoint(cfg.Host, cfg.Port),
+		server.EnableSecurity("None", ua.MessageSecurityModeNone),
+		server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+		server.ServerName("opcli simulator"),
+		server.ProductName("opcli simulator"),
+		server.ManufacturerName("opcli"),
+	)
+
+	// Пространства имён файла UANodeSet должны получить те же индексы,
+	// что и в файле, поэтому он загружается до пространства имён имитатора
+	if cfg.NodeSet != "" {
+		if err := importNodeSet(srv, cfg.NodeSet); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Server{
+		srv:      srv,
+		ns:       newNamespace(srv, cfg.Namespace, time.Duration(cfg.Interval)),
+		endpoint: fmt.Sprintf("opc.tcp://%s:%d", cfg.Host, cfg.Port),
+		methods:  make(map[string]*method),
+		start:    time.Now(),
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := s.build(cfg.Variables); err != nil {
+		return nil, err
+	}
+	srv.RegisterHandler(id.CallRequest_Encoding_DefaultBinary, s.call)
+	srv.RegisterHandler(id.TranslateBrowsePathsToNodeIDsRequest_Encoding_DefaultBinary, s.translateBrowsePaths)
+	srv.RegisterHandler(id.HistoryReadRequest_Encoding_DefaultBinary, s.historyRead)
+	srv.RegisterHandler(id.HistoryUpdateRequest_Encoding_DefaultBinary, s.historyUpdate)
+
+	if err := srv.Start(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to start server: %w", err)
+	}
+	go s.run(time.Duration(cfg.Interval), time.Duration(cfg.EventInterval))
+	return s, nil
+}
+
+// Endpoint возвращает адрес сервера
+func (s *Server) Endpoint() string {
+	return s.endpoint
+}
+
+// Namespace возвращает индекс пространства имён имитатора
+func (s *Server) Namespace() uint16 {
+	return s.ns.ID()
+}
+
+// Close останавливает сервер
+func (s *Server) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.srv.Close()
+}
+
+// build создаёт узлы имитатора: папку Simulation с переменными, объект
+// Events с последним событием и объект Methods с методами
+func (s *Server) build(vars []Variable) error {
+	ns := s.ns
+	root := ns.add(&node{
+		id:      ua.NewStringNodeID(ns.ID(), rootName),
+		class:   ua.NodeClassObject,
+		name:    rootName,
+		typeDef: ua.NewNumericNodeID(0, id.FolderType),
+	}, nil, 0)
+
+	seen := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		if v.Name == "" {
+			return fmt.Errorf("variable name is required")
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variable %s", v.Name)
+		}
+		seen[v.Name] = true
+
+		sv, err := newSimVar(v)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", v.Name, err)
+		}
+		value, err := sv.initial()
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", v.Name, err)
+		}
+		sv.node = ns.add(s.variable(rootName+"."+v.Name, v.Name, value, v.Kind == KindSetpoint), root, id.Organizes)
+		s.vars = append(s.vars, sv)
+	}
+
+	s.buildEvents(root)
+	s.buildMethods(root)
+
+	// Папка имитатора доступна из Objects
+	ns0, err := s.srv.Namespace(0)
+	if err != nil {
+		return err
+	}
+	ns0.Objects().AddRef(root.proxy, id.Organizes, true)
+	root.refs = append(root.refs, &ua.ReferenceDescription{
+		ReferenceTypeID: ua.NewNumericNodeID(0, id.Organizes),
+		IsForward:       false,
+		NodeID:          ua.NewNumericExpandedNodeID(0, id.ObjectsFolder),
+		BrowseName:      &ua.QualifiedName{Name: "Objects"},
+		DisplayName:     &ua.LocalizedText{Text: "Objects"},
+		NodeClass:       ua.NodeClassObject,
+		TypeDefinition:  ua.NewNumericExpandedNodeID(0, id.FolderType),
+	})
+
+	// Функции агрегирования, которые имитатор вычисляет по архиву
+	if folder := s.srv.Node(ua.NewNumericNodeID(0, id.Server_ServerCapabilities_AggregateFunctions)); folder != nil {
+		ids := make([]uint32, 0, len(aggregates))
+		for a := range aggregates {
+			ids = append(ids, a)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, a := range ids {
+			if n := s.srv.Node(ua.NewNumericNodeID(0, a)); n != nil {
+				folder.AddRef(n, id.Organizes, true)
+			}
+		}
+	}
+	return nil
+}
+
+// variable создаёт узел переменной со строковым NodeId ns=<ns>;s=<path>
+func (s *Server) variable(path, name string, value *ua.Variant, writable bool) *node {
+	n := &node{
+		id:        ua.NewStringNodeID(s.ns.ID(), path),
+		class:     ua.NodeClassVariable,
+		name:      name,
+		typeDef:   ua.NewNumericNodeID(0, id.BaseDataVariableType),
+		dataType:  ua.NewNumericNodeID(0, uint32(value.Type())),
+		valueRank: -1,
+		writable:  writable,
+		value: &ua.DataValue{
+			EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+			Value:           value,
+			SourceTimestamp: time.Now(),
+		},
+	}
+	n.archive(n.value)
+	return n
+}
+
+func newSimVar(v Variable) (*simVar, error) {
+	sv := &simVar{Variable: v}
+	if sv.Period <= 0 {
+		sv.Period = Duration(defaultPeriod)
+	}
+	switch v.Kind {
+	case KindSine, KindRamp, KindRandom:
+		if sv.Min == 0 && sv.Max == 0 {
+			sv.Max = 1
+			if v.Kind == KindSine {
+				sv.Min = -1
+			}
+		}
+		if sv.Max < sv.Min {
+			return nil, fmt.Errorf("max %g is less than min %g", sv.Max, sv.Min)
+		}
+	case KindCounter:
+		if sv.Min < 0 || sv.Max < 0 {
+			return nil, fmt.Errorf("counter range must not be negative")
+		}
+		sv.counter = uint32(sv.Min)
+	case KindSetpoint:
+		if sv.Type == "" {
+			sv.Type = "Double"
+		}
+	default:
+		return nil, fmt.Errorf("unknown kind %q (want sine, ramp, random, counter or setpoint)", v.Kind)
+	}
+	return sv, nil
+}
+
+// initial возвращает начальное значение переменной
+func (v *simVar) initial() (*ua.Variant, error) {
+	if v.Kind != KindSetpoint {
+		return v.next(0, 0), nil
+	}
+
+	t, ok := formatter.ParseTypeName(v.Type)
+	if !ok || !setpointType(t) {
+		return nil, fmt.Errorf("unsupported setpoint type %s", v.Type)
+	}
+	text := "0"
+	switch {
+	case v.Value != nil:
+		text = fmt.Sprint(v.Value)
+	case t == ua.TypeIDBoolean:
+		text = "false"
+	case t == ua.TypeIDString:
+		text = ""
+	}
+	val, err := formatter.Parse(t, text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q: %w", formatter.TypeName(t), text, err)
+	}
+	return ua.NewVariant(val)
+}
+
+func setpointType(t ua.TypeID) bool {
+	switch t {
+	case ua.TypeIDBoolean, ua.TypeIDSByte, ua.TypeIDByte, ua.TypeIDInt16, ua.TypeIDUint16,
+		ua.TypeIDInt32, ua.TypeIDUint32, ua.TypeIDInt64, ua.TypeIDUint64,
+		ua.TypeIDFloat, ua.TypeIDDouble, ua.TypeIDString:
+		return true
+	}
+	return false
+}
+
+// next вычисляет значение переменной через elapsed после запуска;
+// r - случайное число из [0, 1) для random
+func (v *simVar) next(elapsed time.Duration, r float64) *ua.Variant {
+	phase := elapsed.Seconds() / time.Duration(v.Period).Seconds()
+	switch v.Kind {
+	case KindSine:
+		mid, amp := (v.Min+v.Max)/2, (v.Max-v.Min)/2
+		return ua.MustVariant(mid + amp*math.Sin(2*math.Pi*phase))
+	case KindRamp:
+		return ua.MustVariant(v.Min + (v.Max-v.Min)*(phase-math.Floor(phase)))
+	case KindRandom:
+		return ua.MustVariant(v.Min + (v.Max-v.Min)*r)
+	}
+	return ua.MustVariant(v.counter)
+}
+
+// run обновляет значения переменных и генерирует события до вызова Close
+func (s *Server) run(interval, eventInterval time.Duration) {
+	defer close(s.done)
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	var events <-chan time.Time
+	if eventInterval > 0 {
+		t := time.NewTicker(eventInterval)
+		defer t.Stop()
+		events = t.C
+	}
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-tick.C:
+			s.update(now)
+		case <-events:
+			s.mu.Lock()
+			severity := uint16(100 + s.rnd.Intn(900))
+			s.mu.Unlock()
+			s.raise("Simulated event", severity)
+		}
+	}
+}
+
+func (s *Server) update(now time.Time) {
+	type change struct {
+		n *node
+		v *ua.Variant
+	}
+	var changes []change
+
+	s.mu.Lock()
+	for _, v := range s.vars {
+		switch v.Kind {
+		case KindSetpoint:
+			continue
+		case KindCounter:
+			if v.Max > 0 && float64(v.counter) >= v.Max {
+				v.counter = uint32(v.Min)
+			} else {
+				v.counter++
+			}
+		}
+		changes = append(changes, change{v.node, v.next(now.Sub(s.start), s.rnd.Float64())})
+	}
+	s.mu.Unlock()
+
+	for _, c := range changes {
+		s.ns.set(c.n, c.v)
+	}
+}
+
+// reset возвращает счётчики к начальным значениям и начинает периоды заново
+func (s *Server) reset() {
+	s.mu.Lock()
+	s.start = time.Now()
+	var counters []*simVar
+	for _, v := range s.vars {
+		if v.Kind == KindCounter {
+			v.counter = uint32(v.Min)
+			counters = append(counters, v)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, v := range counters {
+		s.ns.set(v.node, ua.MustVariant(uint32(v.Min)))
+	}
+}
+
+// importNodeSet загружает узлы из файла UANodeSet. Импорт gopcua
+// завершается паникой на некорректных NodeId, она возвращается как ошибка.
+func importNodeSet(srv *server.Server, path string) (err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var set schema.UANodeSet
+	if err := xml.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to import %s: %v", path, r)
+		}
+	}()
+	if err := srv.ImportNodeSet(&set); err != nil {
+		return fmt.Errorf("failed to import %s: %w", path, err)
+	}
+	return nil
+}
+
+// freePort возвращает свободный TCP-порт на host
+func freePort(host string) (int, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// Summary перечисляет переменные и методы имитатора для вывода при запуске
+func (s *Server) Summary() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines := make([]string, 0, len(s.vars))
+	for _, v := range s.vars {
+		kind := v.Kind
+		if v.Kind == KindSetpoint {
+			kind += " " + v.Type
+		}
+		lines = append(lines, fmt.Sprintf("%-36s %s", v.node.id, kind))
+	}
+	keys := make([]string, 0, len(s.methods))
+	for k := range s.methods {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%-36s method %s", k, methodSignature(s.methods[k])))
+	}
+	return lines
+}
@@ -0,0 +1,481 @@
+package simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+// startTest запускает сервер имитации и подключает к нему клиента
+func startTest(t *testing.T, cfg Config) (*Server, *opcua.Client) {
+	t.Helper()
+	s, err := Start(cfg)
+	if err != nil {
+		t.Fatalf("Start() получена непредвиденная ошибка = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	c, err := opcua.NewClient(s.Endpoint())
+	if err != nil {
+		t.Fatalf("NewClient() получена непредвиденная ошибка = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect() получена непредвиденная ошибка = %v", err)
+	}
+	t.Cleanup(func() { c.Close(context.Background()) })
+	return s, c
+}
+
+func (s *Server) testNode(path string) *ua.NodeID {
+	return ua.NewStringNodeID(s.Namespace(), path)
+}
+
+// TestServer проверяет сервер имитации через клиент gopcua.
+//
+// Основные аспекты тестирования:
+// - Пространство имён имитатора есть в NamespaceArray.
+// - Папка Simulation доступна из Objects.
+// - Атрибуты и значения переменных читаются, уставки записываются.
+// - Запись в имитируемую переменную и значение неверного типа отклоняются.
+// - Уставка записывается со статусом и меткой времени, у неё записывается Description.
+// - Методы вызываются и проверяют аргументы.
+func TestServer(t *testing.T) {
+	s, c := startTest(t, Config{Interval: Duration(50 * time.Millisecond)})
+	ctx := context.Background()
+
+	ns, err := c.NamespaceArray(ctx)
+	if err != nil {
+		t.Fatalf("NamespaceArray() получена непредвиденная ошибка = %v", err)
+	}
+	if int(s.Namespace()) >= len(ns) || ns[s.Namespace()] != DefaultNamespace {
+		t.Errorf("NamespaceArray() = %v, ожидался %s под индексом %d", ns, DefaultNamespace, s.Namespace())
+	}
+
+	res, err := c.Browse(ctx, &ua.BrowseRequest{NodesToBrowse: []*ua.BrowseDescription{{
+		NodeID:          ua.NewNumericNodeID(0, id.ObjectsFolder),
+		BrowseDirection: ua.BrowseDirectionForward,
+		ReferenceTypeID: ua.NewNumericNodeID(0, id.HierarchicalReferences),
+		IncludeSubtypes: true,
+		ResultMask:      uint32(ua.BrowseResultMaskAll),
+	}}})
+	if err != nil {
+		t.Fatalf("Browse() получена непредвиденная ошибка = %v", err)
+	}
+	found := false
+	for _, r := range res.Results[0].References {
+		if r.NodeID.NodeID.Equal(s.testNode(rootName)) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("папка %s не найдена в Objects", rootName)
+	}
+
+	read, err := c.Read(ctx, &ua.ReadRequest{NodesToRead: []*ua.ReadValueID{
+		{NodeID: s.testNode("Simulation.Setpoint"), AttributeID: ua.AttributeIDValue},
+		{NodeID: s.testNode("Simulation.Setpoint"), AttributeID: ua.AttributeIDDataType},
+		{NodeID: s.testNode("Simulation.Sine"), AttributeID: ua.AttributeIDAccessLevel},
+	}})
+	if err != nil {
+		t.Fatalf("Read() получена непредвиденная ошибка = %v", err)
+	}
+	if v := read.Results[0].Value.Value(); v != 20.0 {
+		t.Errorf("Setpoint = %v, ожидалось 20", v)
+	}
+	if dt := read.Results[1].Value.NodeID(); dt == nil || dt.IntID() != id.Double {
+		t.Errorf("DataType = %v, ожидался Double", read.Results[1].Value.Value())
+	}
+	if al := read.Results[2].Value.Value(); al != uint8(ua.AccessLevelTypeCurrentRead|ua.AccessLevelTypeHistoryRead|ua.AccessLevelTypeHistoryWrite) {
+		t.Errorf("AccessLevel Sine = %v", al)
+	}
+
+	write := func(node string, v interface{}) ua.StatusCode {
+		res, err := c.Write(ctx, &ua.WriteRequest{NodesToWrite: []*ua.WriteValue{{
+			NodeID:      s.testNode(node),
+			AttributeID: ua.AttributeIDValue,
+			Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(v)},
+		}}})
+		if err != nil {
+			t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+		}
+		return res.Results[0]
+	}
+	if st := write("Simulation.Setpoint", 42.5); st != ua.StatusOK {
+		t.Errorf("запись уставки: %v", st)
+	}
+	if st := write("Simulation.Setpoint", "text"); st != ua.StatusBadTypeMismatch {
+		t.Errorf("запись неверного типа: %v, ожидался BadTypeMismatch", st)
+	}
+	if st := write("Simulation.Sine", 1.0); st != ua.StatusBadUserAccessDenied {
+		t.Errorf("запись в Sine: %v, ожидался BadUserAccessDenied", st)
+	}
+
+	// Уставка записывается со статусом и меткой времени, описание - отдельно
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	wres, err := c.Write(ctx, &ua.WriteRequest{NodesToWrite: []*ua.WriteValue{
+		{
+			NodeID:      s.testNode("Simulation.Setpoint"),
+			AttributeID: ua.AttributeIDValue,
+			Value: &ua.DataValue{
+				EncodingMask:    ua.DataValueValue | ua.DataValueStatusCode | ua.DataValueSourceTimestamp,
+				Value:           ua.MustVariant(7.5),
+				Status:          ua.StatusUncertain,
+				SourceTimestamp: ts,
+			},
+		},
+		{
+			NodeID:      s.testNode("Simulation.Setpoint"),
+			AttributeID: ua.AttributeIDDescription,
+			Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(ua.NewLocalizedText("Уставка"))},
+		},
+		{
+			NodeID:      s.testNode("Simulation.Setpoint"),
+			AttributeID: ua.AttributeIDDisplayName,
+			Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(ua.NewLocalizedText("Setpoint"))},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Write() получена непредвиденная ошибка = %v", err)
+	}
+	if wres.Results[0] != ua.StatusOK || wres.Results[1] != ua.StatusOK || wres.Results[2] != ua.StatusBadNotWritable {
+		t.Errorf("запись статуса и атрибутов: %v, ожидалось Good, Good, BadNotWritable", wres.Results)
+	}
+	read, err = c.Read(ctx, &ua.ReadRequest{NodesToRead: []*ua.ReadValueID{
+		{NodeID: s.testNode("Simulation.Setpoint"), AttributeID: ua.AttributeIDValue},
+		{NodeID: s.testNode("Simulation.Setpoint"), AttributeID: ua.AttributeIDDescription},
+	}})
+	if err != nil {
+		t.Fatalf("Read() получена непредвиденная ошибка = %v", err)
+	}
+	if dv := read.Results[0]; dv.Status != ua.StatusUncertain || !dv.SourceTimestamp.Equal(ts) {
+		t.Errorf("Setpoint = %v [%v] %v, ожидался статус Uncertain и время %v", dv.Value.Value(), dv.Status, dv.SourceTimestamp, ts)
+	}
+	if text, ok := read.Results[1].Value.Value().(*ua.LocalizedText); !ok || text.Text != "Уставка" {
+		t.Errorf("Description = %v, ожидалось Уставка", read.Results[1].Value.Value())
+	}
+
+	call, err := c.Call(ctx, &ua.CallMethodRequest{
+		ObjectID:       s.testNode("Simulation.Methods"),
+		MethodID:       s.testNode("Simulation.Methods.Add"),
+		InputArguments: []*ua.Variant{ua.MustVariant(1.5), ua.MustVariant(2.0)},
+	})
+	if err != nil {
+		t.Fatalf("Call() получена непредвиденная ошибка = %v", err)
+	}
+	if call.StatusCode != ua.StatusOK || len(call.OutputArguments) != 1 || call.OutputArguments[0].Value() != 3.5 {
+		t.Errorf("Add(1.5, 2) = %v %v", call.StatusCode, call.OutputArguments)
+	}
+
+	call, err = c.Call(ctx, &ua.CallMethodRequest{
+		ObjectID:       s.testNode("Simulation.Methods"),
+		MethodID:       s.testNode("Simulation.Methods.Add"),
+		InputArguments: []*ua.Variant{ua.MustVariant(1.5)},
+	})
+	if err != nil || call.StatusCode != ua.StatusBadArgumentsMissing {
+		t.Errorf("Add(1.5) = %v, %v, ожидался BadArgumentsMissing", call.StatusCode, err)
+	}
+}
+
+// TestSimulation проверяет изменение имитируемых значений и события.
+func TestSimulation(t *testing.T) {
+	s, c := startTest(t, Config{Interval: Duration(20 * time.Millisecond)})
+	ctx := context.Background()
+
+	value := func(node string) interface{} {
+		res, err := c.Read(ctx, &ua.ReadRequest{NodesToRead: []*ua.ReadValueID{
+			{NodeID: s.testNode(node), AttributeID: ua.AttributeIDValue},
+		}})
+		if err != nil {
+			t.Fatalf("Read() получена непредвиденная ошибка = %v", err)
+		}
+		return res.Results[0].Value.Value()
+	}
+
+	before := value("Simulation.Counter").(uint32)
+	time.Sleep(100 * time.Millisecond)
+	if after := value("Simulation.Counter").(uint32); after <= before {
+		t.Errorf("Counter не увеличивается: %d -> %d", before, after)
+	}
+	if v := value("Simulation.Random").(float64); v < 0 || v > 100 {
+		t.Errorf("Random = %v вне диапазона [0, 100]", v)
+	}
+
+	call, err := c.Call(ctx, &ua.CallMethodRequest{
+		ObjectID:       s.testNode("Simulation.Methods"),
+		MethodID:       s.testNode("Simulation.Methods.RaiseEvent"),
+		InputArguments: []*ua.Variant{ua.MustVariant("Overheat"), ua.MustVariant(uint16(700))},
+	})
+	if err != nil || call.StatusCode != ua.StatusOK {
+		t.Fatalf("RaiseEvent() = %v, %v", call, err)
+	}
+	if m := value("Simulation.Events.Message"); m != "Overheat" {
+		t.Errorf("Events.Message = %v", m)
+	}
+	if n := value("Simulation.Events.Count"); n != uint32(1) {
+		t.Errorf("Events.Count = %v", n)
+	}
+}
+
+// TestConfig проверяет разбор и проверку конфигурации.
+func TestConfig(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		vars []Variable
+	}{
+		{"неизвестный вид", []Variable{{Name: "X", Kind: "square"}}},
+		{"повтор имени", []Variable{{Name: "X", Kind: KindSine}, {Name: "X", Kind: KindRamp}}},
+		{"неверный тип уставки", []Variable{{Name: "X", Kind: KindSetpoint, Type: "Guid"}}},
+		{"неверное значение уставки", []Variable{{Name: "X", Kind: KindSetpoint, Type: "Int32", Value: "abc"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if s, err := Start(Config{Variables: tt.vars}); err == nil {
+				s.Close()
+				t.Error("Start() ожидалась ошибка")
+			}
+		})
+	}
+
+	var d Duration
+	if err := d.UnmarshalJSON([]byte(`"250ms"`)); err != nil || time.Duration(d) != 250*time.Millisecond {
+		t.Errorf("Duration(\"250ms\") = %v, %v", time.Duration(d), err)
+	}
+	if err := d.UnmarshalJSON([]byte(`2`)); err != nil || time.Duration(d) != 2*time.Second {
+		t.Errorf("Duration(2) = %v, %v", time.Duration(d), err)
+	}
+}
+
+// TestReadProcessed проверяет вычисление агрегатов по архиву.
+//
+// Основные аспекты тестирования:
+// - Интервал делится на отрезки, метка значения - начало отрезка.
+// - Отрезок без значений возвращает BadNoData, Count - ноль.
+// - Неизвестная функция возвращает BadAggregateNotSupported.
+func TestReadProcessed(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var values []*ua.DataValue
+	for i, x := range []float64{1, 3, 8} {
+		values = append(values, &ua.DataValue{Value: ua.MustVariant(x), SourceTimestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	details := func(agg uint32) *ua.ReadProcessedDetails {
+		return &ua.ReadProcessedDetails{
+			StartTime:          start,
+			EndTime:            start.Add(6 * time.Minute),
+			ProcessingInterval: float64(2 * time.Minute / time.Millisecond),
+			AggregateType:      []*ua.NodeID{ua.NewNumericNodeID(0, agg)},
+		}
+	}
+
+	tests := []struct {
+		agg    uint32
+		values []interface{}
+	}{
+		{id.AggregateFunction_Average, []interface{}{2.0, 8.0, ua.StatusBadNoData}},
+		{id.AggregateFunction_Maximum, []interface{}{3.0, 8.0, ua.StatusBadNoData}},
+		{id.AggregateFunction_Count, []interface{}{int32(2), int32(1), int32(0)}},
+		{id.AggregateFunction_End, []interface{}{3.0, 8.0, ua.StatusBadNoData}},
+	}
+	for _, tt := range tests {
+		res := readProcessed(values, details(tt.agg), ua.NewNumericNodeID(0, tt.agg))
+		data, ok := res.HistoryData.Value.(*ua.HistoryData)
+		if res.StatusCode != ua.StatusOK || !ok || len(data.DataValues) != len(tt.values) {
+			t.Fatalf("readProcessed(%d) = %v, ожидалось %d значений", tt.agg, res.StatusCode, len(tt.values))
+		}
+		for i, want := range tt.values {
+			dv := data.DataValues[i]
+			if !dv.SourceTimestamp.Equal(start.Add(time.Duration(i) * 2 * time.Minute)) {
+				t.Errorf("readProcessed(%d)[%d] метка времени = %v", tt.agg, i, dv.SourceTimestamp)
+			}
+			if code, ok := want.(ua.StatusCode); ok {
+				if dv.Status != code {
+					t.Errorf("readProcessed(%d)[%d] статус = %v, ожидался %v", tt.agg, i, dv.Status, code)
+				}
+			} else if dv.Value == nil || dv.Value.Value() != want {
+				t.Errorf("readProcessed(%d)[%d] = %v, ожидалось %v", tt.agg, i, dv.Value, want)
+			}
+		}
+	}
+
+	if res := readProcessed(values, details(id.AggregateFunction_Delta), ua.NewNumericNodeID(0, id.AggregateFunction_Delta)); res.StatusCode != ua.StatusBadAggregateNotSupported {
+		t.Errorf("readProcessed(Delta) = %v, ожидался BadAggregateNotSupported", res.StatusCode)
+	}
+}
+
+// TestHistoryEvents проверяет чтение архива событий.
+//
+// Основные аспекты тестирования:
+// - События, поднятые методом RaiseEvent, доступны через объекты Server и Events.
+// - Поля выбираются по SelectClauses, события отбираются по WhereClause.
+// - NumValuesPerNode разбивает чтение точками продолжения.
+// - Переменная не хранит события.
+func TestHistoryEvents(t *testing.T) {
+	s, c := startTest(t, Config{})
+	ctx := context.Background()
+	start := time.Now()
+	for _, e := range []struct {
+		message  string
+		severity uint16
+	}{{"Overheat", 700}, {"Pump 1 started", 200}, {"Pump 2 started", 300}} {
+		call, err := c.Call(ctx, &ua.CallMethodRequest{
+			ObjectID:       s.testNode("Simulation.Methods"),
+			MethodID:       s.testNode("Simulation.Methods.RaiseEvent"),
+			InputArguments: []*ua.Variant{ua.MustVariant(e.message), ua.MustVariant(e.severity)},
+		})
+		if err != nil || call.StatusCode != ua.StatusOK {
+			t.Fatalf("RaiseEvent() = %v, %v", call, err)
+		}
+	}
+
+	read := func(node *ua.NodeID, where string, max uint32, cp []byte) *ua.HistoryReadResult {
+		t.Helper()
+		filter, err := client.ParseEventFilter([]string{"Message", "Severity", "SourceName"}, where)
+		if err != nil {
+			t.Fatalf("ParseEventFilter(%q) получена непредвиденная ошибка = %v", where, err)
+		}
+		resp, err := c.HistoryReadEvent(ctx, []*ua.HistoryReadValueID{{NodeID: node, DataEncoding: &ua.QualifiedName{}, ContinuationPoint: cp}},
+			&ua.ReadEventDetails{NumValuesPerNode: max, StartTime: start, EndTime: time.Now().Add(time.Minute), Filter: filter})
+		if err != nil || len(resp.Results) != 1 {
+			t.Fatalf("HistoryReadEvent() = %v, %v", resp, err)
+		}
+		return resp.Results[0]
+	}
+	messages := func(res *ua.HistoryReadResult) []string {
+		var out []string
+		for _, e := range res.HistoryData.Value.(*ua.HistoryEvent).Events {
+			out = append(out, e.EventFields[0].Value().(*ua.LocalizedText).Text)
+		}
+		return out
+	}
+
+	res := read(ua.NewNumericNodeID(0, id.Server), "Severity >= 300 or Message like '%1%'", 0, nil)
+	if got := messages(res); res.StatusCode != ua.StatusOK || len(got) != 3 {
+		t.Errorf("события Server = %v (%v), ожидалось 3", got, res.StatusCode)
+	}
+	res = read(s.testNode("Simulation.Events"), "Message like 'Pump%' and Severity != 200", 0, nil)
+	if got := messages(res); len(got) != 1 || got[0] != "Pump 2 started" {
+		t.Errorf("события Events = %v, ожидалось [Pump 2 started]", got)
+	}
+	if f := res.HistoryData.Value.(*ua.HistoryEvent).Events[0].EventFields; f[1].Value() != uint16(300) || f[2].Value() != "Simulation" {
+		t.Errorf("поля события = %v, %v", f[1].Value(), f[2].Value())
+	}
+
+	res = read(ua.NewNumericNodeID(0, id.Server), "", 2, nil)
+	if got := messages(res); len(got) != 2 || len(res.ContinuationPoint) == 0 {
+		t.Fatalf("первая страница = %v, точка продолжения %v", got, res.ContinuationPoint)
+	}
+	res = read(ua.NewNumericNodeID(0, id.Server), "", 2, res.ContinuationPoint)
+	if got := messages(res); len(got) != 1 || got[0] != "Pump 2 started" || len(res.ContinuationPoint) != 0 {
+		t.Errorf("вторая страница = %v, точка продолжения %v", got, res.ContinuationPoint)
+	}
+
+	if res := read(s.testNode("Simulation.Setpoint"), "", 0, nil); res.StatusCode != ua.StatusBadHistoryOperationUnsupported {
+		t.Errorf("события переменной: статус %v, ожидался BadHistoryOperationUnsupported", res.StatusCode)
+	}
+}
+
+// TestHistoryUpdate проверяет изменение архива через HistoryUpdate.
+//
+// Основные аспекты тестирования:
+// - Вставка прошлых значений сохраняет порядок архива по меткам времени.
+// - Вставка на занятую метку и замена отсутствующего значения отклоняются.
+// - Удаление по меткам времени и за интервал.
+// - Изменения читаются через ReadRawModifiedDetails с IsReadModified.
+func TestHistoryUpdate(t *testing.T) {
+	s, c := startTest(t, Config{})
+	ctx := context.Background()
+	node := s.testNode("Simulation.Setpoint")
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	value := func(minutes int, x interface{}) *ua.DataValue {
+		return &ua.DataValue{EncodingMask: ua.DataValueValue | ua.DataValueSourceTimestamp, Value: ua.MustVariant(x), SourceTimestamp: at(minutes)}
+	}
+
+	update := func(details interface{}) *ua.HistoryUpdateResult {
+		t.Helper()
+		var resp *ua.HistoryUpdateResponse
+		err := c.Send(ctx, &ua.HistoryUpdateRequest{HistoryUpdateDetails: []*ua.ExtensionObject{ua.NewExtensionObject(details)}}, func(v ua.Response) error {
+			resp = v.(*ua.HistoryUpdateResponse)
+			return nil
+		})
+		if err != nil || len(resp.Results) != 1 {
+			t.Fatalf("HistoryUpdate() = %v, %v", resp, err)
+		}
+		return resp.Results[0]
+	}
+	read := func(modified bool) []*ua.DataValue {
+		t.Helper()
+		resp, err := c.HistoryReadRawModified(ctx, []*ua.HistoryReadValueID{{NodeID: node, DataEncoding: &ua.QualifiedName{}}},
+			&ua.ReadRawModifiedDetails{IsReadModified: modified, StartTime: start, EndTime: at(60)})
+		if err != nil || len(resp.Results) != 1 || resp.Results[0].StatusCode != ua.StatusOK {
+			t.Fatalf("HistoryReadRawModified() = %v, %v", resp, err)
+		}
+		switch data := resp.Results[0].HistoryData.Value.(type) {
+		case *ua.HistoryData:
+			return data.DataValues
+		case *ua.HistoryModifiedData:
+			if len(data.ModificationInfos) != len(data.DataValues) {
+				t.Fatalf("изменений %d, значений %d", len(data.ModificationInfos), len(data.DataValues))
+			}
+			return data.DataValues
+		}
+		t.Fatalf("HistoryReadRawModified() вернул %T", resp.Results[0].HistoryData.Value)
+		return nil
+	}
+
+	res := update(&ua.UpdateDataDetails{
+		NodeID:               node,
+		PerformInsertReplace: ua.PerformUpdateTypeInsert,
+		UpdateValues:         []*ua.DataValue{value(20, 2.0), value(10, 1.0), value(30, 3.0), value(10, 5.0), value(40, "text")},
+	})
+	want := []ua.StatusCode{ua.StatusGoodEntryInserted, ua.StatusGoodEntryInserted, ua.StatusGoodEntryInserted, ua.StatusBadEntryExists, ua.StatusBadTypeMismatch}
+	if res.StatusCode != ua.StatusOK || len(res.OperationResults) != len(want) {
+		t.Fatalf("вставка: %v %v", res.StatusCode, res.OperationResults)
+	}
+	for i, code := range want {
+		if res.OperationResults[i] != code {
+			t.Errorf("вставка значения %d: статус %v, ожидался %v", i, res.OperationResults[i], code)
+		}
+	}
+
+	res = update(&ua.UpdateDataDetails{
+		NodeID:               node,
+		PerformInsertReplace: ua.PerformUpdateTypeReplace,
+		UpdateValues:         []*ua.DataValue{value(20, 4.0), value(25, 1.0)},
+	})
+	if len(res.OperationResults) != 2 || res.OperationResults[0] != ua.StatusGoodEntryReplaced || res.OperationResults[1] != ua.StatusBadNoEntryExists {
+		t.Errorf("замена: %v", res.OperationResults)
+	}
+	var got []interface{}
+	for _, dv := range read(false) {
+		got = append(got, dv.Value.Value())
+	}
+	if len(got) != 3 || got[0] != 1.0 || got[1] != 4.0 || got[2] != 3.0 {
+		t.Errorf("архив после вставки и замены = %v, ожидалось [1 4 3]", got)
+	}
+
+	res = update(&ua.DeleteAtTimeDetails{NodeID: node, ReqTimes: []time.Time{at(10), at(15)}})
+	if len(res.OperationResults) != 2 || res.OperationResults[0] != ua.StatusOK || res.OperationResults[1] != ua.StatusBadNoEntryExists {
+		t.Errorf("удаление по меткам времени: %v", res.OperationResults)
+	}
+	if res := update(&ua.DeleteRawModifiedDetails{NodeID: node, StartTime: at(20), EndTime: at(30)}); res.StatusCode != ua.StatusOK {
+		t.Errorf("удаление за интервал: %v", res.StatusCode)
+	}
+	if values := read(false); len(values) != 1 || values[0].Value.Value() != 3.0 {
+		t.Errorf("архив после удаления = %v, ожидалось одно значение 3", values)
+	}
+
+	// Вставки 10, 20, 30; замена 20; удаления 10 и 20
+	if modified := read(true); len(modified) != 6 || modified[0].Value.Value() != 1.0 {
+		t.Errorf("изменения архива = %d значений, ожидалось 6", len(modified))
+	}
+
+	if res := update(&ua.DeleteAtTimeDetails{NodeID: s.testNode("Simulation.Missing"), ReqTimes: []time.Time{start}}); res.StatusCode != ua.StatusBadNodeIDUnknown {
+		t.Errorf("неизвестный узел: статус %v, ожидался BadNodeIdUnknown", res.StatusCode)
+	}
+}
@@ -8,10 +8,19 @@ import (
 	"strings"
 
 	"github.com/alexfrick92/opcli/internal/client"
+	"github.com/alexfrick92/opcli/internal/commands"
 	"github.com/alexfrick92/opcli/internal/parser"
 )
 
 func main() {
+	// opcli serve запускает сервер имитации вместо интерактивной оболочки
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := parser.Serve(os.Args[2:]); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+		return
+	}
+
 	fmt.Println("opcli - OPC UA Interactive Client")
 	fmt.Println("Type 'help' for available commands")
 	fmt.Println()
@@ -25,7 +34,11 @@ func main() {
 
 func runShell() {
 	defer client.Disconnect()
+	if commands.Audit != nil {
+		defer commands.Audit.Close()
+	}
 	reader := bufio.NewReader(os.Stdin)
+	commands.Confirm = commands.ConfirmFrom(reader)
 
 	for {
 		fmt.Print("opcli> ")